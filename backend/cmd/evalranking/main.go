@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/5pirit5eal/swim-gen/internal/logging"
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+)
+
+func main() {
+	// Command line flags
+	queries := flag.String("queries", "", "Path to a file with one search query per line")
+	cutoff := flag.String("cutoff", "", "Date (YYYY-MM-DD) separating the feedback used for ranking from the feedback used as labels")
+	k := flag.Int("k", 5, "Number of ranked plans the NDCG is computed over")
+	envFile := flag.String("env", ".env", "path to .env file")
+	help := flag.Bool("help", false, "display help information")
+
+	flag.Parse()
+
+	// Display help if requested
+	if *help {
+		fmt.Println("Evaluate the re-ranking of reference plans against stored feedback")
+		fmt.Println("Usage: evalranking --queries <file> [--cutoff <date>] [--k <k>] [--env <env_file>]")
+		fmt.Println("  --queries <file>   File with one search query per line")
+		fmt.Println("  --cutoff <date>    Feedback before the date ranks, feedback after it labels the plans (default: 30 days ago)")
+		fmt.Println("  --k <k>            Number of ranked plans the NDCG is computed over (default: 5)")
+		fmt.Println("  --env <file>       Path to environment file (default: .env)")
+		fmt.Println("  --help             Display this help information")
+		os.Exit(0)
+	}
+
+	// Validate required parameters
+	if *queries == "" {
+		log.Fatal("Error: queries parameter is required. Use --queries to specify the query file.")
+	}
+	cutoffTime := time.Now().AddDate(0, 0, -30)
+	if *cutoff != "" {
+		var err error
+		cutoffTime, err = time.Parse(time.DateOnly, *cutoff)
+		if err != nil {
+			log.Fatal("Error parsing cutoff:", err)
+		}
+	}
+
+	lines, err := readQueries(*queries)
+	if err != nil {
+		log.Fatal("Error reading queries:", err)
+	}
+
+	// Load configuration
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		log.Fatal("Error getting current directory:", err)
+	}
+
+	cfg, err := config.LoadConfig(filepath.Join(projectRoot, *envFile), true)
+	if err != nil {
+		log.Fatal("Error loading configuration:", err)
+	}
+	slog.SetDefault(logging.NewTextLogger(os.Stdout, slog.LevelInfo, cfg.DB.Pass, cfg.SB.AnonKey, cfg.SB.ServiceRoleKey))
+
+	ctx := context.Background()
+
+	// Initialize RAG database
+	db, err := rag.NewGoogleAIStore(ctx, cfg)
+	if err != nil {
+		log.Fatal("Error initializing RAG database:", err)
+	}
+	defer func() {
+		if err := db.PlanStore.Close(); err != nil {
+			log.Printf("Error closing plan store connection: %v", err)
+		}
+		if err := db.DrillStore.Close(); err != nil {
+			log.Printf("Error closing drill store connection: %v", err)
+		}
+	}()
+
+	samples, err := db.RankingSamples(ctx, lines, cutoffTime)
+	if err != nil {
+		log.Fatal("Error collecting ranking samples:", err)
+	}
+
+	// Rank the plans as the service would have at the cutoff
+	eval := rag.EvaluateRanking(samples, rag.NewRankingWeights(cfg), *k, cutoffTime)
+	fmt.Printf("Queries with labeled candidates: %d of %d\n", eval.Queries, len(lines))
+	fmt.Printf("NDCG@%d similarity only: %.4f\n", *k, eval.SimilarityNDCG)
+	fmt.Printf("NDCG@%d re-ranked:       %.4f\n", *k, eval.HybridNDCG)
+}
+
+func readQueries(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var queries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if query := strings.TrimSpace(scanner.Text()); query != "" {
+			queries = append(queries, query)
+		}
+	}
+	return queries, scanner.Err()
+}
@@ -28,7 +28,10 @@ const docTemplate = `{
                 ],
                 "description": "Upload and store a new user created swim training plan in the database",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "text/markdown",
+                    "application/vnd.swim-gen.plan+json"
                 ],
                 "produces": [
                     "application/json"
@@ -39,13 +42,25 @@ const docTemplate = `{
                 "summary": "Upload a new private training plan",
                 "parameters": [
                     {
-                        "description": "Training plan data",
+                        "description": "Training plan data or exported plan file",
                         "name": "plan",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "$ref": "#/definitions/models.UploadPlanRequest"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Allow sharing, only used for exported plan files",
+                        "name": "allow_sharing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language, only used for exported plan files",
+                        "name": "language",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -128,14 +143,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/chat": {
+        "/blocks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the training blocks of the authenticated user with their weekly schedule, without the session plans",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Blocks"
+                ],
+                "summary": "List training blocks",
+                "responses": {
+                    "200": {
+                        "description": "Training blocks, newest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TrainingBlock"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Have a conversation with the AI trainer to create, modify, or get information about training plans",
+                "description": "Generate a multi-week block (base, build, taper, race week) with one plan per session and a weekly volume progression. The plans are added to the user's history. Every session counts as one generation of the monthly quota.",
                 "consumes": [
                     "application/json"
                 ],
@@ -143,25 +196,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Chat"
+                    "Training Blocks"
                 ],
-                "summary": "Chat with AI to create or refine training plans",
+                "summary": "Generate a training block",
                 "parameters": [
                     {
-                        "description": "Chat request with message and optional plan ID",
+                        "description": "Training block parameters",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ChatRequest"
+                            "$ref": "#/definitions/models.TrainingBlockRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Successful chat response with updated plan",
+                    "201": {
+                        "description": "Generated training block",
                         "schema": {
-                            "$ref": "#/definitions/models.ChatResponsePayload"
+                            "$ref": "#/definitions/models.TrainingBlock"
                         }
                     },
                     "400": {
@@ -176,8 +229,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Plan not found",
+                    "429": {
+                        "description": "Monthly generation quota exceeded",
                         "schema": {
                             "type": "string"
                         }
@@ -191,50 +244,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/drill": {
+        "/blocks/{block_id}": {
             "get": {
-                "description": "Get a drill exercise by its image name identifier and language",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get a training block of the authenticated user including the plans of all sessions",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Drills"
+                    "Training Blocks"
                 ],
-                "summary": "Get a single drill",
+                "summary": "Get a training block",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Drill image name (unique identifier)",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Language code (en, de)",
-                        "name": "lang",
-                        "in": "query",
+                        "description": "Training block ID",
+                        "name": "block_id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Training block",
                         "schema": {
-                            "$ref": "#/definitions/models.Drill"
+                            "$ref": "#/definitions/models.TrainingBlock"
                         }
                     },
-                    "400": {
-                        "description": "Bad request - missing parameters",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Drill not found",
+                        "description": "Training block not found",
                         "schema": {
                             "type": "string"
                         }
@@ -248,9 +296,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/drills/options": {
-            "get": {
-                "description": "Fetch unique values for drill filters (styles, target groups, etc.) based on language",
+        "/blocks/{block_id}/export-pdf": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a PDF with an overview of the weeks and one page per session of the training block",
                 "consumes": [
                     "application/json"
                 ],
@@ -258,23 +311,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Drills"
+                    "Training Blocks"
                 ],
-                "summary": "Get drill filter options",
+                "summary": "Export training block to PDF",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Language code (en, de)",
-                        "name": "lang",
-                        "in": "query",
+                        "description": "Training block ID",
+                        "name": "block_id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "PDF options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BlockToPDFRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "PDF export response with URI",
                         "schema": {
-                            "$ref": "#/definitions/rag.DrillFilterOptions"
+                            "$ref": "#/definitions/models.PlanToPDFResponse"
                         }
                     },
                     "400": {
@@ -283,6 +345,18 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Training block not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -292,9 +366,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/drills/search": {
-            "get": {
-                "description": "Search drill exercises with optional filters for language, target groups, styles, and difficulty",
+        "/blocks/{block_id}/sessions/{week}/{session}/regenerate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate the plan of one session again with the phase and volume of its week. The session is linked to a new plan, the previous plan stays in the history unchanged.",
                 "consumes": [
                     "application/json"
                 ],
@@ -302,61 +381,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Drills"
+                    "Training Blocks"
                 ],
-                "summary": "Search drills",
+                "summary": "Regenerate a session of a training block",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Language code (en, de)",
-                        "name": "lang",
-                        "in": "query",
+                        "description": "Training block ID",
+                        "name": "block_id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Target groups filter (e.g., Beginner, Competitive Swimmer)",
-                        "name": "target_groups",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Styles filter (e.g., Freestyle, Backstroke)",
-                        "name": "styles",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Difficulty filter (Easy, Medium, Hard)",
-                        "name": "difficulty",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Week of the session, starting at 1",
+                        "name": "week",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Page number (default: 1)",
-                        "name": "page",
-                        "in": "query"
+                        "description": "Session within the week, starting at 1",
+                        "name": "session",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Results per page (default: 20, max: 100)",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Additional wishes for the session",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.RegenerateSessionRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Regenerated session",
                         "schema": {
-                            "$ref": "#/definitions/rag.DrillSearchResult"
+                            "$ref": "#/definitions/models.BlockSession"
                         }
                     },
                     "400": {
@@ -365,6 +428,18 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Training block not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -374,44 +449,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/export-pdf": {
-            "post": {
+        "/calibration": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Generate and download a PDF version of a training plan",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get how hard the swum plans of the user felt relative to their volume and intensity, learned from the difficulty ratings of the feedback. Generated plans adjust their load by it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Training Plans"
-                ],
-                "summary": "Export training plan to PDF",
-                "parameters": [
-                    {
-                        "description": "Training plan data to export",
-                        "name": "plan",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.PlanToPDFRequest"
-                        }
-                    }
+                    "Feedback"
                 ],
+                "summary": "Get difficulty calibration",
                 "responses": {
                     "200": {
-                        "description": "PDF export response with URI",
+                        "description": "Difficulty calibration",
                         "schema": {
-                            "$ref": "#/definitions/models.PlanToPDFResponse"
+                            "$ref": "#/definitions/models.DifficultyCalibration"
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -425,14 +486,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/feedback": {
+        "/chat": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Submit a rating, was_swam status, and difficulty rating for a training plan",
+                "description": "Have a conversation with the AI trainer to create, modify, or get information about training plans",
                 "consumes": [
                     "application/json"
                 ],
@@ -440,25 +501,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Feedback"
+                    "Chat"
                 ],
-                "summary": "Submit feedback for a training plan",
+                "summary": "Chat with AI to create or refine training plans",
                 "parameters": [
                     {
-                        "description": "Feedback data",
+                        "description": "Chat request with message and optional plan ID",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.FeedbackRequest"
+                            "$ref": "#/definitions/models.ChatRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Feedback submitted successfully",
+                        "description": "Successful chat response with updated plan",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ChatResponsePayload"
                         }
                     },
                     "400": {
@@ -474,7 +535,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Plan not found",
+                        "description": "Plan or edited message not found",
                         "schema": {
                             "type": "string"
                         }
@@ -488,88 +549,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/file-to-plan": {
+        "/chat/stream": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Convert a file containing a training plan to a structured plan. Supports PNG, JPEG, and PDF formats.",
+                "description": "Streams the AI trainer response token by token via server-sent events. A \"replace\" event replaces the streamed text if the plan was generated again to meet the constraints. The final \"done\" event contains the updated plan and the ids of the stored messages.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "Upload"
+                    "Chat"
                 ],
-                "summary": "Convert a file (image or PDF) of a plan to a plan",
+                "summary": "Chat with AI and stream the response",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "File containing a plan (PNG, JPEG, or PDF)",
-                        "name": "image",
-                        "in": "formData",
-                        "required": true
+                        "description": "Chat request with message and plan ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChatRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Plan ID of the converted plan",
+                        "description": "Event stream of models.ChatStreamToken and models.ChatStreamReplace events followed by a models.ChatStreamDone or models.ChatStreamError event",
                         "schema": {
-                            "$ref": "#/definitions/models.RAGResponse"
+                            "$ref": "#/definitions/models.ChatStreamDone"
                         }
                     },
                     "400": {
-                        "description": "Bad request or unsupported file type",
+                        "description": "Bad request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
-                    }
-                }
-            }
-        },
-        "/generate-prompt": {
-            "post": {
-                "description": "Generate a prompt for the LLM based on the provided language",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Training Plans"
-                ],
-                "summary": "Generate a prompt for the LLM",
-                "parameters": [
-                    {
-                        "description": "Request to generate a prompt",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.GeneratePromptRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Generated prompt response",
-                        "schema": {
-                            "$ref": "#/definitions/models.GeneratedPromptResponse"
-                        }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "404": {
+                        "description": "Plan or edited message not found",
                         "schema": {
                             "type": "string"
                         }
@@ -583,45 +612,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/drill": {
             "get": {
-                "description": "Returns the health status of the API including database and vector store connectivity",
+                "description": "Get a drill exercise by its image name identifier and language",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "Drills"
+                ],
+                "summary": "Get a single drill",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Drill image name (unique identifier)",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language code (en, de)",
+                        "name": "lang",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "Comprehensive health check",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.HealthStatus"
+                            "$ref": "#/definitions/models.Drill"
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "400": {
+                        "description": "Bad request - missing parameters",
                         "schema": {
-                            "$ref": "#/definitions/models.HealthStatus"
+                            "type": "string"
                         }
-                    }
-                }
-            }
-        },
-        "/health-basic": {
-            "get": {
-                "description": "Returns a simple OK response for basic health monitoring",
-                "produces": [
-                    "text/plain"
-                ],
-                "tags": [
-                    "Health"
-                ],
-                "summary": "Basic health check",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Drill not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "string"
                         }
@@ -629,14 +669,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/memory/conversation": {
+        "/drills/options": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get the full conversation history for a specific plan",
+                "description": "Fetch unique values for drill filters (styles, target groups, etc.) based on language",
                 "consumes": [
                     "application/json"
                 ],
@@ -644,26 +679,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Memory"
+                    "Drills"
                 ],
-                "summary": "Get conversation history",
+                "summary": "Get drill filter options",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Plan ID",
-                        "name": "plan_id",
+                        "description": "Language code (en, de)",
+                        "name": "lang",
                         "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Conversation history",
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.MessagePayload"
-                            }
+                            "$ref": "#/definitions/rag.DrillFilterOptions"
                         }
                     },
                     "400": {
@@ -679,14 +711,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Delete all messages in a conversation for a specific plan",
+            }
+        },
+        "/drills/search": {
+            "get": {
+                "description": "Search drill exercises with optional filters for language, target groups, styles, and difficulty",
                 "consumes": [
                     "application/json"
                 ],
@@ -694,25 +723,61 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Memory"
+                    "Drills"
                 ],
-                "summary": "Delete an entire conversation",
+                "summary": "Search drills",
                 "parameters": [
                     {
-                        "description": "Request to delete a conversation",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.DeleteConversationRequest"
-                        }
+                        "type": "string",
+                        "description": "Language code (en, de)",
+                        "name": "lang",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Target groups filter (e.g., Beginner, Competitive Swimmer)",
+                        "name": "target_groups",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Styles filter (e.g., Freestyle, Backstroke)",
+                        "name": "styles",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Difficulty filter (Easy, Medium, Hard)",
+                        "name": "difficulty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Results per page (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Conversation deleted successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/rag.DrillSearchResult"
                         }
                     },
                     "400": {
@@ -730,43 +795,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/memory/message": {
+        "/export-booklet": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a new message to the conversation history",
+                "description": "Generate a PDF with a cover page, a table of contents, one page per plan and an appendix of the linked drills. The plans are taken from the history of the user or from a training block.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/pdf"
                 ],
                 "tags": [
-                    "Memory"
+                    "Training Plans"
                 ],
-                "summary": "Add a message to conversation",
+                "summary": "Export training plans as PDF booklet",
                 "parameters": [
                     {
-                        "description": "Request to add a message",
+                        "description": "Plans and PDF options",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.AddMessageRequest"
+                            "$ref": "#/definitions/models.BookletToPDFRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/pdf to receive the PDF instead of a signed URL",
+                        "name": "Accept",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Message added successfully",
+                        "description": "PDF export response with URI",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.PlanToPDFResponse"
                         }
                     },
                     "400": {
@@ -775,6 +844,18 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan or training block not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -782,40 +863,42 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/export-fit": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a specific message from the conversation history while maintaining the linked list integrity",
+                "description": "Convert a training plan into a structured pool swim workout file for Garmin and other sport watches",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/vnd.ant.fit"
                 ],
                 "tags": [
-                    "Memory"
+                    "Training Plans"
                 ],
-                "summary": "Delete a single message from conversation",
+                "summary": "Export training plan as FIT workout",
                 "parameters": [
                     {
-                        "description": "Request to delete a message",
-                        "name": "request",
+                        "description": "Training plan data to export",
+                        "name": "plan",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.DeleteMessageRequest"
+                            "$ref": "#/definitions/models.PlanToFITRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Message deleted successfully",
+                        "description": "FIT workout file",
                         "schema": {
-                            "type": "string"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -833,40 +916,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/memory/messages-after": {
-            "delete": {
+        "/export-pdf": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a message and all messages that follow it in the conversation, allowing conversation branching",
+                "description": "Generate and download a PDF version of a training plan. PDFs are cached by their content, so exporting the same plan with the same options again returns a new signed URL of the already uploaded file. With an Accept header of application/pdf the PDF is returned directly instead of a signed URL. With drill_appendix the linked drills are described on an extra page with QR codes to their pages. The layout selects a print layout: standard, compact (A5 card), poolside (high contrast), coach (empty split time columns) or two_lane (two copies side by side).",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/pdf"
                 ],
                 "tags": [
-                    "Memory"
+                    "Training Plans"
                 ],
-                "summary": "Delete a message and all subsequent messages",
+                "summary": "Export training plan to PDF",
                 "parameters": [
                     {
-                        "description": "Request to delete messages from a point",
-                        "name": "request",
+                        "description": "Training plan data to export",
+                        "name": "plan",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.DeleteMessagesAfterRequest"
+                            "$ref": "#/definitions/models.PlanToPDFRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/pdf to receive the PDF itself",
+                        "name": "Accept",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Messages deleted successfully",
+                        "description": "PDF export response with URI, or the PDF for Accept: application/pdf",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.PlanToPDFResponse"
                         }
                     },
                     "400": {
@@ -884,50 +974,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/plan/{plan_id}": {
-            "delete": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Delete a training plan from the authenticated user's history and remove all associated data",
-                "consumes": [
-                    "application/json"
-                ],
+        "/export/schema": {
+            "get": {
+                "description": "Returns the JSON Schema describing the current version of the portable JSON plan document",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Training Plans"
                 ],
-                "summary": "Delete a training plan",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Plan ID to delete",
-                        "name": "plan_id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "Get the JSON Schema of exported plans",
                 "responses": {
                     "200": {
-                        "description": "Plan deleted successfully",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "Plan not found",
+                        "description": "JSON Schema",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -939,40 +1001,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/query": {
+        "/export/{format}": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Query the RAG system for relevant training plans based on input",
+                "description": "Serialize a training plan as CSV, Markdown table or versioned portable JSON document. The files can be uploaded again via /add.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "text/markdown",
+                    "application/vnd.swim-gen.plan+json"
                 ],
                 "tags": [
                     "Training Plans"
                 ],
-                "summary": "Query training plans",
+                "summary": "Export training plan as CSV, Markdown or JSON",
                 "parameters": [
                     {
-                        "description": "Query parameters",
-                        "name": "query",
+                        "enum": [
+                            "csv",
+                            "md",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Training plan data to export",
+                        "name": "plan",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.QueryRequest"
+                            "$ref": "#/definitions/models.PlanExportRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Query results",
+                        "description": "Exported plan",
                         "schema": {
-                            "$ref": "#/definitions/models.RAGResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -990,14 +1066,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/share-plan": {
+        "/feedback": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Share an owned training plan via link. Email sharing is not implemented yet.",
+                "description": "Submit a rating, was_swam status, and difficulty rating for a training plan",
                 "consumes": [
                     "application/json"
                 ],
@@ -1005,25 +1081,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Training Plans"
+                    "Feedback"
                 ],
-                "summary": "Share a training plan",
+                "summary": "Submit feedback for a training plan",
                 "parameters": [
                     {
-                        "description": "Request to share a training plan",
+                        "description": "Feedback data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.SharePlanRequest"
+                            "$ref": "#/definitions/models.FeedbackRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Share plan response with URI",
+                        "description": "Feedback submitted successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.SharePlanResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -1053,16 +1129,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/uploads": {
-            "get": {
+        "/file-to-plan": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all plans uploaded by the authenticated user",
+                "description": "Convert a file containing a training plan to a structured plan. Supports PNG, JPEG, PDF and plain text formats. Text in swim shorthand is parsed directly and not understood fragments are returned in unparsed.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -1070,19 +1146,25 @@ const docTemplate = `{
                 "tags": [
                     "Upload"
                 ],
-                "summary": "Get uploaded plans",
+                "summary": "Convert a file (image, PDF or text) of a plan to a plan",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File containing a plan (PNG, JPEG, PDF or TXT)",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Converted plan",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.UploadedPlanResponse"
-                            }
+                            "$ref": "#/definitions/models.FileToPlanResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad request or unsupported file type",
                         "schema": {
                             "type": "string"
                         }
@@ -1096,14 +1178,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/uploads/{plan_id}": {
+        "/files/{token}": {
             "get": {
-                "security": [
+                "description": "Download a file exported to the local filesystem storage. The token is part of the signed URL returned by the export and expires after 15 minutes.",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "Download an exported file",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "Signed file token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "description": "Get a specific plan uploaded by the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "Exported file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Link expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/generate-prompt": {
+            "post": {
+                "description": "Generate a prompt for the LLM based on the provided language",
                 "consumes": [
                     "application/json"
                 ],
@@ -1111,39 +1229,81 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Upload"
+                    "Training Plans"
                 ],
-                "summary": "Get an uploaded plan",
+                "summary": "Generate a prompt for the LLM",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Plan ID",
-                        "name": "plan_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Request to generate a prompt",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GeneratePromptRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Generated prompt response",
                         "schema": {
-                            "$ref": "#/definitions/models.UploadedPlanResponse"
+                            "$ref": "#/definitions/models.GeneratedPromptResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Plan not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Returns the health status of the API including database and vector store connectivity",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Comprehensive health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/health-basic": {
+            "get": {
+                "description": "Returns a simple OK response for basic health monitoring",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Basic health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "string"
                         }
@@ -1151,14 +1311,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/upsert-plan": {
+        "/memory/branch": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update an existing training plan if it belongs to the user, or insert a new one",
+                "description": "Make the branch through a message the active branch of its conversation. The latest plan snapshot of the branch becomes the current plan.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1166,25 +1326,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Training Plans"
+                    "Memory"
                 ],
-                "summary": "Update or insert a training plan into a user's history",
+                "summary": "Select a conversation branch",
                 "parameters": [
                     {
-                        "description": "Request to upsert a training plan",
+                        "description": "Request to select a branch",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpsertPlanRequest"
+                            "$ref": "#/definitions/models.SelectBranchRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Plan ID of the upserted training plan",
+                        "description": "Messages of the selected branch",
                         "schema": {
-                            "$ref": "#/definitions/models.UpsertPlanResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MessagePayload"
+                            }
                         }
                     },
                     "400": {
@@ -1193,6 +1356,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -1202,14 +1371,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user": {
-            "delete": {
+        "/memory/conversation": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Permanently delete the authenticated user's account and all associated data",
+                "description": "Get the active branch of the conversation for a specific plan. Set branch to a message id to get the branch through that message without selecting it, or view=tree to get all branches as tree.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1217,18 +1386,42 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Memory"
+                ],
+                "summary": "Get conversation history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Plan ID",
+                        "name": "plan_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Message ID of the branch to get",
+                        "name": "branch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "'tree' to get all branches",
+                        "name": "view",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Delete user account",
                 "responses": {
                     "200": {
-                        "description": "User deleted successfully",
+                        "description": "Conversation history, or models.ConversationNode for view=tree",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MessagePayload"
+                            }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad request",
                         "schema": {
                             "type": "string"
                         }
@@ -1240,626 +1433,4307 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "models.AddMessageRequest": {
-            "description": "Request payload for adding a message to the conversation history",
-            "type": "object",
-            "required": [
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete all messages in a conversation for a specific plan",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Memory"
+                ],
+                "summary": "Delete an entire conversation",
+                "parameters": [
+                    {
+                        "description": "Request to delete a conversation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteConversationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversation deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/memory/message": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a new message to the conversation history",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Memory"
+                ],
+                "summary": "Add a message to conversation",
+                "parameters": [
+                    {
+                        "description": "Request to add a message",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message added successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a specific message from the conversation history while maintaining the linked list integrity",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Memory"
+                ],
+                "summary": "Delete a single message from conversation",
+                "parameters": [
+                    {
+                        "description": "Request to delete a message",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Message deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/memory/messages-after": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a message and all messages that follow it on any branch of the conversation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Memory"
+                ],
+                "summary": "Delete a message and all subsequent messages",
+                "parameters": [
+                    {
+                        "description": "Request to delete messages from a point",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteMessagesAfterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization-invitations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the invitations of the authenticated user to clubs, which have to be accepted to become a member",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "List organization invitations",
+                "responses": {
+                    "200": {
+                        "description": "Invitations",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrganizationInvitation"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization-invitations/{invitation_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Decline an invitation of the authenticated user to a club",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Decline an organization invitation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invitation ID",
+                        "name": "invitation_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invitation declined successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Invitation not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization-invitations/{invitation_id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Join the club of an invitation with the role chosen by the coach. Only the invited user can accept the invitation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Accept an organization invitation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invitation ID",
+                        "name": "invitation_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Joined organization",
+                        "schema": {
+                            "$ref": "#/definitions/models.Organization"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Invitation not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the clubs the authenticated user is a member of, with the role of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "List organizations",
+                "responses": {
+                    "200": {
+                        "description": "Organizations",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Organization"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a club or team. The authenticated user becomes its first coach.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Create an organization",
+                "parameters": [
+                    {
+                        "description": "Organization to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateOrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created organization",
+                        "schema": {
+                            "$ref": "#/definitions/models.Organization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/assignments": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Assign a training plan from the coach's history to a squad. The swimmers can read the plan and mark it as swum via /feedback. Only coaches may assign plans.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Assign a plan to a squad",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Plan and squad",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AssignPlanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Plan assigned successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Only coaches may manage the organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization, squad or plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/feedback-sharing": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set whether the coaches of the club see the ratings of the authenticated user on assigned plans. Without it they only see whether a plan was swum.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Share feedback with the coaches",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Feedback sharing",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.FeedbackSharingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Feedback sharing updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the coaches and swimmers of a club the user is a member of. Coaches also see the invited users.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "List organization members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Members",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrganizationMember"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Invite a user by username to the club or change the role of a member. Invited users become members once they accept the invitation via /organization-invitations. Only coaches may add members. The last coach can not become a swimmer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Invite an organization member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invited or updated member",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrganizationMember"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Only coaches may manage the organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization or user not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "The last coach can not become a swimmer",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a member from the club and its squads. Coaches may remove every member and withdraw invitations, swimmers only remove themselves. The last coach can not leave.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Remove an organization member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID of the member",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Member removed successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Only coaches may manage the organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization or member not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "The last coach can not leave the organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/plans": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the plans shared within the club and the plans assigned to squads. Swimmers only see the assignments of their own squads.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "List organization plans",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Plans",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrganizationPlan"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Share a training plan from the user's history with all members of the club, without a public link",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Share a plan within the organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Plan to share",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShareWithOrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Plan shared successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization or plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/plans/{plan_id}/status": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the swimmers of the squads a plan is assigned to and whether they marked it as swum in their feedback. Ratings are only included for swimmers who share their feedback with the club. Only coaches may see the status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Get the status of an assigned plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Plan ID",
+                        "name": "plan_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status per swimmer",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SwimmerPlanStatus"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Only coaches may manage the organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/squads": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the training groups of a club the user is a member of",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "List squads",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Squads",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Squad"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a training group with swimmers of the club. Only coaches may create squads.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organizations"
+                ],
+                "summary": "Create a squad",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Squad to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateSquadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created squad",
+                        "schema": {
+                            "$ref": "#/definitions/models.Squad"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Only coaches may manage the organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Organization or member not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/{plan_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a training plan from the authenticated user's history and remove all associated data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Plans"
+                ],
+                "summary": "Delete a training plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Plan ID to delete",
+                        "name": "plan_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Plan deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/{plan_id}/diff": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the added, removed and changed rows and the volume delta between two plan versions. Without \"to\" the version is compared with the latest version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Memory"
+                ],
+                "summary": "Compare two versions of a plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Plan ID",
+                        "name": "plan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Message ID of the older version",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Message ID of the newer version, defaults to the latest version",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Difference between the versions",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlanDiffResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan or version not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/{plan_id}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the plan snapshots of the AI messages in the conversation of a plan, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Memory"
+                ],
+                "summary": "List the versions of a plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Plan ID",
+                        "name": "plan_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Versions of the plan",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PlanVersion"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/{plan_id}/versions/{message_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the plan snapshot stored with an AI message in the conversation of a plan",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Memory"
+                ],
+                "summary": "Get a version of a plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Plan ID",
+                        "name": "plan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the AI message holding the snapshot",
+                        "name": "message_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Version of the plan",
+                        "schema": {
+                            "$ref": "#/definitions/models.PlanVersion"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan or version not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/{plan_id}/versions/{message_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Save the snapshot of an older version as the current plan. The restore is added to the conversation as AI message with the snapshot, so it becomes the latest version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Memory"
+                ],
+                "summary": "Restore a version of a plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Plan ID",
+                        "name": "plan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the AI message holding the snapshot",
+                        "name": "message_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of the conversation message, 'de' or 'en'",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored plan",
+                        "schema": {
+                            "$ref": "#/definitions/models.RestorePlanVersionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan or version not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the long-term training preferences (injuries, disliked drills, equipment, pool, session length) used to personalize generated and refined plans",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Preferences"
+                ],
+                "summary": "Get training preferences",
+                "responses": {
+                    "200": {
+                        "description": "Training preferences",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrainingPreferences"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the long-term training preferences of the user. Omitted lists are cleared.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Preferences"
+                ],
+                "summary": "Update training preferences",
+                "parameters": [
+                    {
+                        "description": "Training preferences",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TrainingPreferences"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved training preferences",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrainingPreferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences/proposals": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the long-term preferences found in the chat messages of the user. They only become part of the training preferences once the user accepts them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Preferences"
+                ],
+                "summary": "List proposed training preferences",
+                "responses": {
+                    "200": {
+                        "description": "Proposed preferences, oldest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PreferenceProposal"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences/proposals/{proposal_id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Confirm a preference learned from a chat message. It is added to the training preferences and used for all following plans.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Preferences"
+                ],
+                "summary": "Accept a proposed training preference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Proposal ID",
+                        "name": "proposal_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated training preferences",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrainingPreferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Proposal not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences/proposals/{proposal_id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Dismiss a preference learned from a chat message. Rejected preferences are not proposed again.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Preferences"
+                ],
+                "summary": "Reject a proposed training preference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Proposal ID",
+                        "name": "proposal_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Proposal rejected successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Proposal not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/query": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Query the RAG system for relevant training plans based on input",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Plans"
+                ],
+                "summary": "Query training plans",
+                "parameters": [
+                    {
+                        "description": "Query parameters",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.QueryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Query results",
+                        "schema": {
+                            "$ref": "#/definitions/models.RAGResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/share-invitations/{invitation_id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add the plan of an email invitation to the shared history of the authenticated user. Only the account with the invited email address can accept the invitation, until it expires after 30 days.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Plans"
+                ],
+                "summary": "Accept a plan shared by email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invitation ID from the share email",
+                        "name": "invitation_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Accepted plan",
+                        "schema": {
+                            "$ref": "#/definitions/models.AcceptInvitationResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Invitation not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/share-plan": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Share an owned training plan via link or email. Emails contain the plan and a link to accept the share, senders can share a limited number of plans by email per day.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Plans"
+                ],
+                "summary": "Share a training plan",
+                "parameters": [
+                    {
+                        "description": "Request to share a training plan",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SharePlanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Share plan response with URI",
+                        "schema": {
+                            "$ref": "#/definitions/models.SharePlanResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many plans shared by email",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "Email sharing is not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/shared/{url_hash}": {
+            "get": {
+                "description": "Get a plan shared via link with the display name of its owner and the view count of the share. Views of visitors other than the owner are counted. Signed in visitors can set pdf=true to receive a short-lived link to the plan as PDF.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Plans"
+                ],
+                "summary": "Get a shared training plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "URL hash of the share",
+                        "name": "url_hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Export the plan to PDF and return its link, requires authentication",
+                        "name": "pdf",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of the PDF, 'de' or 'en'",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Horizontal PDF layout",
+                        "name": "horizontal",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Large font in the PDF",
+                        "name": "large_font",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Shared plan",
+                        "schema": {
+                            "$ref": "#/definitions/models.SharedPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized, only for pdf=true",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Shared plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Shared plan expired or revoked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke the link of a share owned by the authenticated user. The link and open email invitations stop working, sharing the plan again creates a new link.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Plans"
+                ],
+                "summary": "Revoke a shared training plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "URL hash of the share",
+                        "name": "url_hash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Share revoked successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Shared plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/shared/{url_hash}/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Copy the plan of an active share into the history of the authenticated user. The copy can be edited and is kept when the share is revoked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Plans"
+                ],
+                "summary": "Import a shared training plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "URL hash of the share",
+                        "name": "url_hash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Imported plan",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportSharedPlanResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Shared plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Shared plan expired or revoked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all plans uploaded by the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upload"
+                ],
+                "summary": "Get uploaded plans",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UploadedPlanResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{plan_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a specific plan uploaded by the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upload"
+                ],
+                "summary": "Get an uploaded plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Plan ID",
+                        "name": "plan_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadedPlanResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/upsert-plan": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing training plan if it belongs to the user, or insert a new one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Training Plans"
+                ],
+                "summary": "Update or insert a training plan into a user's history",
+                "parameters": [
+                    {
+                        "description": "Request to upsert a training plan",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpsertPlanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Plan ID of the upserted training plan",
+                        "schema": {
+                            "$ref": "#/definitions/models.UpsertPlanResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Permanently delete the authenticated user's account and all associated data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete user account",
+                "responses": {
+                    "200": {
+                        "description": "User deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.AcceptInvitationResponse": {
+            "description": "Response containing the plan added to the shared history of the recipient",
+            "type": "object",
+            "properties": {
+                "plan_id": {
+                    "description": "PlanID identifies the shared training plan",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "url_hash": {
+                    "description": "URLHash is the hash to access the shared training plan",
+                    "type": "string",
+                    "example": "abc123"
+                }
+            }
+        },
+        "models.AddMemberRequest": {
+            "description": "Request payload for inviting a user by username to a club",
+            "type": "object",
+            "required": [
+                "role",
+                "username"
+            ],
+            "properties": {
+                "role": {
+                    "enum": [
+                        "coach",
+                        "swimmer"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MemberRole"
+                        }
+                    ],
+                    "example": "swimmer"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "anna_k"
+                }
+            }
+        },
+        "models.AddMessageRequest": {
+            "description": "Request payload for adding a message to the conversation history",
+            "type": "object",
+            "required": [
                 "content",
                 "plan_id"
             ],
             "properties": {
-                "content": {
+                "content": {
+                    "type": "string"
+                },
+                "plan_id": {
+                    "type": "string"
+                },
+                "previous_message_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AddPlanToHistoryRequest": {
+            "description": "Request payload for adding a plan to the authenticated user's history",
+            "type": "object",
+            "required": [
+                "description",
+                "plan_id",
+                "table",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "description": "Description of the plan",
+                    "type": "string",
+                    "example": "A comprehensive training plan for improving freestyle technique"
+                },
+                "initial_message": {
+                    "description": "Initial user message to persist when linking an anonymous plan",
+                    "type": "string"
+                },
+                "plan_id": {
+                    "description": "PlanID identifies the plan to add to history",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "description": "Title of the plan",
+                    "type": "string",
+                    "example": "Advanced Freestyle Training"
+                }
+            }
+        },
+        "models.AddPlanToHistoryResponse": {
+            "description": "Response containing the new plan ID and a success message",
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Plan added to history successfully"
+                },
+                "plan_id": {
+                    "type": "string",
+                    "example": "plan_123"
+                }
+            }
+        },
+        "models.AssignPlanRequest": {
+            "description": "Request payload for assigning a training plan to a squad",
+            "type": "object",
+            "required": [
+                "plan_id",
+                "squad_id"
+            ],
+            "properties": {
+                "plan_id": {
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "squad_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BlockSession": {
+            "description": "Training plan of a single session in a training block",
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "phase": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Phase"
+                        }
+                    ],
+                    "example": "base"
+                },
+                "plan_id": {
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "session": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "target_volume": {
+                    "type": "integer",
+                    "example": 3400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Grundlagenausdauer Kraul"
+                },
+                "week": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.BlockToPDFRequest": {
+            "description": "Request payload for exporting all sessions of a training block into one PDF",
+            "type": "object",
+            "properties": {
+                "frontend_base_url": {
+                    "description": "FrontendBaseURL is the base URL for drill links in the PDF",
+                    "type": "string",
+                    "example": "https://swim-gen.app"
+                },
+                "horizontal": {
+                    "description": "Horizontal indicates if the PDF should be in landscape orientation",
+                    "type": "boolean",
+                    "example": false
+                },
+                "language": {
+                    "description": "Language specifies the language for the PDF content",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Language"
+                        }
+                    ],
+                    "example": "en"
+                },
+                "large_font": {
+                    "description": "LargeFont indicates if the PDF should use a larger font size",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.BlockWeek": {
+            "description": "Phase and session volume of a week in a training block",
+            "type": "object",
+            "properties": {
+                "phase": {
+                    "description": "Phase is the training phase of the week",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Phase"
+                        }
+                    ],
+                    "example": "base"
+                },
+                "recovery": {
+                    "description": "Recovery marks an unloading week with reduced volume",
+                    "type": "boolean",
+                    "example": false
+                },
+                "volume": {
+                    "description": "Volume is the target volume of each session in meters",
+                    "type": "integer",
+                    "example": 3400
+                },
+                "week": {
+                    "description": "Week is the number of the week starting at 1",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.BookletToPDFRequest": {
+            "description": "Request payload for exporting plans of the history or the sessions of a training block into one PDF booklet",
+            "type": "object",
+            "properties": {
+                "block_id": {
+                    "description": "BlockID exports all sessions of the training block instead of PlanIDs",
+                    "type": "string",
+                    "example": "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+                },
+                "frontend_base_url": {
+                    "description": "FrontendBaseURL is the base URL for drill links in the PDF",
+                    "type": "string",
+                    "example": "https://swim-gen.app"
+                },
+                "horizontal": {
+                    "description": "Horizontal indicates if the PDF should be in landscape orientation",
+                    "type": "boolean",
+                    "example": false
+                },
+                "language": {
+                    "description": "Language specifies the language for the PDF content",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Language"
+                        }
+                    ],
+                    "example": "en"
+                },
+                "large_font": {
+                    "description": "LargeFont indicates if the PDF should use a larger font size",
+                    "type": "boolean",
+                    "example": true
+                },
+                "plan_ids": {
+                    "description": "PlanIDs are plans of the user history in the order of the booklet",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+                    ]
+                },
+                "title": {
+                    "description": "Title is printed on the cover, it defaults to the block title",
+                    "type": "string",
+                    "example": "Sommertraining"
+                }
+            }
+        },
+        "models.CalibrationVerdict": {
+            "type": "string",
+            "enum": [
+                "insufficient_data",
+                "balanced",
+                "too_hard",
+                "too_easy"
+            ],
+            "x-enum-varnames": [
+                "CalibrationInsufficientData",
+                "CalibrationBalanced",
+                "CalibrationTooHard",
+                "CalibrationTooEasy"
+            ]
+        },
+        "models.ChatRequest": {
+            "description": "Request payload for conversational training plan creation and refinement",
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "constraints": {
+                    "description": "Constraints are requirements the refined plan has to meet",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PlanConstraints"
+                        }
+                    ]
+                },
+                "edit_message_id": {
+                    "description": "EditMessageID identifies a user message that Message replaces. The edit starts a new\nbranch of the conversation, the original branch is kept and can be selected again.",
+                    "type": "string",
+                    "example": "6f1c..."
+                },
+                "language": {
+                    "description": "Language specifies the language for the response",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Language"
+                        }
+                    ],
+                    "example": "en"
+                },
+                "message": {
+                    "description": "Message is the user's input to the chat",
+                    "type": "string",
+                    "example": "Make it more challenging"
+                },
+                "plan_id": {
+                    "description": "PlanID identifies the conversation/plan (optional for new conversations)",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "pool_length": {
+                    "description": "PoolLength specifies the pool length for the training plan"
+                }
+            }
+        },
+        "models.ChatResponsePayload": {
+            "description": "Response containing the updated plan and conversational response",
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "Description of the training plan",
+                    "type": "string",
+                    "example": "A comprehensive training plan for improving freestyle technique"
+                },
+                "duration": {
+                    "description": "Duration is the estimated time needed to swim the updated plan",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DurationEstimate"
+                        }
+                    ]
+                },
+                "plan_id": {
+                    "description": "PlanID identifies the conversation/plan",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "response": {
+                    "description": "Response is the conversational AI response explaining changes",
+                    "type": "string",
+                    "example": "I've made the plan more challenging by adding butterfly sets"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "description": "Title of the training plan",
+                    "type": "string",
+                    "example": "Advanced Freestyle Training"
+                }
+            }
+        },
+        "models.ChatStreamDone": {
+            "description": "Final event of a streamed chat containing the validated plan and persisted message ids",
+            "type": "object",
+            "properties": {
+                "ai_message_id": {
+                    "description": "AIMessageID is the id of the stored AI message",
+                    "type": "string",
+                    "example": "9a2b..."
+                },
+                "description": {
+                    "description": "Description of the training plan",
+                    "type": "string",
+                    "example": "A comprehensive training plan for improving freestyle technique"
+                },
+                "duration": {
+                    "description": "Duration is the estimated time needed to swim the updated plan",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DurationEstimate"
+                        }
+                    ]
+                },
+                "plan_id": {
+                    "description": "PlanID identifies the conversation/plan",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "response": {
+                    "description": "Response is the conversational AI response explaining changes",
+                    "type": "string",
+                    "example": "I've made the plan more challenging by adding butterfly sets"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "description": "Title of the training plan",
+                    "type": "string",
+                    "example": "Advanced Freestyle Training"
+                },
+                "user_message_id": {
+                    "description": "UserMessageID is the id of the stored user message",
+                    "type": "string",
+                    "example": "6f1c..."
+                }
+            }
+        },
+        "models.CreateOrganizationRequest": {
+            "description": "Request payload for creating a club",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "SV Blau-Weiß"
+                }
+            }
+        },
+        "models.CreateSquadRequest": {
+            "description": "Request payload for creating a training group with its swimmers",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "members": {
+                    "description": "Members are the user ids of the swimmers, they must be members of the club",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Leistungsgruppe 1"
+                }
+            }
+        },
+        "models.DeleteConversationRequest": {
+            "description": "Request payload for deleting an entire conversation and all its messages",
+            "type": "object",
+            "required": [
+                "plan_id"
+            ],
+            "properties": {
+                "plan_id": {
+                    "description": "PlanID identifies the conversation to delete",
+                    "type": "string",
+                    "example": "plan_123"
+                }
+            }
+        },
+        "models.DeleteMessageRequest": {
+            "description": "Request payload for deleting a single message from conversation history",
+            "type": "object",
+            "required": [
+                "message_id"
+            ],
+            "properties": {
+                "message_id": {
+                    "description": "MessageID identifies the message to delete",
+                    "type": "string",
+                    "example": "msg_123"
+                }
+            }
+        },
+        "models.DeleteMessagesAfterRequest": {
+            "description": "Request payload for deleting a message and all subsequent messages in the conversation",
+            "type": "object",
+            "required": [
+                "message_id"
+            ],
+            "properties": {
+                "message_id": {
+                    "description": "MessageID identifies the message from which to delete (inclusive)",
+                    "type": "string",
+                    "example": "msg_123"
+                }
+            }
+        },
+        "models.DiffOp": {
+            "type": "string",
+            "enum": [
+                "added",
+                "removed",
+                "changed"
+            ],
+            "x-enum-varnames": [
+                "DiffAdded",
+                "DiffRemoved",
+                "DiffChanged"
+            ]
+        },
+        "models.DifficultyCalibration": {
+            "description": "Personal difficulty calibration learned from the difficulty ratings of swum plans",
+            "type": "object",
+            "properties": {
+                "average_difficulty": {
+                    "description": "AverageDifficulty is the mean difficulty rating of the plans",
+                    "type": "number",
+                    "example": 8.2
+                },
+                "average_hard_share": {
+                    "description": "AverageHardShare is the mean share of the volume at threshold pace or faster",
+                    "type": "number",
+                    "example": 0.3
+                },
+                "average_volume": {
+                    "description": "AverageVolume is the mean volume of the plans in meters",
+                    "type": "integer",
+                    "example": 3400
+                },
+                "load_factor": {
+                    "description": "LoadFactor is the suggested change of the training load, 1 keeps it",
+                    "type": "number",
+                    "example": 0.85
+                },
+                "recommended_volume": {
+                    "description": "RecommendedVolume is the volume reaching the target difficulty at the average intensity",
+                    "type": "integer",
+                    "example": 2900
+                },
+                "samples": {
+                    "description": "Samples is the number of rated plans used",
+                    "type": "integer",
+                    "example": 6
+                },
+                "target_difficulty": {
+                    "description": "TargetDifficulty is the difficulty new plans aim for",
+                    "type": "integer",
+                    "example": 6
+                },
+                "verdict": {
+                    "description": "Verdict tells whether the plans were too hard or too easy",
+                    "enum": [
+                        "insufficient_data",
+                        "balanced",
+                        "too_hard",
+                        "too_easy"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CalibrationVerdict"
+                        }
+                    ],
+                    "example": "too_hard"
+                }
+            }
+        },
+        "models.Drill": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "difficulty": {
+                    "type": "string"
+                },
+                "img_description": {
+                    "type": "string"
+                },
+                "img_name": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "short_description": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "styles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "target_groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "targets": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "video_url": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.DurationEstimate": {
+            "description": "Estimated duration of a training plan based on the CSS pace zones of the swimmer",
+            "type": "object",
+            "properties": {
+                "css_pace": {
+                    "description": "CSSPace is the pace per 100 m at critical swim speed used for the estimate",
+                    "type": "number",
+                    "example": 95
+                },
+                "personal": {
+                    "description": "Personal is true if the CSS times of the user were used",
+                    "type": "boolean",
+                    "example": true
+                },
+                "rest_seconds": {
+                    "description": "RestSeconds is the estimated time spent resting",
+                    "type": "integer",
+                    "example": 540
+                },
+                "rows": {
+                    "description": "Rows contains one estimate per row of the table, without the total row",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RowDuration"
+                    }
+                },
+                "swim_seconds": {
+                    "description": "SwimSeconds is the estimated time spent swimming",
+                    "type": "integer",
+                    "example": 2700
+                },
+                "total_seconds": {
+                    "description": "TotalSeconds is the sum of swim and rest time",
+                    "type": "integer",
+                    "example": 3240
+                }
+            }
+        },
+        "models.EquipmentType": {
+            "type": "string",
+            "enum": [
+                "Flossen",
+                "Kickboard",
+                "Handpaddles",
+                "Pull buoy",
+                "Schnorchel"
+            ],
+            "x-enum-varnames": [
+                "EquipmentFins",
+                "EquipmentKickboard",
+                "EquipmentPaddles",
+                "EquipmentBuoy",
+                "EquipmentSnorkel"
+            ]
+        },
+        "models.FeedbackRequest": {
+            "description": "Request payload for submitting feedback on a training plan",
+            "type": "object",
+            "required": [
+                "plan_id",
+                "rating"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "Great plan!"
+                },
+                "difficulty_rating": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1,
+                    "example": 7
+                },
+                "plan_id": {
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1,
+                    "example": 5
+                },
+                "was_swam": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.FeedbackSharingRequest": {
+            "description": "Request payload for sharing the ratings of assigned plans with the coaches of a club",
+            "type": "object",
+            "properties": {
+                "share_feedback": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.FileToPlanResponse": {
+            "description": "Converted plan with the fragments of a text upload that could not be parsed",
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "A comprehensive training plan for improving freestyle technique"
+                },
+                "duration": {
+                    "description": "Duration is the estimated time needed to swim the plan",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DurationEstimate"
+                        }
+                    ]
+                },
+                "plan_id": {
+                    "description": "PlanID is the identifier of the training plan",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Advanced Freestyle Training"
+                },
+                "unparsed": {
+                    "description": "Unparsed lists parts of a text upload that were not understood",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ShorthandFragment"
+                    }
+                }
+            }
+        },
+        "models.GeneratePromptRequest": {
+            "description": "Request payload for generating a prompt for swim training plan creation",
+            "type": "object",
+            "required": [
+                "language"
+            ],
+            "properties": {
+                "language": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Language"
+                        }
+                    ],
+                    "example": "en"
+                }
+            }
+        },
+        "models.GeneratedPromptResponse": {
+            "description": "Response containing the generated prompt for swim training plan creation",
+            "type": "object",
+            "properties": {
+                "prompt": {
+                    "type": "string",
+                    "example": "Generate a swim training plan for improving freestyle technique"
+                }
+            }
+        },
+        "models.HealthStatus": {
+            "description": "Health status of the service and its components",
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "schema_version": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportSharedPlanResponse": {
+            "description": "Response containing the copy of the shared plan in the history of the user",
+            "type": "object",
+            "properties": {
+                "plan_id": {
+                    "description": "PlanID identifies the imported copy of the plan",
+                    "type": "string",
+                    "example": "plan_123"
+                }
+            }
+        },
+        "models.Language": {
+            "type": "string",
+            "enum": [
+                "en",
+                "de"
+            ],
+            "x-enum-varnames": [
+                "LanguageEN",
+                "LanguageDE"
+            ]
+        },
+        "models.MemberRole": {
+            "type": "string",
+            "enum": [
+                "coach",
+                "swimmer"
+            ],
+            "x-enum-varnames": [
+                "MemberRoleCoach",
+                "MemberRoleSwimmer"
+            ]
+        },
+        "models.MemberStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "invited"
+            ],
+            "x-enum-varnames": [
+                "MemberStatusActive",
+                "MemberStatusInvited"
+            ]
+        },
+        "models.MessagePayload": {
+            "description": "Snapshot of a training plan",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "Table containing the training plan details",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "next_message_id": {
+                    "type": "string"
+                },
+                "plan_id": {
+                    "type": "string"
+                },
+                "plan_snapshot": {
+                    "$ref": "#/definitions/models.RAGResponse"
+                },
+                "previous_message_id": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.Role"
+                },
+                "siblings": {
+                    "description": "Siblings are the ids of the branches at the position of the message, including the message",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Organization": {
+            "description": "Club or team the user is a member of",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "SV Blau-Weiß"
+                },
+                "org_id": {
+                    "type": "string",
+                    "example": "2f1d4c9e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+                },
+                "role": {
+                    "description": "Role is the role of the requesting user",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MemberRole"
+                        }
+                    ],
+                    "example": "coach"
+                },
+                "share_feedback": {
+                    "description": "ShareFeedback tells whether the requesting user shares ratings with the coaches",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.OrganizationInvitation": {
+            "description": "Invitation to a club, which the invited user has to accept to become a member",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "invitation_id": {
+                    "type": "string"
+                },
+                "invited_by": {
+                    "description": "InvitedBy is the username of the inviting coach",
+                    "type": "string",
+                    "example": "trainer"
+                },
+                "org_id": {
+                    "type": "string"
+                },
+                "org_name": {
+                    "type": "string",
+                    "example": "SV Blau-Weiß"
+                },
+                "role": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MemberRole"
+                        }
+                    ],
+                    "example": "swimmer"
+                }
+            }
+        },
+        "models.OrganizationMember": {
+            "description": "Member of a club with the username of the profile",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MemberRole"
+                        }
+                    ],
+                    "example": "swimmer"
+                },
+                "status": {
+                    "description": "Status is invited until the user accepts the invitation",
+                    "enum": [
+                        "active",
+                        "invited"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MemberStatus"
+                        }
+                    ],
+                    "example": "active"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "anna_k"
+                }
+            }
+        },
+        "models.OrganizationPlan": {
+            "description": "Training plan assigned to a squad or shared within a club",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "org_id": {
+                    "type": "string"
+                },
+                "plan_id": {
+                    "type": "string"
+                },
+                "shared_by": {
+                    "description": "SharedBy is the username of the coach or member who shared the plan",
+                    "type": "string"
+                },
+                "squad_id": {
+                    "description": "SquadID is set if the plan was assigned to a squad",
+                    "type": "string"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PDFLayout": {
+            "type": "string",
+            "enum": [
+                "standard",
+                "compact",
+                "poolside",
+                "coach",
+                "two_lane"
+            ],
+            "x-enum-comments": {
+                "PDFLayoutCoach": "Landscape sheet with empty split time columns",
+                "PDFLayoutCompact": "A5 card with small print",
+                "PDFLayoutPoolside": "High contrast sheet with large bold print",
+                "PDFLayoutStandard": "A4 with the horizontal and large font options",
+                "PDFLayoutTwoLane": "Two copies side by side, one for each lane"
+            },
+            "x-enum-descriptions": [
+                "A4 with the horizontal and large font options",
+                "A5 card with small print",
+                "High contrast sheet with large bold print",
+                "Landscape sheet with empty split time columns",
+                "Two copies side by side, one for each lane"
+            ],
+            "x-enum-varnames": [
+                "PDFLayoutStandard",
+                "PDFLayoutCompact",
+                "PDFLayoutPoolside",
+                "PDFLayoutCoach",
+                "PDFLayoutTwoLane"
+            ]
+        },
+        "models.Phase": {
+            "type": "string",
+            "enum": [
+                "base",
+                "build",
+                "taper",
+                "race"
+            ],
+            "x-enum-varnames": [
+                "PhaseBase",
+                "PhaseBuild",
+                "PhaseTaper",
+                "PhaseRace"
+            ]
+        },
+        "models.PlanConstraints": {
+            "description": "Explicit requirements a generated or refined plan has to meet",
+            "type": "object",
+            "properties": {
+                "equipment": {
+                    "description": "Equipment lists the available equipment. Omitted allows all equipment, an empty list none",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EquipmentType"
+                    },
+                    "example": [
+                        "Flossen",
+                        "Pull buoy"
+                    ]
+                },
+                "excluded_strokes": {
+                    "description": "ExcludedStrokes lists strokes which must not be part of the plan",
+                    "type": "array",
+                    "items": {
+                        "enum": [
+                            "freistil",
+                            "brust",
+                            "delfin",
+                            "ruecken",
+                            "lagen"
+                        ],
+                        "$ref": "#/definitions/models.StrokeType"
+                    },
+                    "example": [
+                        "delfin"
+                    ]
+                },
+                "max_duration": {
+                    "description": "MaxDuration is the maximum session length in minutes including rest",
+                    "type": "integer",
+                    "example": 60
+                },
+                "target_volume": {
+                    "description": "TargetVolume is the total distance of the plan in meters",
+                    "type": "integer",
+                    "example": 3000
+                }
+            }
+        },
+        "models.PlanDiffResponse": {
+            "description": "Row level difference between two versions of a training plan",
+            "type": "object",
+            "properties": {
+                "diff": {
+                    "$ref": "#/definitions/models.TableDiff"
+                },
+                "from": {
+                    "$ref": "#/definitions/models.PlanVersion"
+                },
+                "to": {
+                    "$ref": "#/definitions/models.PlanVersion"
+                }
+            }
+        },
+        "models.PlanExportRequest": {
+            "description": "Request payload for exporting a training plan as CSV, Markdown or portable JSON document",
+            "type": "object",
+            "required": [
+                "table",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "A comprehensive training plan for improving freestyle technique"
+                },
+                "language": {
+                    "description": "Language specifies the language of the table headers",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Language"
+                        }
+                    ],
+                    "example": "en"
+                },
+                "plan_id": {
+                    "description": "PlanID identifies the training plan to be exported",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Advanced Freestyle Training"
+                }
+            }
+        },
+        "models.PlanToFITRequest": {
+            "description": "Request payload for exporting a training plan as structured FIT workout for sport watches",
+            "type": "object",
+            "required": [
+                "table",
+                "title"
+            ],
+            "properties": {
+                "plan_id": {
+                    "description": "PlanID identifies the training plan to be exported",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "pool_length": {
+                    "description": "PoolLength is stored in the workout, defaults to 25"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Advanced Freestyle Training"
+                }
+            }
+        },
+        "models.PlanToPDFRequest": {
+            "description": "Request payload for exporting a training plan to PDF format",
+            "type": "object",
+            "required": [
+                "description",
+                "table",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "A comprehensive training plan for improving freestyle technique"
+                },
+                "drill_appendix": {
+                    "description": "DrillAppendix adds a page describing the linked drills with QR codes to their pages",
+                    "type": "boolean",
+                    "example": true
+                },
+                "frontend_base_url": {
+                    "description": "FrontendBaseURL is the base URL for drill links in the PDF",
+                    "type": "string",
+                    "example": "https://swim-gen.app"
+                },
+                "horizontal": {
+                    "description": "Horizontal indicates if the PDF should be in landscape orientation",
+                    "type": "boolean",
+                    "example": false
+                },
+                "language": {
+                    "description": "Language specifies the language for the PDF content",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Language"
+                        }
+                    ],
+                    "example": "en"
+                },
+                "large_font": {
+                    "description": "LargeFont indicates if the PDF should use a larger font size",
+                    "type": "boolean",
+                    "example": true
+                },
+                "layout": {
+                    "description": "Layout selects a print layout, Horizontal and LargeFont only apply to the standard layout",
+                    "enum": [
+                        "standard",
+                        "compact",
+                        "poolside",
+                        "coach",
+                        "two_lane"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PDFLayout"
+                        }
+                    ],
+                    "example": "poolside"
+                },
+                "plan_id": {
+                    "description": "PlanID identifies the training plan to be exported",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Advanced Freestyle Training"
+                }
+            }
+        },
+        "models.PlanToPDFResponse": {
+            "description": "Response containing the URI to the generated PDF file",
+            "type": "object",
+            "properties": {
+                "uri": {
+                    "type": "string",
+                    "example": "https://storage.googleapis.com/bucket/plans/plan_123.pdf"
+                }
+            }
+        },
+        "models.PlanVersion": {
+            "description": "Version of a training plan from its conversation history",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "message_id": {
+                    "description": "MessageID identifies the AI message holding the snapshot",
+                    "type": "string",
+                    "example": "9a2b..."
+                },
+                "plan": {
+                    "description": "Plan is only returned for a single version",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RAGResponse"
+                        }
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Ausdauer Kraul"
+                },
+                "version": {
+                    "description": "Version numbers the snapshots of the conversation starting at 1",
+                    "type": "integer",
+                    "example": 2
+                },
+                "volume": {
+                    "description": "Volume is the total distance of the version in meters",
+                    "type": "integer",
+                    "example": 3200
+                }
+            }
+        },
+        "models.PreferenceField": {
+            "type": "string",
+            "enum": [
+                "injury",
+                "disliked_drill",
+                "equipment",
+                "pool_length",
+                "session_minutes"
+            ],
+            "x-enum-varnames": [
+                "PreferenceInjury",
+                "PreferenceDislikedDrill",
+                "PreferenceEquipment",
+                "PreferencePoolLength",
+                "PreferenceSessionMinutes"
+            ]
+        },
+        "models.PreferenceProposal": {
+            "description": "Training preference proposed from a chat message, waiting for confirmation",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "field": {
+                    "enum": [
+                        "injury",
+                        "disliked_drill",
+                        "equipment",
+                        "pool_length",
+                        "session_minutes"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PreferenceField"
+                        }
+                    ],
+                    "example": "injury"
+                },
+                "message_id": {
+                    "description": "MessageID is the chat message the fact was learned from",
                     "type": "string"
                 },
                 "plan_id": {
+                    "description": "PlanID is the plan of the conversation the fact was learned in",
                     "type": "string"
                 },
-                "previous_message_id": {
+                "proposal_id": {
                     "type": "string"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "Schulterverletzung"
                 }
             }
         },
-        "models.AddPlanToHistoryRequest": {
-            "description": "Request payload for adding a plan to the authenticated user's history",
+        "models.QueryRequest": {
+            "description": "Request payload for querying swim training plans from the RAG system",
             "type": "object",
             "required": [
-                "description",
-                "plan_id",
-                "table",
-                "title"
+                "content",
+                "method"
             ],
+            "properties": {
+                "constraints": {
+                    "description": "Constraints are requirements the generated plan has to meet",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PlanConstraints"
+                        }
+                    ]
+                },
+                "content": {
+                    "description": "Content describes what kind of training plan is needed",
+                    "type": "string",
+                    "example": "I need a training plan for improving my freestyle technique"
+                },
+                "filter": {
+                    "description": "Filter allows filtering plans by metadata like difficulty or stroke type",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "language": {
+                    "description": "Language specifies the language for the response",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Language"
+                        }
+                    ],
+                    "example": "en"
+                },
+                "method": {
+                    "description": "Method can be either 'choose' (select existing plan) or 'generate' (create new plan)",
+                    "type": "string",
+                    "enum": [
+                        "choose",
+                        "generate"
+                    ],
+                    "example": "generate"
+                },
+                "pool_length": {
+                    "description": "PoolLength specifies the pool length for the training plan"
+                },
+                "preferences": {
+                    "description": "Preferences indicates if the user profile should be used for generation",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.RAGResponse": {
+            "description": "Response containing a generated or selected swim training plan",
+            "type": "object",
             "properties": {
                 "description": {
-                    "description": "Description of the plan",
                     "type": "string",
                     "example": "A comprehensive training plan for improving freestyle technique"
                 },
-                "initial_message": {
-                    "description": "Initial user message to persist when linking an anonymous plan",
-                    "type": "string"
+                "duration": {
+                    "description": "Duration is the estimated time needed to swim the plan",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DurationEstimate"
+                        }
+                    ]
                 },
                 "plan_id": {
-                    "description": "PlanID identifies the plan to add to history",
+                    "description": "PlanID is the identifier of the training plan",
+                    "type": "string",
+                    "example": "plan_123"
+                },
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Advanced Freestyle Training"
+                }
+            }
+        },
+        "models.RegenerateSessionRequest": {
+            "description": "Request payload for regenerating one session of a training block",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Content contains additional wishes for the new plan",
+                    "type": "string",
+                    "example": "Mehr Technik für die Wende"
+                },
+                "preferences": {
+                    "description": "Preferences indicates if the user profile should be used for generation",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.RestorePlanVersionResponse": {
+            "description": "Response containing the restored plan and the new version recording the restore",
+            "type": "object",
+            "properties": {
+                "plan_id": {
                     "type": "string",
                     "example": "plan_123"
                 },
-                "table": {
-                    "description": "A structured training plan table containing exercise rows",
+                "version": {
+                    "$ref": "#/definitions/models.PlanVersion"
+                }
+            }
+        },
+        "models.Role": {
+            "type": "string",
+            "enum": [
+                "user",
+                "ai"
+            ],
+            "x-enum-varnames": [
+                "RoleUser",
+                "RoleAI"
+            ]
+        },
+        "models.Row": {
+            "description": "A single exercise entry with amount, distance, breaks, content, intensity and total volume. Supports nested SubRows for compound sets like 8 x (800 + 200).",
+            "type": "object",
+            "properties": {
+                "Amount": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "Break": {
+                    "type": "string",
+                    "example": "20"
+                },
+                "Content": {
+                    "type": "string",
+                    "example": "Freestyle swim"
+                },
+                "Distance": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "Equipment": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EquipmentType"
+                    },
+                    "example": [
+                        "[\"Flossen\"]"
+                    ]
+                },
+                "Intensity": {
+                    "type": "string",
+                    "example": "Z1"
+                },
+                "Multiplier": {
+                    "type": "string",
+                    "example": "x"
+                },
+                "SubRows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Row"
+                    }
+                },
+                "Sum": {
+                    "type": "integer",
+                    "example": 400
+                }
+            }
+        },
+        "models.RowDiff": {
+            "description": "Added, removed or changed row of a training plan table",
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "description": "Fields lists the changed fields of changed rows",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Amount",
+                        "Sum"
+                    ]
+                },
+                "from": {
+                    "$ref": "#/definitions/models.Row"
+                },
+                "from_index": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "op": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DiffOp"
+                        }
+                    ],
+                    "example": "changed"
+                },
+                "sub_rows": {
+                    "description": "SubRows holds the changes of the sub rows of changed rows",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Row"
+                        "$ref": "#/definitions/models.RowDiff"
                     }
                 },
-                "title": {
-                    "description": "Title of the plan",
-                    "type": "string",
-                    "example": "Advanced Freestyle Training"
+                "to": {
+                    "$ref": "#/definitions/models.Row"
+                },
+                "to_index": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "models.AddPlanToHistoryResponse": {
-            "description": "Response containing the new plan ID and a success message",
+        "models.RowDuration": {
+            "description": "Estimated swim and rest time of a row in seconds",
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Plan added to history successfully"
+                "rest_seconds": {
+                    "type": "integer",
+                    "example": 80
                 },
-                "plan_id": {
+                "swim_seconds": {
+                    "type": "integer",
+                    "example": 420
+                }
+            }
+        },
+        "models.SelectBranchRequest": {
+            "description": "Request payload for making the branch through a message the active branch",
+            "type": "object",
+            "required": [
+                "message_id"
+            ],
+            "properties": {
+                "message_id": {
+                    "description": "MessageID identifies a message of the branch to select",
                     "type": "string",
-                    "example": "plan_123"
+                    "example": "msg_123"
                 }
             }
         },
-        "models.ChatRequest": {
-            "description": "Request payload for conversational training plan creation and refinement",
+        "models.SharePlanRequest": {
+            "description": "Request payload for sharing a swim training plan",
             "type": "object",
             "required": [
-                "message"
+                "method",
+                "plan_id"
             ],
             "properties": {
+                "email": {
+                    "description": "Email is the recipient address, required for the 'email' method",
+                    "type": "string",
+                    "example": "anna@example.com"
+                },
+                "expires_in_days": {
+                    "description": "ExpiresInDays limits how long a link share is valid, 0 keeps the current expiry",
+                    "type": "integer",
+                    "example": 30
+                },
                 "language": {
-                    "description": "Language specifies the language for the response",
+                    "description": "Language of the email, English if not German",
                     "allOf": [
                         {
                             "$ref": "#/definitions/models.Language"
                         }
                     ],
-                    "example": "en"
+                    "example": "de"
                 },
-                "message": {
-                    "description": "Message is the user's input to the chat",
-                    "type": "string",
-                    "example": "Make it more challenging"
+                "method": {
+                    "description": "Method specifies the sharing method, e.g., 'link' or 'email'",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SharingMethod"
+                        }
+                    ],
+                    "example": "link"
                 },
                 "plan_id": {
-                    "description": "PlanID identifies the conversation/plan (optional for new conversations)",
+                    "description": "PlanID identifies the training plan to be shared",
                     "type": "string",
                     "example": "plan_123"
-                },
-                "pool_length": {
-                    "description": "PoolLength specifies the pool length for the training plan"
                 }
             }
         },
-        "models.ChatResponsePayload": {
-            "description": "Response containing the updated plan and conversational response",
+        "models.SharePlanResponse": {
+            "description": "Response containing the sharing details of the swim training plan",
             "type": "object",
             "properties": {
-                "description": {
-                    "description": "Description of the training plan",
-                    "type": "string",
-                    "example": "A comprehensive training plan for improving freestyle technique"
-                },
-                "plan_id": {
-                    "description": "PlanID identifies the conversation/plan",
-                    "type": "string",
-                    "example": "plan_123"
-                },
-                "response": {
-                    "description": "Response is the conversational AI response explaining changes",
-                    "type": "string",
-                    "example": "I've made the plan more challenging by adding butterfly sets"
-                },
-                "table": {
-                    "description": "A structured training plan table containing exercise rows",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Row"
-                    }
-                },
-                "title": {
-                    "description": "Title of the training plan",
+                "url_hash": {
+                    "description": "URLHash is the hash to access the shared training plan",
                     "type": "string",
-                    "example": "Advanced Freestyle Training"
+                    "example": "abc123"
                 }
             }
         },
-        "models.DeleteConversationRequest": {
-            "description": "Request payload for deleting an entire conversation and all its messages",
+        "models.ShareWithOrganizationRequest": {
+            "description": "Request payload for sharing a training plan within a club",
             "type": "object",
             "required": [
                 "plan_id"
             ],
             "properties": {
                 "plan_id": {
-                    "description": "PlanID identifies the conversation to delete",
                     "type": "string",
                     "example": "plan_123"
                 }
             }
         },
-        "models.DeleteMessageRequest": {
-            "description": "Request payload for deleting a single message from conversation history",
-            "type": "object",
-            "required": [
-                "message_id"
-            ],
-            "properties": {
-                "message_id": {
-                    "description": "MessageID identifies the message to delete",
-                    "type": "string",
-                    "example": "msg_123"
-                }
-            }
-        },
-        "models.DeleteMessagesAfterRequest": {
-            "description": "Request payload for deleting a message and all subsequent messages in the conversation",
-            "type": "object",
-            "required": [
-                "message_id"
-            ],
-            "properties": {
-                "message_id": {
-                    "description": "MessageID identifies the message from which to delete (inclusive)",
-                    "type": "string",
-                    "example": "msg_123"
-                }
-            }
-        },
-        "models.Drill": {
+        "models.SharedPlan": {
+            "description": "Training plan shared via link, with the display name of its owner",
             "type": "object",
             "properties": {
                 "description": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "difficulty": {
                     "type": "string"
                 },
-                "img_description": {
+                "expires_at": {
+                    "description": "ExpiresAt is set if the share expires",
                     "type": "string"
                 },
-                "img_name": {
-                    "type": "string"
+                "owner": {
+                    "description": "Owner is the username of the sharing user",
+                    "type": "string",
+                    "example": "anna_k"
                 },
-                "language": {
+                "pdf_url": {
+                    "description": "PDFURL is a short-lived link to the plan as PDF, if requested",
                     "type": "string"
                 },
-                "short_description": {
-                    "type": "string"
+                "plan_id": {
+                    "type": "string",
+                    "example": "plan_123"
                 },
-                "slug": {
+                "shared_at": {
                     "type": "string"
                 },
-                "styles": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "target_groups": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "targets": {
+                "table": {
+                    "description": "A structured training plan table containing exercise rows",
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.Row"
                     }
                 },
                 "title": {
                     "type": "string"
                 },
-                "video_url": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "url_hash": {
+                    "type": "string",
+                    "example": "abc123"
+                },
+                "view_count": {
+                    "type": "integer",
+                    "example": 12
                 }
             }
         },
-        "models.EquipmentType": {
+        "models.SharingMethod": {
             "type": "string",
             "enum": [
-                "Flossen",
-                "Kickboard",
-                "Handpaddles",
-                "Pull buoy",
-                "Schnorchel"
+                "link",
+                "email"
             ],
             "x-enum-varnames": [
-                "EquipmentFins",
-                "EquipmentKickboard",
-                "EquipmentPaddles",
-                "EquipmentBuoy",
-                "EquipmentSnorkel"
+                "SharingMethodLink",
+                "SharingMethodEmail"
             ]
         },
-        "models.FeedbackRequest": {
-            "description": "Request payload for submitting feedback on a training plan",
+        "models.ShorthandFragment": {
+            "description": "A part of the uploaded text that was not understood by the shorthand parser",
             "type": "object",
-            "required": [
-                "plan_id",
-                "rating"
-            ],
             "properties": {
-                "comment": {
-                    "type": "string",
-                    "example": "Great plan!"
-                },
-                "difficulty_rating": {
+                "line": {
+                    "description": "Line is the 1-based line number of the fragment",
                     "type": "integer",
-                    "maximum": 10,
-                    "minimum": 1,
-                    "example": 7
+                    "example": 3
                 },
-                "plan_id": {
+                "reason": {
+                    "description": "Reason describes why the fragment was rejected",
                     "type": "string",
-                    "example": "plan_123"
-                },
-                "rating": {
-                    "type": "integer",
-                    "maximum": 5,
-                    "minimum": 1,
-                    "example": 5
+                    "example": "missing distance"
                 },
-                "was_swam": {
-                    "type": "boolean",
-                    "example": true
-                }
-            }
-        },
-        "models.GeneratePromptRequest": {
-            "description": "Request payload for generating a prompt for swim training plan creation",
-            "type": "object",
-            "required": [
-                "language"
-            ],
-            "properties": {
-                "language": {
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.Language"
-                        }
-                    ],
-                    "example": "en"
-                }
-            }
-        },
-        "models.GeneratedPromptResponse": {
-            "description": "Response containing the generated prompt for swim training plan creation",
-            "type": "object",
-            "properties": {
-                "prompt": {
+                "text": {
+                    "description": "Text is the fragment as written in the input",
                     "type": "string",
-                    "example": "Generate a swim training plan for improving freestyle technique"
+                    "example": "4x"
                 }
             }
         },
-        "models.HealthStatus": {
-            "description": "Health status of the service and its components",
+        "models.Squad": {
+            "description": "Training group of a club",
             "type": "object",
             "properties": {
-                "components": {
-                    "type": "object",
-                    "additionalProperties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "members": {
+                    "description": "Members are the user ids of the swimmers in the squad",
+                    "type": "array",
+                    "items": {
                         "type": "string"
                     }
                 },
-                "schema_version": {
-                    "type": "integer"
+                "name": {
+                    "type": "string",
+                    "example": "Leistungsgruppe 1"
                 },
-                "status": {
+                "org_id": {
                     "type": "string"
                 },
-                "timestamp": {
+                "squad_id": {
                     "type": "string"
                 }
             }
         },
-        "models.Language": {
+        "models.StrokeType": {
             "type": "string",
             "enum": [
-                "en",
-                "de"
+                "freistil",
+                "brust",
+                "delfin",
+                "ruecken",
+                "lagen"
             ],
             "x-enum-varnames": [
-                "LanguageEN",
-                "LanguageDE"
+                "StrokeFreestyle",
+                "StrokeBreaststroke",
+                "StrokeButterfly",
+                "StrokeBackstroke",
+                "StrokeMedley"
             ]
         },
-        "models.MessagePayload": {
-            "description": "Snapshot of a training plan",
+        "models.SwimmerPlanStatus": {
+            "description": "Feedback of a swimmer on an assigned plan",
             "type": "object",
             "properties": {
-                "content": {
-                    "type": "string"
+                "difficulty_rating": {
+                    "type": "integer",
+                    "example": 7
                 },
-                "created_at": {
-                    "description": "Table containing the training plan details",
+                "feedback_at": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
+                "rating": {
+                    "type": "integer",
+                    "example": 4
                 },
-                "next_message_id": {
+                "squad_id": {
                     "type": "string"
                 },
-                "plan_id": {
+                "user_id": {
                     "type": "string"
                 },
-                "plan_snapshot": {
-                    "$ref": "#/definitions/models.RAGResponse"
+                "username": {
+                    "type": "string",
+                    "example": "anna_k"
                 },
-                "previous_message_id": {
-                    "type": "string"
+                "was_swam": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.TableDiff": {
+            "description": "Row level difference between two training plan tables",
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "role": {
-                    "$ref": "#/definitions/models.Role"
+                "changed": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "from_volume": {
+                    "type": "integer",
+                    "example": 3000
+                },
+                "removed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RowDiff"
+                    }
+                },
+                "to_volume": {
+                    "type": "integer",
+                    "example": 3400
+                },
+                "volume_delta": {
+                    "type": "integer",
+                    "example": 400
                 }
             }
         },
-        "models.PlanToPDFRequest": {
-            "description": "Request payload for exporting a training plan to PDF format",
+        "models.TrainingBlock": {
+            "description": "Periodized training block with its weeks and the generated session plans",
             "type": "object",
-            "required": [
-                "description",
-                "table",
-                "title"
-            ],
             "properties": {
-                "description": {
+                "block_id": {
                     "type": "string",
-                    "example": "A comprehensive training plan for improving freestyle technique"
+                    "example": "2f1d4c9e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
                 },
-                "frontend_base_url": {
-                    "description": "FrontendBaseURL is the base URL for drill links in the PDF",
-                    "type": "string",
-                    "example": "https://swim-gen.app"
+                "constraints": {
+                    "$ref": "#/definitions/models.PlanConstraints"
                 },
-                "horizontal": {
-                    "description": "Horizontal indicates if the PDF should be in landscape orientation",
-                    "type": "boolean",
-                    "example": false
+                "created_at": {
+                    "type": "string"
+                },
+                "goal": {
+                    "type": "string",
+                    "example": "Bestzeit über 400m Freistil"
                 },
                 "language": {
-                    "description": "Language specifies the language for the PDF content",
                     "allOf": [
                         {
                             "$ref": "#/definitions/models.Language"
                         }
                     ],
-                    "example": "en"
+                    "example": "de"
                 },
-                "large_font": {
-                    "description": "LargeFont indicates if the PDF should use a larger font size",
-                    "type": "boolean",
-                    "example": true
+                "peak_volume": {
+                    "type": "integer",
+                    "example": 4000
                 },
-                "plan_id": {
-                    "description": "PlanID identifies the training plan to be exported",
+                "pool_length": {
                     "type": "string",
-                    "example": "plan_123"
+                    "example": "25"
                 },
-                "table": {
-                    "description": "A structured training plan table containing exercise rows",
+                "schedule": {
+                    "description": "Schedule contains the phase and volume of every week",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Row"
+                        "$ref": "#/definitions/models.BlockWeek"
+                    }
+                },
+                "sessions": {
+                    "description": "Sessions are ordered by week and session, they are omitted in lists",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BlockSession"
                     }
                 },
+                "sessions_per_week": {
+                    "type": "integer",
+                    "example": 3
+                },
                 "title": {
                     "type": "string",
-                    "example": "Advanced Freestyle Training"
-                }
-            }
-        },
-        "models.PlanToPDFResponse": {
-            "description": "Response containing the URI to the generated PDF file",
-            "type": "object",
-            "properties": {
-                "uri": {
-                    "type": "string",
-                    "example": "https://storage.googleapis.com/bucket/plans/plan_123.pdf"
+                    "example": "Frühjahrs-Meisterschaften"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weeks": {
+                    "type": "integer",
+                    "example": 10
                 }
             }
         },
-        "models.QueryRequest": {
-            "description": "Request payload for querying swim training plans from the RAG system",
+        "models.TrainingBlockRequest": {
+            "description": "Request payload for generating a periodized training block with one plan per session",
             "type": "object",
             "required": [
-                "content",
-                "method"
+                "goal",
+                "peak_volume",
+                "sessions_per_week",
+                "title",
+                "weeks"
             ],
             "properties": {
-                "content": {
-                    "description": "Content describes what kind of training plan is needed",
-                    "type": "string",
-                    "example": "I need a training plan for improving my freestyle technique"
+                "constraints": {
+                    "description": "Constraints apply to every session, the target volume is set by the periodization",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PlanConstraints"
+                        }
+                    ]
                 },
-                "filter": {
-                    "description": "Filter allows filtering plans by metadata like difficulty or stroke type",
-                    "type": "object",
-                    "additionalProperties": {}
+                "goal": {
+                    "description": "Goal describes the competition or aim of the block",
+                    "type": "string",
+                    "example": "Bestzeit über 400m Freistil bei den Bezirksmeisterschaften"
                 },
                 "language": {
-                    "description": "Language specifies the language for the response",
+                    "description": "Language specifies the language of the plans",
                     "allOf": [
                         {
                             "$ref": "#/definitions/models.Language"
                         }
                     ],
-                    "example": "en"
+                    "example": "de"
                 },
-                "method": {
-                    "description": "Method can be either 'choose' (select existing plan) or 'generate' (create new plan)",
-                    "type": "string",
-                    "enum": [
-                        "choose",
-                        "generate"
-                    ],
-                    "example": "generate"
+                "peak_volume": {
+                    "description": "PeakVolume is the session volume in meters at the end of the build phase",
+                    "type": "integer",
+                    "example": 4000
                 },
                 "pool_length": {
-                    "description": "PoolLength specifies the pool length for the training plan"
+                    "description": "PoolLength specifies the pool length for the plans"
                 },
                 "preferences": {
                     "description": "Preferences indicates if the user profile should be used for generation",
                     "type": "boolean"
-                }
-            }
-        },
-        "models.RAGResponse": {
-            "description": "Response containing a generated or selected swim training plan",
-            "type": "object",
-            "properties": {
-                "description": {
-                    "type": "string",
-                    "example": "A comprehensive training plan for improving freestyle technique"
-                },
-                "plan_id": {
-                    "description": "PlanID is the identifier of the training plan",
-                    "type": "string",
-                    "example": "plan_123"
                 },
-                "table": {
-                    "description": "A structured training plan table containing exercise rows",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Row"
-                    }
+                "sessions_per_week": {
+                    "description": "SessionsPerWeek is the number of plans per week",
+                    "type": "integer",
+                    "example": 3
                 },
                 "title": {
+                    "description": "Title is the name of the block",
                     "type": "string",
-                    "example": "Advanced Freestyle Training"
+                    "example": "Frühjahrs-Meisterschaften"
+                },
+                "weeks": {
+                    "description": "Weeks is the length of the block including the race week",
+                    "type": "integer",
+                    "example": 10
                 }
             }
         },
-        "models.Role": {
-            "type": "string",
-            "enum": [
-                "user",
-                "ai"
-            ],
-            "x-enum-varnames": [
-                "RoleUser",
-                "RoleAI"
-            ]
-        },
-        "models.Row": {
-            "description": "A single exercise entry with amount, distance, breaks, content, intensity and total volume. Supports nested SubRows for compound sets like 8 x (800 + 200).",
+        "models.TrainingPreferences": {
+            "description": "Long-term training preferences of the user",
             "type": "object",
             "properties": {
-                "Amount": {
-                    "type": "integer",
-                    "example": 4
-                },
-                "Break": {
-                    "type": "string",
-                    "example": "20"
-                },
-                "Content": {
-                    "type": "string",
-                    "example": "Freestyle swim"
-                },
-                "Distance": {
-                    "type": "integer",
-                    "example": 100
+                "disliked_drills": {
+                    "description": "DislikedDrills are drills or exercises the plans should avoid",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Abschlagschwimmen"
+                    ]
                 },
-                "Equipment": {
+                "equipment": {
+                    "description": "Equipment is the equipment the swimmer usually has available",
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.EquipmentType"
                     },
                     "example": [
-                        "[\"Flossen\"]"
+                        "Flossen",
+                        "Pull buoy"
                     ]
                 },
-                "Intensity": {
-                    "type": "string",
-                    "example": "Z1"
-                },
-                "Multiplier": {
-                    "type": "string",
-                    "example": "x"
-                },
-                "SubRows": {
+                "injuries": {
+                    "description": "Injuries are injuries or complaints the plans have to take into account",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Row"
-                    }
+                        "type": "string"
+                    },
+                    "example": [
+                        "Schulterverletzung"
+                    ]
                 },
-                "Sum": {
+                "pool_length": {
+                    "description": "PoolLength is the length of the pool the swimmer usually trains in",
                     "type": "integer",
-                    "example": 400
-                }
-            }
-        },
-        "models.SharePlanRequest": {
-            "description": "Request payload for sharing a swim training plan",
-            "type": "object",
-            "required": [
-                "method",
-                "plan_id"
-            ],
-            "properties": {
-                "method": {
-                    "description": "Method specifies the sharing method, e.g., 'link' or 'email'",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.SharingMethod"
-                        }
+                    "enum": [
+                        25,
+                        50
                     ],
-                    "example": "link"
+                    "example": 25
                 },
-                "plan_id": {
-                    "description": "PlanID identifies the training plan to be shared",
-                    "type": "string",
-                    "example": "plan_123"
-                }
-            }
-        },
-        "models.SharePlanResponse": {
-            "description": "Response containing the sharing details of the swim training plan",
-            "type": "object",
-            "properties": {
-                "url_hash": {
-                    "description": "URLHash is the hash to access the shared training plan",
-                    "type": "string",
-                    "example": "abc123"
+                "session_minutes": {
+                    "description": "SessionMinutes is the usual length of a training session",
+                    "type": "integer",
+                    "example": 60
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "models.SharingMethod": {
-            "type": "string",
-            "enum": [
-                "link",
-                "email"
-            ],
-            "x-enum-varnames": [
-                "SharingMethodLink",
-                "SharingMethodEmail"
-            ]
-        },
         "models.UploadPlanRequest": {
             "description": "Request payload for donating a swim training plan to the system",
             "type": "object",
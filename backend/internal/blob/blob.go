@@ -0,0 +1,18 @@
+// Package blob stores exported files in object storage and hands out signed,
+// expiring download URLs for them.
+package blob
+
+import (
+	"context"
+	"time"
+)
+
+// BlobStore stores objects and signs download URLs for them.
+type BlobStore interface {
+	// Put stores the data under the object name, replacing an existing object.
+	Put(ctx context.Context, name, contentType string, data []byte) error
+	// Exists reports whether an object with the name is stored.
+	Exists(ctx context.Context, name string) (bool, error)
+	// SignedURL returns a URL downloading the object until it expires.
+	SignedURL(ctx context.Context, name string, expires time.Duration) (string, error)
+}
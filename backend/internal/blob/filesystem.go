@@ -0,0 +1,224 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid file token")
+	ErrTokenExpired = errors.New("file token expired")
+)
+
+// cleanupInterval is the minimum time between two removals of expired files.
+const cleanupInterval = time.Hour
+
+// FileStore stores objects in a local directory. Its signed URLs point to the
+// /files/{token} route of the server, where Open checks the token and returns
+// the file until the token expires. Files older than the max age are treated as
+// missing and removed on later uploads, like a lifecycle rule of a bucket.
+type FileStore struct {
+	dir     string
+	baseURL string
+	key     []byte
+	maxAge  time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	cleaned time.Time
+}
+
+// Ensure FileStore implements BlobStore
+var _ BlobStore = (*FileStore)(nil)
+
+// NewFileStore creates the directory if needed. The base URL is the public URL
+// of the server, the key signs the tokens. Without a key a random one is used,
+// so URLs become invalid when the server restarts. A max age of 0 keeps files forever.
+func NewFileStore(dir, baseURL string, key []byte, maxAge time.Duration) (*FileStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("error creating directory: %w", err)
+	}
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("error generating signing key: %w", err)
+		}
+	}
+	return &FileStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), key: key, maxAge: maxAge, now: time.Now}, nil
+}
+
+// path returns the file of the object, rejecting names outside the directory.
+func (s *FileStore) path(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || strings.Contains(name, "\\") {
+		return "", fmt.Errorf("invalid object name %q", name)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+// Put writes the object to the directory. The content type is derived from the
+// file extension when the file is served.
+func (s *FileStore) Put(ctx context.Context, name, contentType string, data []byte) error {
+	file, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+	// Write to a temporary file first, so concurrent downloads never see a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	s.removeExpired()
+	return nil
+}
+
+// Exists reports expired files as missing, so they are uploaded again.
+func (s *FileStore) Exists(ctx context.Context, name string) (bool, error) {
+	file, err := s.path(name)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error reading file: %w", err)
+	}
+	return !s.expired(info), nil
+}
+
+func (s *FileStore) expired(info fs.FileInfo) bool {
+	return s.maxAge > 0 && s.now().Sub(info.ModTime()) > s.maxAge
+}
+
+// removeExpired removes the expired files and the directories left empty, at
+// most once per cleanup interval. Failures only keep the files until the next cleanup.
+func (s *FileStore) removeExpired() {
+	if s.maxAge <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.now().Sub(s.cleaned) < cleanupInterval {
+		return
+	}
+	s.cleaned = s.now()
+
+	var dirs []string
+	err := filepath.WalkDir(s.dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if file != s.dir {
+				dirs = append(dirs, file)
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if s.expired(info) {
+			if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Error removing expired files", "dir", s.dir, "error", err)
+	}
+	// Directories are walked before their contents, so remove them in reverse.
+	// Directories that are not empty fail to be removed and are kept.
+	for _, dir := range slices.Backward(dirs) {
+		_ = os.Remove(dir)
+	}
+}
+
+// SignedURL returns the URL of the /files/{token} route for the object.
+func (s *FileStore) SignedURL(ctx context.Context, name string, expires time.Duration) (string, error) {
+	if _, err := s.path(name); err != nil {
+		return "", err
+	}
+	payload := name + "\n" + strconv.FormatInt(s.now().Add(expires).Unix(), 10)
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.signature(payload))
+	return s.baseURL + "/files/" + token, nil
+}
+
+// Open checks the token and opens the file it was signed for. It returns the
+// object name along with the file.
+func (s *FileStore) Open(token string) (*os.File, string, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, "", ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, "", ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.signature(string(payload))) {
+		return nil, "", ErrInvalidToken
+	}
+
+	name, expiry, ok := strings.Cut(string(payload), "\n")
+	if !ok {
+		return nil, "", ErrInvalidToken
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return nil, "", ErrInvalidToken
+	}
+	if s.now().Unix() > expiresAt {
+		return nil, "", ErrTokenExpired
+	}
+
+	file, err := s.path(name)
+	if err != nil {
+		return nil, "", ErrInvalidToken
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, "", err
+	}
+	return f, name, nil
+}
+
+func (s *FileStore) signature(payload string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
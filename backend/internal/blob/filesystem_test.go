@@ -0,0 +1,105 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStoreServesSignedFiles(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), "http://localhost:8080/", []byte("secret"), 0)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	exists, err := store.Exists(ctx, "abc/plan.pdf")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, store.Put(ctx, "abc/plan.pdf", "application/pdf", []byte("%PDF")))
+	exists, err = store.Exists(ctx, "abc/plan.pdf")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	url, err := store.SignedURL(ctx, "abc/plan.pdf", 15*time.Minute)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(url, "http://localhost:8080/files/"))
+
+	file, name, err := store.Open(strings.TrimPrefix(url, "http://localhost:8080/files/"))
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF", string(data))
+	assert.Equal(t, "abc/plan.pdf", name)
+}
+
+func TestFileStoreRejectsInvalidTokens(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), "", []byte("secret"), 0)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "plan.pdf", "application/pdf", []byte("%PDF")))
+
+	url, err := store.SignedURL(ctx, "plan.pdf", time.Minute)
+	require.NoError(t, err)
+	token := strings.TrimPrefix(url, "/files/")
+
+	store.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, _, err = store.Open(token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	other, err := NewFileStore(t.TempDir(), "", []byte("other"), 0)
+	require.NoError(t, err)
+	for _, token := range []string{"", "abc", token + "x", "a.b"} {
+		_, _, err = other.Open(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, token)
+	}
+	_, _, err = other.Open(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestFileStoreKeepsObjectsInDirectory(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, "", nil, 0)
+	require.NoError(t, err)
+
+	file, err := store.path("../../etc/passwd")
+	require.NoError(t, err)
+	assert.Equal(t, dir+"/etc/passwd", file)
+
+	_, err = store.path("/")
+	assert.Error(t, err)
+	assert.Error(t, store.Put(context.Background(), "..", "application/pdf", nil))
+}
+
+func TestFileStoreRemovesExpiredFiles(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, "", nil, 24*time.Hour)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "cache/old/plan.pdf", "application/pdf", []byte("%PDF")))
+	require.NoError(t, store.Put(ctx, "cache/new/plan.pdf", "application/pdf", []byte("%PDF")))
+	old := time.Now().Add(-25 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "cache", "old", "plan.pdf"), old, old))
+
+	exists, err := store.Exists(ctx, "cache/old/plan.pdf")
+	require.NoError(t, err)
+	assert.False(t, exists, "expired files must be uploaded again")
+
+	// The first upload cleaned already, the next cleanup runs an interval later.
+	store.now = func() time.Time { return time.Now().Add(cleanupInterval) }
+	require.NoError(t, store.Put(ctx, "plan.pdf", "application/pdf", []byte("%PDF")))
+
+	_, err = os.Stat(filepath.Join(dir, "cache", "old"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	exists, err = store.Exists(ctx, "cache/new/plan.pdf")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
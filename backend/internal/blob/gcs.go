@@ -0,0 +1,82 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// GCSStore stores objects in a Google Cloud Storage bucket and signs V4 URLs
+// with the service account.
+type GCSStore struct {
+	client         *storage.Client
+	bucket         string
+	serviceAccount string
+}
+
+// Ensure GCSStore implements BlobStore
+var _ BlobStore = (*GCSStore)(nil)
+
+// NewGCSStore creates the client shared by all requests, using the application
+// default credentials of the environment.
+func NewGCSStore(ctx context.Context, bucket, serviceAccount string) (*GCSStore, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %w", err)
+	}
+	return &GCSStore{client: client, bucket: bucket, serviceAccount: serviceAccount}, nil
+}
+
+// Close closes the client of the store.
+func (s *GCSStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *GCSStore) Put(ctx context.Context, name, contentType string, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	// Creates an object writer.
+	wc := s.client.Bucket(s.bucket).Object(name).NewWriter(ctx)
+	wc.ContentType = contentType
+
+	// Uploads the data.
+	if _, err := io.Copy(wc, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	// Close and flush the contents
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
+	}
+	return nil
+}
+
+func (s *GCSStore) Exists(ctx context.Context, name string) (bool, error) {
+	_, err := s.client.Bucket(s.bucket).Object(name).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("ObjectHandle.Attrs: %w", err)
+	}
+	return true, nil
+}
+
+func (s *GCSStore) SignedURL(ctx context.Context, name string, expires time.Duration) (string, error) {
+	// Generate signed url (V4 Signing)
+	url, err := s.client.Bucket(s.bucket).SignedURL(name, &storage.SignedURLOptions{
+		GoogleAccessID: s.serviceAccount,
+		Scheme:         storage.SigningSchemeV4,
+		Method:         "GET",
+		Expires:        time.Now().Add(expires),
+	})
+	if err != nil {
+		return "", fmt.Errorf("storage.SignedURL: %w", err)
+	}
+	return url, nil
+}
@@ -0,0 +1,111 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// s3MaxExpires is the longest validity of a presigned URL, seven days.
+const s3MaxExpires = 7 * 24 * time.Hour
+
+// S3Config configures an S3 compatible object storage.
+type S3Config struct {
+	// Endpoint of an S3 compatible API, e.g. http://localhost:9000 for MinIO.
+	// Objects are addressed path style there. Without an endpoint AWS S3 is used
+	// with virtual hosted buckets.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store stores objects in an S3 compatible bucket. Requests are signed by the
+// AWS SDK client, download URLs by its presigner.
+type S3Store struct {
+	bucket    string
+	client    *s3.Client
+	presigner *s3.PresignClient
+}
+
+// Ensure S3Store implements BlobStore
+var _ BlobStore = (*S3Store)(nil)
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket name is required")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("access key and secret key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	options := s3.Options{
+		Region:      cfg.Region,
+		Credentials: credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, ""),
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if cfg.Endpoint != "" {
+		if _, err := url.ParseRequestURI(cfg.Endpoint); err != nil {
+			return nil, fmt.Errorf("invalid endpoint: %w", err)
+		}
+		options.BaseEndpoint = aws.String(cfg.Endpoint)
+		options.UsePathStyle = true
+		// Other providers do not all support the checksums the SDK adds by default
+		options.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		options.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+	}
+	client := s3.New(options)
+	return &S3Store{bucket: cfg.Bucket, client: client, presigner: s3.NewPresignClient(client)}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, name, contentType string, data []byte) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(name),
+		Body:   bytes.NewReader(data),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("error uploading object: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) Exists(ctx context.Context, name string) (bool, error) {
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(name)})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading object: %w", err)
+	}
+	return true, nil
+}
+
+// SignedURL presigns a GET request of the object, valid for at most seven days.
+func (s *S3Store) SignedURL(ctx context.Context, name string, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > s3MaxExpires {
+		return "", fmt.Errorf("expiry must be between 1 second and %s", s3MaxExpires)
+	}
+	req, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(name)},
+		s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("error presigning object URL: %w", err)
+	}
+	return req.URL, nil
+}
@@ -17,6 +17,18 @@ type Config struct {
 	LogLevel   string `env:"LOG_LEVEL"`
 	Port       string `env:"PORT"`
 
+	LLM struct {
+		// Provider selects the LLM backend, one of "vertex", "openai" or "fake".
+		Provider string `env:"LLM_PROVIDER" default:"vertex"`
+		// BaseURL of an OpenAI compatible API, e.g. http://localhost:11434/v1 for Ollama.
+		BaseURL string `env:"LLM_BASE_URL"`
+		APIKey  string `env:"LLM_API_KEY"`
+		// FixtureDir holds the canned responses served by the "fake" provider.
+		FixtureDir string `env:"LLM_FIXTURE_DIR"`
+		// RecordDir enables recording of all LLM responses as fixtures into this directory.
+		RecordDir string `env:"LLM_RECORD_DIR"`
+	}
+
 	Embedding struct {
 		Name      string `env:"EMBEDDING_NAME"`
 		DrillName string `env:"EMBEDDING_DRILL_NAME"`
@@ -41,14 +53,76 @@ type Config struct {
 	}
 
 	Bucket struct {
+		// Backend selects the storage of exported PDFs, one of "gcs", "s3" or "filesystem".
+		Backend        string `env:"BUCKET_BACKEND" default:"gcs"`
 		Name           string `env:"BUCKET_NAME"`
 		ServiceAccount string `env:"SIGNING_SA"`
+		// Endpoint of an S3 compatible API, e.g. http://localhost:9000 for MinIO, empty uses AWS S3.
+		Endpoint  string `env:"BUCKET_ENDPOINT"`
+		Region    string `env:"BUCKET_REGION" default:"us-east-1"`
+		AccessKey string `env:"BUCKET_ACCESS_KEY"`
+		SecretKey string `env:"BUCKET_SECRET_KEY"`
+		// Directory of the filesystem backend, its files are served under /files/{token}.
+		Directory string `env:"BUCKET_DIRECTORY" default:"exports"`
+		// PublicURL is the base URL of this server in the file URLs of the filesystem backend.
+		PublicURL string `env:"BUCKET_PUBLIC_URL" default:"http://localhost:8080"`
+		// SigningKey signs the file URLs of the filesystem backend, a random key is used if empty.
+		SigningKey string `env:"BUCKET_SIGNING_KEY"`
+		// MaxAgeHours removes files of the filesystem backend after that many hours, 0 keeps them.
+		// Buckets of the other backends need a lifecycle rule instead.
+		MaxAgeHours int `env:"BUCKET_MAX_AGE_HOURS" default:"24"`
+	}
+
+	PDF struct {
+		// DrillImageDir holds the drill images printed in the drill appendix of PDF exports.
+		DrillImageDir string `env:"PDF_DRILL_IMAGE_DIR" default:"../data/images"`
+	}
+
+	RateLimit struct {
+		// Store selects where limits are tracked, either "memory" or "postgres".
+		Store string `env:"RATE_LIMIT_STORE" default:"memory"`
+		// Requests per minute and burst size per authenticated user and per IP, 0 disables the limit.
+		UserPerMinute int `env:"RATE_LIMIT_USER_PER_MINUTE" default:"10"`
+		UserBurst     int `env:"RATE_LIMIT_USER_BURST" default:"5"`
+		IPPerMinute   int `env:"RATE_LIMIT_IP_PER_MINUTE" default:"20"`
+		IPBurst       int `env:"RATE_LIMIT_IP_BURST" default:"10"`
+		// Monthly generations on /query, /chat and /file-to-plan, 0 disables the quota.
+		MonthlyGenerations          int `env:"QUOTA_MONTHLY_GENERATIONS" default:"300"`
+		AnonymousMonthlyGenerations int `env:"QUOTA_ANONYMOUS_MONTHLY_GENERATIONS" default:"30"`
+		// Proxies in front of the API appending to X-Forwarded-For, e.g. 1 for the
+		// Cloud Run front end. The client IP is the entry appended by the outermost one.
+		TrustedProxies int `env:"RATE_LIMIT_TRUSTED_PROXIES" default:"1"`
+	}
+
+	Mail struct {
+		// SMTP server for plans shared by email, email sharing is disabled without a host.
+		Host     string `env:"SMTP_HOST"`
+		Port     string `env:"SMTP_PORT" default:"587"`
+		Username string `env:"SMTP_USERNAME"`
+		Password string `env:"SMTP_PASSWORD"`
+		From     string `env:"MAIL_FROM" default:"Swim Gen <noreply@swim-gen.com>"`
+		// FrontendURL is the base URL of the share links in emails.
+		FrontendURL string `env:"FRONTEND_URL" default:"https://swim-gen.com"`
+		// Plans a user may share by email per day, 0 disables the limit.
+		SharesPerDay int `env:"MAIL_SHARES_PER_DAY" default:"20"`
 	}
 
 	Chat struct {
 		HistoryLimit  int  `env:"CHAT_HISTORY_LIMIT" default:"10"`
 		UseRAGContext bool `env:"CHAT_USE_RAG_CONTEXT" default:"true"`
 	}
+
+	Ranking struct {
+		// Candidates is the number of plans retrieved by similarity before re-ranking.
+		Candidates int `env:"RANKING_CANDIDATES" default:"20"`
+		// Weights of the signals in the re-ranking score, all 0 except similarity keeps the vector order.
+		SimilarityWeight float64 `env:"RANKING_SIMILARITY_WEIGHT" default:"1"`
+		FeedbackWeight   float64 `env:"RANKING_FEEDBACK_WEIGHT" default:"0.3"`
+		ExportWeight     float64 `env:"RANKING_EXPORT_WEIGHT" default:"0.1"`
+		RecencyWeight    float64 `env:"RANKING_RECENCY_WEIGHT" default:"0.1"`
+		// RecencyHalfLifeDays is the age in days at which the recency score of a plan is halved.
+		RecencyHalfLifeDays int `env:"RANKING_RECENCY_HALF_LIFE_DAYS" default:"180"`
+	}
 }
 
 func LoadConfig(filename string, overwrite bool) (Config, error) {
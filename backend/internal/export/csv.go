@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+)
+
+// CSV columns: position, the columns of Table.Header and the equipment. The
+// position is "2" for the second row and "2.1" for its first sub row.
+const (
+	csvPosition = iota
+	csvAmount
+	csvMultiplier
+	csvDistance
+	csvBreak
+	csvContent
+	csvIntensity
+	csvSum
+	csvEquipment
+)
+
+func exportCSV(table models.Table, lang models.Language) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := append([]string{"#"}, table.Header(lang)...)
+	header = append(header, equipmentHeader(lang))
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for i, row := range table {
+		position := strconv.Itoa(i + 1)
+		if err := w.Write(csvRecord(position, row)); err != nil {
+			return nil, err
+		}
+		for j, sub := range row.SubRows {
+			if err := w.Write(csvRecord(fmt.Sprintf("%s.%d", position, j+1), sub)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func csvRecord(position string, row models.Row) []string {
+	return []string{
+		position,
+		strconv.Itoa(row.Amount),
+		row.Multiplier,
+		strconv.Itoa(row.Distance),
+		row.Break.String(),
+		row.Content,
+		row.Intensity,
+		strconv.Itoa(row.Sum),
+		joinEquipment(row.Equipment),
+	}
+}
+
+// importCSV parses a table written by exportCSV. The header line is optional and
+// the file may also use semicolons as separator, as spreadsheet programs do for
+// German locales.
+func importCSV(data []byte) (models.Table, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	var table models.Table
+	for i, record := range records {
+		if len(record) < csvEquipment {
+			return nil, fmt.Errorf("line %d: expected at least %d columns, got %d", i+1, csvEquipment, len(record))
+		}
+		if i == 0 && !isNumber(record[csvAmount]) {
+			continue // header
+		}
+		row, err := csvRow(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		parent, _, isSubRow := strings.Cut(record[csvPosition], ".")
+		if !isSubRow {
+			table = append(table, row)
+			continue
+		}
+		if len(table) == 0 || parent != strconv.Itoa(len(table)) {
+			return nil, fmt.Errorf("line %d: sub row %s does not follow its parent row", i+1, record[csvPosition])
+		}
+		table[len(table)-1].SubRows = append(table[len(table)-1].SubRows, row)
+	}
+	return table, nil
+}
+
+func csvRow(record []string) (models.Row, error) {
+	amount, err := parseInt(record[csvAmount])
+	if err != nil {
+		return models.Row{}, fmt.Errorf("invalid amount: %w", err)
+	}
+	distance, err := parseInt(record[csvDistance])
+	if err != nil {
+		return models.Row{}, fmt.Errorf("invalid distance: %w", err)
+	}
+	sum, err := parseInt(strings.TrimSuffix(strings.TrimSpace(record[csvSum]), " m"))
+	if err != nil {
+		return models.Row{}, fmt.Errorf("invalid volume: %w", err)
+	}
+	row := models.Row{
+		Amount:     amount,
+		Multiplier: strings.TrimSpace(record[csvMultiplier]),
+		Distance:   distance,
+		Break:      models.ParseInterval(record[csvBreak]),
+		Content:    strings.TrimSpace(record[csvContent]),
+		Intensity:  strings.TrimSpace(record[csvIntensity]),
+		Sum:        sum,
+	}
+	if len(record) > csvEquipment {
+		row.Equipment = splitEquipment(record[csvEquipment])
+	}
+	return row, nil
+}
+
+// parseInt parses an optional integer, empty values are zero.
+func parseInt(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func isNumber(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return true
+	}
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
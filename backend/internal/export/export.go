@@ -0,0 +1,157 @@
+// Package export converts training plans into portable interchange formats and
+// parses them back into validated plans.
+package export
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+)
+
+// Format is a supported interchange format.
+type Format string
+
+const (
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "md"
+	FormatJSON     Format = "json"
+)
+
+// Media types of the formats. The portable JSON document uses its own media type
+// to tell it apart from regular JSON API payloads.
+const (
+	MediaTypeCSV      = "text/csv"
+	MediaTypeMarkdown = "text/markdown"
+	MediaTypeJSON     = "application/vnd.swim-gen.plan+json"
+)
+
+// ParseFormat returns the format for the given name, e.g. from an URL parameter.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return FormatCSV, nil
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", name)
+	}
+}
+
+// FormatFromMediaType returns the format for a Content-Type header value.
+func FormatFromMediaType(contentType string) (Format, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch mediaType {
+	case MediaTypeCSV:
+		return FormatCSV, true
+	case MediaTypeMarkdown:
+		return FormatMarkdown, true
+	case MediaTypeJSON:
+		return FormatJSON, true
+	default:
+		return "", false
+	}
+}
+
+// MediaType returns the Content-Type of the format.
+func (f Format) MediaType() string {
+	switch f {
+	case FormatCSV:
+		return MediaTypeCSV + "; charset=utf-8"
+	case FormatMarkdown:
+		return MediaTypeMarkdown + "; charset=utf-8"
+	default:
+		return MediaTypeJSON
+	}
+}
+
+// Extension returns the file extension of the format including the dot.
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Export serializes the plan in the given format. Headers of the CSV and
+// Markdown formats are written in the given language.
+func Export(plan *models.Plan, format Format, lang models.Language) ([]byte, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("plan is nil")
+	}
+	switch format {
+	case FormatCSV:
+		return exportCSV(plan.Table, lang)
+	case FormatMarkdown:
+		return exportMarkdown(plan, lang), nil
+	case FormatJSON:
+		return exportJSON(plan, lang)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// Import parses data of the given format into a plan. The sums of the table are
+// recalculated and the plan is validated. CSV files only contain the table, so
+// title and description stay empty.
+func Import(data []byte, format Format) (*models.Plan, error) {
+	var (
+		plan *models.Plan
+		err  error
+	)
+	switch format {
+	case FormatCSV:
+		var table models.Table
+		table, err = importCSV(data)
+		plan = &models.Plan{Table: table}
+	case FormatMarkdown:
+		plan, err = importMarkdown(data)
+	case FormatJSON:
+		plan, err = importJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plan.Table) == 0 {
+		return nil, fmt.Errorf("plan contains no rows")
+	}
+	plan.Table.UpdateSum()
+	plan.Table.AddSum()
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// equipmentHeader returns the header of the equipment column, which is not part
+// of Table.Header.
+func equipmentHeader(lang models.Language) string {
+	if lang == models.LanguageDE {
+		return "Ausrüstung"
+	}
+	return "Equipment"
+}
+
+func joinEquipment(equipment []models.EquipmentType) string {
+	names := make([]string, len(equipment))
+	for i, e := range equipment {
+		names[i] = string(e)
+	}
+	return strings.Join(names, ", ")
+}
+
+func splitEquipment(s string) []models.EquipmentType {
+	var equipment []models.EquipmentType
+	for part := range strings.SplitSeq(s, ",") {
+		if part = strings.TrimSpace(part); part != "" && part != "-" {
+			equipment = append(equipment, models.EquipmentType(part))
+		}
+	}
+	return equipment
+}
@@ -0,0 +1,138 @@
+package export_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/export"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func samplePlan() *models.Plan {
+	plan := &models.Plan{
+		Title:       "Ausdauer | Technik",
+		Description: "Grundlagenausdauer mit Technikteil.",
+		Table: models.Table{
+			{Amount: 1, Multiplier: "x", Distance: 400, Break: models.Rest(20), Content: "Einschwimmen", Intensity: "GA1"},
+			{
+				Amount: 3, Multiplier: "x", Break: models.SendOff(300), Content: "Set mit | Pipe", Intensity: "GA2",
+				SubRows: []models.Row{
+					{Amount: 2, Multiplier: "x", Distance: 50, Break: models.Rest(10), Content: "Beine", Intensity: "GA2", Equipment: []models.EquipmentType{models.EquipmentKickboard}},
+					{Amount: 1, Multiplier: "x", Distance: 150, Content: "Lagen, locker", Intensity: "GA1", Equipment: []models.EquipmentType{models.EquipmentFins, models.EquipmentSnorkel}},
+				},
+			},
+			{Amount: 1, Multiplier: "x", Distance: 200, Content: "Ausschwimmen", Intensity: "REKOM"},
+		},
+	}
+	plan.Table.UpdateSum()
+	plan.Table.AddSum()
+	return plan
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []export.Format{export.FormatCSV, export.FormatMarkdown, export.FormatJSON} {
+		for _, lang := range []models.Language{models.LanguageDE, models.LanguageEN} {
+			t.Run(string(format)+"/"+string(lang), func(t *testing.T) {
+				plan := samplePlan()
+				data, err := export.Export(plan, format, lang)
+				require.NoError(t, err)
+
+				imported, err := export.Import(data, format)
+				require.NoError(t, err)
+
+				assert.Equal(t, plan.Table, imported.Table)
+				if format != export.FormatCSV {
+					assert.Equal(t, plan.Title, imported.Title)
+					assert.Equal(t, plan.Description, imported.Description)
+				}
+			})
+		}
+	}
+}
+
+func TestExportCSVUsesLanguageHeader(t *testing.T) {
+	data, err := export.Export(samplePlan(), export.FormatCSV, models.LanguageDE)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(data), "\n")
+	assert.Equal(t, "#,Anzahl,,Strecke(m),Pause(s),Inhalt,Intensität,Umfang,Ausrüstung", lines[0])
+	assert.Equal(t, "2.1,2,x,50,10,Beine,GA2,100,Kickboard", lines[3])
+}
+
+func TestExportMarkdown(t *testing.T) {
+	data, err := export.Export(samplePlan(), export.FormatMarkdown, models.LanguageEN)
+	require.NoError(t, err)
+
+	md := string(data)
+	assert.True(t, strings.HasPrefix(md, "# Ausdauer | Technik\n\nGrundlagenausdauer mit Technikteil.\n\n"))
+	assert.Contains(t, md, "| Amount |  | Distance(m) | Break(s) | Content | Intensity | Volume | Equipment |\n|---|---|---|---|---|---|---|---|\n")
+	assert.Contains(t, md, `| 3 | x | 250 | @5:00 | Set mit \| Pipe | GA2 | 750 |  |`)
+	assert.Contains(t, md, "| ↳ 1 | x | 150 |  | Lagen, locker | GA1 | 150 | Flossen, Schnorchel |")
+}
+
+func TestImportCSVWithSemicolonsAndWithoutTotal(t *testing.T) {
+	data := "1;4;x;100;20;Kraul;GA1;;\n2;1;x;200;;Rücken;;;Pull buoy\n"
+
+	plan, err := export.Import([]byte(data), export.FormatCSV)
+	require.NoError(t, err)
+
+	require.Len(t, plan.Table, 3)
+	assert.Equal(t, 400, plan.Table[0].Sum)
+	assert.Equal(t, []models.EquipmentType{models.EquipmentBuoy}, plan.Table[1].Equipment)
+	assert.Equal(t, "Gesamt", plan.Table[2].Content)
+	assert.Equal(t, 600, plan.Table[2].Sum)
+}
+
+func TestImportRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		format export.Format
+		data   string
+	}{
+		{"csv orphan sub row", export.FormatCSV, "1.1,1,x,100,,Kraul,,,\n"},
+		{"csv invalid amount", export.FormatCSV, "1,viele,x,100,,Kraul,,,\n"},
+		{"csv too few columns", export.FormatCSV, "1,1,x\n"},
+		{"csv invalid table", export.FormatCSV, "1,1,x,-100,,Kraul,,,\n"},
+		{"markdown without table", export.FormatMarkdown, "# Titel\n\nNur Text"},
+		{"markdown orphan sub row", export.FormatMarkdown, "| a | b | c | d | e | f | g |\n|---|---|---|---|---|---|---|\n| ↳ 1 | x | 100 |  | Kraul |  | 100 |\n"},
+		{"json without version", export.FormatJSON, `{"title":"Plan","table":[{"Amount":1,"Distance":100}]}`},
+		{"json future version", export.FormatJSON, `{"version":99,"title":"Plan","table":[{"Amount":1,"Distance":100}]}`},
+		{"json unknown field", export.FormatJSON, `{"version":1,"owner":"someone","table":[{"Amount":1,"Distance":100}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := export.Import([]byte(tt.data), tt.format)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := export.JSONSchema()
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, export.SchemaID, schema["$id"])
+
+	doc, err := export.Export(samplePlan(), export.FormatJSON, models.LanguageDE)
+	require.NoError(t, err)
+	assert.Contains(t, string(doc), `"$schema": "`+export.SchemaID+`"`)
+	assert.Contains(t, string(doc), `"version": 1`)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := export.ParseFormat("Markdown")
+	require.NoError(t, err)
+	assert.Equal(t, export.FormatMarkdown, format)
+
+	_, err = export.ParseFormat("xlsx")
+	assert.Error(t, err)
+
+	format, ok := export.FormatFromMediaType("text/csv; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, export.FormatCSV, format)
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/invopop/jsonschema"
+)
+
+// SchemaVersion is the version of the portable JSON document. It has to be
+// increased on every incompatible change of Document or models.Row.
+const SchemaVersion = 1
+
+// SchemaID identifies the JSON Schema of the current document version.
+var SchemaID = fmt.Sprintf("https://swim-gen.com/schemas/plan/v%d.json", SchemaVersion)
+
+// Document is the portable JSON representation of a training plan.
+type Document struct {
+	Schema      string          `json:"$schema" jsonschema_description:"URI of the JSON Schema of this document"`
+	Version     int             `json:"version" jsonschema:"minimum=1" jsonschema_description:"Version of the document format"`
+	ExportedAt  time.Time       `json:"exported_at" jsonschema_description:"Time of the export"`
+	Language    models.Language `json:"language,omitempty" jsonschema_description:"Language of the plan"`
+	Title       string          `json:"title" jsonschema_description:"Title of the training plan"`
+	Description string          `json:"description" jsonschema_description:"Description of the training plan"`
+	Table       models.Table    `json:"table" jsonschema_description:"Rows of the training plan"`
+}
+
+// JSONSchema returns the JSON Schema of Document.
+func JSONSchema() ([]byte, error) {
+	schema := jsonschema.Reflect(&Document{})
+	schema.ID = jsonschema.ID(SchemaID)
+	schema.Title = "Swim-Gen training plan"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func exportJSON(plan *models.Plan, lang models.Language) ([]byte, error) {
+	doc := Document{
+		Schema:      SchemaID,
+		Version:     SchemaVersion,
+		ExportedAt:  time.Now().UTC(),
+		Language:    lang,
+		Title:       plan.Title,
+		Description: plan.Description,
+		Table:       plan.Table,
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	return data, nil
+}
+
+func importJSON(data []byte) (*models.Plan, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var doc Document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse plan document: %w", err)
+	}
+	switch {
+	case doc.Version == 0:
+		return nil, fmt.Errorf("plan document has no version")
+	case doc.Version > SchemaVersion:
+		return nil, fmt.Errorf("plan document version %d is newer than the supported version %d", doc.Version, SchemaVersion)
+	}
+	return &models.Plan{
+		Title:       doc.Title,
+		Description: doc.Description,
+		Table:       doc.Table,
+	}, nil
+}
@@ -0,0 +1,150 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+)
+
+// subRowMarker prefixes the amount cell of sub rows, like the sub row lines of Table.String.
+const subRowMarker = "↳"
+
+// exportMarkdown writes the title as heading, the description as paragraph and
+// the table with the columns of Table.String.
+func exportMarkdown(plan *models.Plan, lang models.Language) []byte {
+	var buf bytes.Buffer
+	if plan.Title != "" {
+		fmt.Fprintf(&buf, "# %s\n\n", oneLine(plan.Title))
+	}
+	if plan.Description != "" {
+		fmt.Fprintf(&buf, "%s\n\n", strings.TrimSpace(plan.Description))
+	}
+
+	header := append(plan.Table.Header(lang), equipmentHeader(lang))
+	writeMarkdownRow(&buf, header)
+	buf.WriteString(strings.Repeat("|---", len(header)) + "|\n")
+	for _, row := range plan.Table {
+		writeMarkdownRow(&buf, markdownCells("", row))
+		for _, sub := range row.SubRows {
+			writeMarkdownRow(&buf, markdownCells(subRowMarker+" ", sub))
+		}
+	}
+	return buf.Bytes()
+}
+
+func markdownCells(prefix string, row models.Row) []string {
+	return []string{
+		prefix + strconv.Itoa(row.Amount),
+		row.Multiplier,
+		strconv.Itoa(row.Distance),
+		row.Break.String(),
+		row.Content,
+		row.Intensity,
+		strconv.Itoa(row.Sum),
+		joinEquipment(row.Equipment),
+	}
+}
+
+func writeMarkdownRow(buf *bytes.Buffer, cells []string) {
+	buf.WriteString("|")
+	for _, cell := range cells {
+		buf.WriteString(" " + strings.ReplaceAll(oneLine(cell), "|", `\|`) + " |")
+	}
+	buf.WriteString("\n")
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// importMarkdown parses a document written by exportMarkdown. The first level
+// one heading is the title, other text before the table the description.
+func importMarkdown(data []byte) (*models.Plan, error) {
+	plan := &models.Plan{}
+	var description []string
+	tableLine := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "|") {
+			if title, ok := strings.CutPrefix(line, "# "); ok && plan.Title == "" && len(plan.Table) == 0 {
+				plan.Title = strings.TrimSpace(title)
+			} else if tableLine == 0 {
+				description = append(description, line)
+			}
+			continue
+		}
+
+		tableLine++
+		cells := splitMarkdownRow(line)
+		if tableLine == 1 || isSeparatorRow(cells) {
+			continue // header
+		}
+		if len(cells) < 7 {
+			return nil, fmt.Errorf("line %d: expected at least 7 columns, got %d", lineNo, len(cells))
+		}
+
+		amount, isSubRow := strings.CutPrefix(cells[0], subRowMarker)
+		cells[0] = amount
+		row, err := csvRow(append([]string{""}, cells...))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if !isSubRow {
+			plan.Table = append(plan.Table, row)
+			continue
+		}
+		if len(plan.Table) == 0 {
+			return nil, fmt.Errorf("line %d: sub row without parent row", lineNo)
+		}
+		parent := &plan.Table[len(plan.Table)-1]
+		parent.SubRows = append(parent.SubRows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read markdown: %w", err)
+	}
+
+	plan.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return plan, nil
+}
+
+// splitMarkdownRow returns the trimmed cells of a table line, honoring escaped pipes.
+func splitMarkdownRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var (
+		cells []string
+		cell  strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func isSeparatorRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.Trim(cell, ":-") != "" || cell == "" {
+			return false
+		}
+	}
+	return true
+}
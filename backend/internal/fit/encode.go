@@ -0,0 +1,196 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	headerSize      = 14
+	protocolVersion = 0x20      // 2.0
+	profileVersion  = 2132      // 21.32
+	fitEpochOffset  = 631065600 // seconds between the unix epoch and 1989-12-31T00:00:00Z
+
+	manufacturerDevelopment = 255
+
+	mesgFileID      = 0
+	mesgWorkout     = 26
+	mesgWorkoutStep = 27
+
+	fileTypeWorkout = 5
+)
+
+// FIT base types used by the workout messages.
+const (
+	baseEnum    byte = 0x00
+	baseString  byte = 0x07
+	baseUint16  byte = 0x84
+	baseUint32  byte = 0x86
+	baseUint32z byte = 0x8C
+)
+
+// Fixed string sizes including the terminating null byte. Longer texts are truncated.
+const (
+	workoutNameSize = 40
+	stepNameSize    = 32
+	stepNotesSize   = 64
+)
+
+type fieldDef struct {
+	num  byte
+	size byte
+	base byte
+}
+
+var (
+	fileIDFields = []fieldDef{
+		{0, 1, baseEnum},    // type
+		{1, 2, baseUint16},  // manufacturer
+		{2, 2, baseUint16},  // product
+		{3, 4, baseUint32z}, // serial_number
+		{4, 4, baseUint32},  // time_created
+	}
+	workoutFields = []fieldDef{
+		{4, 1, baseEnum},                 // sport
+		{6, 2, baseUint16},               // num_valid_steps
+		{8, workoutNameSize, baseString}, // wkt_name
+		{11, 1, baseEnum},                // sub_sport
+		{14, 2, baseUint16},              // pool_length
+		{15, 1, baseEnum},                // pool_length_unit
+	}
+	workoutStepFields = []fieldDef{
+		{254, 2, baseUint16},           // message_index
+		{0, stepNameSize, baseString},  // wkt_step_name
+		{1, 1, baseEnum},               // duration_type
+		{2, 4, baseUint32},             // duration_value
+		{3, 1, baseEnum},               // target_type
+		{4, 4, baseUint32},             // target_value
+		{7, 1, baseEnum},               // intensity
+		{8, stepNotesSize, baseString}, // notes
+		{9, 1, baseEnum},               // equipment
+	}
+)
+
+// Encode serializes the workout as FIT file. created is stored as creation time
+// of the file and is also used as serial number, so repeated exports of the same
+// plan are distinguishable on the device.
+func (w *Workout) Encode(created time.Time) ([]byte, error) {
+	if len(w.Steps) == 0 {
+		return nil, fmt.Errorf("workout %q has no steps", w.Name)
+	}
+	if len(w.Steps) > 0xFFFE {
+		return nil, fmt.Errorf("workout %q has too many steps: %d", w.Name, len(w.Steps))
+	}
+
+	var data bytes.Buffer
+	timestamp := uint32(created.Unix() - fitEpochOffset)
+
+	writeDefinition(&data, 0, mesgFileID, fileIDFields)
+	data.WriteByte(0)
+	data.WriteByte(fileTypeWorkout)
+	writeUint16(&data, manufacturerDevelopment)
+	writeUint16(&data, 0)
+	writeUint32(&data, max(timestamp, 1))
+	writeUint32(&data, timestamp)
+
+	poolLength := uint16(0xFFFF) // invalid value for open water
+	if w.PoolLength > 0 {
+		poolLength = uint16(w.PoolLength * 100)
+	}
+	writeDefinition(&data, 1, mesgWorkout, workoutFields)
+	data.WriteByte(1)
+	data.WriteByte(byte(w.Sport))
+	writeUint16(&data, uint16(len(w.Steps)))
+	writeString(&data, w.Name, workoutNameSize)
+	data.WriteByte(byte(w.SubSport))
+	writeUint16(&data, poolLength)
+	data.WriteByte(0) // metric
+
+	writeDefinition(&data, 2, mesgWorkoutStep, workoutStepFields)
+	for i, s := range w.Steps {
+		data.WriteByte(2)
+		writeUint16(&data, uint16(i))
+		writeString(&data, s.Name, stepNameSize)
+		data.WriteByte(byte(s.DurationType))
+		writeUint32(&data, s.DurationValue)
+		data.WriteByte(byte(s.TargetType))
+		writeUint32(&data, s.TargetValue)
+		data.WriteByte(byte(s.Intensity))
+		writeString(&data, s.Notes, stepNotesSize)
+		data.WriteByte(byte(s.Equipment))
+	}
+
+	var file bytes.Buffer
+	file.Grow(headerSize + data.Len() + 2)
+	header := make([]byte, headerSize)
+	header[0] = headerSize
+	header[1] = protocolVersion
+	binary.LittleEndian.PutUint16(header[2:4], profileVersion)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(data.Len()))
+	copy(header[8:12], ".FIT")
+	binary.LittleEndian.PutUint16(header[12:14], crc(header[:12]))
+	file.Write(header)
+	file.Write(data.Bytes())
+	writeUint16(&file, crc(file.Bytes()))
+
+	return file.Bytes(), nil
+}
+
+// writeDefinition writes a little endian definition message for the given local message type.
+func writeDefinition(buf *bytes.Buffer, local byte, global uint16, fields []fieldDef) {
+	buf.WriteByte(0x40 | local)
+	buf.WriteByte(0) // reserved
+	buf.WriteByte(0) // little endian
+	writeUint16(buf, global)
+	buf.WriteByte(byte(len(fields)))
+	for _, f := range fields {
+		buf.Write([]byte{f.num, f.size, f.base})
+	}
+}
+
+func writeUint16(buf *bytes.Buffer, v uint16) {
+	buf.Write(binary.LittleEndian.AppendUint16(nil, v))
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	buf.Write(binary.LittleEndian.AppendUint32(nil, v))
+}
+
+// writeString writes s as null terminated string padded to size bytes. Strings
+// that are too long are cut at a rune boundary.
+func writeString(buf *bytes.Buffer, s string, size int) {
+	if len(s) > size-1 {
+		for len(s) > size-1 {
+			_, n := utf8.DecodeLastRuneInString(s)
+			s = s[:len(s)-n]
+		}
+		s = strings.TrimRightFunc(s, unicode.IsSpace)
+	}
+	buf.WriteString(s)
+	buf.Write(make([]byte, size-len(s)))
+}
+
+var crcTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// crc computes the FIT CRC-16 of data.
+func crc(data []byte) uint16 {
+	var sum uint16
+	for _, b := range data {
+		tmp := crcTable[sum&0xF]
+		sum = (sum >> 4) & 0x0FFF
+		sum = sum ^ tmp ^ crcTable[b&0xF]
+
+		tmp = crcTable[sum&0xF]
+		sum = (sum >> 4) & 0x0FFF
+		sum = sum ^ tmp ^ crcTable[(b>>4)&0xF]
+	}
+	return sum
+}
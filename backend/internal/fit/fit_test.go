@@ -0,0 +1,184 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC(t *testing.T) {
+	assert.Equal(t, uint16(0), crc(nil))
+	// Check value of CRC-16/ARC, which the FIT CRC is equivalent to.
+	assert.Equal(t, uint16(0xBB3D), crc([]byte("123456789")))
+}
+
+func TestParsePoolLength(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    int
+		wantErr bool
+	}{
+		{nil, 25, false},
+		{25, 25, false},
+		{float64(50), 50, false},
+		{"50", 50, false},
+		{"Freiwasser", 0, false},
+		{float64(25.5), 0, true},
+		{"Becken", 0, true},
+		{-25, 0, true},
+		{0, 0, true},
+		{9, 0, true},
+		{100, 100, false},
+		{"700", 0, true},
+		{float64(1e12), 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePoolLength(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, "input %v", tt.in)
+			continue
+		}
+		assert.NoError(t, err, "input %v", tt.in)
+		assert.Equal(t, tt.want, got, "input %v", tt.in)
+	}
+}
+
+func TestFromTable(t *testing.T) {
+	table := models.Table{
+		{Amount: 1, Distance: 400, Break: models.Rest(30), Content: "Einschwimmen"},
+		{Amount: 4, Distance: 100, Break: models.Rest(90), Content: "Kraul", Intensity: "GA2", Equipment: []models.EquipmentType{models.EquipmentPaddles}},
+		{
+			Amount: 3, Distance: 250, Break: models.SendOff(300), Content: "Set",
+			SubRows: []models.Row{
+				{Amount: 2, Distance: 50, Break: models.Rest(10), Content: "Beine"},
+				{Amount: 1, Distance: 150, Content: "Lagen"},
+			},
+		},
+		{Amount: 1, Distance: 0, Content: "Dehnen"},
+		{Amount: 1, Distance: 200, Content: "Ausschwimmen"},
+		{Content: "Gesamt", Sum: 2150},
+	}
+
+	w := FromTable("Test", table, 50)
+
+	assert.Equal(t, SubSportLapSwimming, w.SubSport)
+	assert.Equal(t, 50, w.PoolLength)
+	want := []Step{
+		{Name: "Einschwimmen", DurationType: DurationDistance, DurationValue: 40000, TargetType: TargetOpen, Intensity: IntensityWarmup},
+		{DurationType: DurationTime, DurationValue: 30000, TargetType: TargetOpen, Intensity: IntensityRest},
+		{Name: "Kraul", Notes: "GA2", DurationType: DurationDistance, DurationValue: 10000, TargetType: TargetOpen, Equipment: EquipmentPaddles},
+		{DurationType: DurationTime, DurationValue: 90000, TargetType: TargetOpen, Intensity: IntensityRest},
+		{DurationType: DurationRepeatUntilStepsComplete, DurationValue: 2, TargetType: TargetOpen, TargetValue: 4},
+		{Name: "Beine", DurationType: DurationDistance, DurationValue: 5000, TargetType: TargetOpen},
+		{DurationType: DurationTime, DurationValue: 10000, TargetType: TargetOpen, Intensity: IntensityRest},
+		{DurationType: DurationRepeatUntilStepsComplete, DurationValue: 5, TargetType: TargetOpen, TargetValue: 2},
+		{Name: "Lagen", DurationType: DurationDistance, DurationValue: 15000, TargetType: TargetOpen},
+		{Notes: "@5:00", DurationType: DurationOpen, TargetType: TargetOpen, Intensity: IntensityRest},
+		{DurationType: DurationRepeatUntilStepsComplete, DurationValue: 5, TargetType: TargetOpen, TargetValue: 3},
+		{Name: "Ausschwimmen", DurationType: DurationDistance, DurationValue: 20000, TargetType: TargetOpen, Intensity: IntensityCooldown},
+	}
+	assert.Equal(t, want, w.Steps)
+}
+
+func TestFromTableOpenWater(t *testing.T) {
+	// A rest of zero seconds adds no rest step.
+	w := FromTable("See", models.Table{{Amount: 1, Distance: 1500, Break: models.Rest(0), Content: "Kraul"}}, 0)
+	assert.Equal(t, SubSportOpenWater, w.SubSport)
+	require.Len(t, w.Steps, 1)
+}
+
+func TestEncode(t *testing.T) {
+	w := FromTable("Ein sehr langer Name für ein Training mit Überlänge", models.Table{
+		{Amount: 2, Distance: 100, Break: models.Rest(20), Content: "Kraul"},
+	}, 25)
+	created := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+
+	data, err := w.Encode(created)
+	require.NoError(t, err)
+
+	// Header
+	require.Greater(t, len(data), headerSize+2)
+	assert.Equal(t, byte(headerSize), data[0])
+	assert.Equal(t, ".FIT", string(data[8:12]))
+	assert.Equal(t, crc(data[:12]), binary.LittleEndian.Uint16(data[12:14]))
+	dataSize := binary.LittleEndian.Uint32(data[4:8])
+	assert.Equal(t, len(data), headerSize+int(dataSize)+2)
+	// The CRC of a file including its trailing CRC is zero.
+	assert.Equal(t, uint16(0), crc(data))
+
+	msgs := decode(t, data[headerSize:headerSize+int(dataSize)])
+	require.Len(t, msgs, 5)
+
+	assert.Equal(t, uint16(mesgFileID), msgs[0].global)
+	assert.Equal(t, byte(fileTypeWorkout), msgs[0].fields[0][0])
+	assert.Equal(t, uint32(created.Unix()-fitEpochOffset), binary.LittleEndian.Uint32(msgs[0].fields[4]))
+
+	workout := msgs[1]
+	assert.Equal(t, uint16(mesgWorkout), workout.global)
+	assert.Equal(t, byte(SportSwimming), workout.fields[4][0])
+	assert.Equal(t, uint16(3), binary.LittleEndian.Uint16(workout.fields[6]))
+	name := string(bytes.TrimRight(workout.fields[8], "\x00"))
+	assert.Equal(t, "Ein sehr langer Name für ein Training", name)
+	assert.Equal(t, uint16(2500), binary.LittleEndian.Uint16(workout.fields[14]))
+
+	repeat := msgs[4]
+	assert.Equal(t, uint16(mesgWorkoutStep), repeat.global)
+	assert.Equal(t, uint16(2), binary.LittleEndian.Uint16(repeat.fields[254]))
+	assert.Equal(t, byte(DurationRepeatUntilStepsComplete), repeat.fields[1][0])
+	assert.Equal(t, uint32(0), binary.LittleEndian.Uint32(repeat.fields[2]))
+	assert.Equal(t, uint32(2), binary.LittleEndian.Uint32(repeat.fields[4]))
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	w := FromTable("Leer", models.Table{{Content: "Gesamt"}}, 25)
+	_, err := w.Encode(time.Now())
+	assert.Error(t, err)
+}
+
+type message struct {
+	global uint16
+	fields map[byte][]byte
+}
+
+// decode parses the data records of a FIT file written by Encode.
+func decode(t *testing.T, data []byte) []message {
+	t.Helper()
+	defs := map[byte]struct {
+		global uint16
+		fields []fieldDef
+	}{}
+	var msgs []message
+	for len(data) > 0 {
+		header := data[0]
+		local := header & 0x0F
+		if header&0x40 != 0 {
+			require.Equal(t, byte(0), data[2], "only little endian is supported")
+			n := int(data[5])
+			def := defs[local]
+			def.global = binary.LittleEndian.Uint16(data[3:5])
+			def.fields = nil
+			for i := range n {
+				f := data[6+3*i : 9+3*i]
+				def.fields = append(def.fields, fieldDef{f[0], f[1], f[2]})
+			}
+			defs[local] = def
+			data = data[6+3*n:]
+			continue
+		}
+		def, ok := defs[local]
+		require.True(t, ok, "data message without definition")
+		msg := message{global: def.global, fields: map[byte][]byte{}}
+		data = data[1:]
+		for _, f := range def.fields {
+			msg.fields[f.num] = data[:f.size]
+			data = data[f.size:]
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
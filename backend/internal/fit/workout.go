@@ -0,0 +1,231 @@
+// Package fit exports training plans as structured FIT workouts, which can be
+// loaded onto Garmin and other sport watches.
+package fit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+)
+
+// Sport values of the FIT profile.
+type Sport byte
+
+const SportSwimming Sport = 5
+
+// SubSport values of the FIT profile.
+type SubSport byte
+
+const (
+	SubSportLapSwimming SubSport = 17
+	SubSportOpenWater   SubSport = 18
+)
+
+// DurationType defines how the end of a workout step is determined.
+type DurationType byte
+
+const (
+	DurationTime                     DurationType = 0 // DurationValue in milliseconds
+	DurationDistance                 DurationType = 1 // DurationValue in centimeters
+	DurationOpen                     DurationType = 5 // ended by pressing the lap button
+	DurationRepeatUntilStepsComplete DurationType = 6 // DurationValue is the first repeated step, TargetValue the repetitions
+)
+
+// TargetType defines the target of a workout step. Plans have no machine readable
+// targets, so all steps are open.
+type TargetType byte
+
+const TargetOpen TargetType = 2
+
+// Intensity of a workout step.
+type Intensity byte
+
+const (
+	IntensityActive   Intensity = 0
+	IntensityRest     Intensity = 1
+	IntensityWarmup   Intensity = 2
+	IntensityCooldown Intensity = 3
+)
+
+// Equipment used in a swim workout step.
+type Equipment byte
+
+const (
+	EquipmentNone      Equipment = 0
+	EquipmentFins      Equipment = 1
+	EquipmentKickboard Equipment = 2
+	EquipmentPaddles   Equipment = 3
+	EquipmentPullBuoy  Equipment = 4
+	EquipmentSnorkel   Equipment = 5
+)
+
+var equipmentMap = map[models.EquipmentType]Equipment{
+	models.EquipmentFins:      EquipmentFins,
+	models.EquipmentKickboard: EquipmentKickboard,
+	models.EquipmentPaddles:   EquipmentPaddles,
+	models.EquipmentBuoy:      EquipmentPullBuoy,
+	models.EquipmentSnorkel:   EquipmentSnorkel,
+}
+
+// Step is a single workout_step message.
+type Step struct {
+	Name          string
+	Notes         string
+	DurationType  DurationType
+	DurationValue uint32
+	TargetType    TargetType
+	TargetValue   uint32
+	Intensity     Intensity
+	Equipment     Equipment
+}
+
+// Workout is a structured swim workout. PoolLength is given in meters and is
+// zero for open water workouts.
+type Workout struct {
+	Name       string
+	Sport      Sport
+	SubSport   SubSport
+	PoolLength int
+	Steps      []Step
+}
+
+// Pool lengths in meters a workout can be exported for.
+const (
+	MinPoolLength = 10
+	MaxPoolLength = 100
+)
+
+// ParsePoolLength converts the pool length of a request into meters. Nil
+// defaults to 25 m, "Freiwasser" (open water) returns 0. Pools have to be
+// between MinPoolLength and MaxPoolLength long.
+func ParsePoolLength(poolLength any) (int, error) {
+	length := -1
+	switch v := poolLength.(type) {
+	case nil:
+		return 25, nil
+	case int:
+		length = v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= math.MaxInt32 {
+			length = int(v)
+		}
+	case string:
+		if strings.EqualFold(v, "Freiwasser") {
+			return 0, nil
+		}
+		if n, err := strconv.Atoi(v); err == nil {
+			length = n
+		}
+	}
+	if length < MinPoolLength || length > MaxPoolLength {
+		return 0, fmt.Errorf("invalid pool length %v, must be between %d and %d meters or Freiwasser", poolLength, MinPoolLength, MaxPoolLength)
+	}
+	return length, nil
+}
+
+// FromTable converts a plan table into a workout. Every row becomes a swim step
+// followed by an optional rest step from its break. Rows with an amount above
+// one are wrapped in a repeat step, rows with SubRows repeat all of their
+// children. Total rows and rows without distance are skipped.
+func FromTable(name string, table models.Table, poolLength int) Workout {
+	w := Workout{
+		Name:       name,
+		Sport:      SportSwimming,
+		SubSport:   SubSportLapSwimming,
+		PoolLength: poolLength,
+	}
+	if poolLength == 0 {
+		w.SubSport = SubSportOpenWater
+	}
+	for _, row := range table {
+		w.addRow(row)
+	}
+	return w
+}
+
+func (w *Workout) addRow(row models.Row) {
+	if row.IsTotal() || row.Distance <= 0 && len(row.SubRows) == 0 {
+		return
+	}
+
+	first := len(w.Steps)
+	if len(row.SubRows) > 0 {
+		for _, sub := range row.SubRows {
+			w.addRow(sub)
+		}
+		if len(w.Steps) == first {
+			return
+		}
+	} else {
+		w.Steps = append(w.Steps, Step{
+			Name:          row.Content,
+			Notes:         row.Intensity,
+			DurationType:  DurationDistance,
+			DurationValue: uint32(row.Distance) * 100,
+			TargetType:    TargetOpen,
+			Intensity:     rowIntensity(row),
+			Equipment:     rowEquipment(row),
+		})
+	}
+
+	if rest, ok := restStep(row.Break); ok {
+		w.Steps = append(w.Steps, rest)
+	}
+
+	if row.Amount > 1 {
+		w.Steps = append(w.Steps, Step{
+			DurationType:  DurationRepeatUntilStepsComplete,
+			DurationValue: uint32(first),
+			TargetType:    TargetOpen,
+			TargetValue:   uint32(row.Amount),
+			Intensity:     IntensityActive,
+		})
+	}
+}
+
+// rowIntensity detects warm up and cool down rows by their content.
+func rowIntensity(row models.Row) Intensity {
+	content := strings.ToLower(row.Content)
+	switch {
+	case strings.Contains(content, "einschwimmen") || strings.Contains(content, "warm"):
+		return IntensityWarmup
+	case strings.Contains(content, "ausschwimmen") || strings.Contains(content, "cool"):
+		return IntensityCooldown
+	default:
+		return IntensityActive
+	}
+}
+
+// rowEquipment returns the first equipment of the row, as a step supports only one.
+func rowEquipment(row models.Row) Equipment {
+	for _, e := range row.Equipment {
+		if eq, ok := equipmentMap[e]; ok {
+			return eq
+		}
+	}
+	return EquipmentNone
+}
+
+// restStep converts the break of a row into a rest step. Send-off times and free
+// text become an open rest with the break as note, the swimmer ends it with the
+// lap button.
+func restStep(brk models.Interval) (Step, bool) {
+	step := Step{TargetType: TargetOpen, Intensity: IntensityRest}
+	switch brk.Kind {
+	case models.IntervalRest:
+		if brk.Seconds == 0 {
+			return step, false
+		}
+		step.DurationType = DurationTime
+		step.DurationValue = uint32(brk.Seconds) * 1000
+	case models.IntervalSendOff, models.IntervalFree:
+		step.DurationType = DurationOpen
+		step.Notes = brk.String()
+	default:
+		return step, false
+	}
+	return step, true
+}
@@ -3,32 +3,113 @@ package genai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/go-chi/httplog/v2"
 	"github.com/tmc/langchaingo/schema"
-	"google.golang.org/genai"
 )
 
 // ChatRefine generates or refines a training plan based on conversation context.
-// It uses the conversation history, current plan state, and user's latest message
-// to create or update a plan while maintaining conversational context.
-func (gc *GoogleGenAIClient) ChatRefine(
+// It uses the conversation history, current plan state, user profile and the user's
+// latest message to create or update a plan while maintaining conversational context.
+// The summary of older messages, if any, is placed ahead of the history.
+func (gc *Client) ChatRefine(
 	ctx context.Context,
-	conversationHistory string,
+	summary, conversationHistory string,
 	currentPlan *models.Plan,
 	userMessage string,
 	lang string,
+	userProfile string,
 	poolLength any,
+	constraints *models.PlanConstraints,
 	contextDocs []schema.Document,
 ) (*models.ChatResponse, error) {
 	logger := httplog.LogEntry(ctx)
 
+	genReq, err := chatRefineRequest(summary, conversationHistory, currentPlan, userMessage, lang, userProfile, poolLength, constraints, contextDocs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Call the LLM
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.Model, genReq)
+	if err != nil {
+		logger.Error("Error when generating chat response with LLM", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error when generating chat response: %w", err)
+	}
+
+	chatResponse, err := parseChatResponse(ctx, answer)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("Chat response generated successfully")
+	return chatResponse, nil
+}
+
+// ChatRefineStream works like ChatRefine but streams the conversational response
+// text to onToken while the model is still generating. The returned plan is
+// additionally validated, as it is not reviewed before being sent to the client.
+func (gc *Client) ChatRefineStream(
+	ctx context.Context,
+	summary, conversationHistory string,
+	currentPlan *models.Plan,
+	userMessage string,
+	lang string,
+	userProfile string,
+	poolLength any,
+	constraints *models.PlanConstraints,
+	contextDocs []schema.Document,
+	onToken func(string) error,
+) (*models.ChatResponse, error) {
+	logger := httplog.LogEntry(ctx)
+
+	genReq, err := chatRefineRequest(summary, conversationHistory, currentPlan, userMessage, lang, userProfile, poolLength, constraints, contextDocs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The model answers with a JSON object, only the response field is forwarded.
+	streamer := newJSONFieldStreamer("response", onToken)
+	answer, err := gc.backend.generateContentStream(ctx, gc.cfg.Model, genReq, streamer.write)
+	if err != nil {
+		logger.Error("Error when streaming chat response with LLM", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error when streaming chat response: %w", err)
+	}
+
+	chatResponse, err := parseChatResponse(ctx, answer)
+	if err != nil {
+		return nil, err
+	}
+	if chatResponse.Plan != nil {
+		if err := chatResponse.Plan.Table.Validate(); err != nil {
+			logger.Error("Validation failed for streamed plan", httplog.ErrAttr(err))
+			return nil, fmt.Errorf("validation failed for streamed plan: %w", err)
+		}
+	}
+
+	logger.Debug("Chat response streamed successfully")
+	return chatResponse, nil
+}
+
+// chatRefineRequest builds the structured chat refinement request for the LLM.
+func chatRefineRequest(
+	summary, conversationHistory string,
+	currentPlan *models.Plan,
+	userMessage string,
+	lang string,
+	userProfile string,
+	poolLength any,
+	constraints *models.PlanConstraints,
+	contextDocs []schema.Document,
+) (contentRequest, error) {
 	// Get the ChatResponse JSON schema
 	chatSchema, err := models.ChatResponseSchema()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ChatResponse schema: %w", err)
+		return contentRequest{}, fmt.Errorf("failed to get ChatResponse schema: %w", err)
 	}
 
 	// Format reference plans for context
@@ -54,29 +135,91 @@ func (gc *GoogleGenAIClient) ChatRefine(
 		chatRefineTemplateStr,
 		poolLength,
 		lang,
+		userProfile,
+		formatSummary(summary),
 		conversationHistory,
 		currentPlanStr,
 		contextStr,
 		userMessage,
+		formatConstraints(constraints),
 	)
 
 	// Configure generation with structured output
-	genCfg := *gc.gcfg
-	genCfg.ResponseMIMEType = "application/json"
-	genCfg.ResponseJsonSchema = chatSchema
+	return contentRequest{Prompt: query, JSON: true, Schema: chatSchema}, nil
+}
 
-	// Call the LLM
-	answer, err := gc.gc.Models.GenerateContent(ctx, gc.cfg.Model, genai.Text(query), &genCfg)
+// SummarizeConversation condenses the messages that drop out of the chat history
+// into the previous summary, using the small model.
+func (gc *Client) SummarizeConversation(ctx context.Context, previousSummary, messages string) (string, error) {
+	logger := httplog.LogEntry(ctx)
+
+	if previousSummary == "" {
+		previousSummary = "Noch keine Zusammenfassung."
+	}
+	prompt := fmt.Sprintf(summarizeConversationTemplateStr, previousSummary, messages)
+
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.SmallModel, contentRequest{Prompt: prompt})
 	if err != nil {
-		logger.Error("Error when generating chat response with LLM", httplog.ErrAttr(err))
-		return nil, fmt.Errorf("error when generating chat response: %w", err)
+		logger.Error("Error when summarizing conversation with LLM", httplog.ErrAttr(err))
+		return "", fmt.Errorf("error when summarizing conversation: %w", err)
+	}
+
+	summary := strings.TrimSpace(answer)
+	if summary == "" {
+		return "", errors.New("error when summarizing conversation: empty summary")
+	}
+	logger.Debug("Conversation summarized successfully")
+	return summary, nil
+}
+
+// ExtractPreferences returns the long-term training preferences stated in a chat
+// message of the user, using the small model. Known preferences are passed to
+// the model to avoid proposing them again.
+func (gc *Client) ExtractPreferences(ctx context.Context, message, knownPreferences string) ([]models.PreferenceFact, error) {
+	logger := httplog.LogEntry(ctx)
+
+	factsSchema, err := models.PreferenceFactsSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PreferenceFacts schema: %w", err)
+	}
+	if strings.TrimSpace(knownPreferences) == "" {
+		knownPreferences = "Keine."
+	}
+	prompt := fmt.Sprintf(extractPreferencesTemplateStr, knownPreferences, message)
+
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.SmallModel, contentRequest{Prompt: prompt, JSON: true, Schema: factsSchema})
+	if err != nil {
+		logger.Error("Error when extracting preferences with LLM", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error when extracting preferences: %w", err)
+	}
+
+	var facts models.PreferenceFacts
+	if err := json.Unmarshal([]byte(answer), &facts); err != nil {
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
+		return nil, fmt.Errorf("error parsing LLM response: %w", err)
 	}
+	logger.Debug("Preferences extracted successfully", "count", len(facts.Facts))
+	return facts.Facts, nil
+}
+
+// formatSummary returns the prompt section for the summary of older messages,
+// empty if the whole conversation fits into the history.
+func formatSummary(summary string) string {
+	if summary == "" {
+		return ""
+	}
+	return fmt.Sprintf("ZUSAMMENFASSUNG DES FRÜHEREN GESPRÄCHS (diese Vorgaben gelten weiterhin):\n%s\n\n", summary)
+}
+
+// parseChatResponse parses the LLM answer and corrects the sums of the plan.
+func parseChatResponse(ctx context.Context, answer string) (*models.ChatResponse, error) {
+	logger := httplog.LogEntry(ctx)
 
 	// Parse the response
 	var chatResponse models.ChatResponse
-	err = json.Unmarshal([]byte(answer.Text()), &chatResponse)
+	err := json.Unmarshal([]byte(answer), &chatResponse)
 	if err != nil {
-		logger.Debug("LLM response could not be parsed", "raw_response", answer.Text())
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
 		logger.Error("Error parsing LLM response", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("error parsing LLM response: %w", err)
 	}
@@ -84,6 +227,7 @@ func (gc *GoogleGenAIClient) ChatRefine(
 	// If plan was generated, update sums
 	if chatResponse.Plan != nil && len(chatResponse.Plan.Table) > 0 {
 		chatResponse.Plan.Table.FlattenSingleParentRow()
+		chatResponse.Plan.Table.NormalizeBreaks()
 		// Ensure total row exists
 		if !containsTotal(chatResponse.Plan.Table) {
 			chatResponse.Plan.Table.AddSum()
@@ -91,8 +235,6 @@ func (gc *GoogleGenAIClient) ChatRefine(
 		// Recalculate sums to ensure correctness
 		chatResponse.Plan.Table.UpdateSum()
 	}
-
-	logger.Debug("Chat response generated successfully")
 	return &chatResponse, nil
 }
 
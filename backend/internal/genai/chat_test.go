@@ -0,0 +1,52 @@
+package genai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestChatRefineRequestPlacesSummaryBeforeHistory(t *testing.T) {
+	req, err := chatRefineRequest("Kein Delfin wegen Schulterverletzung", "Schwimmer: Mehr Beine", nil, "Noch mehr", "de", "", 25, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := strings.Index(req.Prompt, "Kein Delfin wegen Schulterverletzung")
+	history := strings.Index(req.Prompt, "GESPRÄCHSVERLAUF:")
+	if summary < 0 || history < 0 || summary > history {
+		t.Fatalf("summary at %d, history at %d, want summary ahead of the history", summary, history)
+	}
+
+	req, err = chatRefineRequest("", "Schwimmer: Mehr Beine", nil, "Noch mehr", "de", "", 25, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(req.Prompt, "ZUSAMMENFASSUNG") {
+		t.Fatal("prompt without summary contains a summary section")
+	}
+}
+
+func TestSummarizeConversationUsesSmallModel(t *testing.T) {
+	cfg := fakeTestConfig("")
+	cfg.SmallModel = "small"
+	fake, err := NewFakeClient(cfg, Fixture{Model: "small", Response: "  Kein Delfin wegen Schulterverletzung\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := fake.SummarizeConversation(context.Background(), "", "Schwimmer: Kein Delfin, Schulterverletzung")
+	if err != nil {
+		t.Fatalf("SummarizeConversation() error = %v", err)
+	}
+	if summary != "Kein Delfin wegen Schulterverletzung" {
+		t.Fatalf("SummarizeConversation() = %q", summary)
+	}
+
+	empty, err := NewFakeClient(cfg, Fixture{Model: "small", Response: " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := empty.SummarizeConversation(context.Background(), "Kein Delfin", "Schwimmer: Kürzer"); err == nil {
+		t.Fatal("SummarizeConversation() accepted an empty summary")
+	}
+}
@@ -2,52 +2,137 @@ package genai
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"github.com/5pirit5eal/swim-gen/internal/config"
-	"google.golang.org/genai"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/tmc/langchaingo/schema"
 )
 
-type GoogleGenAIClient struct {
-	gc        *genai.Client
-	gcfg      *genai.GenerateContentConfig
-	embedCfg  *genai.EmbedContentConfig
+const (
+	ProviderVertex = "vertex"
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
+// PlanModel covers all LLM backed operations on training plans.
+type PlanModel interface {
+	GeneratePlan(ctx context.Context, q, lang, userProfile string, poolLength any, constraints *models.PlanConstraints, planDocs, drillDocs []schema.Document) (*models.GeneratedPlan, error)
+	ChoosePlan(ctx context.Context, q, lang string, poolLength any, docs []schema.Document) (string, error)
+	ChatRefine(ctx context.Context, summary, conversationHistory string, currentPlan *models.Plan, userMessage string, lang string, userProfile string, poolLength any, constraints *models.PlanConstraints, contextDocs []schema.Document) (*models.ChatResponse, error)
+	ChatRefineStream(ctx context.Context, summary, conversationHistory string, currentPlan *models.Plan, userMessage string, lang string, userProfile string, poolLength any, constraints *models.PlanConstraints, contextDocs []schema.Document, onToken func(string) error) (*models.ChatResponse, error)
+	SummarizeConversation(ctx context.Context, previousSummary, messages string) (string, error)
+	ExtractPreferences(ctx context.Context, message, knownPreferences string) ([]models.PreferenceFact, error)
+	TranslatePlan(ctx context.Context, plan *models.Plan, lang models.Language) (*models.Plan, error)
+	FileToPlan(ctx context.Context, file []byte, filename string, mimeType string, language models.Language) (*models.GeneratedPlan, error)
+	DescribeTable(ctx context.Context, table *models.Table) (*models.Description, error)
+	GenerateMetadata(ctx context.Context, plan *models.Plan) (*models.Metadata, error)
+	GeneratePrompt(ctx context.Context, req models.GeneratePromptRequest) (string, error)
+	ImprovePlan(ctx context.Context, plan models.ScrapedPlan, syncGroup *sync.WaitGroup, c chan<- models.Document, ec chan<- error)
+}
+
+// Embedder creates embeddings for documents and queries. It satisfies the
+// langchaingo embeddings.EmbedderClient interface.
+type Embedder interface {
+	CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error)
+	QueryMode()
+	DocumentMode()
+}
+
+// Provider is a LLM provider offering both plan generation and embeddings.
+type Provider interface {
+	PlanModel
+	Embedder
+}
+
+// Client implements the plan generation and embedding logic on top of a
+// provider specific backend. Prompt construction and response post-processing
+// live here, the backend only transports requests to the model.
+type Client struct {
+	backend   backend
 	queryMode bool
 	cfg       config.Config
 }
 
-func NewGoogleGenAIClient(ctx context.Context, cfg config.Config) (*GoogleGenAIClient, error) {
-	gc, err := genai.NewClient(ctx, &genai.ClientConfig{
-		Project:  cfg.ProjectID,
-		Location: cfg.Region,
-		Backend:  genai.BackendVertexAI,
-	})
+// backend is the minimal set of operations a LLM provider has to support.
+type backend interface {
+	generateContent(ctx context.Context, model string, req contentRequest) (string, error)
+	// generateContentStream calls onChunk for every received text chunk and
+	// returns the complete answer.
+	generateContentStream(ctx context.Context, model string, req contentRequest, onChunk func(string) error) (string, error)
+	embedContent(ctx context.Context, model string, text string) ([]float32, error)
+}
+
+// contentRequest describes a single generation call independent of the provider.
+type contentRequest struct {
+	// Prompt is the text instruction sent to the model.
+	Prompt string
+	// File is an optional attachment (image or document) sent before the prompt.
+	File []byte
+	// MIMEType is the mime type of File.
+	MIMEType string
+	// JSON requests a JSON encoded answer.
+	JSON bool
+	// Schema optionally constrains the JSON answer to the given JSON schema.
+	Schema any
+	// Thinking keeps the default reasoning behaviour of the model enabled.
+	Thinking bool
+}
+
+func newClient(b backend, cfg config.Config) *Client {
+	return &Client{
+		backend:   b,
+		queryMode: false,
+		cfg:       cfg,
+	}
+}
+
+// NewClient creates the client for the provider selected in cfg.LLM.Provider.
+// If cfg.LLM.RecordDir is set, all responses are additionally stored as fixtures.
+func NewClient(ctx context.Context, cfg config.Config) (*Client, error) {
+	var (
+		c   *Client
+		err error
+	)
+	switch cfg.LLM.Provider {
+	case "", ProviderVertex:
+		c, err = NewGoogleGenAIClient(ctx, cfg)
+	case ProviderOpenAI:
+		c, err = NewOpenAIClient(cfg)
+	case ProviderFake:
+		return NewFakeClient(cfg)
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.LLM.Provider)
+	}
 	if err != nil {
 		return nil, err
 	}
-	gcfg := &genai.GenerateContentConfig{
-		CandidateCount: int32(1),
-		Temperature:    genai.Ptr(float32(1.5)),
-		SafetySettings: []*genai.SafetySetting{
-			{Category: genai.HarmCategoryHateSpeech, Threshold: genai.HarmBlockThresholdBlockLowAndAbove},
-			{Category: genai.HarmCategorySexuallyExplicit, Threshold: genai.HarmBlockThresholdBlockLowAndAbove},
-			{Category: genai.HarmCategoryHarassment, Threshold: genai.HarmBlockThresholdBlockLowAndAbove},
-			{Category: genai.HarmCategoryDangerousContent, Threshold: genai.HarmBlockThresholdBlockLowAndAbove},
-		},
-		ThinkingConfig: &genai.ThinkingConfig{
-			IncludeThoughts: false,
-			ThinkingBudget:  genai.Ptr[int32](0),
-		},
+	if cfg.LLM.RecordDir != "" {
+		rb, err := newRecordingBackend(c.backend, cfg.LLM.RecordDir)
+		if err != nil {
+			return nil, err
+		}
+		c.backend = rb
+	}
+	return c, nil
+}
+
+// NewGoogleGenAIClient creates a client backed by Gemini models on Vertex AI.
+func NewGoogleGenAIClient(ctx context.Context, cfg config.Config) (*Client, error) {
+	b, err := newVertexBackend(ctx, cfg)
+	if err != nil {
+		return nil, err
 	}
-	embedCfg := &genai.EmbedContentConfig{
-		// Gemini Embedding 2 uses task instructions in the content instead of the
-		// task_type field used by earlier embedding models.
-		OutputDimensionality: genai.Ptr(int32(cfg.Embedding.Size)),
+	return newClient(b, cfg), nil
+}
+
+// NewOpenAIClient creates a client backed by an OpenAI compatible HTTP API,
+// e.g. a local Ollama or llama.cpp server.
+func NewOpenAIClient(cfg config.Config) (*Client, error) {
+	b, err := newOpenAIBackend(cfg)
+	if err != nil {
+		return nil, err
 	}
-	return &GoogleGenAIClient{
-		gc:        gc,
-		gcfg:      gcfg,
-		embedCfg:  embedCfg,
-		queryMode: false,
-		cfg:       cfg,
-	}, nil
+	return newClient(b, cfg), nil
 }
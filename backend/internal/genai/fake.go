@@ -0,0 +1,238 @@
+package genai
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+)
+
+// ErrFixtureNotFound is returned by the fake backend if no fixture matches a request.
+var ErrFixtureNotFound = errors.New("no LLM fixture found")
+
+const (
+	fixtureKindGenerate = "generate"
+	fixtureKindEmbed    = "embed"
+)
+
+// Fixture is a canned LLM response. Fixtures are stored as <Key>.json files and
+// matched by the hash of the request, see FixtureKey. A generation fixture with
+// an empty prompt is the default answer for all unmatched prompts of its model.
+type Fixture struct {
+	Key       string    `json:"key"`
+	Kind      string    `json:"kind"`
+	Model     string    `json:"model"`
+	Prompt    string    `json:"prompt"`
+	Response  string    `json:"response,omitempty"`
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+// FixtureKey returns the hash identifying a generation request for the given
+// model, prompt and optional file attachment.
+func FixtureKey(model, prompt string, file []byte) string {
+	return fixtureKey(fixtureKindGenerate, model, prompt, file)
+}
+
+// EmbeddingFixtureKey returns the hash identifying an embedding request.
+func EmbeddingFixtureKey(model, text string) string {
+	return fixtureKey(fixtureKindEmbed, model, text, nil)
+}
+
+func fixtureKey(kind, model, prompt string, file []byte) string {
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(kind), []byte(model), []byte(prompt), file} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fakeBackend answers requests from fixtures instead of calling a LLM. Embeddings
+// without a fixture are derived deterministically from the hash of the text.
+type fakeBackend struct {
+	mu            sync.RWMutex
+	fixtures      map[string]Fixture
+	embeddingSize int
+}
+
+// NewFakeClient creates a client that never leaves the process. It serves the
+// fixtures stored in cfg.LLM.FixtureDir and the additionally given ones.
+func NewFakeClient(cfg config.Config, fixtures ...Fixture) (*Client, error) {
+	b := &fakeBackend{fixtures: map[string]Fixture{}, embeddingSize: cfg.Embedding.Size}
+	if cfg.LLM.FixtureDir != "" {
+		loaded, err := LoadFixtures(cfg.LLM.FixtureDir)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(loaded, fixtures...)
+	}
+	for _, f := range fixtures {
+		if f.Key == "" {
+			f.Key = fixtureKey(cmp.Or(f.Kind, fixtureKindGenerate), f.Model, f.Prompt, nil)
+		}
+		b.fixtures[f.Key] = f
+	}
+	return newClient(b, cfg), nil
+}
+
+// LoadFixtures reads all fixture files from dir.
+func LoadFixtures(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	fixtures := make([]Fixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+func (f *fakeBackend) generateContent(_ context.Context, model string, req contentRequest) (string, error) {
+	key := FixtureKey(model, req.Prompt, req.File)
+	f.mu.RLock()
+	fixture, ok := f.fixtures[key]
+	if !ok {
+		fixture, ok = f.fixtures[FixtureKey(model, "", nil)]
+	}
+	f.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w for model %s (key %s)", ErrFixtureNotFound, model, key)
+	}
+	return fixture.Response, nil
+}
+
+// generateContentStream replays the fixture word by word.
+func (f *fakeBackend) generateContentStream(ctx context.Context, model string, req contentRequest, onChunk func(string) error) (string, error) {
+	answer, err := f.generateContent(ctx, model, req)
+	if err != nil {
+		return "", err
+	}
+	for _, chunk := range strings.SplitAfter(answer, " ") {
+		if err := onChunk(chunk); err != nil {
+			return "", err
+		}
+	}
+	return answer, nil
+}
+
+func (f *fakeBackend) embedContent(_ context.Context, model string, text string) ([]float32, error) {
+	key := EmbeddingFixtureKey(model, text)
+	f.mu.RLock()
+	fixture, ok := f.fixtures[key]
+	f.mu.RUnlock()
+	if ok && len(fixture.Embedding) > 0 {
+		return fixture.Embedding, nil
+	}
+	return hashEmbedding(key, f.embeddingSize), nil
+}
+
+// hashEmbedding expands the key into a unit vector of the given size, so equal
+// texts always produce equal vectors.
+func hashEmbedding(key string, size int) []float32 {
+	values := make([]float32, size)
+	var norm float64
+	for i := range values {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", key, i)))
+		v := float64(binary.BigEndian.Uint32(sum[:4]))/math.MaxUint32*2 - 1
+		values[i] = float32(v)
+		norm += v * v
+	}
+	if norm == 0 {
+		return values
+	}
+	norm = math.Sqrt(norm)
+	for i := range values {
+		values[i] = float32(float64(values[i]) / norm)
+	}
+	return values
+}
+
+// recordingBackend forwards requests to a real backend and stores every
+// successful answer as fixture file in dir, to be replayed by the fake backend.
+type recordingBackend struct {
+	next backend
+	dir  string
+}
+
+func newRecordingBackend(next backend, dir string) (*recordingBackend, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	return &recordingBackend{next: next, dir: dir}, nil
+}
+
+func (r *recordingBackend) generateContent(ctx context.Context, model string, req contentRequest) (string, error) {
+	answer, err := r.next.generateContent(ctx, model, req)
+	if err != nil {
+		return "", err
+	}
+	err = r.write(Fixture{
+		Key:      FixtureKey(model, req.Prompt, req.File),
+		Kind:     fixtureKindGenerate,
+		Model:    model,
+		Prompt:   req.Prompt,
+		Response: answer,
+	})
+	return answer, err
+}
+
+func (r *recordingBackend) generateContentStream(ctx context.Context, model string, req contentRequest, onChunk func(string) error) (string, error) {
+	answer, err := r.next.generateContentStream(ctx, model, req, onChunk)
+	if err != nil {
+		return "", err
+	}
+	err = r.write(Fixture{
+		Key:      FixtureKey(model, req.Prompt, req.File),
+		Kind:     fixtureKindGenerate,
+		Model:    model,
+		Prompt:   req.Prompt,
+		Response: answer,
+	})
+	return answer, err
+}
+
+func (r *recordingBackend) embedContent(ctx context.Context, model string, text string) ([]float32, error) {
+	values, err := r.next.embedContent(ctx, model, text)
+	if err != nil {
+		return nil, err
+	}
+	err = r.write(Fixture{
+		Key:       EmbeddingFixtureKey(model, text),
+		Kind:      fixtureKindEmbed,
+		Model:     model,
+		Prompt:    text,
+		Embedding: values,
+	})
+	return values, err
+}
+
+func (r *recordingBackend) write(f Fixture) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, f.Key+".json"), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package genai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+)
+
+type stubBackend struct {
+	answer string
+	calls  int
+}
+
+func (s *stubBackend) generateContent(context.Context, string, contentRequest) (string, error) {
+	s.calls++
+	return s.answer, nil
+}
+
+func (s *stubBackend) generateContentStream(ctx context.Context, model string, req contentRequest, onChunk func(string) error) (string, error) {
+	answer, err := s.generateContent(ctx, model, req)
+	if err != nil {
+		return "", err
+	}
+	return answer, onChunk(answer)
+}
+
+func (s *stubBackend) embedContent(context.Context, string, string) ([]float32, error) {
+	s.calls++
+	return []float32{1, 0, 0}, nil
+}
+
+func fakeTestConfig(dir string) config.Config {
+	var cfg config.Config
+	cfg.Model = "test-model"
+	cfg.Embedding.Model = "test-embedding"
+	cfg.Embedding.Size = 3
+	cfg.LLM.FixtureDir = dir
+	return cfg
+}
+
+func TestRecordedFixturesAreReplayedByFakeClient(t *testing.T) {
+	dir := t.TempDir()
+	cfg := fakeTestConfig(dir)
+	stub := &stubBackend{answer: `{"title":"Recorded","text":"From the real model"}`}
+	rb, err := newRecordingBackend(stub, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := newClient(rb, cfg)
+
+	table := models.Table{{Amount: 4, Distance: 100, Content: "Kraul"}}
+	want, err := recorder.DescribeTable(context.Background(), &table)
+	if err != nil {
+		t.Fatalf("DescribeTable() error = %v", err)
+	}
+	wantEmbedding, err := recorder.CreateEmbedding(context.Background(), []string{"plan"})
+	if err != nil {
+		t.Fatalf("CreateEmbedding() error = %v", err)
+	}
+
+	fake, err := NewFakeClient(cfg)
+	if err != nil {
+		t.Fatalf("NewFakeClient() error = %v", err)
+	}
+	got, err := fake.DescribeTable(context.Background(), &table)
+	if err != nil {
+		t.Fatalf("fake DescribeTable() error = %v", err)
+	}
+	if got.Title != want.Title || got.Text != want.Text {
+		t.Fatalf("fake DescribeTable() = %+v, want %+v", got, want)
+	}
+	gotEmbedding, err := fake.CreateEmbedding(context.Background(), []string{"plan"})
+	if err != nil {
+		t.Fatalf("fake CreateEmbedding() error = %v", err)
+	}
+	if gotEmbedding[0][0] != wantEmbedding[0][0] {
+		t.Fatalf("fake CreateEmbedding() = %v, want %v", gotEmbedding, wantEmbedding)
+	}
+	if stub.calls != 2 {
+		t.Fatalf("real backend called %d times, want 2", stub.calls)
+	}
+}
+
+func TestFakeClientDefaultFixtureAndMissingFixture(t *testing.T) {
+	cfg := fakeTestConfig("")
+	cfg.SmallModel = "unknown"
+	fake, err := NewFakeClient(cfg, Fixture{Model: "small", Response: "Erstelle einen Trainingsplan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fake.GeneratePrompt(context.Background(), models.GeneratePromptRequest{Language: models.LanguageEN})
+	if !errors.Is(err, ErrFixtureNotFound) {
+		t.Fatalf("GeneratePrompt() error = %v, want ErrFixtureNotFound", err)
+	}
+
+	fake.cfg.SmallModel = "small"
+	prompt, err := fake.GeneratePrompt(context.Background(), models.GeneratePromptRequest{Language: models.LanguageEN})
+	if err != nil {
+		t.Fatalf("GeneratePrompt() error = %v", err)
+	}
+	if prompt != "Erstelle einen Trainingsplan" {
+		t.Fatalf("GeneratePrompt() = %q", prompt)
+	}
+}
+
+func TestHashEmbeddingIsDeterministicUnitVector(t *testing.T) {
+	a := hashEmbedding(EmbeddingFixtureKey("m", "text"), 8)
+	b := hashEmbedding(EmbeddingFixtureKey("m", "text"), 8)
+	var norm float32
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("hashEmbedding not deterministic at %d", i)
+		}
+		norm += a[i] * a[i]
+	}
+	if norm < 0.999 || norm > 1.001 {
+		t.Fatalf("hashEmbedding norm = %f, want 1", norm)
+	}
+}
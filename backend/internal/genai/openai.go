@@ -0,0 +1,256 @@
+package genai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+)
+
+// openAIBackend talks to any server implementing the OpenAI chat completions
+// and embeddings API, e.g. Ollama, llama.cpp or vLLM.
+type openAIBackend struct {
+	baseURL       string
+	apiKey        string
+	embeddingSize int
+	httpClient    *http.Client
+}
+
+func newOpenAIBackend(cfg config.Config) (*openAIBackend, error) {
+	if cfg.LLM.BaseURL == "" {
+		return nil, fmt.Errorf("LLM_BASE_URL is required for the %s provider", ProviderOpenAI)
+	}
+	return &openAIBackend{
+		baseURL:       strings.TrimSuffix(cfg.LLM.BaseURL, "/"),
+		apiKey:        cfg.LLM.APIKey,
+		embeddingSize: cfg.Embedding.Size,
+		httpClient:    &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+}
+
+type openAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Temperature    float32               `json:"temperature"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+type openAIChatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+type openAIEmbeddingRequest struct {
+	Model      string `json:"model"`
+	Input      string `json:"input"`
+	Dimensions int    `json:"dimensions,omitempty"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (o *openAIBackend) generateContent(ctx context.Context, model string, req contentRequest) (string, error) {
+	body, err := o.chatRequest(model, req)
+	if err != nil {
+		return "", err
+	}
+
+	var resp openAIChatResponse
+	if err := o.post(ctx, "/chat/completions", body, &resp); err != nil {
+		return "", fmt.Errorf("chat/completions: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("chat/completions: response contains no choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (o *openAIBackend) generateContentStream(ctx context.Context, model string, req contentRequest, onChunk func(string) error) (string, error) {
+	body, err := o.chatRequest(model, req)
+	if err != nil {
+		return "", err
+	}
+	body.Stream = true
+
+	resp, err := o.do(ctx, "/chat/completions", body)
+	if err != nil {
+		return "", fmt.Errorf("chat/completions: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// The answer is sent as server-sent events with one "data: {...}" line per chunk.
+	var answer strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk openAIChatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("chat/completions: failed to parse stream chunk: %w", err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		answer.WriteString(chunk.Choices[0].Delta.Content)
+		if err := onChunk(chunk.Choices[0].Delta.Content); err != nil {
+			return "", err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("chat/completions: %w", err)
+	}
+	return answer.String(), nil
+}
+
+// chatRequest converts the provider independent request into a chat completions request.
+func (o *openAIBackend) chatRequest(model string, req contentRequest) (openAIChatRequest, error) {
+	var content any = req.Prompt
+	if len(req.File) > 0 {
+		filePart, err := fileToOpenAIPart(req.File, req.MIMEType)
+		if err != nil {
+			return openAIChatRequest{}, err
+		}
+		content = []openAIContentPart{filePart, {Type: "text", Text: req.Prompt}}
+	}
+
+	body := openAIChatRequest{
+		Model:       model,
+		Messages:    []openAIMessage{{Role: "user", Content: content}},
+		Temperature: 1.5,
+	}
+	if req.JSON {
+		body.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+		if req.Schema != nil {
+			body.ResponseFormat = &openAIResponseFormat{
+				Type:       "json_schema",
+				JSONSchema: &openAIJSONSchema{Name: "response", Schema: req.Schema},
+			}
+		}
+	}
+	return body, nil
+}
+
+func (o *openAIBackend) embedContent(ctx context.Context, model string, text string) ([]float32, error) {
+	body := openAIEmbeddingRequest{Model: model, Input: text, Dimensions: o.embeddingSize}
+
+	var resp openAIEmbeddingResponse
+	if err := o.post(ctx, "/embeddings", body, &resp); err != nil {
+		return nil, fmt.Errorf("embeddings: %w", err)
+	}
+	if len(resp.Data) != 1 {
+		return nil, fmt.Errorf("embeddings: expected one embedding, got %d", len(resp.Data))
+	}
+	values := resp.Data[0].Embedding
+	if o.embeddingSize > 0 && len(values) != o.embeddingSize {
+		return nil, fmt.Errorf("embeddings: expected %d dimensions, got %d", o.embeddingSize, len(values))
+	}
+	return values, nil
+}
+
+// post sends body as JSON to the given API path and decodes the JSON answer into out.
+func (o *openAIBackend) post(ctx context.Context, path string, body, out any) error {
+	resp, err := o.do(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do sends body as JSON to the given API path. The caller has to close the
+// body of the returned response.
+func (o *openAIBackend) do(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// fileToOpenAIPart converts an attachment into a message part. Images are sent
+// inline as data URLs and text files as plain text, other documents such as PDFs
+// are not part of the OpenAI chat completions contract.
+func fileToOpenAIPart(file []byte, mimeType string) (openAIContentPart, error) {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		url := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(file)
+		return openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{URL: url}}, nil
+	case strings.HasPrefix(mimeType, "text/"):
+		return openAIContentPart{Type: "text", Text: string(file)}, nil
+	default:
+		return openAIContentPart{}, fmt.Errorf("mime type %s is not supported by the %s provider", mimeType, ProviderOpenAI)
+	}
+}
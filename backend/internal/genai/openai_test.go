@@ -0,0 +1,116 @@
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+)
+
+func newTestOpenAIClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	var cfg config.Config
+	cfg.Model = "llama3"
+	cfg.LLM.Provider = ProviderOpenAI
+	cfg.LLM.BaseURL = srv.URL + "/v1"
+	cfg.Embedding.Model = "nomic-embed-text"
+	cfg.Embedding.Size = 3
+	c, err := NewClient(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return c
+}
+
+func TestOpenAIBackendGenerateContent(t *testing.T) {
+	var got openAIChatRequest
+	c := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"title\":\"T\",\"text\":\"D\"}"}}]}`))
+	})
+
+	desc, err := c.DescribeTable(context.Background(), &models.Table{})
+	if err != nil {
+		t.Fatalf("DescribeTable() error = %v", err)
+	}
+	if desc.Title != "T" || desc.Text != "D" {
+		t.Fatalf("DescribeTable() = %+v", desc)
+	}
+	if got.Model != "llama3" || got.ResponseFormat == nil || got.ResponseFormat.Type != "json_object" {
+		t.Fatalf("unexpected request %+v", got)
+	}
+}
+
+func TestOpenAIBackendCreateEmbedding(t *testing.T) {
+	var inputs []string
+	c := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req openAIEmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		inputs = append(inputs, req.Input)
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[0.1,0.2,0.3]}]}`))
+	})
+	c.QueryMode()
+
+	embeddings, err := c.CreateEmbedding(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("CreateEmbedding() error = %v", err)
+	}
+	if len(embeddings) != 2 || len(embeddings[0]) != 3 {
+		t.Fatalf("unexpected embeddings %v", embeddings)
+	}
+	if inputs[0] != formatQueryEmbeddingInput("a") {
+		t.Fatalf("query mode input = %q", inputs[0])
+	}
+}
+
+func TestFileToOpenAIPartRejectsPDF(t *testing.T) {
+	if _, err := fileToOpenAIPart([]byte("%PDF"), "application/pdf"); err == nil {
+		t.Fatal("expected error for pdf attachment")
+	}
+}
+
+func TestOpenAIBackendChatRefineStream(t *testing.T) {
+	c := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Errorf("expected streaming request")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{`{\"response\":\"Mehr `, `Tempo\",\"plan\":null}`} {
+			_, _ = w.Write([]byte(`data: {"choices":[{"delta":{"content":"` + chunk + `"}}]}` + "\n\n"))
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	})
+
+	var tokens []string
+	resp, err := c.ChatRefineStream(context.Background(), "", "", nil, "schneller", "de", "", 25, nil, nil, func(token string) error {
+		tokens = append(tokens, token)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatRefineStream() error = %v", err)
+	}
+	if resp.Response != "Mehr Tempo" || resp.Plan != nil {
+		t.Fatalf("ChatRefineStream() = %+v", resp)
+	}
+	if len(tokens) != 2 || tokens[0] != "Mehr " || tokens[1] != "Tempo" {
+		t.Fatalf("streamed tokens = %q", tokens)
+	}
+}
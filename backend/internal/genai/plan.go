@@ -9,11 +9,11 @@ import (
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/go-chi/httplog/v2"
 	"github.com/tmc/langchaingo/schema"
-	"google.golang.org/genai"
 )
 
 // GeneratePlan generates a plan using the LLM based on the provided query and documents.
-func (gc *GoogleGenAIClient) GeneratePlan(ctx context.Context, q, lang, userProfile string, poolLength any, planDocs, drillDocs []schema.Document) (*models.GeneratedPlan, error) {
+// Constraints are added to the prompt, they are not checked here.
+func (gc *Client) GeneratePlan(ctx context.Context, q, lang, userProfile string, poolLength any, constraints *models.PlanConstraints, planDocs, drillDocs []schema.Document) (*models.GeneratedPlan, error) {
 	logger := httplog.LogEntry(ctx)
 	gps, err := models.GeneratedPlanSchema()
 	if err != nil {
@@ -40,13 +40,12 @@ func (gc *GoogleGenAIClient) GeneratePlan(ctx context.Context, q, lang, userProf
 		lang,
 		userProfile,
 		q,
+		formatConstraints(constraints),
 		strings.Join(pdc, "\n \n"),
 		strings.Join(ddc, "\n \n"),
 	)
-	genCfg := *gc.gcfg
-	genCfg.ResponseMIMEType = "application/json"
-	genCfg.ResponseJsonSchema = gps
-	answer, err := gc.gc.Models.GenerateContent(ctx, gc.cfg.Model, genai.Text(query), &genCfg)
+	genReq := contentRequest{Prompt: query, JSON: true, Schema: gps}
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.Model, genReq)
 
 	if err != nil {
 		logger.Error("Error when generating answer with LLM", httplog.ErrAttr(err))
@@ -55,13 +54,15 @@ func (gc *GoogleGenAIClient) GeneratePlan(ctx context.Context, q, lang, userProf
 
 	// read description and table from the LLM response
 	var p models.GeneratedPlan
-	err = json.Unmarshal([]byte(answer.Text()), &p)
+	err = json.Unmarshal([]byte(answer), &p)
 	if err != nil {
-		logger.Debug("LLM response could not be parsed", "raw_response", answer.Text())
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
 		logger.Error("Error parsing LLM response", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("error parsing LLM response: %w", err)
 	}
 	p.Table.FlattenSingleParentRow()
+	// Breaks were parsed on unmarshal, keep those that are out of bounds as text
+	p.Table.NormalizeBreaks()
 	// Add the total to the table if it is not already present
 	if len(p.Table) == 0 || !strings.Contains(p.Table[len(p.Table)-1].Content, "Gesamt") {
 		p.Table.AddSum()
@@ -76,7 +77,7 @@ func (gc *GoogleGenAIClient) GeneratePlan(ctx context.Context, q, lang, userProf
 
 // ChoosePlan lets an LLM choose the best fitting plan from the given documents.
 // Returns the plan id of the chosen plan
-func (gc *GoogleGenAIClient) ChoosePlan(ctx context.Context, q, lang string, poolLength any, docs []schema.Document) (string, error) {
+func (gc *Client) ChoosePlan(ctx context.Context, q, lang string, poolLength any, docs []schema.Document) (string, error) {
 	logger := httplog.LogEntry(ctx)
 	var dc string
 	for i, doc := range docs {
@@ -85,9 +86,8 @@ func (gc *GoogleGenAIClient) ChoosePlan(ctx context.Context, q, lang string, poo
 
 	// Create a RAG query for the LLM with the most relevant documents as context
 	query := fmt.Sprintf(choosePlanTemplateStr, poolLength, lang, q, dc)
-	genCfg := *gc.gcfg
-	genCfg.ResponseMIMEType = "application/json"
-	answer, err := gc.gc.Models.GenerateContent(ctx, gc.cfg.Model, genai.Text(query), &genCfg)
+	genReq := contentRequest{Prompt: query, JSON: true}
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.Model, genReq)
 	if err != nil {
 		logger.Error("Error when generating answer with LLM", httplog.ErrAttr(err))
 		return "", fmt.Errorf("error generating answer: %w", err)
@@ -95,9 +95,9 @@ func (gc *GoogleGenAIClient) ChoosePlan(ctx context.Context, q, lang string, poo
 	logger.Debug("Successful answer from LLM", "answer", answer)
 
 	var cr models.ChoiceResult
-	err = json.Unmarshal([]byte(answer.Text()), &cr)
+	err = json.Unmarshal([]byte(answer), &cr)
 	if err != nil {
-		logger.Debug("LLM response could not be parsed", "raw_response", answer.Text())
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
 		logger.Error("Error parsing LLM response", httplog.ErrAttr(err))
 		return "", fmt.Errorf("error parsing LLM response: %w", err)
 	}
@@ -112,7 +112,7 @@ func (gc *GoogleGenAIClient) ChoosePlan(ctx context.Context, q, lang string, poo
 	return planIDStr, nil
 }
 
-func (gc *GoogleGenAIClient) DescribeTable(ctx context.Context, table *models.Table) (*models.Description, error) {
+func (gc *Client) DescribeTable(ctx context.Context, table *models.Table) (*models.Description, error) {
 	logger := httplog.LogEntry(ctx)
 	ds, err := models.DescriptionSchema()
 	if err != nil {
@@ -121,16 +121,15 @@ func (gc *GoogleGenAIClient) DescribeTable(ctx context.Context, table *models.Ta
 	}
 	// Create a description of the table
 	query := fmt.Sprintf(describeTemplateStr, ds, table.String())
-	genCfg := *gc.gcfg
-	genCfg.ResponseMIMEType = "application/json"
-	answer, err := gc.gc.Models.GenerateContent(ctx, gc.cfg.Model, genai.Text(query), &genCfg)
+	genReq := contentRequest{Prompt: query, JSON: true}
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.Model, genReq)
 	if err != nil {
 		return nil, fmt.Errorf("Models.GenerateContent: %w", err)
 	}
 	var desc models.Description
-	err = json.Unmarshal([]byte(answer.Text()), &desc)
+	err = json.Unmarshal([]byte(answer), &desc)
 	if err != nil {
-		logger.Debug("LLM response could not be parsed", "raw_response", answer.Text())
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
 		logger.Error("Error parsing LLM response", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("error parsing LLM response: %w", err)
 	}
@@ -140,7 +139,7 @@ func (gc *GoogleGenAIClient) DescribeTable(ctx context.Context, table *models.Ta
 // TranslatePlan translates the given plan into the specified language.
 //
 // Returns a copy of the plan translated to the target language.
-func (gc *GoogleGenAIClient) TranslatePlan(ctx context.Context, plan *models.Plan, lang models.Language) (*models.Plan, error) {
+func (gc *Client) TranslatePlan(ctx context.Context, plan *models.Plan, lang models.Language) (*models.Plan, error) {
 	logger := httplog.LogEntry(ctx)
 	gps, err := models.GeneratedPlanSchema()
 	if err != nil {
@@ -156,23 +155,22 @@ func (gc *GoogleGenAIClient) TranslatePlan(ctx context.Context, plan *models.Pla
 	// Translate the plan to the requested language
 	// Create a RAG query for the LLM with the most relevant documents as context
 	query := fmt.Sprintf(translateTemplateStr, lang, models.Abbreviations, plan.Title, plan.Description, string(tableJSON))
-	genCfg := *gc.gcfg
-	genCfg.ResponseMIMEType = "application/json"
-	genCfg.ResponseJsonSchema = gps
-	answer, err := gc.gc.Models.GenerateContent(ctx, gc.cfg.Model, genai.Text(query), &genCfg)
+	genReq := contentRequest{Prompt: query, JSON: true, Schema: gps}
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.Model, genReq)
 	if err != nil {
 		logger.Error("Error when generating answer with LLM", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("error when generating answer with LLM: %w", err)
 	}
 
 	var gp models.GeneratedPlan
-	err = json.Unmarshal([]byte(answer.Text()), &gp)
+	err = json.Unmarshal([]byte(answer), &gp)
 	if err != nil {
-		logger.Debug("LLM response could not be parsed", "raw_response", answer.Text())
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
 		logger.Error("Error parsing LLM response", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("error parsing LLM response: %w", err)
 	}
 	gp.Table.FlattenSingleParentRow()
+	gp.Table.NormalizeBreaks()
 
 	p := models.Plan{
 		PlanID:      plan.PlanID,
@@ -184,7 +182,7 @@ func (gc *GoogleGenAIClient) TranslatePlan(ctx context.Context, plan *models.Pla
 	return &p, nil
 }
 
-func (gc *GoogleGenAIClient) FileToPlan(ctx context.Context, file []byte, filename string, mimeType string, language models.Language) (*models.GeneratedPlan, error) {
+func (gc *Client) FileToPlan(ctx context.Context, file []byte, filename string, mimeType string, language models.Language) (*models.GeneratedPlan, error) {
 	logger := httplog.LogEntry(ctx)
 	logger.Debug("FileToPlan", "filename", filename, "mimeType", mimeType)
 	gps, err := models.GeneratedPlanSchema()
@@ -195,19 +193,15 @@ func (gc *GoogleGenAIClient) FileToPlan(ctx context.Context, file []byte, filena
 	prompt := fmt.Sprintf(ocrTemplateStr, language)
 
 	// Create a RAG query for the LLM with the most relevant documents as context
-	genCfg := *gc.gcfg
-	genCfg.ResponseMIMEType = "application/json"
-	genCfg.ResponseJsonSchema = gps
-	parts := []*genai.Part{
-		genai.NewPartFromBytes(file, mimeType),
-		genai.NewPartFromText(prompt),
+	genReq := contentRequest{
+		Prompt:   prompt,
+		File:     file,
+		MIMEType: mimeType,
+		JSON:     true,
+		Schema:   gps,
 	}
 
-	contents := []*genai.Content{
-		genai.NewContentFromParts(parts, genai.RoleUser),
-	}
-
-	answer, err := gc.gc.Models.GenerateContent(ctx, gc.cfg.Model, contents, &genCfg)
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.Model, genReq)
 
 	if err != nil {
 		logger.Error("Error when extracting plan from image with LLM", httplog.ErrAttr(err))
@@ -216,13 +210,15 @@ func (gc *GoogleGenAIClient) FileToPlan(ctx context.Context, file []byte, filena
 
 	// read description and table from the LLM response
 	var p models.GeneratedPlan
-	err = json.Unmarshal([]byte(answer.Text()), &p)
+	err = json.Unmarshal([]byte(answer), &p)
 	if err != nil {
-		logger.Debug("LLM response could not be parsed", "raw_response", answer.Text())
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
 		logger.Error("Error parsing LLM response", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("error parsing LLM response: %w", err)
 	}
 	p.Table.FlattenSingleParentRow()
+	// Breaks were parsed on unmarshal, keep those that are out of bounds as text
+	p.Table.NormalizeBreaks()
 	// Add the total to the table if it is not already present
 	if len(p.Table) == 0 || !strings.Contains(p.Table[len(p.Table)-1].Content, "Gesamt") {
 		p.Table.AddSum()
@@ -234,3 +230,47 @@ func (gc *GoogleGenAIClient) FileToPlan(ctx context.Context, file []byte, filena
 	logger.Debug("Plan extracted from image successfully")
 	return &p, nil
 }
+
+var strokeNames = map[models.StrokeType]string{
+	models.StrokeFreestyle:    "Kraul/Freistil",
+	models.StrokeBreaststroke: "Brust",
+	models.StrokeButterfly:    "Delfin/Schmetterling",
+	models.StrokeBackstroke:   "Rücken",
+	models.StrokeMedley:       "Lagen",
+}
+
+// formatConstraints describes the constraints for the prompt. It returns an empty
+// string if no constraint is set.
+func formatConstraints(c *models.PlanConstraints) string {
+	if c.IsZero() {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\nVERBINDLICHE VORGABEN (haben Vorrang vor allen anderen Angaben):\n")
+	if c.TargetVolume > 0 {
+		fmt.Fprintf(&sb, "- Gesamtumfang: %dm, höchstens %.0f%% Abweichung\n", c.TargetVolume, models.VolumeTolerance*100)
+	}
+	if c.MaxDuration > 0 {
+		fmt.Fprintf(&sb, "- Maximale Dauer inklusive Pausen: %d Minuten\n", c.MaxDuration)
+	}
+	if c.Equipment != nil {
+		if len(c.Equipment) == 0 {
+			sb.WriteString("- Es ist keine Ausrüstung verfügbar. Verwende keine Ausrüstung.\n")
+		} else {
+			equipment := make([]string, len(c.Equipment))
+			for i, e := range c.Equipment {
+				equipment[i] = string(e)
+			}
+			fmt.Fprintf(&sb, "- Verfügbare Ausrüstung: %s. Verwende keine andere Ausrüstung.\n", strings.Join(equipment, ", "))
+		}
+	}
+	if len(c.ExcludedStrokes) > 0 {
+		strokes := make([]string, len(c.ExcludedStrokes))
+		for i, s := range c.ExcludedStrokes {
+			strokes[i] = strokeNames[s]
+		}
+		fmt.Fprintf(&sb, "- Diese Schwimmarten dürfen nicht vorkommen: %s\n", strings.Join(strokes, ", "))
+	}
+	return sb.String()
+}
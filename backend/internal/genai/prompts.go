@@ -19,6 +19,7 @@ In den Untereinheiten sollten die spezifischen Übungen mit Distanz und Equipmen
 Falls persönliche CSS-Tempozonen im Benutzerprofil angegeben sind, verwende sie als Intensitäts-Vorgabe für passende Schwimmübungen.
 Wähle die Zone passend zum Trainingsziel und skaliere die Pace auf die jeweilige Distanz. Setze das Intensity Feld mit der jeweiligen Pace im Format "mm:ss / 100m".Verwende keine erfundenen persönlichen Pace-Werte, wenn keine CSS-Zonen angegeben sind.
 Wenn Tempozonen angegeben sind, kann das Break-Feld für eine verbleibende Pause genutzt werden. Beschreibe sie eindeutig als "Restzeit bis 2:00". Verwende CSS nicht pauschal für Technik-, Erholungs- oder Sprintübungen, wenn die Zone nicht zum Trainingsziel passt.
+Falls eine persönliche Belastungskalibrierung im Benutzerprofil angegeben ist, passe Umfang und Intensität des Plans entsprechend an. Eine in der Anfrage genannte Gesamtdistanz hat Vorrang, dann passe nur die Intensität an.
 
 Die technischen Übungen dürfen nur als Referenzen eingefügt werden. Das Format ist ein Markdown URL Link.
 Dafür wird der slug als Linktext verwendet und die URL als Linkziel. Exemplarisch: [slug](URL).
@@ -44,7 +45,7 @@ Die Antwort soll keine Fragen enthalten und auch nicht die Anweisung wiederholen
 
 Anfrage:
 %s
-
+%s
 Legende:
 Gängige Abkürzungen für Schwimmstile und -techniken:
 K, Kr, Freistil, F, Fr: Kraulschwimmen (Freistil)
@@ -238,8 +239,8 @@ Falls persönliche Tempozonen im bisherigen Plan oder Benutzerkontext vorhanden
 bewahre sie bei Änderungen und verwende sie für passende Pace- und Intervallvorgaben.
 Bei festen Intervallen ist die verbleibende Zeit nach dem Schwimmen die Pause.
 Die Antwort soll in %s (Sprache) sein.
-
-GESPRÄCHSVERLAUF:
+%s
+%sGESPRÄCHSVERLAUF:
 %s
 
 AKTUELLER PLAN:
@@ -250,7 +251,7 @@ REFERENZPLÄNE (zur Inspiration):
 
 NEUE NACHRICHT VOM SCHWIMMER:
 %s
-
+%s
 Bitte gib deine Antwort in folgendem JSON-Format zurück:
 - "plan": Der aktualisierte oder neue Trainingsplan mit title, description und table
   - Wenn der Schwimmer nur eine Frage stellt ohne Änderungswunsch, gib den bestehenden Plan zurück (oder null falls keiner existiert)
@@ -264,6 +265,49 @@ Bitte gib deine Antwort in folgendem JSON-Format zurück:
 Antwort:
 `
 
+const summarizeConversationTemplateStr string = `
+Du fasst ein Gespräch zwischen einem Schwimmer und seinem Trainer über einen Trainingsplan zusammen.
+Die Zusammenfassung ersetzt die älteren Nachrichten, die der Trainer nicht mehr sieht.
+
+Behalte unbedingt alle Vorgaben und Informationen des Schwimmers, die weiterhin gelten, z.B.:
+- Verletzungen, Beschwerden und Lagen oder Übungen, die vermieden werden sollen
+- Ziele, Wettkämpfe, Trainingsumfang, Zeitaufwand und verfügbare Ausrüstung
+- Vorlieben und Abneigungen sowie getroffene Entscheidungen zum Plan
+Lass Begrüßungen, Details zu einzelnen Planversionen und überholte Wünsche weg.
+Wenn neuere Nachrichten einer früheren Vorgabe widersprechen, gilt die neuere.
+
+Schreibe höchstens 10 kurze Stichpunkte im Fließtext ohne weitere Formatierung
+und in der Sprache des Gesprächs.
+
+BISHERIGE ZUSAMMENFASSUNG:
+%s
+
+NEUE NACHRICHTEN:
+%s
+
+Zusammenfassung:
+`
+
+const extractPreferencesTemplateStr string = `
+Du liest die Nachricht eines Schwimmers an seinen Trainer und erkennst dauerhafte Trainingsvorlieben des Schwimmers.
+Dauerhaft sind nur Angaben, die auch für zukünftige Trainingspläne gelten, z.B.:
+- injury: Verletzungen oder Beschwerden, z.B. "Schulterverletzung"
+- disliked_drill: Übungen oder Lagen, die der Schwimmer nicht mag oder nicht machen kann, z.B. "Delfin"
+- equipment: Ausrüstung, die der Schwimmer üblicherweise dabei hat. Erlaubt sind nur Flossen, Kickboard, Handpaddles, Pull buoy und Schnorchel.
+- pool_length: Länge des Beckens, in dem der Schwimmer üblicherweise trainiert, 25 oder 50
+- session_minutes: übliche Dauer einer Trainingseinheit in Minuten
+
+Wünsche, die nur den aktuellen Plan betreffen (z.B. "mach es heute kürzer", "mehr Beine"), sind keine dauerhaften Vorlieben.
+Erfinde nichts und gib nur Angaben zurück, die eindeutig in der Nachricht stehen und noch nicht bekannt sind.
+Formuliere die Werte kurz und in der Sprache der Nachricht. Gib eine leere Liste zurück, wenn die Nachricht keine dauerhaften Vorlieben enthält.
+
+BEREITS BEKANNTE VORLIEBEN:
+%s
+
+NACHRICHT DES SCHWIMMERS:
+%s
+`
+
 const ocrTemplateStr string = `
 Analysiere diese Datei und extrahiere den darin enthaltenen Plan möglichst genau.
 Falls das Schema für den Trainingsplan nicht genau passt, modifiziere den Plan entsprechend
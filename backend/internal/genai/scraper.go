@@ -10,10 +10,9 @@ import (
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/go-chi/httplog/v2"
-	"google.golang.org/genai"
 )
 
-func (gc *GoogleGenAIClient) ImprovePlan(ctx context.Context, plan models.ScrapedPlan, syncGroup *sync.WaitGroup, c chan<- models.Document, ec chan<- error) {
+func (gc *Client) ImprovePlan(ctx context.Context, plan models.ScrapedPlan, syncGroup *sync.WaitGroup, c chan<- models.Document, ec chan<- error) {
 	if syncGroup != nil {
 		defer syncGroup.Done()
 	}
@@ -54,7 +53,7 @@ func (gc *GoogleGenAIClient) ImprovePlan(ctx context.Context, plan models.Scrape
 // and representing them using nested SubRows instead of flat rows. This happens only once during
 // the scraping/import process. The actual training content is NEVER modified - only the schema
 // representation is optimized for better structure.
-func (gc *GoogleGenAIClient) RestructurePlan(ctx context.Context, plan *models.Plan) (*models.Plan, error) {
+func (gc *Client) RestructurePlan(ctx context.Context, plan *models.Plan) (*models.Plan, error) {
 	logger := slog.Default()
 	gps, err := models.GeneratedPlanSchema()
 	if err != nil {
@@ -66,11 +65,9 @@ func (gc *GoogleGenAIClient) RestructurePlan(ctx context.Context, plan *models.P
 	genericPlan.Table = annotateTable(genericPlan.Table)
 	query := fmt.Sprintf(restructureTemplateStr, gps, genericPlan.Title, genericPlan.Description, genericPlan.Table.String())
 
-	genCfg := *gc.gcfg
-	genCfg.ResponseMIMEType = "application/json"
-	genCfg.ResponseJsonSchema = gps
+	genReq := contentRequest{Prompt: query, JSON: true, Schema: gps}
 	logger.Debug("Requesting restructuring by LLM", "plan", genericPlan.String())
-	answer, err := gc.gc.Models.GenerateContent(ctx, gc.cfg.Model, genai.Text(query), &genCfg)
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.Model, genReq)
 
 	if err != nil {
 		logger.Error("Error when restructuring plan", httplog.ErrAttr(err))
@@ -78,9 +75,9 @@ func (gc *GoogleGenAIClient) RestructurePlan(ctx context.Context, plan *models.P
 	}
 
 	var gp models.GeneratedPlan
-	err = json.Unmarshal([]byte(answer.Text()), &gp)
+	err = json.Unmarshal([]byte(answer), &gp)
 	if err != nil {
-		logger.Debug("LLM response could not be parsed", "raw_response", answer.Text())
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
 		logger.Error("Error parsing restructured plan", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("error parsing restructured plan: %w", err)
 	}
@@ -119,7 +116,7 @@ func annotateTable(table models.Table) models.Table {
 	return table
 }
 
-func (gc *GoogleGenAIClient) GenerateMetadata(ctx context.Context, plan *models.Plan) (*models.Metadata, error) {
+func (gc *Client) GenerateMetadata(ctx context.Context, plan *models.Plan) (*models.Metadata, error) {
 	logger := slog.Default()
 	ms, err := models.MetadataSchema()
 	if err != nil {
@@ -135,20 +132,19 @@ func (gc *GoogleGenAIClient) GenerateMetadata(ctx context.Context, plan *models.
 	}
 	// Enhance scraped documents with gemini and create meaningful metadata
 	query := fmt.Sprintf(metadataTemplateStr, models.Abbreviations, genericPlan.Title, genericPlan.Description, string(tableJSON), ms)
-	genCfg := *gc.gcfg
-	genCfg.ResponseMIMEType = "application/json"
-	answer, err := gc.gc.Models.GenerateContent(ctx, gc.cfg.Model, genai.Text(query), &genCfg)
+	genReq := contentRequest{Prompt: query, JSON: true}
+	answer, err := gc.backend.generateContent(ctx, gc.cfg.Model, genReq)
 	if err != nil {
 		logger.Error("Error when generating answer with LLM", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("Models.GenerateContent: %w", err)
 	}
-	logger.Debug("Successful answer from LLM", "answer", answer.Text())
+	logger.Debug("Successful answer from LLM", "answer", answer)
 
 	// Parse the answer as JSON
 	var metadata models.Metadata
-	err = json.Unmarshal([]byte(answer.Text()), &metadata)
+	err = json.Unmarshal([]byte(answer), &metadata)
 	if err != nil {
-		logger.Debug("LLM response could not be parsed", "raw_response", answer.Text())
+		logger.Debug("LLM response could not be parsed", "raw_response", answer)
 		logger.Error("Error parsing LLM response", httplog.ErrAttr(err))
 		return nil, fmt.Errorf("JSON unmarshal error: %w", err)
 	}
@@ -0,0 +1,121 @@
+package genai
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsonFieldStreamer extracts the value of a top level string field from a JSON
+// object that arrives in chunks and forwards newly decoded text as soon as it
+// is available.
+type jsonFieldStreamer struct {
+	field   string
+	onToken func(string) error
+	buf     strings.Builder
+	emitted int
+}
+
+func newJSONFieldStreamer(field string, onToken func(string) error) *jsonFieldStreamer {
+	return &jsonFieldStreamer{field: field, onToken: onToken}
+}
+
+// write consumes the next chunk of the raw JSON answer.
+func (s *jsonFieldStreamer) write(chunk string) error {
+	s.buf.WriteString(chunk)
+	value, ok := partialJSONStringField(s.buf.String(), s.field)
+	if !ok || len(value) <= s.emitted {
+		return nil
+	}
+	token := value[s.emitted:]
+	s.emitted = len(value)
+	return s.onToken(token)
+}
+
+// partialJSONStringField returns the decoded, possibly incomplete, value of the
+// top level string field in the possibly incomplete JSON object data. Nested
+// objects and string contents are skipped, so only the real field matches.
+func partialJSONStringField(data, field string) (string, bool) {
+	depth := 0
+	expectKey := false
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '{', '[':
+			depth++
+			expectKey = c == '{' && depth == 1
+		case '}', ']':
+			depth--
+		case ',':
+			expectKey = depth == 1
+		case '"':
+			end := stringEnd(data, i+1)
+			if end < 0 {
+				return "", false
+			}
+			if !expectKey {
+				i = end
+				continue
+			}
+			expectKey = false
+			key := data[i+1 : end]
+			// Skip to the value of the key
+			j := end + 1
+			for j < len(data) && (data[j] == ' ' || data[j] == '\n' || data[j] == '\t' || data[j] == '\r' || data[j] == ':') {
+				j++
+			}
+			if key != field {
+				i = end
+				continue
+			}
+			if j >= len(data) || data[j] != '"' {
+				return "", false
+			}
+			raw := data[j+1:]
+			if valueEnd := stringEnd(data, j+1); valueEnd >= 0 {
+				raw = data[j+1 : valueEnd]
+			}
+			return decodePartialJSONString(raw), true
+		}
+	}
+	return "", false
+}
+
+// stringEnd returns the index of the closing quote of the JSON string starting
+// at start or -1 if the string is not terminated yet.
+func stringEnd(data string, start int) int {
+	for i := start; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// decodePartialJSONString decodes the raw content of a JSON string, dropping an
+// incomplete escape sequence or UTF-8 character at the end.
+func decodePartialJSONString(raw string) string {
+	for trim := 0; trim <= 12 && trim <= len(raw); trim++ {
+		candidate := raw[:len(raw)-trim]
+		if !utf8.ValidString(candidate) || hasTrailingHighSurrogate(candidate) {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal([]byte(`"`+candidate+`"`), &s); err == nil {
+			return s
+		}
+	}
+	return ""
+}
+
+// hasTrailingHighSurrogate reports whether raw ends with the first half of an
+// escaped UTF-16 surrogate pair, whose second half has not arrived yet.
+func hasTrailingHighSurrogate(raw string) bool {
+	if len(raw) < 6 {
+		return false
+	}
+	tail := strings.ToLower(raw[len(raw)-6:])
+	return strings.HasPrefix(tail, `\ud`) && strings.ContainsAny(tail[3:4], "89ab")
+}
@@ -0,0 +1,61 @@
+package genai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPartialJSONStringField(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		wantOK bool
+	}{
+		{name: "empty", data: "", wantOK: false},
+		{name: "key incomplete", data: `{"respo`, wantOK: false},
+		{name: "value not started", data: `{"response": `, wantOK: false},
+		{name: "value started", data: `{"response": "Ich habe`, want: "Ich habe", wantOK: true},
+		{name: "value complete", data: `{"response":"fertig","plan":null}`, want: "fertig", wantOK: true},
+		{name: "escapes", data: `{"response":"Zeile\n\"zwei\"`, want: "Zeile\n\"zwei\"", wantOK: true},
+		{name: "incomplete escape", data: `{"response":"A\u00`, want: "A", wantOK: true},
+		{name: "incomplete surrogate pair", data: `{"response":"A\ud83d`, want: "A", wantOK: true},
+		{
+			name:   "nested field with same name is skipped",
+			data:   `{"plan":{"response":"nested","Content":"\"response\": x"},"response":"top`,
+			want:   "top",
+			wantOK: true,
+		},
+		{name: "value in other field", data: `{"plan":{"title":"response"`, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := partialJSONStringField(tt.data, "response")
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("partialJSONStringField(%q) = %q, %v, want %q, %v", tt.data, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestJSONFieldStreamerEmitsOnlyNewText(t *testing.T) {
+	answer := `{"plan":{"title":"T","table":[]},"response":"Mehr Kraul übungen"}`
+	var tokens []string
+	s := newJSONFieldStreamer("response", func(token string) error {
+		tokens = append(tokens, token)
+		return nil
+	})
+	for i := 0; i < len(answer); i += 3 {
+		if err := s.write(answer[i:min(i+3, len(answer))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := strings.Join(tokens, ""); got != "Mehr Kraul übungen" {
+		t.Fatalf("streamed %q", got)
+	}
+	if len(tokens) < 2 {
+		t.Fatalf("expected multiple tokens, got %v", tokens)
+	}
+}
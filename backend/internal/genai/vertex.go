@@ -0,0 +1,107 @@
+package genai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"google.golang.org/genai"
+)
+
+// vertexBackend sends requests to Gemini models hosted on Vertex AI.
+type vertexBackend struct {
+	gc       *genai.Client
+	gcfg     *genai.GenerateContentConfig
+	embedCfg *genai.EmbedContentConfig
+}
+
+func newVertexBackend(ctx context.Context, cfg config.Config) (*vertexBackend, error) {
+	gc, err := genai.NewClient(ctx, &genai.ClientConfig{
+		Project:  cfg.ProjectID,
+		Location: cfg.Region,
+		Backend:  genai.BackendVertexAI,
+	})
+	if err != nil {
+		return nil, err
+	}
+	gcfg := &genai.GenerateContentConfig{
+		CandidateCount: int32(1),
+		Temperature:    genai.Ptr(float32(1.5)),
+		SafetySettings: []*genai.SafetySetting{
+			{Category: genai.HarmCategoryHateSpeech, Threshold: genai.HarmBlockThresholdBlockLowAndAbove},
+			{Category: genai.HarmCategorySexuallyExplicit, Threshold: genai.HarmBlockThresholdBlockLowAndAbove},
+			{Category: genai.HarmCategoryHarassment, Threshold: genai.HarmBlockThresholdBlockLowAndAbove},
+			{Category: genai.HarmCategoryDangerousContent, Threshold: genai.HarmBlockThresholdBlockLowAndAbove},
+		},
+		ThinkingConfig: &genai.ThinkingConfig{
+			IncludeThoughts: false,
+			ThinkingBudget:  genai.Ptr[int32](0),
+		},
+	}
+	embedCfg := &genai.EmbedContentConfig{
+		// Gemini Embedding 2 uses task instructions in the content instead of the
+		// task_type field used by earlier embedding models.
+		OutputDimensionality: genai.Ptr(int32(cfg.Embedding.Size)),
+	}
+	return &vertexBackend{gc: gc, gcfg: gcfg, embedCfg: embedCfg}, nil
+}
+
+func (v *vertexBackend) generateContent(ctx context.Context, model string, req contentRequest) (string, error) {
+	contents, genCfg := v.request(req)
+	answer, err := v.gc.Models.GenerateContent(ctx, model, contents, genCfg)
+	if err != nil {
+		return "", fmt.Errorf("Models.GenerateContent: %w", err)
+	}
+	return answer.Text(), nil
+}
+
+func (v *vertexBackend) generateContentStream(ctx context.Context, model string, req contentRequest, onChunk func(string) error) (string, error) {
+	contents, genCfg := v.request(req)
+	var answer strings.Builder
+	for resp, err := range v.gc.Models.GenerateContentStream(ctx, model, contents, genCfg) {
+		if err != nil {
+			return "", fmt.Errorf("Models.GenerateContentStream: %w", err)
+		}
+		chunk := resp.Text()
+		answer.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			return "", err
+		}
+	}
+	return answer.String(), nil
+}
+
+// request converts the provider independent request into Gemini contents and config.
+func (v *vertexBackend) request(req contentRequest) ([]*genai.Content, *genai.GenerateContentConfig) {
+	genCfg := *v.gcfg
+	if req.Thinking {
+		genCfg.ThinkingConfig = nil
+	}
+	if req.JSON {
+		genCfg.ResponseMIMEType = "application/json"
+		genCfg.ResponseJsonSchema = req.Schema
+	}
+
+	contents := genai.Text(req.Prompt)
+	if len(req.File) > 0 {
+		parts := []*genai.Part{
+			genai.NewPartFromBytes(req.File, req.MIMEType),
+			genai.NewPartFromText(req.Prompt),
+		}
+		contents = []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+	}
+	return contents, &genCfg
+}
+
+func (v *vertexBackend) embedContent(ctx context.Context, model string, text string) ([]float32, error) {
+	content := genai.NewContentFromText(text, genai.RoleUser)
+	resp, err := v.gc.Models.EmbedContent(ctx, model, []*genai.Content{content}, v.embedCfg)
+	if err != nil {
+		return nil, fmt.Errorf("Models.EmbedContent: %w", err)
+	}
+	if len(resp.Embeddings) != 1 {
+		return nil, fmt.Errorf("Models.EmbedContent: expected one embedding, got %d", len(resp.Embeddings))
+	}
+	return resp.Embeddings[0].Values, nil
+}
@@ -0,0 +1,199 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSink is a minimal SMTP server that records the envelope and data of
+// the messages it receives.
+type smtpSink struct {
+	listener net.Listener
+	messages chan sinkMessage
+}
+
+type sinkMessage struct {
+	from, to string
+	data     string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sink := &smtpSink{listener: listener, messages: make(chan sinkMessage, 1)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go sink.serve()
+	return sink
+}
+
+func (s *smtpSink) addr() (string, string) {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host, port
+}
+
+func (s *smtpSink) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP sink")
+
+	var msg sinkMessage
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.to = strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>")
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			msg.data = data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			s.messages <- msg
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSendsMultipartMessage(t *testing.T) {
+	sink := newSMTPSink(t)
+	host, port := sink.addr()
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "Swim Gen <noreply@swim-gen.com>"})
+	require.NoError(t, err)
+
+	err = mailer.Send(context.Background(), Message{
+		To:      "anna@example.com",
+		Subject: "Plan für dich\r\nBcc: evil@example.com",
+		Text:    "Hallo Anna",
+		HTML:    "<p>Hallo Anna</p>",
+	})
+	require.NoError(t, err)
+
+	var received sinkMessage
+	select {
+	case received = <-sink.messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("smtp sink did not receive a message")
+	}
+	assert.Equal(t, "noreply@swim-gen.com", received.from)
+	assert.Equal(t, "anna@example.com", received.to)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(received.data))
+	require.NoError(t, err)
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Plan für dich Bcc: evil@example.com", subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		parts = append(parts, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8: Hallo Anna",
+		"text/html; charset=utf-8: <p>Hallo Anna</p>",
+	}, parts)
+}
+
+func TestSMTPMailerRejectsInvalidAddresses(t *testing.T) {
+	_, err := NewSMTPMailer(SMTPConfig{Host: "localhost", From: "not an address"})
+	assert.Error(t, err)
+
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: "localhost", From: "noreply@swim-gen.com"})
+	require.NoError(t, err)
+	err = mailer.Send(context.Background(), Message{To: "anna@example.com\r\nBcc: evil@example.com"})
+	assert.ErrorContains(t, err, "invalid recipient address")
+}
+
+func TestShareMessage(t *testing.T) {
+	data := ShareData{
+		Sender:      "anna_k",
+		Title:       "Sprint <Serie>",
+		Description: "Kurze Sprints",
+		Table: models.Table{
+			{Amount: 4, Multiplier: "x", Distance: 50, Break: models.Interval{Kind: models.IntervalRest, Seconds: 20}, Content: "Kraul <b>schnell</b>", Intensity: "GA2", Sum: 200},
+			{Amount: 2, Multiplier: "x", Distance: 100, Content: "Serie", Sum: 200, SubRows: []models.Row{
+				{Amount: 1, Multiplier: "x", Distance: 50, Content: "Rücken", Sum: 50},
+			}},
+			{Content: "Gesamt", Sum: 400},
+		},
+		Link:      "https://swim-gen.com/shared/abc?invitation=def",
+		ExpiresAt: time.Date(2026, 11, 17, 0, 0, 0, 0, time.UTC),
+		Language:  models.LanguageDE,
+	}
+
+	msg, err := ShareMessage("anna@example.com", data)
+	require.NoError(t, err)
+	assert.Equal(t, "anna@example.com", msg.To)
+	assert.Equal(t, "anna_k hat dir einen Trainingsplan geteilt: Sprint <Serie>", msg.Subject)
+	assert.Contains(t, msg.Text, "4 x 50 m | 20 | Kraul <b>schnell</b> | GA2 | 200 m")
+	assert.Contains(t, msg.Text, "  ↳ 1 x 50 m | Rücken | 50 m")
+	assert.Contains(t, msg.Text, "Gesamt: 400 m")
+	assert.Contains(t, msg.Text, "bis zum 17.11.2026 gültig")
+	assert.Contains(t, msg.HTML, "Kraul &lt;b&gt;schnell&lt;/b&gt;")
+	assert.Contains(t, msg.HTML, "Sprint &lt;Serie&gt;")
+	assert.Contains(t, msg.HTML, `href="https://swim-gen.com/shared/abc?invitation=def"`)
+	assert.Contains(t, msg.HTML, "Strecke(m)")
+	assert.Equal(t, 1, strings.Count(msg.HTML, "Gesamt"))
+
+	data.Language = models.LanguageEN
+	data.Sender = ""
+	msg, err = ShareMessage("anna@example.com", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Someone shared a training plan with you: Sprint <Serie>", msg.Subject)
+	assert.Contains(t, msg.Text, "valid until November 17, 2026")
+	assert.Contains(t, msg.HTML, "Distance(m)")
+}
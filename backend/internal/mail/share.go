@@ -0,0 +1,156 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	shareHTML = map[models.Language]*htmltemplate.Template{
+		models.LanguageDE: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/share_de.html", "templates/table.html")),
+		models.LanguageEN: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/share_en.html", "templates/table.html")),
+	}
+	shareText = map[models.Language]*texttemplate.Template{
+		models.LanguageDE: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/share_de.txt", "templates/table.txt")),
+		models.LanguageEN: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/share_en.txt", "templates/table.txt")),
+	}
+)
+
+// ShareData is the content of the email for a plan shared by email.
+type ShareData struct {
+	// Sender is the username of the sharing user, it may be empty.
+	Sender      string
+	Title       string
+	Description string
+	Table       models.Table
+	// Link opens the shared plan in the frontend and accepts the invitation.
+	Link      string
+	ExpiresAt time.Time
+	Language  models.Language
+}
+
+// shareView is the template data of the share email.
+type shareView struct {
+	Sender      string
+	Title       string
+	Description string
+	Link        string
+	ExpiresAt   string
+	Header      []string
+	Rows        []tableRow
+	TotalLabel  string
+	Total       string
+}
+
+type tableRow struct {
+	Sub        bool
+	Amount     string
+	Multiplier string
+	Distance   string
+	Break      string
+	Content    string
+	Intensity  string
+	Sum        string
+}
+
+// ShareMessage renders the email for a plan shared by email. Languages other
+// than German are written in English, like the table headers.
+func ShareMessage(to string, data ShareData) (Message, error) {
+	lang := data.Language
+	if lang != models.LanguageDE {
+		lang = models.LanguageEN
+	}
+	view := newShareView(data, lang)
+
+	var text, html bytes.Buffer
+	if err := shareText[lang].Execute(&text, view); err != nil {
+		return Message{}, fmt.Errorf("failed to render text email: %w", err)
+	}
+	if err := shareHTML[lang].Execute(&html, view); err != nil {
+		return Message{}, fmt.Errorf("failed to render html email: %w", err)
+	}
+
+	var subject string
+	switch lang {
+	case models.LanguageDE:
+		subject = fmt.Sprintf("%s hat dir einen Trainingsplan geteilt: %s", view.Sender, view.Title)
+	default:
+		subject = fmt.Sprintf("%s shared a training plan with you: %s", view.Sender, view.Title)
+	}
+	return Message{To: to, Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+func newShareView(data ShareData, lang models.Language) shareView {
+	view := shareView{
+		Sender:      strings.TrimSpace(data.Sender),
+		Title:       strings.TrimSpace(data.Title),
+		Description: strings.TrimSpace(data.Description),
+		Link:        data.Link,
+		Header:      data.Table.Header(lang),
+	}
+	switch lang {
+	case models.LanguageDE:
+		view.ExpiresAt = data.ExpiresAt.Format("02.01.2006")
+		view.TotalLabel = "Gesamt"
+		if view.Sender == "" {
+			view.Sender = "Jemand"
+		}
+		if view.Title == "" {
+			view.Title = "Trainingsplan"
+		}
+	default:
+		view.ExpiresAt = data.ExpiresAt.Format("January 2, 2006")
+		view.TotalLabel = "Total"
+		if view.Sender == "" {
+			view.Sender = "Someone"
+		}
+		if view.Title == "" {
+			view.Title = "Training plan"
+		}
+	}
+
+	// The last row holds the total, like in the PDF export.
+	rows := data.Table
+	total := 0
+	if n := len(rows); n > 0 && rows[n-1].IsTotal() {
+		total = rows[n-1].Sum
+		rows = rows[:n-1]
+	} else {
+		for _, row := range rows {
+			total += row.Sum
+		}
+	}
+	view.Total = strconv.Itoa(total) + " m"
+
+	for _, row := range rows {
+		view.Rows = append(view.Rows, newTableRow(row, false))
+		for _, sub := range row.SubRows {
+			view.Rows = append(view.Rows, newTableRow(sub, true))
+		}
+	}
+	return view
+}
+
+func newTableRow(row models.Row, sub bool) tableRow {
+	return tableRow{
+		Sub:        sub,
+		Amount:     strconv.Itoa(row.Amount),
+		Multiplier: row.Multiplier,
+		Distance:   strconv.Itoa(row.Distance),
+		Break:      row.Break.String(),
+		Content:    row.Content,
+		Intensity:  row.Intensity,
+		Sum:        strconv.Itoa(row.Sum),
+	}
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"math"
+)
+
+// CalibrationVerdict tells whether the plans swum by a user were too hard or too easy.
+type CalibrationVerdict string
+
+const (
+	CalibrationInsufficientData CalibrationVerdict = "insufficient_data"
+	CalibrationBalanced         CalibrationVerdict = "balanced"
+	CalibrationTooHard          CalibrationVerdict = "too_hard"
+	CalibrationTooEasy          CalibrationVerdict = "too_easy"
+)
+
+const (
+	// TargetDifficulty is the difficulty rating of a plan which challenges the
+	// swimmer without overloading them.
+	TargetDifficulty = 6
+	// MinCalibrationSamples is the number of rated plans needed for a calibration.
+	MinCalibrationSamples = 3
+	// MaxCalibrationSamples is the number of the latest rated plans the calibration uses.
+	MaxCalibrationSamples = 20
+	// calibrationPriorCount shrinks the calibration of few samples towards no adjustment.
+	calibrationPriorCount = 3.0
+	// hardLoadFactor is the extra load of hard meters compared to easy ones.
+	hardLoadFactor = 1.0
+	// Bounds of the suggested change of the training load.
+	minLoadFactor = 0.6
+	maxLoadFactor = 1.4
+)
+
+// CalibrationSample is a swum plan rated by the user.
+type CalibrationSample struct {
+	Difficulty int // Difficulty is the rating of the user from 1 to 10
+	Volume     int // Volume is the total distance of the plan in meters
+	HardVolume int // HardVolume is the distance swum at threshold pace or faster
+}
+
+// load is the training load of the plan, counting hard meters more.
+func (s CalibrationSample) load() float64 {
+	return float64(s.Volume) / 1000 * (1 + hardLoadFactor*s.hardShare())
+}
+
+func (s CalibrationSample) hardShare() float64 {
+	if s.Volume == 0 {
+		return 0
+	}
+	return float64(s.HardVolume) / float64(s.Volume)
+}
+
+// DifficultyCalibration describes how hard the plans swum by a user felt
+// relative to their volume and intensity, and the load suggested for new plans.
+// @Description Personal difficulty calibration learned from the difficulty ratings of swum plans
+type DifficultyCalibration struct {
+	Samples           int                `json:"samples" example:"6"`                                                             // Samples is the number of rated plans used
+	Verdict           CalibrationVerdict `json:"verdict" example:"too_hard" enums:"insufficient_data,balanced,too_hard,too_easy"` // Verdict tells whether the plans were too hard or too easy
+	TargetDifficulty  int                `json:"target_difficulty" example:"6"`                                                   // TargetDifficulty is the difficulty new plans aim for
+	AverageDifficulty float64            `json:"average_difficulty" example:"8.2"`                                                // AverageDifficulty is the mean difficulty rating of the plans
+	AverageVolume     int                `json:"average_volume" example:"3400"`                                                   // AverageVolume is the mean volume of the plans in meters
+	AverageHardShare  float64            `json:"average_hard_share" example:"0.3"`                                                // AverageHardShare is the mean share of the volume at threshold pace or faster
+	LoadFactor        float64            `json:"load_factor" example:"0.85"`                                                      // LoadFactor is the suggested change of the training load, 1 keeps it
+	RecommendedVolume int                `json:"recommended_volume" example:"2900"`                                               // RecommendedVolume is the volume reaching the target difficulty at the average intensity
+}
+
+// IsAdjusting reports whether new plans should change their load.
+func (c *DifficultyCalibration) IsAdjusting() bool {
+	return c != nil && (c.Verdict == CalibrationTooHard || c.Verdict == CalibrationTooEasy)
+}
+
+// CalibrateDifficulty learns from the ratings how the perceived difficulty of
+// the swimmer grows with the training load of a plan and returns the load
+// reaching TargetDifficulty. With a spread of loads a linear model is fitted,
+// otherwise the difficulty is assumed to be proportional to the load. Few
+// samples are shrunk towards no adjustment.
+func CalibrateDifficulty(samples []CalibrationSample) *DifficultyCalibration {
+	calibration := &DifficultyCalibration{
+		Samples:          len(samples),
+		Verdict:          CalibrationInsufficientData,
+		TargetDifficulty: TargetDifficulty,
+		LoadFactor:       1,
+	}
+	if len(samples) == 0 {
+		return calibration
+	}
+
+	n := float64(len(samples))
+	var difficulty, volume, hardShare, load float64
+	for _, s := range samples {
+		difficulty += float64(s.Difficulty)
+		volume += float64(s.Volume)
+		hardShare += s.hardShare()
+		load += s.load()
+	}
+	difficulty, volume, hardShare, load = difficulty/n, volume/n, hardShare/n, load/n
+	calibration.AverageDifficulty = math.Round(difficulty*10) / 10
+	calibration.AverageVolume = roundVolume(volume)
+	calibration.AverageHardShare = math.Round(hardShare*100) / 100
+	calibration.RecommendedVolume = calibration.AverageVolume
+	if len(samples) < MinCalibrationSamples || load == 0 {
+		return calibration
+	}
+
+	targetLoad := load * TargetDifficulty / difficulty
+	if slope, intercept, ok := fitLine(samples); ok {
+		targetLoad = (TargetDifficulty - intercept) / slope
+	}
+	factor := math.Min(math.Max(targetLoad/load, minLoadFactor), maxLoadFactor)
+	weight := n / (n + calibrationPriorCount)
+	factor = 1 + (factor-1)*weight
+
+	offset := (difficulty - TargetDifficulty) * weight
+	switch {
+	case offset > 1:
+		calibration.Verdict = CalibrationTooHard
+	case offset < -1:
+		calibration.Verdict = CalibrationTooEasy
+	default:
+		calibration.Verdict = CalibrationBalanced
+		factor = 1
+	}
+	calibration.LoadFactor = math.Round(factor*100) / 100
+	calibration.RecommendedVolume = roundVolume(volume * factor)
+	return calibration
+}
+
+// fitLine fits the difficulty as a linear function of the load. It fails if
+// the loads hardly differ or harder plans did not feel harder.
+func fitLine(samples []CalibrationSample) (slope, intercept float64, ok bool) {
+	n := float64(len(samples))
+	var meanLoad, meanDifficulty float64
+	for _, s := range samples {
+		meanLoad += s.load()
+		meanDifficulty += float64(s.Difficulty)
+	}
+	meanLoad, meanDifficulty = meanLoad/n, meanDifficulty/n
+
+	var covariance, variance float64
+	for _, s := range samples {
+		covariance += (s.load() - meanLoad) * (float64(s.Difficulty) - meanDifficulty)
+		variance += (s.load() - meanLoad) * (s.load() - meanLoad)
+	}
+	// Loads within about 10% of each other do not tell the slope.
+	if variance/n < math.Pow(0.1*meanLoad, 2) || covariance <= 0 {
+		return 0, 0, false
+	}
+	slope = covariance / variance
+	return slope, meanDifficulty - slope*meanLoad, true
+}
+
+func roundVolume(volume float64) int {
+	return int(math.Round(volume/100)) * 100
+}
+
+// IntensityMix returns the volume of the table and the part of it swum at
+// threshold pace or faster. The intensity of a row is derived like for the
+// duration estimate, sub rows without intensity inherit it from their parent.
+func (t *Table) IntensityMix() (volume, hardVolume int) {
+	for _, row := range *t {
+		if row.IsTotal() {
+			continue
+		}
+		v, h := rowIntensityMix(row, "")
+		volume += v
+		hardVolume += h
+	}
+	return volume, hardVolume
+}
+
+func rowIntensityMix(row Row, parentIntensity string) (volume, hardVolume int) {
+	intensity := row.Intensity
+	if intensity == "" {
+		intensity = parentIntensity
+	}
+
+	if len(row.SubRows) > 0 {
+		for _, sub := range row.SubRows {
+			v, h := rowIntensityMix(sub, intensity)
+			volume += v
+			hardVolume += h
+		}
+	} else {
+		volume = row.Distance
+		if isHardIntensity(intensity, row.Content) {
+			hardVolume = row.Distance
+		}
+	}
+
+	amount := max(row.Amount, 1)
+	return volume * amount, hardVolume * amount
+}
+
+// isHardIntensity reports whether the row is swum at threshold pace or faster.
+// Explicit paces are not compared with the CSS of the swimmer and count as easy.
+func isHardIntensity(intensity, content string) bool {
+	zone, ok := intensityZone(intensity)
+	if !ok {
+		zone, ok = intensityZone(content)
+	}
+	return ok && zone >= 2
+}
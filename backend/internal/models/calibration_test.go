@@ -0,0 +1,95 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntensityMixInheritsParentIntensity(t *testing.T) {
+	table := models.Table{
+		{Amount: 1, Distance: 400, Content: "Einschwimmen"},
+		{Amount: 4, Content: "Serie", Intensity: "SA", SubRows: []models.Row{
+			{Amount: 1, Distance: 50, Content: "Kraul"},
+			{Amount: 1, Distance: 50, Content: "Rücken locker", Intensity: "Rekom"},
+		}},
+		{Amount: 2, Distance: 100, Content: "Kraul", Intensity: "GA1"},
+		{Content: "Gesamt", Sum: 1000},
+	}
+
+	volume, hardVolume := table.IntensityMix()
+
+	assert.Equal(t, 1000, volume)
+	assert.Equal(t, 200, hardVolume)
+}
+
+func TestCalibrateDifficultyNeedsSamples(t *testing.T) {
+	calibration := models.CalibrateDifficulty([]models.CalibrationSample{
+		{Difficulty: 9, Volume: 3000},
+		{Difficulty: 9, Volume: 3000},
+	})
+
+	assert.Equal(t, models.CalibrationInsufficientData, calibration.Verdict)
+	assert.Equal(t, 1.0, calibration.LoadFactor)
+	assert.Equal(t, 3000, calibration.RecommendedVolume)
+	assert.False(t, calibration.IsAdjusting())
+	assert.Equal(t, models.CalibrationInsufficientData, models.CalibrateDifficulty(nil).Verdict)
+}
+
+func TestCalibrateDifficultyTooHard(t *testing.T) {
+	calibration := models.CalibrateDifficulty([]models.CalibrationSample{
+		{Difficulty: 9, Volume: 3000, HardVolume: 600},
+		{Difficulty: 8, Volume: 3000, HardVolume: 600},
+		{Difficulty: 9, Volume: 3000, HardVolume: 600},
+		{Difficulty: 8, Volume: 3000, HardVolume: 600},
+		{Difficulty: 9, Volume: 3000, HardVolume: 600},
+		{Difficulty: 8, Volume: 3000, HardVolume: 600},
+	})
+
+	assert.Equal(t, models.CalibrationTooHard, calibration.Verdict)
+	assert.Equal(t, 8.5, calibration.AverageDifficulty)
+	assert.Equal(t, 0.2, calibration.AverageHardShare)
+	// Proportional model: 6 / 8.5 of the load, shrunk by 6 / (6 + 3).
+	assert.Equal(t, 0.8, calibration.LoadFactor)
+	assert.Equal(t, 2400, calibration.RecommendedVolume)
+	assert.True(t, calibration.IsAdjusting())
+}
+
+func TestCalibrateDifficultyFitsLoad(t *testing.T) {
+	// Difficulty grows by 2 per km, 6 is reached at 2000m.
+	calibration := models.CalibrateDifficulty([]models.CalibrationSample{
+		{Difficulty: 6, Volume: 2000},
+		{Difficulty: 8, Volume: 3000},
+		{Difficulty: 10, Volume: 4000},
+		{Difficulty: 8, Volume: 3000},
+		{Difficulty: 10, Volume: 4000},
+		{Difficulty: 6, Volume: 2000},
+	})
+
+	assert.Equal(t, models.CalibrationTooHard, calibration.Verdict)
+	// Fitted target of 2000m is 0.67 of the load, shrunk by 6 / (6 + 3).
+	assert.Equal(t, 0.78, calibration.LoadFactor)
+	assert.Equal(t, 2300, calibration.RecommendedVolume)
+}
+
+func TestCalibrateDifficultyBalancedAndTooEasy(t *testing.T) {
+	balanced := models.CalibrateDifficulty([]models.CalibrationSample{
+		{Difficulty: 6, Volume: 2000},
+		{Difficulty: 7, Volume: 2000},
+		{Difficulty: 5, Volume: 2000},
+	})
+	assert.Equal(t, models.CalibrationBalanced, balanced.Verdict)
+	assert.Equal(t, 1.0, balanced.LoadFactor)
+
+	easy := models.CalibrateDifficulty([]models.CalibrationSample{
+		{Difficulty: 2, Volume: 2000},
+		{Difficulty: 3, Volume: 2000},
+		{Difficulty: 2, Volume: 2000},
+		{Difficulty: 3, Volume: 2000},
+	})
+	assert.Equal(t, models.CalibrationTooEasy, easy.Verdict)
+	assert.Greater(t, easy.LoadFactor, 1.0)
+	assert.LessOrEqual(t, easy.LoadFactor, 1.4)
+	assert.Greater(t, easy.RecommendedVolume, 2000)
+}
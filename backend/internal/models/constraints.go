@@ -0,0 +1,231 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// StrokeType is a swimming stroke, named like the stroke keys of Metadata.
+type StrokeType string
+
+const (
+	StrokeFreestyle    StrokeType = "freistil"
+	StrokeBreaststroke StrokeType = "brust"
+	StrokeButterfly    StrokeType = "delfin"
+	StrokeBackstroke   StrokeType = "ruecken"
+	StrokeMedley       StrokeType = "lagen"
+)
+
+// strokeKeywords are the lower case words which mark a row as swum in the stroke.
+// They match words starting with them, like "Brustbeine" or "Lagenstaffel".
+var strokeKeywords = map[StrokeType][]string{
+	StrokeFreestyle:    {"kraul", "freistil", "freestyle", "crawl"},
+	StrokeBreaststroke: {"brust", "breaststroke"},
+	StrokeButterfly:    {"delfin", "delphin", "schmetterling", "butterfly"},
+	StrokeBackstroke:   {"rücken", "ruecken", "backstroke"},
+	StrokeMedley:       {"lagen", "medley"},
+}
+
+// strokeFalseFriends are words starting with a stroke keyword which are not
+// about the stroke.
+var strokeFalseFriends = []string{"brustkorb"}
+
+var equipmentTypes = []EquipmentType{EquipmentFins, EquipmentKickboard, EquipmentPaddles, EquipmentBuoy, EquipmentSnorkel}
+
+const (
+	// VolumeTolerance is the relative deviation from the target volume a plan may have.
+	VolumeTolerance = 0.1
+	// MaxConstraintDuration is the longest session duration in minutes.
+	MaxConstraintDuration = 600
+)
+
+// PlanConstraints are explicit requirements for a generated plan. They are
+// passed to the LLM and checked after the generation.
+// @Description Explicit requirements a generated or refined plan has to meet
+type PlanConstraints struct {
+	TargetVolume    int             `json:"target_volume,omitempty" example:"3000"`                                                  // TargetVolume is the total distance of the plan in meters
+	MaxDuration     int             `json:"max_duration,omitempty" example:"60"`                                                     // MaxDuration is the maximum session length in minutes including rest
+	Equipment       []EquipmentType `json:"equipment,omitempty" example:"Flossen,Pull buoy"`                                         // Equipment lists the available equipment. Omitted allows all equipment, an empty list none
+	ExcludedStrokes []StrokeType    `json:"excluded_strokes,omitempty" example:"delfin" enums:"freistil,brust,delfin,ruecken,lagen"` // ExcludedStrokes lists strokes which must not be part of the plan
+}
+
+// Validate checks the bounds and the enum values of the constraints.
+func (c *PlanConstraints) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.TargetVolume < 0 || c.TargetVolume > MaxRowDistance {
+		return fmt.Errorf("target volume must be between 0 and %d", MaxRowDistance)
+	}
+	if c.MaxDuration < 0 || c.MaxDuration > MaxConstraintDuration {
+		return fmt.Errorf("max duration must be between 0 and %d minutes", MaxConstraintDuration)
+	}
+	for _, e := range c.Equipment {
+		if !slices.Contains(equipmentTypes, e) {
+			return fmt.Errorf("unknown equipment %q", e)
+		}
+	}
+	for _, s := range c.ExcludedStrokes {
+		if _, ok := strokeKeywords[s]; !ok {
+			return fmt.Errorf("unknown stroke %q", s)
+		}
+	}
+	return nil
+}
+
+// IsZero reports whether no constraint is set.
+func (c *PlanConstraints) IsZero() bool {
+	return c == nil || (c.TargetVolume == 0 && c.MaxDuration == 0 && c.Equipment == nil && len(c.ExcludedStrokes) == 0)
+}
+
+// Check returns the violations of the constraints by the table. The messages are
+// German, as they are sent back to the LLM like the prompts.
+func (c *PlanConstraints) Check(table Table, profile *UserProfile) []string {
+	if c.IsZero() {
+		return nil
+	}
+
+	var violations []string
+	if c.TargetVolume > 0 {
+		if volume := table.GetTotalVolume(); !c.volumeMatches(volume) {
+			violations = append(violations, fmt.Sprintf("Der Gesamtumfang beträgt %dm, gefordert sind %dm.", volume, c.TargetVolume))
+		}
+	}
+	if c.MaxDuration > 0 {
+		if estimate := EstimateDuration(table, profile); estimate.TotalSeconds > c.MaxDuration*60 {
+			violations = append(violations, fmt.Sprintf("Der Plan dauert ca. %d Minuten, erlaubt sind höchstens %d Minuten.", roundMinutes(estimate.TotalSeconds), c.MaxDuration))
+		}
+	}
+
+	var equipment []string
+	var strokes []string
+	walkRows(table, func(row Row) {
+		for _, e := range row.Equipment {
+			if c.Equipment != nil && !slices.Contains(c.Equipment, e) && !slices.Contains(equipment, string(e)) {
+				equipment = append(equipment, string(e))
+			}
+		}
+		for _, s := range c.ExcludedStrokes {
+			if rowHasStroke(row, s) {
+				strokes = append(strokes, fmt.Sprintf("%q enthält die ausgeschlossene Schwimmart %s.", row.Content, s))
+			}
+		}
+	})
+	if len(equipment) > 0 {
+		violations = append(violations, fmt.Sprintf("Die Ausrüstung %s ist nicht verfügbar.", strings.Join(equipment, ", ")))
+	}
+	return append(violations, strokes...)
+}
+
+func (c *PlanConstraints) volumeMatches(volume int) bool {
+	return math.Abs(float64(volume-c.TargetVolume)) <= float64(c.TargetVolume)*VolumeTolerance
+}
+
+// Apply fixes the violations which do not need the LLM. Equipment which is not
+// available is removed from the rows and the repetitions are scaled to the target
+// volume and below the maximum duration. Excluded strokes can not be fixed.
+func (c *PlanConstraints) Apply(table *Table, profile *UserProfile) {
+	if c.IsZero() {
+		return
+	}
+
+	if c.Equipment != nil {
+		removeEquipment(*table, c.Equipment)
+	}
+	if c.TargetVolume > 0 && !c.volumeMatches(table.GetTotalVolume()) {
+		table.ScaleVolume(c.TargetVolume)
+	}
+	if c.MaxDuration > 0 {
+		// Rounding of the repetitions may leave the plan slightly too long, so it is
+		// scaled down a few times at most.
+		for range 3 {
+			estimate := EstimateDuration(*table, profile)
+			if estimate.TotalSeconds <= c.MaxDuration*60 {
+				break
+			}
+			volume := table.GetTotalVolume()
+			target := volume * c.MaxDuration * 60 / estimate.TotalSeconds
+			if target >= volume {
+				target = volume - 1
+			}
+			table.ScaleVolume(target)
+		}
+	}
+}
+
+// ScaleVolume changes the repetitions of the rows so that the total volume gets
+// close to target. Rows swum once, like warm up, keep their amount as long as
+// other rows are repeated. The sums, including the total row, are updated.
+func (t *Table) ScaleVolume(target int) {
+	repeated := false
+	for _, row := range *t {
+		if !row.IsTotal() && row.Amount > 1 {
+			repeated = true
+		}
+	}
+
+	var fixed, scalable int
+	for _, row := range *t {
+		switch {
+		case row.IsTotal():
+		case repeated && row.Amount <= 1:
+			fixed += row.Sum
+		default:
+			scalable += row.Sum
+		}
+	}
+	if scalable == 0 {
+		return
+	}
+
+	factor := math.Max(float64(target-fixed), 0) / float64(scalable)
+	for i := range *t {
+		row := &(*t)[i]
+		if row.IsTotal() || (repeated && row.Amount <= 1) {
+			continue
+		}
+		row.Amount = min(max(int(math.Round(float64(row.Amount)*factor)), 1), MaxRowAmount)
+	}
+	t.UpdateSum()
+}
+
+// walkRows calls fn for all rows and sub rows of the table.
+func walkRows(table Table, fn func(Row)) {
+	for _, row := range table {
+		fn(row)
+		walkRows(row.SubRows, fn)
+	}
+}
+
+func rowHasStroke(row Row, stroke StrokeType) bool {
+	words := strings.FieldsFunc(strings.ToLower(row.Content), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if slices.ContainsFunc(strokeFalseFriends, func(f string) bool { return strings.HasPrefix(word, f) }) {
+			continue
+		}
+		for _, keyword := range strokeKeywords[stroke] {
+			if strings.HasPrefix(word, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func removeEquipment(table Table, allowed []EquipmentType) {
+	for i := range table {
+		row := &table[i]
+		row.Equipment = slices.DeleteFunc(row.Equipment, func(e EquipmentType) bool {
+			return !slices.Contains(allowed, e)
+		})
+		if len(row.Equipment) == 0 {
+			row.Equipment = nil
+		}
+		removeEquipment(row.SubRows, allowed)
+	}
+}
@@ -0,0 +1,113 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func constraintTable() models.Table {
+	table := models.Table{
+		{Amount: 1, Multiplier: "x", Distance: 400, Content: "Einschwimmen Kraul", Intensity: "1:40 / 100m"},
+		{Amount: 4, Multiplier: "x", Distance: 100, Break: models.Rest(20), Content: "Delfin", Intensity: "1:40 / 100m", Equipment: []models.EquipmentType{models.EquipmentFins}},
+		{
+			Amount: 2, Multiplier: "x", Intensity: "1:40 / 100m",
+			SubRows: []models.Row{
+				{Amount: 2, Multiplier: "x", Distance: 100, Content: "Rücken", Equipment: []models.EquipmentType{models.EquipmentBuoy, models.EquipmentPaddles}},
+			},
+		},
+		{Amount: 1, Multiplier: "x", Distance: 200, Content: "Ausschwimmen", Intensity: "1:40 / 100m"},
+	}
+	table.UpdateSum()
+	table.AddSum()
+	return table
+}
+
+func TestPlanConstraintsValidate(t *testing.T) {
+	var none *models.PlanConstraints
+	assert.NoError(t, none.Validate())
+	assert.NoError(t, (&models.PlanConstraints{TargetVolume: 3000, MaxDuration: 60, Equipment: []models.EquipmentType{models.EquipmentFins}, ExcludedStrokes: []models.StrokeType{models.StrokeButterfly}}).Validate())
+
+	assert.Error(t, (&models.PlanConstraints{TargetVolume: -1}).Validate())
+	assert.Error(t, (&models.PlanConstraints{MaxDuration: models.MaxConstraintDuration + 1}).Validate())
+	assert.Error(t, (&models.PlanConstraints{Equipment: []models.EquipmentType{"Schwimmbrille"}}).Validate())
+	assert.Error(t, (&models.PlanConstraints{ExcludedStrokes: []models.StrokeType{"hundepaddeln"}}).Validate())
+}
+
+func TestPlanConstraintsCheck(t *testing.T) {
+	table := constraintTable()
+	require.Equal(t, 1400, table.GetTotalVolume())
+
+	assert.Empty(t, (&models.PlanConstraints{TargetVolume: 1500, MaxDuration: 60}).Check(table, nil))
+
+	constraints := &models.PlanConstraints{
+		TargetVolume:    2000,
+		MaxDuration:     20,
+		Equipment:       []models.EquipmentType{models.EquipmentBuoy},
+		ExcludedStrokes: []models.StrokeType{models.StrokeButterfly, models.StrokeBackstroke},
+	}
+	assert.Equal(t, []string{
+		"Der Gesamtumfang beträgt 1400m, gefordert sind 2000m.",
+		"Der Plan dauert ca. 25 Minuten, erlaubt sind höchstens 20 Minuten.",
+		"Die Ausrüstung Flossen, Handpaddles ist nicht verfügbar.",
+		`"Delfin" enthält die ausgeschlossene Schwimmart delfin.`,
+		`"Rücken" enthält die ausgeschlossene Schwimmart ruecken.`,
+	}, constraints.Check(table, nil))
+}
+
+func TestPlanConstraintsCheckMatchesStrokeWords(t *testing.T) {
+	table := models.Table{
+		{Amount: 1, Multiplier: "x", Distance: 400, Content: "Grundlagenausdauer Kraul", Intensity: "GA1"},
+		{Amount: 4, Multiplier: "x", Distance: 50, Content: "Kraul mit Atmung in den Brustkorb", Intensity: "GA1"},
+		{Amount: 4, Multiplier: "x", Distance: 50, Content: "Brustbeine/Kraularme", Intensity: "GA1"},
+		{Amount: 2, Multiplier: "x", Distance: 100, Content: "Lagenstaffel", Intensity: "GA1"},
+	}
+	table.UpdateSum()
+	table.AddSum()
+
+	constraints := &models.PlanConstraints{ExcludedStrokes: []models.StrokeType{models.StrokeMedley, models.StrokeBreaststroke}}
+	assert.Equal(t, []string{
+		`"Brustbeine/Kraularme" enthält die ausgeschlossene Schwimmart brust.`,
+		`"Lagenstaffel" enthält die ausgeschlossene Schwimmart lagen.`,
+	}, constraints.Check(table, nil))
+}
+
+func TestTableScaleVolume(t *testing.T) {
+	table := constraintTable()
+
+	table.ScaleVolume(2200)
+
+	// Warm up and cool down keep their amount, the sets are doubled
+	assert.Equal(t, 1, table[0].Amount)
+	assert.Equal(t, 8, table[1].Amount)
+	assert.Equal(t, 4, table[2].Amount)
+	assert.Equal(t, 2200, table.GetTotalVolume())
+	assert.Equal(t, 2200, table[len(table)-1].Sum)
+
+	table.ScaleVolume(0)
+	assert.Equal(t, 1, table[1].Amount)
+	assert.Equal(t, 1, table[2].Amount)
+}
+
+func TestPlanConstraintsApply(t *testing.T) {
+	table := constraintTable()
+	constraints := &models.PlanConstraints{
+		TargetVolume:    2200,
+		MaxDuration:     30,
+		Equipment:       []models.EquipmentType{models.EquipmentBuoy},
+		ExcludedStrokes: []models.StrokeType{models.StrokeButterfly},
+	}
+
+	constraints.Apply(&table, nil)
+
+	assert.Nil(t, table[1].Equipment)
+	assert.Equal(t, []models.EquipmentType{models.EquipmentBuoy}, table[2].SubRows[0].Equipment)
+	assert.LessOrEqual(t, models.EstimateDuration(table, nil).TotalSeconds, 30*60)
+	// The volume yields to the maximum duration and the stroke can not be fixed without the LLM
+	violations := constraints.Check(table, nil)
+	require.Len(t, violations, 2)
+	assert.Contains(t, violations[0], "Der Gesamtumfang beträgt")
+	assert.Equal(t, `"Delfin" enthält die ausgeschlossene Schwimmart delfin.`, violations[1])
+}
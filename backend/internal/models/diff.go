@@ -0,0 +1,181 @@
+package models
+
+import "slices"
+
+// DiffOp is the kind of change of a row between two tables.
+type DiffOp string
+
+const (
+	DiffAdded   DiffOp = "added"
+	DiffRemoved DiffOp = "removed"
+	DiffChanged DiffOp = "changed"
+)
+
+// RowDiff is a row that differs between two tables. Indices refer to the
+// position of the row in the old (From) and new (To) table, or sub rows.
+// @Description Added, removed or changed row of a training plan table
+type RowDiff struct {
+	Op        DiffOp    `json:"op" example:"changed"`
+	FromIndex *int      `json:"from_index,omitempty" example:"1"`
+	ToIndex   *int      `json:"to_index,omitempty" example:"1"`
+	From      *Row      `json:"from,omitempty"`
+	To        *Row      `json:"to,omitempty"`
+	Fields    []string  `json:"fields,omitempty" example:"Amount,Sum"` // Fields lists the changed fields of changed rows
+	SubRows   []RowDiff `json:"sub_rows,omitempty"`                    // SubRows holds the changes of the sub rows of changed rows
+}
+
+// TableDiff is the row level difference between two tables. Total rows are
+// not compared, the change of the volume is given by VolumeDelta.
+// @Description Row level difference between two training plan tables
+type TableDiff struct {
+	Rows        []RowDiff `json:"rows"`
+	Added       int       `json:"added" example:"1"`
+	Removed     int       `json:"removed" example:"0"`
+	Changed     int       `json:"changed" example:"2"`
+	FromVolume  int       `json:"from_volume" example:"3000"`
+	ToVolume    int       `json:"to_volume" example:"3400"`
+	VolumeDelta int       `json:"volume_delta" example:"400"`
+}
+
+// Equal reports whether the diff contains no row changes.
+func (d TableDiff) Equal() bool {
+	return len(d.Rows) == 0
+}
+
+// DiffTables compares the rows of two tables. Rows that are equal in both
+// tables are matched by their longest common subsequence. Between matched rows,
+// rows with the same content or the same repetitions are paired as changed
+// rows, the other rows are added or removed.
+func DiffTables(from, to Table) TableDiff {
+	from = slices.DeleteFunc(slices.Clone(from), Row.IsTotal)
+	to = slices.DeleteFunc(slices.Clone(to), Row.IsTotal)
+
+	diff := TableDiff{Rows: diffRows(from, to)}
+	for _, row := range diff.Rows {
+		switch row.Op {
+		case DiffAdded:
+			diff.Added++
+		case DiffRemoved:
+			diff.Removed++
+		case DiffChanged:
+			diff.Changed++
+		}
+	}
+	diff.FromVolume = from.GetTotalVolume()
+	diff.ToVolume = to.GetTotalVolume()
+	diff.VolumeDelta = diff.ToVolume - diff.FromVolume
+	return diff
+}
+
+func diffRows(from, to []Row) []RowDiff {
+	diffs := []RowDiff{}
+	// The gaps between equal rows are compared again to find the changed rows.
+	walkAlignment(len(from), len(to), func(i, j int) bool { return rowsEqual(from[i], to[j]) },
+		func(fromGap, toGap []int) {
+			walkAlignment(len(fromGap), len(toGap), func(i, j int) bool { return rowsSimilar(from[fromGap[i]], to[toGap[j]]) },
+				func(removed, added []int) {
+					for _, k := range removed {
+						i := fromGap[k]
+						diffs = append(diffs, RowDiff{Op: DiffRemoved, FromIndex: &i, From: &from[i]})
+					}
+					for _, k := range added {
+						j := toGap[k]
+						diffs = append(diffs, RowDiff{Op: DiffAdded, ToIndex: &j, To: &to[j]})
+					}
+				},
+				func(i, j int) {
+					diffs = append(diffs, changedRow(fromGap[i], toGap[j], from[fromGap[i]], to[toGap[j]]))
+				})
+		},
+		func(int, int) {})
+	return diffs
+}
+
+// walkAlignment matches the indices of two sequences of length n and m by
+// their longest common subsequence under match. It calls matched for each
+// matched pair and gap with the unmatched indices before each match and at
+// the end, if there are any.
+func walkAlignment(n, m int, match func(i, j int) bool, gap func(from, to []int), matched func(i, j int)) {
+	// lcs[i][j] is the length of the longest common subsequence of [i:n] and [j:m].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if match(i, j) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var fromGap, toGap []int
+	flush := func() {
+		if len(fromGap) > 0 || len(toGap) > 0 {
+			gap(fromGap, toGap)
+		}
+		fromGap, toGap = nil, nil
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && match(i, j):
+			flush()
+			matched(i, j)
+			i++
+			j++
+		case j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+			fromGap = append(fromGap, i)
+			i++
+		default:
+			toGap = append(toGap, j)
+			j++
+		}
+	}
+	flush()
+}
+
+func changedRow(i, j int, from, to Row) RowDiff {
+	diff := RowDiff{Op: DiffChanged, FromIndex: &i, ToIndex: &j, From: &from, To: &to}
+	fields := []struct {
+		name  string
+		equal bool
+	}{
+		{"Amount", from.Amount == to.Amount},
+		{"Multiplier", from.Multiplier == to.Multiplier},
+		{"Distance", from.Distance == to.Distance},
+		{"Break", from.Break == to.Break},
+		{"Content", from.Content == to.Content},
+		{"Intensity", from.Intensity == to.Intensity},
+		{"Sum", from.Sum == to.Sum},
+		{"Equipment", slices.Equal(from.Equipment, to.Equipment)},
+	}
+	for _, field := range fields {
+		if !field.equal {
+			diff.Fields = append(diff.Fields, field.name)
+		}
+	}
+	if diff.SubRows = diffRows(from.SubRows, to.SubRows); len(diff.SubRows) > 0 {
+		diff.Fields = append(diff.Fields, "SubRows")
+	}
+	return diff
+}
+
+// rowsSimilar reports whether b is likely an edited version of a.
+func rowsSimilar(a, b Row) bool {
+	return a.Content == b.Content || (a.Amount == b.Amount && a.Distance == b.Distance)
+}
+
+func rowsEqual(a, b Row) bool {
+	return a.Amount == b.Amount &&
+		a.Multiplier == b.Multiplier &&
+		a.Distance == b.Distance &&
+		a.Break == b.Break &&
+		a.Content == b.Content &&
+		a.Intensity == b.Intensity &&
+		a.Sum == b.Sum &&
+		slices.Equal(a.Equipment, b.Equipment) &&
+		slices.EqualFunc(a.SubRows, b.SubRows, rowsEqual)
+}
@@ -0,0 +1,101 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTablesEqual(t *testing.T) {
+	table := constraintTable()
+
+	diff := models.DiffTables(table, constraintTable())
+
+	assert.True(t, diff.Equal())
+	assert.Empty(t, diff.Rows)
+	assert.Equal(t, diff.FromVolume, diff.ToVolume)
+	assert.Zero(t, diff.VolumeDelta)
+}
+
+func TestDiffTablesRows(t *testing.T) {
+	from := constraintTable()
+	to := constraintTable()
+	// Remove the butterfly set, add a kick set before cool down and change the sub rows of the back stroke set.
+	to = append(to[:1], to[2:]...)
+	to[1].SubRows = append(to[1].SubRows, models.Row{Amount: 1, Multiplier: "x", Distance: 50, Content: "Beine"})
+	to = append(to[:2], append(models.Table{{Amount: 4, Multiplier: "x", Distance: 50, Content: "Beine mit Brett"}}, to[2:]...)...)
+	to.UpdateSum()
+
+	diff := models.DiffTables(from, to)
+
+	require.Len(t, diff.Rows, 3)
+	assert.Equal(t, 1, diff.Removed)
+	assert.Equal(t, 1, diff.Changed)
+	assert.Equal(t, 1, diff.Added)
+
+	removed := diff.Rows[0]
+	assert.Equal(t, models.DiffRemoved, removed.Op)
+	assert.Equal(t, 1, *removed.FromIndex)
+	assert.Nil(t, removed.ToIndex)
+	assert.Equal(t, "Delfin", removed.From.Content)
+
+	changed := diff.Rows[1]
+	assert.Equal(t, models.DiffChanged, changed.Op)
+	assert.Equal(t, 2, *changed.FromIndex)
+	assert.Equal(t, 1, *changed.ToIndex)
+	assert.Equal(t, []string{"Distance", "Sum", "SubRows"}, changed.Fields)
+	require.Len(t, changed.SubRows, 1)
+	assert.Equal(t, models.DiffAdded, changed.SubRows[0].Op)
+	assert.Equal(t, 1, *changed.SubRows[0].ToIndex)
+	assert.Equal(t, "Beine", changed.SubRows[0].To.Content)
+
+	added := diff.Rows[2]
+	assert.Equal(t, models.DiffAdded, added.Op)
+	assert.Equal(t, 2, *added.ToIndex)
+	assert.Equal(t, "Beine mit Brett", added.To.Content)
+
+	assert.Equal(t, 1400, diff.FromVolume)
+	assert.Equal(t, 1300, diff.ToVolume)
+	assert.Equal(t, -100, diff.VolumeDelta)
+}
+
+func TestDiffTablesChangedFields(t *testing.T) {
+	from := constraintTable()
+	to := constraintTable()
+	to[1].Amount = 6
+	to[1].Break = models.SendOff(105)
+	to.UpdateSum()
+
+	diff := models.DiffTables(from, to)
+
+	require.Len(t, diff.Rows, 1)
+	row := diff.Rows[0]
+	assert.Equal(t, models.DiffChanged, row.Op)
+	assert.Equal(t, []string{"Amount", "Break", "Sum"}, row.Fields)
+	assert.Empty(t, row.SubRows)
+	assert.Equal(t, 200, diff.VolumeDelta)
+}
+
+func TestDiffTablesAddedAndRemoved(t *testing.T) {
+	from := models.Table{{Amount: 1, Distance: 400, Content: "Einschwimmen", Sum: 400}}
+	to := models.Table{
+		{Amount: 1, Distance: 400, Content: "Einschwimmen", Sum: 400},
+		{Amount: 8, Distance: 50, Content: "Sprints", Sum: 400},
+		{Content: "Gesamt", Sum: 800},
+	}
+
+	diff := models.DiffTables(from, to)
+	require.Len(t, diff.Rows, 1)
+	assert.Equal(t, models.DiffAdded, diff.Rows[0].Op)
+	assert.Nil(t, diff.Rows[0].FromIndex)
+	assert.Equal(t, 1, *diff.Rows[0].ToIndex)
+	assert.Equal(t, 400, diff.VolumeDelta)
+
+	reverse := models.DiffTables(to, from)
+	require.Len(t, reverse.Rows, 1)
+	assert.Equal(t, models.DiffRemoved, reverse.Rows[0].Op)
+	assert.Equal(t, 1, reverse.Removed)
+	assert.Equal(t, -400, reverse.VolumeDelta)
+}
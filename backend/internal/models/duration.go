@@ -0,0 +1,186 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultCSSPace is the CSS pace per 100 m in seconds, which is assumed when the
+// user has not entered CSS times.
+const DefaultCSSPace = 120.0
+
+// kickPaceFactor slows down the pace of rows with kick sets.
+const kickPaceFactor = 1.3
+
+// RowDuration is the estimated time of a row including its repetitions and sub rows.
+// @Description Estimated swim and rest time of a row in seconds
+type RowDuration struct {
+	SwimSeconds int `json:"swim_seconds" example:"420"`
+	RestSeconds int `json:"rest_seconds" example:"80"`
+}
+
+// DurationEstimate is the estimated time of a training plan.
+// @Description Estimated duration of a training plan based on the CSS pace zones of the swimmer
+type DurationEstimate struct {
+	SwimSeconds  int           `json:"swim_seconds" example:"2700"`  // SwimSeconds is the estimated time spent swimming
+	RestSeconds  int           `json:"rest_seconds" example:"540"`   // RestSeconds is the estimated time spent resting
+	TotalSeconds int           `json:"total_seconds" example:"3240"` // TotalSeconds is the sum of swim and rest time
+	CSSPace      float64       `json:"css_pace" example:"95"`        // CSSPace is the pace per 100 m at critical swim speed used for the estimate
+	Personal     bool          `json:"personal" example:"true"`      // Personal is true if the CSS times of the user were used
+	Rows         []RowDuration `json:"rows"`                         // Rows contains one estimate per row of the table, without the total row
+}
+
+// Label returns a short description of the estimate for print outs, e.g.
+// "ca. 54 min (45 min Schwimmen, 9 min Pause)".
+func (d *DurationEstimate) Label(lang Language) string {
+	total, swim, rest := roundMinutes(d.TotalSeconds), roundMinutes(d.SwimSeconds), roundMinutes(d.RestSeconds)
+	switch lang {
+	case LanguageDE:
+		return fmt.Sprintf("ca. %d min (%d min Schwimmen, %d min Pause)", total, swim, rest)
+	default: // LanguageEN and any other unsupported languages
+		return fmt.Sprintf("approx. %d min (%d min swimming, %d min rest)", total, swim, rest)
+	}
+}
+
+func roundMinutes(seconds int) int {
+	return (seconds + 30) / 60
+}
+
+// EstimateDuration estimates the swim and rest time of the table. The pace of a
+// row is taken from the CSS zone matching its intensity, using the CSS times of
+// the profile or DefaultCSSPace if the profile has none. Rest is added per
+// repetition, send-off times fill up the repetition to the given time.
+func EstimateDuration(table Table, profile *UserProfile) *DurationEstimate {
+	estimate := &DurationEstimate{CSSPace: DefaultCSSPace}
+	if profile != nil {
+		if cssPace, ok := CalculateCSSPace(profile.CSS200mSeconds, profile.CSS400mSeconds); ok {
+			estimate.CSSPace = cssPace
+			estimate.Personal = true
+		}
+	}
+
+	zones := CalculateCSSZones(estimate.CSSPace)
+	estimate.Rows = make([]RowDuration, 0, len(table))
+	for _, row := range table {
+		if row.IsTotal() {
+			continue
+		}
+		d := rowDuration(row, "", zones)
+		estimate.Rows = append(estimate.Rows, d)
+		estimate.SwimSeconds += d.SwimSeconds
+		estimate.RestSeconds += d.RestSeconds
+	}
+	estimate.TotalSeconds = estimate.SwimSeconds + estimate.RestSeconds
+	return estimate
+}
+
+// rowDuration returns the time of all repetitions of the row. Sub rows without
+// intensity inherit the intensity of their parent.
+func rowDuration(row Row, parentIntensity string, zones []CSSZone) RowDuration {
+	intensity := row.Intensity
+	if intensity == "" {
+		intensity = parentIntensity
+	}
+
+	var rep RowDuration
+	if len(row.SubRows) > 0 {
+		for _, sub := range row.SubRows {
+			d := rowDuration(sub, intensity, zones)
+			rep.SwimSeconds += d.SwimSeconds
+			rep.RestSeconds += d.RestSeconds
+		}
+	} else {
+		pace := intensityPace(intensity, row.Content, zones)
+		rep.SwimSeconds = int(math.Round(float64(row.Distance) / 100 * pace))
+	}
+
+	switch row.Break.Kind {
+	case IntervalRest:
+		rep.RestSeconds += row.Break.Seconds
+	case IntervalSendOff:
+		if busy := rep.SwimSeconds + rep.RestSeconds; row.Break.Seconds > busy {
+			rep.RestSeconds += row.Break.Seconds - busy
+		}
+	}
+
+	amount := max(row.Amount, 1)
+	return RowDuration{SwimSeconds: rep.SwimSeconds * amount, RestSeconds: rep.RestSeconds * amount}
+}
+
+// paceZoneWords maps words of intensities to the index of the zone returned by
+// CalculateCSSZones. Faster zones come first, so "GA2-SA" counts as SA.
+var paceZoneWords = []struct {
+	zone  int
+	words []string
+}{
+	{4, []string{"sprint", "max", "maximal", "wk", "race"}},
+	{3, []string{"sa", "wa", "vo2", "vo2max", "anaerob", "anaerobic", "hoch", "hard"}},
+	{2, []string{"ga2", "lt", "schwelle", "threshold", "css", "zügig"}},
+	{0, []string{"rekom", "kom", "regeneration", "recovery", "locker", "easy", "einschwimmen", "ausschwimmen", "technik", "technique", "drill", "warmup", "cooldown"}},
+	{1, []string{"ga", "ga1", "lza", "ta", "aerob", "aerobic", "ausdauer", "endurance", "mittel", "moderate"}},
+}
+
+var (
+	explicitPace = regexp.MustCompile(`(\d{1,2}):(\d{2})\s*/\s*100\s*m`)
+	zoneNumber   = regexp.MustCompile(`^(?:bz|z|zone)([1-5])$`)
+)
+
+// intensityPace returns the pace per 100 m for a row. Explicit paces like
+// "1:45 / 100m" are used as is, otherwise the zone is derived from the words of
+// the intensity or, if it is unknown, of the content. Everything else is swum in
+// the aerobic zone.
+func intensityPace(intensity, content string, zones []CSSZone) float64 {
+	factor := 1.0
+	lowerContent := strings.ToLower(content)
+	if strings.Contains(lowerContent, "bein") || strings.Contains(lowerContent, "kick") {
+		factor = kickPaceFactor
+	}
+
+	if m := explicitPace.FindStringSubmatch(intensity); m != nil {
+		minutes, _ := strconv.Atoi(m[1])
+		seconds, _ := strconv.Atoi(m[2])
+		return float64(minutes*60+seconds) * factor
+	}
+
+	zone, ok := intensityZone(intensity)
+	if !ok {
+		// The content often tells the intensity as well, e.g. "Einschwimmen"
+		if zone, ok = intensityZone(content); !ok {
+			zone = 1
+		}
+	}
+	return zonePace(zones[zone]) * factor
+}
+
+// intensityZone returns the zone of a zone number like "BZ3" or of the first
+// matching word group of paceZoneWords.
+func intensityZone(s string) (int, bool) {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if m := zoneNumber.FindStringSubmatch(word); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			return n - 1, true
+		}
+	}
+	for _, group := range paceZoneWords {
+		for _, word := range words {
+			for _, key := range group.words {
+				if word == key {
+					return group.zone, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// zonePace returns the pace in the middle of the zone.
+func zonePace(zone CSSZone) float64 {
+	return (zone.FasterPaceSeconds + zone.SlowerPaceSeconds) / 2
+}
@@ -0,0 +1,82 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateDurationRestAndSendOff(t *testing.T) {
+	table := models.Table{
+		{Amount: 4, Distance: 100, Break: models.Rest(20), Content: "Kraul", Intensity: "1:40 / 100m"},
+		{Amount: 4, Distance: 100, Break: models.SendOff(120), Content: "Kraul", Intensity: "1:40/100m"},
+		{Amount: 2, Distance: 100, Break: models.SendOff(90), Content: "Kraul", Intensity: "1:40 / 100m"},
+		{Amount: 1, Distance: 100, Break: models.FreeInterval("nach Bedarf"), Content: "Kraul", Intensity: "1:40 / 100m"},
+		{Content: "Gesamt", Sum: 1100},
+	}
+
+	estimate := models.EstimateDuration(table, nil)
+
+	assert.Equal(t, []models.RowDuration{
+		{SwimSeconds: 400, RestSeconds: 80},
+		{SwimSeconds: 400, RestSeconds: 80},
+		{SwimSeconds: 200, RestSeconds: 0},
+		{SwimSeconds: 100, RestSeconds: 0},
+	}, estimate.Rows)
+	assert.Equal(t, 1100, estimate.SwimSeconds)
+	assert.Equal(t, 160, estimate.RestSeconds)
+	assert.Equal(t, 1260, estimate.TotalSeconds)
+	assert.False(t, estimate.Personal)
+	assert.Equal(t, models.DefaultCSSPace, estimate.CSSPace)
+}
+
+func TestEstimateDurationSubRows(t *testing.T) {
+	table := models.Table{
+		{
+			Amount: 2, Break: models.Rest(30), Intensity: "1:40 / 100m",
+			SubRows: []models.Row{
+				{Amount: 2, Distance: 50, Break: models.Rest(10), Content: "Kraul"},
+				{Amount: 1, Distance: 100, Content: "Beine", Intensity: "2:00 / 100m"},
+			},
+		},
+	}
+
+	estimate := models.EstimateDuration(table, nil)
+
+	// One repetition: 2x50 at 1:40 with 10s rest, 100 kick at 2:00 slowed down by 30%, 30s rest
+	require.Len(t, estimate.Rows, 1)
+	assert.Equal(t, models.RowDuration{SwimSeconds: 2 * (100 + 156), RestSeconds: 2 * (20 + 30)}, estimate.Rows[0])
+}
+
+func TestEstimateDurationUsesCSSZones(t *testing.T) {
+	css200, css400 := 180, 380
+	profile := &models.UserProfile{CSS200mSeconds: &css200, CSS400mSeconds: &css400}
+	table := models.Table{
+		{Amount: 1, Distance: 100, Content: "Einschwimmen"},
+		{Amount: 1, Distance: 100, Content: "Kraul", Intensity: "GA1"},
+		{Amount: 1, Distance: 100, Content: "Kraul", Intensity: "GA2"},
+		{Amount: 1, Distance: 100, Content: "Kraul", Intensity: "SA"},
+		{Amount: 1, Distance: 100, Content: "Kraul", Intensity: "Sprint"},
+		{Amount: 1, Distance: 100, Content: "Kraul"},
+	}
+
+	estimate := models.EstimateDuration(table, profile)
+
+	assert.True(t, estimate.Personal)
+	assert.Equal(t, 100.0, estimate.CSSPace)
+	for i := 1; i < 5; i++ {
+		assert.Less(t, estimate.Rows[i].SwimSeconds, estimate.Rows[i-1].SwimSeconds, "row %d", i)
+	}
+	// Rows without intensity are swum in the aerobic zone
+	assert.Equal(t, estimate.Rows[1], estimate.Rows[5])
+	assert.Equal(t, 101, estimate.Rows[2].SwimSeconds)
+}
+
+func TestDurationEstimateLabel(t *testing.T) {
+	estimate := &models.DurationEstimate{SwimSeconds: 2700, RestSeconds: 550, TotalSeconds: 3250}
+
+	assert.Equal(t, "ca. 54 min (45 min Schwimmen, 9 min Pause)", estimate.Label(models.LanguageDE))
+	assert.Equal(t, "approx. 54 min (45 min swimming, 9 min rest)", estimate.Label(models.LanguageEN))
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/invopop/jsonschema"
+)
+
+// IntervalKind defines how the break of a row is meant.
+type IntervalKind string
+
+const (
+	IntervalNone    IntervalKind = ""         // no break
+	IntervalRest    IntervalKind = "rest"     // rest for Seconds after each repetition
+	IntervalSendOff IntervalKind = "send_off" // start a repetition every Seconds
+	IntervalFree    IntervalKind = "free"     // free text that is no fixed time, e.g. "nach Bedarf"
+)
+
+// MaxIntervalSeconds is the longest rest or send-off time. Longer times are kept
+// as free text.
+const MaxIntervalSeconds = 60 * 60
+
+// Interval is the break of a row. In JSON it is written as the string that was
+// used for Row.Break before, e.g. "20" for 20 seconds rest, "@1:45" for a
+// send-off time or free text, so stored plans and clients stay compatible.
+type Interval struct {
+	Kind    IntervalKind
+	Seconds int    // Rest or send-off time
+	Text    string // Text of free intervals
+}
+
+// Rest returns a rest interval of the given seconds. Zero seconds is a rest
+// written as "0", which differs from no break only in its written form.
+func Rest(seconds int) Interval {
+	return Interval{Kind: IntervalRest, Seconds: seconds}
+}
+
+// SendOff returns a send-off interval of the given seconds.
+func SendOff(seconds int) Interval {
+	return Interval{Kind: IntervalSendOff, Seconds: seconds}
+}
+
+// FreeInterval returns an interval described by free text.
+func FreeInterval(text string) Interval {
+	if text == "" {
+		return Interval{}
+	}
+	return Interval{Kind: IntervalFree, Text: text}
+}
+
+var (
+	intervalSeconds = regexp.MustCompile(`(?i)^(\d+)\s*(?:s|sec|sek|sek\.|sekunden|seconds|"|'')?$`)
+	intervalMinutes = regexp.MustCompile(`(?i)^(\d+)\s*(?:min|min\.|minute|minuten|minutes|')$`)
+	intervalClock   = regexp.MustCompile(`^(\d+)\s*[:'’]\s*(\d{2})\s*(?:"|''|min)?$`)
+	intervalRest    = regexp.MustCompile(`(?i)^(?:pause|rest|p|r)\s*:?\s*|\s+(?:pause|rest)$`)
+	intervalSendOff = regexp.MustCompile(`(?i)^(?:@|restzeit bis|alle|every|abgang|on)\s*:?\s*`)
+)
+
+// ParseInterval parses the written form of a break. Plain numbers are seconds of
+// rest, "1:30" or "1'30" are minutes and seconds and an "@" marks a send-off
+// time. Common wordings like "Pause 20", "20 Sek." or "Restzeit bis 2:00" are
+// understood as well. "0" is a rest of zero seconds. Everything that is no time
+// becomes a free interval, as do times longer than MaxIntervalSeconds, so breaks
+// of stored plans always stay valid. Empty strings and "-" mean no break.
+func ParseInterval(s string) Interval {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return Interval{}
+	}
+	if loc := intervalSendOff.FindStringIndex(s); loc != nil {
+		if seconds, ok := parseIntervalSeconds(s[loc[1]:]); ok && seconds > 0 && seconds <= MaxIntervalSeconds {
+			return SendOff(seconds)
+		}
+		return FreeInterval(s)
+	}
+	if seconds, ok := parseIntervalSeconds(intervalRest.ReplaceAllString(s, "")); ok && seconds <= MaxIntervalSeconds {
+		return Rest(seconds)
+	}
+	return FreeInterval(s)
+}
+
+func parseIntervalSeconds(s string) (int, bool) {
+	if m := intervalSeconds.FindStringSubmatch(s); m != nil {
+		seconds, err := strconv.Atoi(m[1])
+		return seconds, err == nil
+	}
+	if m := intervalMinutes.FindStringSubmatch(s); m != nil {
+		minutes, err := strconv.Atoi(m[1])
+		return minutes * 60, err == nil
+	}
+	if m := intervalClock.FindStringSubmatch(s); m != nil {
+		minutes, err1 := strconv.Atoi(m[1])
+		seconds, err2 := strconv.Atoi(m[2])
+		if err1 != nil || err2 != nil || seconds >= 60 {
+			return 0, false
+		}
+		return minutes*60 + seconds, true
+	}
+	return 0, false
+}
+
+// String returns the canonical written form, which ParseInterval reads back.
+// Rest is written in seconds, matching the "Pause(s)" table header.
+func (i Interval) String() string {
+	switch i.Kind {
+	case IntervalRest:
+		return strconv.Itoa(i.Seconds)
+	case IntervalSendOff:
+		return "@" + FormatClock(i.Seconds)
+	case IntervalFree:
+		return i.Text
+	default:
+		return ""
+	}
+}
+
+// IsZero reports whether the row has no break.
+func (i Interval) IsZero() bool {
+	return i.Kind == IntervalNone
+}
+
+// Validate checks the bounds of the interval.
+func (i Interval) Validate() error {
+	switch i.Kind {
+	case IntervalNone:
+		return nil
+	case IntervalRest:
+		if i.Seconds < 0 || i.Seconds > MaxIntervalSeconds {
+			return fmt.Errorf("invalid rest of %d seconds (must be between 0 and %d)", i.Seconds, MaxIntervalSeconds)
+		}
+		return nil
+	case IntervalSendOff:
+		if i.Seconds <= 0 || i.Seconds > MaxIntervalSeconds {
+			return fmt.Errorf("invalid send-off time of %d seconds (must be between 1 and %d)", i.Seconds, MaxIntervalSeconds)
+		}
+		return nil
+	case IntervalFree:
+		if len(i.Text) > MaxRowBreakLen {
+			return fmt.Errorf("break exceeds maximum length of %d", MaxRowBreakLen)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown break kind %q", i.Kind)
+	}
+}
+
+// Normalize makes the interval valid. Times out of bounds are kept as free text
+// and texts that are too long are cut.
+func (i Interval) Normalize() Interval {
+	if i.Validate() == nil {
+		return i
+	}
+	text := i.String()
+	for len(text) > MaxRowBreakLen {
+		_, size := utf8.DecodeLastRuneInString(text)
+		text = text[:len(text)-size]
+	}
+	return FreeInterval(strings.TrimSpace(text))
+}
+
+// NormalizeBreaks makes the breaks of all rows and sub rows valid. It is used for
+// LLM output, which is not validated against the row limits.
+func (t *Table) NormalizeBreaks() {
+	for i := range *t {
+		row := &(*t)[i]
+		row.Break = row.Break.Normalize()
+		if len(row.SubRows) > 0 {
+			subRows := Table(row.SubRows)
+			subRows.NormalizeBreaks()
+		}
+	}
+}
+
+// MarshalJSON writes the interval as string.
+func (i Interval) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON reads strings and plain numbers, which LLMs occasionally return,
+// with ParseInterval.
+func (i *Interval) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*i = ParseInterval(s)
+		return nil
+	}
+	var seconds json.Number
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("break must be a string: %w", err)
+	}
+	if _, err := seconds.Int64(); err != nil {
+		return fmt.Errorf("break must be whole seconds: %w", err)
+	}
+	*i = ParseInterval(seconds.String())
+	return nil
+}
+
+// JSONSchema describes the interval as the string it is serialized to.
+func (Interval) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:        "string",
+		Description: `Break after each repetition: rest in seconds like "20", a send-off time like "@1:45" or free text. Empty for no break`,
+	}
+}
+
+// FormatClock formats seconds as m:ss.
+func FormatClock(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
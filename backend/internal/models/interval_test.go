@@ -0,0 +1,109 @@
+package models_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		input string
+		want  models.Interval
+	}{
+		{"", models.Interval{}},
+		{"-", models.Interval{}},
+		{"0", models.Rest(0)},
+		{"3600", models.Rest(models.MaxIntervalSeconds)},
+		{"7200", models.FreeInterval("7200")},
+		{"90 min", models.FreeInterval("90 min")},
+		{"@2:00:00", models.FreeInterval("@2:00:00")},
+		{"@90:00", models.FreeInterval("@90:00")},
+		{"20", models.Rest(20)},
+		{"20s", models.Rest(20)},
+		{"20 Sek.", models.Rest(20)},
+		{"P:20", models.Rest(20)},
+		{"Pause 1:00", models.Rest(60)},
+		{"30 Pause", models.Rest(30)},
+		{"1:30", models.Rest(90)},
+		{"1'30", models.Rest(90)},
+		{"2 min", models.Rest(120)},
+		{"@1:45", models.SendOff(105)},
+		{"@90", models.SendOff(90)},
+		{"Restzeit bis 2:00", models.SendOff(120)},
+		{"alle 1:50", models.SendOff(110)},
+		{"nach Bedarf", models.FreeInterval("nach Bedarf")},
+		{"1:75", models.FreeInterval("1:75")},
+		{"@", models.FreeInterval("@")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.ParseInterval(tt.input))
+		})
+	}
+}
+
+func TestIntervalStringRoundTrip(t *testing.T) {
+	for _, interval := range []models.Interval{{}, models.Rest(0), models.Rest(45), models.SendOff(105), models.FreeInterval("locker")} {
+		assert.Equal(t, interval, models.ParseInterval(interval.String()))
+	}
+	assert.Equal(t, "@1:05", models.SendOff(65).String())
+}
+
+func TestIntervalJSON(t *testing.T) {
+	var row models.Row
+	require.NoError(t, json.Unmarshal([]byte(`{"Break":"1:30"}`), &row))
+	assert.Equal(t, models.Rest(90), row.Break)
+
+	data, err := json.Marshal(row)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Break":"90"`)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"Break":30}`), &row))
+	assert.Equal(t, models.Rest(30), row.Break)
+
+	// Legacy plans keep long breaks as written, so they stay valid.
+	require.NoError(t, json.Unmarshal([]byte(`{"Break":"5400"}`), &row))
+	assert.Equal(t, models.FreeInterval("5400"), row.Break)
+	assert.NoError(t, row.Break.Validate())
+	require.NoError(t, json.Unmarshal([]byte(`{"Break":0}`), &row))
+	assert.Equal(t, models.Rest(0), row.Break)
+	data, err = json.Marshal(row)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Break":"0"`)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"Break":1.5}`), &row))
+	assert.Error(t, json.Unmarshal([]byte(`{"Break":true}`), &row))
+}
+
+func TestIntervalValidate(t *testing.T) {
+	assert.NoError(t, models.Interval{}.Validate())
+	assert.NoError(t, models.Rest(0).Validate())
+	assert.Error(t, models.SendOff(0).Validate())
+	assert.NoError(t, models.SendOff(models.MaxIntervalSeconds).Validate())
+	assert.Error(t, models.Rest(models.MaxIntervalSeconds+1).Validate())
+	assert.Error(t, models.Rest(-5).Validate())
+	assert.Error(t, models.FreeInterval(strings.Repeat("x", models.MaxRowBreakLen+1)).Validate())
+	assert.Error(t, models.Interval{Kind: "sometimes"}.Validate())
+}
+
+func TestTableNormalizeBreaks(t *testing.T) {
+	table := models.Table{
+		{Amount: 1, Distance: 100, Break: models.Rest(2 * models.MaxIntervalSeconds)},
+		{Amount: 2, Distance: 100, SubRows: []models.Row{
+			{Amount: 1, Distance: 50, Break: models.FreeInterval(strings.Repeat("ü", models.MaxRowBreakLen))},
+		}},
+		{Amount: 1, Distance: 100, Break: models.SendOff(90)},
+	}
+
+	table.NormalizeBreaks()
+
+	assert.Equal(t, models.FreeInterval("7200"), table[0].Break)
+	assert.Len(t, table[1].SubRows[0].Break.Text, models.MaxRowBreakLen)
+	assert.NoError(t, table[1].SubRows[0].Break.Validate())
+	assert.Equal(t, models.SendOff(90), table[2].Break)
+}
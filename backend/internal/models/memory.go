@@ -24,13 +24,40 @@ type Message struct {
 	NextMessageID     *string   `db:"next_message_id"`
 	PlanSnapshot      *Plan     `db:"plan_snapshot"`
 	CreatedAt         time.Time `db:"created_at"`
+	// Siblings are the ids of the messages with the same previous message,
+	// including the message itself, i.e. the branches at its position.
+	Siblings []string `db:"siblings"`
+}
+
+// ConversationSummary is the rolling summary of the messages of a conversation
+// that no longer fit into the chat history limit.
+type ConversationSummary struct {
+	PlanID  string `db:"plan_id"`
+	UserID  string `db:"user_id"`
+	Summary string `db:"summary"`
+	// LastMessageID is the id of the latest message covered by the summary.
+	LastMessageID string    `db:"last_message_id"`
+	UpdatedAt     time.Time `db:"updated_at"`
 }
 
 type Memory interface {
 	AddMessage(ctx context.Context, planID, userID string, role Role, content string, previousMessageID *string, planSnapshot *Plan) (*Message, error)
+	// GetConversation returns the active branch of the conversation.
 	GetConversation(ctx context.Context, planID, userID string) ([]Message, error)
+	// GetBranch returns the branch of the conversation through a message, without activating it.
+	GetBranch(ctx context.Context, planID, userID, messageID string) ([]Message, error)
+	// GetConversationTree returns the messages of all branches of the conversation.
+	GetConversationTree(ctx context.Context, planID, userID string) ([]Message, error)
+	// BranchMessage adds a sibling of a message with new content and activates its branch.
+	BranchMessage(ctx context.Context, messageID, userID, content string) (*Message, error)
+	// SelectBranch activates the branch through a message and makes its latest plan snapshot the current plan.
+	SelectBranch(ctx context.Context, messageID, userID string) ([]Message, error)
 	GetLastMessage(ctx context.Context, q pgxscan.Querier, planID, userID string) (*Message, error)
 	DeleteConversation(ctx context.Context, planID, userID string) error
 	DeleteMessage(ctx context.Context, messageID, userID string) error
 	DeleteMessagesAfter(ctx context.Context, messageID, userID string) error
+	// GetSummary returns the summary of the older messages of the conversation, nil if there is none.
+	GetSummary(ctx context.Context, planID, userID string) (*ConversationSummary, error)
+	// SaveSummary replaces the summary of the conversation.
+	SaveSummary(ctx context.Context, summary *ConversationSummary) error
 }
@@ -21,6 +21,10 @@ type UserProfile struct {
 	Exports            int       `db:"exports"`
 	CSS200mSeconds     *int      `db:"css_200m_seconds"`
 	CSS400mSeconds     *int      `db:"css_400m_seconds"`
+	// Preferences are loaded from the training preferences, nil if the user has none.
+	Preferences *TrainingPreferences `db:"-"`
+	// Calibration is learned from the difficulty ratings of swum plans, nil if it could not be loaded.
+	Calibration *DifficultyCalibration `db:"-"`
 }
 
 type Feedback struct {
@@ -35,6 +39,22 @@ type Feedback struct {
 	RemovedFromHistory bool      `db:"removed_from_history"`
 }
 
+// ShareInvitation is a plan shared by email, together with the content of the email.
+type ShareInvitation struct {
+	InvitationID   string    `db:"invitation_id"`
+	PlanID         string    `db:"plan_id"`
+	SharedBy       string    `db:"shared_by"`
+	SenderName     string    `db:"sender_name"`
+	RecipientEmail string    `db:"recipient_email"`
+	Language       Language  `db:"language"`
+	URLHash        string    `db:"url_hash"`
+	Title          string    `db:"title"`
+	Description    string    `db:"description"`
+	Table          Table     `db:"plan_table"`
+	CreatedAt      time.Time `db:"created_at"`
+	ExpiresAt      time.Time `db:"expires_at"`
+}
+
 type ChoiceResult struct {
 	Idx         int    `json:"index" example:"1"`
 	Description string `json:"description" example:"Selected plan based on your requirements"`
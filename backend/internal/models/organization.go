@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MemberRole is the role of a user in an organization.
+type MemberRole string
+
+const (
+	MemberRoleCoach   MemberRole = "coach"
+	MemberRoleSwimmer MemberRole = "swimmer"
+)
+
+// MemberStatus tells whether a user is a member of an organization or still has
+// to accept the invitation.
+type MemberStatus string
+
+const (
+	MemberStatusActive  MemberStatus = "active"
+	MemberStatusInvited MemberStatus = "invited"
+)
+
+// MaxOrganizationNameLength is the maximum length of organization and squad names.
+const MaxOrganizationNameLength = 200
+
+// Organization is a club with coaches and swimmers.
+// @Description Club or team the user is a member of
+type Organization struct {
+	OrgID         string     `json:"org_id" db:"org_id" example:"2f1d4c9e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"`
+	Name          string     `json:"name" db:"name" example:"SV Blau-Weiß"`
+	Role          MemberRole `json:"role" db:"role" example:"coach"`                     // Role is the role of the requesting user
+	ShareFeedback bool       `json:"share_feedback" db:"share_feedback" example:"false"` // ShareFeedback tells whether the requesting user shares ratings with the coaches
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// OrganizationMember is a member of an organization or a user invited to it.
+// @Description Member of a club with the username of the profile
+type OrganizationMember struct {
+	UserID    string       `json:"user_id" db:"user_id"`
+	Username  string       `json:"username" db:"username" example:"anna_k"`
+	Role      MemberRole   `json:"role" db:"role" example:"swimmer"`
+	Status    MemberStatus `json:"status" db:"status" example:"active" enums:"active,invited"` // Status is invited until the user accepts the invitation
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// OrganizationInvitation is an invitation of the user to an organization.
+// @Description Invitation to a club, which the invited user has to accept to become a member
+type OrganizationInvitation struct {
+	InvitationID string     `json:"invitation_id" db:"invitation_id"`
+	OrgID        string     `json:"org_id" db:"org_id"`
+	OrgName      string     `json:"org_name" db:"org_name" example:"SV Blau-Weiß"`
+	Role         MemberRole `json:"role" db:"role" example:"swimmer"`
+	InvitedBy    string     `json:"invited_by" db:"invited_by" example:"trainer"` // InvitedBy is the username of the inviting coach
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Squad is a training group of an organization.
+// @Description Training group of a club
+type Squad struct {
+	SquadID   string    `json:"squad_id" db:"squad_id"`
+	OrgID     string    `json:"org_id" db:"org_id"`
+	Name      string    `json:"name" db:"name" example:"Leistungsgruppe 1"`
+	Members   []string  `json:"members" db:"members"` // Members are the user ids of the swimmers in the squad
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// OrganizationPlan is a plan assigned to a squad of the user or shared with one
+// of the organizations of the user.
+// @Description Training plan assigned to a squad or shared within a club
+type OrganizationPlan struct {
+	OrgID       string    `json:"org_id" db:"org_id"`
+	SquadID     *string   `json:"squad_id,omitempty" db:"squad_id"` // SquadID is set if the plan was assigned to a squad
+	PlanID      string    `json:"plan_id" db:"plan_id"`
+	Title       string    `json:"title" db:"title"`
+	Description string    `json:"description" db:"description"`
+	Table       Table     `json:"table" db:"plan_table"`
+	SharedBy    *string   `json:"shared_by,omitempty" db:"shared_by"` // SharedBy is the username of the coach or member who shared the plan
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// SwimmerPlanStatus tells whether a swimmer of an assigned squad swam the plan.
+// The ratings and the time of the feedback are only set if the swimmer shares
+// feedback with the coaches.
+// @Description Feedback of a swimmer on an assigned plan
+type SwimmerPlanStatus struct {
+	UserID           string     `json:"user_id" db:"user_id"`
+	Username         string     `json:"username" db:"username" example:"anna_k"`
+	SquadID          string     `json:"squad_id" db:"squad_id"`
+	WasSwam          bool       `json:"was_swam" db:"was_swam" example:"true"`
+	Rating           *int       `json:"rating,omitempty" db:"rating" example:"4"`
+	DifficultyRating *int       `json:"difficulty_rating,omitempty" db:"difficulty_rating" example:"7"`
+	FeedbackAt       *time.Time `json:"feedback_at,omitempty" db:"feedback_at"`
+}
+
+// CreateOrganizationRequest is the request to create an organization. The creator becomes its first coach.
+// @Description Request payload for creating a club
+type CreateOrganizationRequest struct {
+	Name string `json:"name" example:"SV Blau-Weiß" binding:"required"`
+}
+
+func (r *CreateOrganizationRequest) Validate() error {
+	return validateOrganizationName(r.Name)
+}
+
+// AddMemberRequest is the request to invite a user to an organization or to
+// change the role of a member.
+// @Description Request payload for inviting a user by username to a club
+type AddMemberRequest struct {
+	Username string     `json:"username" example:"anna_k" binding:"required"`
+	Role     MemberRole `json:"role" example:"swimmer" enums:"coach,swimmer" binding:"required"`
+}
+
+func (r *AddMemberRequest) Validate() error {
+	if strings.TrimSpace(r.Username) == "" {
+		return fmt.Errorf("username is required")
+	}
+	return r.Role.Validate()
+}
+
+// CreateSquadRequest is the request to create a squad.
+// @Description Request payload for creating a training group with its swimmers
+type CreateSquadRequest struct {
+	Name    string   `json:"name" example:"Leistungsgruppe 1" binding:"required"`
+	Members []string `json:"members,omitempty"` // Members are the user ids of the swimmers, they must be members of the club
+}
+
+func (r *CreateSquadRequest) Validate() error {
+	return validateOrganizationName(r.Name)
+}
+
+// FeedbackSharingRequest is the request of a member to share ratings with the coaches of an organization.
+// @Description Request payload for sharing the ratings of assigned plans with the coaches of a club
+type FeedbackSharingRequest struct {
+	ShareFeedback bool `json:"share_feedback" example:"true"`
+}
+
+// AssignPlanRequest is the request to assign a plan of the coach to a squad.
+// @Description Request payload for assigning a training plan to a squad
+type AssignPlanRequest struct {
+	PlanID  string `json:"plan_id" example:"plan_123" binding:"required"`
+	SquadID string `json:"squad_id" binding:"required"`
+}
+
+func (r *AssignPlanRequest) Validate() error {
+	if r.PlanID == "" || r.SquadID == "" {
+		return fmt.Errorf("plan_id and squad_id are required")
+	}
+	return nil
+}
+
+// ShareWithOrganizationRequest is the request to share a plan with all members of an organization.
+// @Description Request payload for sharing a training plan within a club
+type ShareWithOrganizationRequest struct {
+	PlanID string `json:"plan_id" example:"plan_123" binding:"required"`
+}
+
+func (r *ShareWithOrganizationRequest) Validate() error {
+	if r.PlanID == "" {
+		return fmt.Errorf("plan_id is required")
+	}
+	return nil
+}
+
+func (r MemberRole) Validate() error {
+	switch r {
+	case MemberRoleCoach, MemberRoleSwimmer:
+		return nil
+	default:
+		return fmt.Errorf("role must be %q or %q", MemberRoleCoach, MemberRoleSwimmer)
+	}
+}
+
+func validateOrganizationName(name string) error {
+	if strings.TrimSpace(name) == "" || len(name) > MaxOrganizationNameLength {
+		return fmt.Errorf("name must be between 1 and %d characters", MaxOrganizationNameLength)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateOrganizationRequestValidate(t *testing.T) {
+	assert.NoError(t, (&models.CreateOrganizationRequest{Name: "SV Blau-Weiß"}).Validate())
+	assert.Error(t, (&models.CreateOrganizationRequest{Name: " "}).Validate())
+	assert.Error(t, (&models.CreateOrganizationRequest{Name: strings.Repeat("a", models.MaxOrganizationNameLength+1)}).Validate())
+}
+
+func TestAddMemberRequestValidate(t *testing.T) {
+	assert.NoError(t, (&models.AddMemberRequest{Username: "anna_k", Role: models.MemberRoleSwimmer}).Validate())
+	assert.NoError(t, (&models.AddMemberRequest{Username: "trainer", Role: models.MemberRoleCoach}).Validate())
+	assert.Error(t, (&models.AddMemberRequest{Username: "", Role: models.MemberRoleSwimmer}).Validate())
+	assert.Error(t, (&models.AddMemberRequest{Username: "anna_k", Role: "admin"}).Validate())
+}
+
+func TestAssignPlanRequestValidate(t *testing.T) {
+	assert.NoError(t, (&models.AssignPlanRequest{PlanID: "plan", SquadID: "squad"}).Validate())
+	assert.Error(t, (&models.AssignPlanRequest{PlanID: "plan"}).Validate())
+	assert.Error(t, (&models.ShareWithOrganizationRequest{}).Validate())
+}
@@ -2,7 +2,10 @@ package models
 
 import (
 	"fmt"
+	"slices"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // UploadPlanRequest represents the request body for donating a training plan
@@ -28,28 +31,30 @@ func (r *UploadPlanRequest) Validate() error {
 // QueryRequest represents the request body for querying the RAG system
 // @Description Request payload for querying swim training plans from the RAG system
 type QueryRequest struct {
-	Content     string         `json:"content" example:"I need a training plan for improving my freestyle technique" binding:"required"` // Content describes what kind of training plan is needed
-	Filter      map[string]any `json:"filter,omitempty"`                                                                                 // Filter allows filtering plans by metadata like difficulty or stroke type
-	Method      string         `json:"method" example:"generate" validate:"oneof=choose generate" binding:"required"`                    // Method can be either 'choose' (select existing plan) or 'generate' (create new plan)
-	Language    Language       `json:"language,omitempty" example:"en"`                                                                  // Language specifies the language for the response
-	PoolLength  any            `json:"pool_length,omitempty" validate:"oneof=25 50 Freiwasser"`                                          // PoolLength specifies the pool length for the training plan
-	Preferences *bool          `json:"preferences,omitempty"`                                                                            // Preferences indicates if the user profile should be used for generation
+	Content     string           `json:"content" example:"I need a training plan for improving my freestyle technique" binding:"required"` // Content describes what kind of training plan is needed
+	Filter      map[string]any   `json:"filter,omitempty"`                                                                                 // Filter allows filtering plans by metadata like difficulty or stroke type
+	Method      string           `json:"method" example:"generate" validate:"oneof=choose generate" binding:"required"`                    // Method can be either 'choose' (select existing plan) or 'generate' (create new plan)
+	Language    Language         `json:"language,omitempty" example:"en"`                                                                  // Language specifies the language for the response
+	PoolLength  any              `json:"pool_length,omitempty" validate:"oneof=25 50 Freiwasser"`                                          // PoolLength specifies the pool length for the training plan
+	Preferences *bool            `json:"preferences,omitempty"`                                                                            // Preferences indicates if the user profile should be used for generation
+	Constraints *PlanConstraints `json:"constraints,omitempty"`                                                                            // Constraints are requirements the generated plan has to meet
 }
 
 func (r *QueryRequest) Validate() error {
 	if len(r.Content) > MaxQueryContentLength {
 		return fmt.Errorf("query content exceeds maximum length of %d", MaxQueryContentLength)
 	}
-	return nil
+	return r.Constraints.Validate()
 }
 
 // RAGResponse represents the response after a query to the RAG system
 // @Description Response containing a generated or selected swim training plan
 type RAGResponse struct {
-	PlanID      string `json:"plan_id,omitempty" example:"plan_123"` // PlanID is the identifier of the training plan
-	Title       string `json:"title" example:"Advanced Freestyle Training"`
-	Description string `json:"description" example:"A comprehensive training plan for improving freestyle technique"`
-	Table       Table  `json:"table"`
+	PlanID      string            `json:"plan_id,omitempty" example:"plan_123"` // PlanID is the identifier of the training plan
+	Title       string            `json:"title" example:"Advanced Freestyle Training"`
+	Description string            `json:"description" example:"A comprehensive training plan for improving freestyle technique"`
+	Table       Table             `json:"table"`
+	Duration    *DurationEstimate `json:"duration,omitempty"` // Duration is the estimated time needed to swim the plan
 }
 
 func (r *RAGResponse) Plan() *Plan {
@@ -72,17 +77,48 @@ func (r *RAGResponse) Map() map[string]any {
 	}
 }
 
+// FileToPlanResponse represents the response of a file to plan conversion
+// @Description Converted plan with the fragments of a text upload that could not be parsed
+type FileToPlanResponse struct {
+	RAGResponse
+	Unparsed []ShorthandFragment `json:"unparsed,omitempty"` // Unparsed lists parts of a text upload that were not understood
+}
+
+// PDFLayout is a named print layout of plan PDFs.
+type PDFLayout string
+
+const (
+	PDFLayoutStandard PDFLayout = "standard" // A4 with the horizontal and large font options
+	PDFLayoutCompact  PDFLayout = "compact"  // A5 card with small print
+	PDFLayoutPoolside PDFLayout = "poolside" // High contrast sheet with large bold print
+	PDFLayoutCoach    PDFLayout = "coach"    // Landscape sheet with empty split time columns
+	PDFLayoutTwoLane  PDFLayout = "two_lane" // Two copies side by side, one for each lane
+)
+
+// Validate accepts the known layouts, an empty layout is the standard layout.
+func (l PDFLayout) Validate() error {
+	switch l {
+	case "", PDFLayoutStandard, PDFLayoutCompact, PDFLayoutPoolside, PDFLayoutCoach, PDFLayoutTwoLane:
+		return nil
+	default:
+		return fmt.Errorf("layout must be one of %q, %q, %q, %q or %q",
+			PDFLayoutStandard, PDFLayoutCompact, PDFLayoutPoolside, PDFLayoutCoach, PDFLayoutTwoLane)
+	}
+}
+
 // PlanToPDFRequest represents the request for PDF export
 // @Description Request payload for exporting a training plan to PDF format
 type PlanToPDFRequest struct {
-	PlanID          string   `json:"plan_id,omitempty" example:"plan_123"` // PlanID identifies the training plan to be exported
-	Title           string   `json:"title" example:"Advanced Freestyle Training" binding:"required"`
-	Description     string   `json:"description" example:"A comprehensive training plan for improving freestyle technique" binding:"required"`
-	Table           Table    `json:"table" binding:"required"`
-	Horizontal      bool     `json:"horizontal" example:"false"`                                 // Horizontal indicates if the PDF should be in landscape orientation
-	LargeFont       bool     `json:"large_font" example:"true"`                                  // LargeFont indicates if the PDF should use a larger font size
-	Language        Language `json:"language,omitempty" example:"en"`                            // Language specifies the language for the PDF content
-	FrontendBaseURL string   `json:"frontend_base_url,omitempty" example:"https://swim-gen.app"` // FrontendBaseURL is the base URL for drill links in the PDF
+	PlanID          string    `json:"plan_id,omitempty" example:"plan_123"` // PlanID identifies the training plan to be exported
+	Title           string    `json:"title" example:"Advanced Freestyle Training" binding:"required"`
+	Description     string    `json:"description" example:"A comprehensive training plan for improving freestyle technique" binding:"required"`
+	Table           Table     `json:"table" binding:"required"`
+	Horizontal      bool      `json:"horizontal" example:"false"`                                                           // Horizontal indicates if the PDF should be in landscape orientation
+	LargeFont       bool      `json:"large_font" example:"true"`                                                            // LargeFont indicates if the PDF should use a larger font size
+	Language        Language  `json:"language,omitempty" example:"en"`                                                      // Language specifies the language for the PDF content
+	FrontendBaseURL string    `json:"frontend_base_url,omitempty" example:"https://swim-gen.app"`                           // FrontendBaseURL is the base URL for drill links in the PDF
+	DrillAppendix   bool      `json:"drill_appendix" example:"true"`                                                        // DrillAppendix adds a page describing the linked drills with QR codes to their pages
+	Layout          PDFLayout `json:"layout,omitempty" example:"poolside" enums:"standard,compact,poolside,coach,two_lane"` // Layout selects a print layout, Horizontal and LargeFont only apply to the standard layout
 }
 
 func (r *PlanToPDFRequest) Validate() error {
@@ -92,6 +128,9 @@ func (r *PlanToPDFRequest) Validate() error {
 	if len(r.Description) > MaxPlanDescriptionLength {
 		return fmt.Errorf("description exceeds maximum length of %d", MaxPlanDescriptionLength)
 	}
+	if err := r.Layout.Validate(); err != nil {
+		return err
+	}
 	return r.Table.Validate()
 }
 
@@ -101,6 +140,83 @@ type PlanToPDFResponse struct {
 	URI string `json:"uri" example:"https://storage.googleapis.com/bucket/plans/plan_123.pdf"`
 }
 
+// MaxBookletPlans is the maximum number of plans exported into one booklet.
+const MaxBookletPlans = 50
+
+// BookletToPDFRequest represents the request for exporting several plans into one PDF
+// @Description Request payload for exporting plans of the history or the sessions of a training block into one PDF booklet
+type BookletToPDFRequest struct {
+	Title           string   `json:"title,omitempty" example:"Sommertraining"`                          // Title is printed on the cover, it defaults to the block title
+	PlanIDs         []string `json:"plan_ids,omitempty" example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"` // PlanIDs are plans of the user history in the order of the booklet
+	BlockID         string   `json:"block_id,omitempty" example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"` // BlockID exports all sessions of the training block instead of PlanIDs
+	Horizontal      bool     `json:"horizontal" example:"false"`                                        // Horizontal indicates if the PDF should be in landscape orientation
+	LargeFont       bool     `json:"large_font" example:"true"`                                         // LargeFont indicates if the PDF should use a larger font size
+	Language        Language `json:"language,omitempty" example:"en"`                                   // Language specifies the language for the PDF content
+	FrontendBaseURL string   `json:"frontend_base_url,omitempty" example:"https://swim-gen.app"`        // FrontendBaseURL is the base URL for drill links in the PDF
+}
+
+func (r *BookletToPDFRequest) Validate() error {
+	if len(r.Title) > MaxPlanTitleLength {
+		return fmt.Errorf("title exceeds maximum length of %d", MaxPlanTitleLength)
+	}
+	if (len(r.PlanIDs) == 0) == (r.BlockID == "") {
+		return fmt.Errorf("either plan_ids or block_id is required")
+	}
+	if len(r.PlanIDs) > MaxBookletPlans {
+		return fmt.Errorf("booklet exceeds maximum of %d plans", MaxBookletPlans)
+	}
+	for i, planID := range r.PlanIDs {
+		if _, err := uuid.Parse(planID); err != nil {
+			return fmt.Errorf("plan_ids[%d] is not a valid plan id", i)
+		}
+		if slices.Contains(r.PlanIDs[:i], planID) {
+			return fmt.Errorf("plan_ids[%d] is a duplicate", i)
+		}
+	}
+	if r.BlockID != "" {
+		if _, err := uuid.Parse(r.BlockID); err != nil {
+			return fmt.Errorf("block_id is not a valid block id")
+		}
+	}
+	return nil
+}
+
+// PlanToFITRequest represents the request for FIT workout export
+// @Description Request payload for exporting a training plan as structured FIT workout for sport watches
+type PlanToFITRequest struct {
+	PlanID     string `json:"plan_id,omitempty" example:"plan_123"` // PlanID identifies the training plan to be exported
+	Title      string `json:"title" example:"Advanced Freestyle Training" binding:"required"`
+	Table      Table  `json:"table" binding:"required"`
+	PoolLength any    `json:"pool_length,omitempty" validate:"oneof=25 50 Freiwasser"` // PoolLength is stored in the workout, defaults to 25
+}
+
+func (r *PlanToFITRequest) Validate() error {
+	if len(r.Title) > MaxPlanTitleLength {
+		return fmt.Errorf("title exceeds maximum length of %d", MaxPlanTitleLength)
+	}
+	return r.Table.Validate()
+}
+
+// PlanExportRequest represents the request for exporting a plan to an interchange format
+// @Description Request payload for exporting a training plan as CSV, Markdown or portable JSON document
+type PlanExportRequest struct {
+	PlanID      string   `json:"plan_id,omitempty" example:"plan_123"` // PlanID identifies the training plan to be exported
+	Title       string   `json:"title" example:"Advanced Freestyle Training" binding:"required"`
+	Description string   `json:"description" example:"A comprehensive training plan for improving freestyle technique"`
+	Table       Table    `json:"table" binding:"required"`
+	Language    Language `json:"language,omitempty" example:"en"` // Language specifies the language of the table headers
+}
+
+func (r *PlanExportRequest) Validate() error {
+	if len(r.Title) > MaxPlanTitleLength {
+		return fmt.Errorf("title exceeds maximum length of %d", MaxPlanTitleLength)
+	}
+	if len(r.Description) > MaxPlanDescriptionLength {
+		return fmt.Errorf("description exceeds maximum length of %d", MaxPlanDescriptionLength)
+	}
+	return r.Table.Validate()
+}
+
 // GeneratePromptRequest represents the request for prompt generation
 // @Description Request payload for generating a prompt for swim training plan creation
 type GeneratePromptRequest struct {
@@ -150,40 +266,109 @@ type UpsertPlanResponse struct {
 // SharePlanRequest represents the request payload for sharing a training plan
 // @Description Request payload for sharing a swim training plan
 type SharePlanRequest struct {
-	PlanID string        `json:"plan_id" example:"plan_123" binding:"required"` // PlanID identifies the training plan to be shared
-	Method SharingMethod `json:"method" example:"link" binding:"required"`      // Method specifies the sharing method, e.g., 'link' or 'email'
+	PlanID        string        `json:"plan_id" example:"plan_123" binding:"required"` // PlanID identifies the training plan to be shared
+	Method        SharingMethod `json:"method" example:"link" binding:"required"`      // Method specifies the sharing method, e.g., 'link' or 'email'
+	Email         string        `json:"email,omitempty" example:"anna@example.com"`    // Email is the recipient address, required for the 'email' method
+	Language      Language      `json:"language,omitempty" example:"de"`               // Language of the email, English if not German
+	ExpiresInDays int           `json:"expires_in_days,omitempty" example:"30"`        // ExpiresInDays limits how long a link share is valid, 0 keeps the current expiry
 }
 
+// MaxShareExpiryDays is the longest expiry of a link share.
+const MaxShareExpiryDays = 365
+
 // SharePlanResponse represents the response after sharing a training plan
 // @Description Response containing the sharing details of the swim training plan
 type SharePlanResponse struct {
 	URLHash string `json:"url_hash" example:"abc123"` // URLHash is the hash to access the shared training plan
 }
 
+// SharedPlan represents a plan resolved by the url hash of its share
+// @Description Training plan shared via link, with the display name of its owner
+type SharedPlan struct {
+	URLHash     string     `json:"url_hash" db:"url_hash" example:"abc123"`
+	PlanID      string     `json:"plan_id" db:"plan_id" example:"plan_123"`
+	Title       string     `json:"title" db:"title"`
+	Description string     `json:"description" db:"description"`
+	Table       Table      `json:"table" db:"plan_table"`
+	OwnerID     string     `json:"-" db:"owner_id"`
+	Owner       string     `json:"owner" db:"owner" example:"anna_k"` // Owner is the username of the sharing user
+	SharedAt    time.Time  `json:"shared_at" db:"shared_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"` // ExpiresAt is set if the share expires
+	RevokedAt   *time.Time `json:"-" db:"revoked_at"`
+	ViewCount   int        `json:"view_count" db:"view_count" example:"12"`
+	PDFURL      string     `json:"pdf_url,omitempty" db:"-"` // PDFURL is a short-lived link to the plan as PDF, if requested
+}
+
+// ImportSharedPlanResponse represents the response after importing a shared plan
+// @Description Response containing the copy of the shared plan in the history of the user
+type ImportSharedPlanResponse struct {
+	PlanID string `json:"plan_id" example:"plan_123"` // PlanID identifies the imported copy of the plan
+}
+
+// AcceptInvitationResponse represents the response after accepting a plan shared by email
+// @Description Response containing the plan added to the shared history of the recipient
+type AcceptInvitationResponse struct {
+	PlanID  string `json:"plan_id" example:"plan_123"` // PlanID identifies the shared training plan
+	URLHash string `json:"url_hash" example:"abc123"`  // URLHash is the hash to access the shared training plan
+}
+
 // ChatRequest represents the request payload for chat-based plan refinement
 // @Description Request payload for conversational training plan creation and refinement
 type ChatRequest struct {
-	PlanID     string   `json:"plan_id,omitempty" example:"plan_123"`                          // PlanID identifies the conversation/plan (optional for new conversations)
-	Message    string   `json:"message" example:"Make it more challenging" binding:"required"` // Message is the user's input to the chat
-	Language   Language `json:"language,omitempty" example:"en"`                               // Language specifies the language for the response
-	PoolLength any      `json:"pool_length,omitempty" validate:"oneof=25 50 Freiwasser"`       // PoolLength specifies the pool length for the training plan
+	PlanID      string           `json:"plan_id,omitempty" example:"plan_123"`                          // PlanID identifies the conversation/plan (optional for new conversations)
+	Message     string           `json:"message" example:"Make it more challenging" binding:"required"` // Message is the user's input to the chat
+	Language    Language         `json:"language,omitempty" example:"en"`                               // Language specifies the language for the response
+	PoolLength  any              `json:"pool_length,omitempty" validate:"oneof=25 50 Freiwasser"`       // PoolLength specifies the pool length for the training plan
+	Constraints *PlanConstraints `json:"constraints,omitempty"`                                         // Constraints are requirements the refined plan has to meet
+	// EditMessageID identifies a user message that Message replaces. The edit starts a new
+	// branch of the conversation, the original branch is kept and can be selected again.
+	EditMessageID string `json:"edit_message_id,omitempty" example:"6f1c..."`
 }
 
 func (r *ChatRequest) Validate() error {
 	if len(r.Message) > MaxChatMessageLength {
 		return fmt.Errorf("chat message exceeds maximum length of %d", MaxChatMessageLength)
 	}
-	return nil
+	return r.Constraints.Validate()
 }
 
 // ChatResponsePayload represents the response from a chat interaction
 // @Description Response containing the updated plan and conversational response
 type ChatResponsePayload struct {
-	PlanID      string `json:"plan_id" example:"plan_123"`                                                                      // PlanID identifies the conversation/plan
-	Title       string `json:"title,omitempty" example:"Advanced Freestyle Training"`                                           // Title of the training plan
-	Description string `json:"description,omitempty" example:"A comprehensive training plan for improving freestyle technique"` // Description of the training plan
-	Table       Table  `json:"table,omitempty"`                                                                                 // Table containing the training plan details
-	Response    string `json:"response" example:"I've made the plan more challenging by adding butterfly sets"`                 // Response is the conversational AI response explaining changes
+	PlanID      string            `json:"plan_id" example:"plan_123"`                                                                      // PlanID identifies the conversation/plan
+	Title       string            `json:"title,omitempty" example:"Advanced Freestyle Training"`                                           // Title of the training plan
+	Description string            `json:"description,omitempty" example:"A comprehensive training plan for improving freestyle technique"` // Description of the training plan
+	Table       Table             `json:"table,omitempty"`                                                                                 // Table containing the training plan details
+	Response    string            `json:"response" example:"I've made the plan more challenging by adding butterfly sets"`                 // Response is the conversational AI response explaining changes
+	Duration    *DurationEstimate `json:"duration,omitempty"`                                                                              // Duration is the estimated time needed to swim the updated plan
+}
+
+// ChatStreamToken is sent as "token" event for every streamed part of the response
+// @Description Partial conversational response text streamed via server-sent events
+type ChatStreamToken struct {
+	Text string `json:"text" example:"I've made the plan "`
+}
+
+// ChatStreamReplace is sent as "replace" event if the plan missed the constraints
+// and was generated again. Its text replaces all text streamed before.
+// @Description Complete conversational response replacing the streamed text
+type ChatStreamReplace struct {
+	Text string `json:"text" example:"I've made the plan more challenging by adding butterfly sets"`
+}
+
+// ChatStreamDone is sent as final "done" event of a streamed chat interaction
+// @Description Final event of a streamed chat containing the validated plan and persisted message ids
+type ChatStreamDone struct {
+	ChatResponsePayload
+	UserMessageID string `json:"user_message_id" example:"6f1c..."` // UserMessageID is the id of the stored user message
+	AIMessageID   string `json:"ai_message_id" example:"9a2b..."`   // AIMessageID is the id of the stored AI message
+}
+
+// ChatStreamError is sent as "error" event if a streamed chat interaction fails
+// @Description Error event of a streamed chat
+type ChatStreamError struct {
+	Status int    `json:"status" example:"500"`
+	Error  string `json:"error" example:"Internal server error"`
 }
 
 // PlanSnapshot represents a snapshot of a training plan
@@ -197,6 +382,21 @@ type MessagePayload struct {
 	NextMessageID     *string      `json:"next_message_id" db:"next_message_id"`
 	PlanSnapshot      *RAGResponse `json:"plan_snapshot" db:"plan_snapshot"`
 	CreatedAt         time.Time    `json:"created_at" db:"created_at"` // Table containing the training plan details
+	Siblings          []string     `json:"siblings,omitempty"`         // Siblings are the ids of the branches at the position of the message, including the message
+}
+
+// ConversationNode is a message with the messages following it on all branches
+// @Description Message of the conversation tree with its following messages
+type ConversationNode struct {
+	MessagePayload
+	Active   bool               `json:"active"`   // Active indicates if the message is on the active branch
+	Children []ConversationNode `json:"children"` // Children are the first messages of the branches following the message
+}
+
+// SelectBranchRequest represents the request payload for selecting a branch of the conversation
+// @Description Request payload for making the branch through a message the active branch
+type SelectBranchRequest struct {
+	MessageID string `json:"message_id" example:"msg_123" binding:"required"` // MessageID identifies a message of the branch to select
 }
 
 // GetConversationResponse represents the response from a conversation history request
@@ -205,6 +405,32 @@ type GetConversationResponse struct {
 	Conversation []MessagePayload `json:"conversation"` // Conversation history
 }
 
+// PlanVersion is a snapshot of a plan stored with an AI message of its conversation
+// @Description Version of a training plan from its conversation history
+type PlanVersion struct {
+	MessageID string       `json:"message_id" example:"9a2b..."` // MessageID identifies the AI message holding the snapshot
+	Version   int          `json:"version" example:"2"`          // Version numbers the snapshots of the conversation starting at 1
+	Title     string       `json:"title" example:"Ausdauer Kraul"`
+	Volume    int          `json:"volume" example:"3200"` // Volume is the total distance of the version in meters
+	CreatedAt time.Time    `json:"created_at"`
+	Plan      *RAGResponse `json:"plan,omitempty"` // Plan is only returned for a single version
+}
+
+// PlanDiffResponse represents the difference between two versions of a plan
+// @Description Row level difference between two versions of a training plan
+type PlanDiffResponse struct {
+	From PlanVersion `json:"from"`
+	To   PlanVersion `json:"to"`
+	Diff TableDiff   `json:"diff"`
+}
+
+// RestorePlanVersionResponse represents the response after restoring a plan version
+// @Description Response containing the restored plan and the new version recording the restore
+type RestorePlanVersionResponse struct {
+	PlanID  string      `json:"plan_id" example:"plan_123"`
+	Version PlanVersion `json:"version"`
+}
+
 // DeleteMessageRequest represents the request payload for deleting a single message
 // @Description Request payload for deleting a single message from conversation history
 type DeleteMessageRequest struct {
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Phase is a period of a training block with its own training focus.
+type Phase string
+
+const (
+	PhaseBase  Phase = "base"
+	PhaseBuild Phase = "build"
+	PhaseTaper Phase = "taper"
+	PhaseRace  Phase = "race"
+)
+
+const (
+	MinBlockWeeks      = 4
+	MaxBlockWeeks      = 16
+	MaxSessionsPerWeek = 7
+	// MaxBlockSessions limits the number of plans generated for one block.
+	MaxBlockSessions = 48
+)
+
+// Relative session volumes of the phases. Base and build rise from the first to
+// the second value, the peak volume is reached in the last build week.
+var (
+	baseVolume     = [2]float64{0.7, 0.85}
+	buildVolume    = [2]float64{0.9, 1.0}
+	taperVolume    = [2]float64{0.75, 0.6}
+	raceVolume     = 0.5
+	recoveryVolume = 0.8
+)
+
+// BlockWeek is a week of a training block.
+// @Description Phase and session volume of a week in a training block
+type BlockWeek struct {
+	Week     int   `json:"week" example:"3"`         // Week is the number of the week starting at 1
+	Phase    Phase `json:"phase" example:"base"`     // Phase is the training phase of the week
+	Volume   int   `json:"volume" example:"3400"`    // Volume is the target volume of each session in meters
+	Recovery bool  `json:"recovery" example:"false"` // Recovery marks an unloading week with reduced volume
+}
+
+// Periodize distributes the phases over the weeks of a block ending with the race
+// week. Taper takes one week in short blocks and two otherwise, the remaining
+// weeks are split into 60% base and 40% build. The volume rises to peakVolume in
+// the last build week, every fourth loading week is a recovery week. Volumes are
+// rounded to 100 m.
+func Periodize(weeks, peakVolume int) []BlockWeek {
+	if weeks <= 0 {
+		return nil
+	}
+	taper := 2
+	if weeks < 8 {
+		taper = 1
+	}
+	loading := max(weeks-taper-1, 0)
+	build := int(math.Round(float64(loading) * 0.4))
+	base := loading - build
+
+	plan := make([]BlockWeek, 0, weeks)
+	for i := range base {
+		plan = append(plan, BlockWeek{Phase: PhaseBase, Volume: blockVolume(peakVolume, lerp(baseVolume, i, base))})
+	}
+	for i := range build {
+		plan = append(plan, BlockWeek{Phase: PhaseBuild, Volume: blockVolume(peakVolume, lerp(buildVolume, i, build))})
+	}
+	for i := range min(taper, weeks-len(plan)-1) {
+		plan = append(plan, BlockWeek{Phase: PhaseTaper, Volume: blockVolume(peakVolume, lerp(taperVolume, i, taper))})
+	}
+	plan = append(plan, BlockWeek{Phase: PhaseRace, Volume: blockVolume(peakVolume, raceVolume)})
+
+	for i := range plan {
+		plan[i].Week = i + 1
+		// The last loading week before the taper keeps the peak volume
+		if i < loading-1 && (i+1)%4 == 0 {
+			plan[i].Recovery = true
+			plan[i].Volume = blockVolume(plan[i].Volume, recoveryVolume)
+		}
+	}
+	return plan
+}
+
+// lerp returns the value at step i of n between the bounds. A single step gets
+// the mean of both bounds.
+func lerp(bounds [2]float64, i, n int) float64 {
+	if n <= 1 {
+		return (bounds[0] + bounds[1]) / 2
+	}
+	return bounds[0] + (bounds[1]-bounds[0])*float64(i)/float64(n-1)
+}
+
+func blockVolume(volume int, factor float64) int {
+	return max(int(math.Round(float64(volume)*factor/100))*100, 100)
+}
+
+// TrainingBlockRequest is the request to generate a multi-week training block.
+// @Description Request payload for generating a periodized training block with one plan per session
+type TrainingBlockRequest struct {
+	Title           string           `json:"title" example:"Frühjahrs-Meisterschaften" binding:"required"`                                 // Title is the name of the block
+	Goal            string           `json:"goal" example:"Bestzeit über 400m Freistil bei den Bezirksmeisterschaften" binding:"required"` // Goal describes the competition or aim of the block
+	Weeks           int              `json:"weeks" example:"10" binding:"required"`                                                        // Weeks is the length of the block including the race week
+	SessionsPerWeek int              `json:"sessions_per_week" example:"3" binding:"required"`                                             // SessionsPerWeek is the number of plans per week
+	PeakVolume      int              `json:"peak_volume" example:"4000" binding:"required"`                                                // PeakVolume is the session volume in meters at the end of the build phase
+	Language        Language         `json:"language,omitempty" example:"de"`                                                              // Language specifies the language of the plans
+	PoolLength      any              `json:"pool_length,omitempty" validate:"oneof=25 50 Freiwasser"`                                      // PoolLength specifies the pool length for the plans
+	Preferences     *bool            `json:"preferences,omitempty"`                                                                        // Preferences indicates if the user profile should be used for generation
+	Constraints     *PlanConstraints `json:"constraints,omitempty"`                                                                        // Constraints apply to every session, the target volume is set by the periodization
+}
+
+func (r *TrainingBlockRequest) Validate() error {
+	if strings.TrimSpace(r.Title) == "" || len(r.Title) > MaxPlanTitleLength {
+		return fmt.Errorf("title must be between 1 and %d characters", MaxPlanTitleLength)
+	}
+	if strings.TrimSpace(r.Goal) == "" || len(r.Goal) > MaxQueryContentLength {
+		return fmt.Errorf("goal must be between 1 and %d characters", MaxQueryContentLength)
+	}
+	if r.Weeks < MinBlockWeeks || r.Weeks > MaxBlockWeeks {
+		return fmt.Errorf("weeks must be between %d and %d", MinBlockWeeks, MaxBlockWeeks)
+	}
+	if r.SessionsPerWeek < 1 || r.SessionsPerWeek > MaxSessionsPerWeek {
+		return fmt.Errorf("sessions per week must be between 1 and %d", MaxSessionsPerWeek)
+	}
+	if r.Weeks*r.SessionsPerWeek > MaxBlockSessions {
+		return fmt.Errorf("a block may contain at most %d sessions", MaxBlockSessions)
+	}
+	if r.PeakVolume < 100 || r.PeakVolume > MaxRowDistance {
+		return fmt.Errorf("peak volume must be between 100 and %d", MaxRowDistance)
+	}
+	if r.Constraints != nil && r.Constraints.TargetVolume != 0 {
+		return fmt.Errorf("target volume is set by the periodization, use peak_volume instead")
+	}
+	return r.Constraints.Validate()
+}
+
+// TrainingBlock is a multi-week training block with one plan per session.
+// @Description Periodized training block with its weeks and the generated session plans
+type TrainingBlock struct {
+	BlockID         string           `json:"block_id" db:"block_id" example:"2f1d4c9e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"`
+	Title           string           `json:"title" db:"title" example:"Frühjahrs-Meisterschaften"`
+	Goal            string           `json:"goal" db:"goal" example:"Bestzeit über 400m Freistil"`
+	Weeks           int              `json:"weeks" db:"weeks" example:"10"`
+	SessionsPerWeek int              `json:"sessions_per_week" db:"sessions_per_week" example:"3"`
+	PeakVolume      int              `json:"peak_volume" db:"peak_volume" example:"4000"`
+	Language        Language         `json:"language" db:"language" example:"de"`
+	PoolLength      string           `json:"pool_length,omitempty" db:"pool_length" example:"25"`
+	Constraints     *PlanConstraints `json:"constraints,omitempty" db:"constraints"`
+	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
+	Schedule        []BlockWeek      `json:"schedule" db:"-"`           // Schedule contains the phase and volume of every week
+	Sessions        []BlockSession   `json:"sessions,omitempty" db:"-"` // Sessions are ordered by week and session, they are omitted in lists
+}
+
+// BlockSession is a plan of a training block.
+// @Description Training plan of a single session in a training block
+type BlockSession struct {
+	Week         int    `json:"week" db:"week" example:"3"`
+	Session      int    `json:"session" db:"session" example:"2"`
+	Phase        Phase  `json:"phase" db:"phase" example:"base"`
+	TargetVolume int    `json:"target_volume" db:"target_volume" example:"3400"`
+	PlanID       string `json:"plan_id" db:"plan_id" example:"plan_123"`
+	Title        string `json:"title" db:"title" example:"Grundlagenausdauer Kraul"`
+	Description  string `json:"description" db:"description"`
+	Table        Table  `json:"table" db:"plan_table"`
+}
+
+// Plan returns the plan of the session.
+func (s *BlockSession) Plan() *Plan {
+	return &Plan{PlanID: s.PlanID, Title: s.Title, Description: s.Description, Table: s.Table}
+}
+
+// RegenerateSessionRequest is the request to generate a single session of a block again.
+// @Description Request payload for regenerating one session of a training block
+type RegenerateSessionRequest struct {
+	Content     string `json:"content,omitempty" example:"Mehr Technik für die Wende"` // Content contains additional wishes for the new plan
+	Preferences *bool  `json:"preferences,omitempty"`                                  // Preferences indicates if the user profile should be used for generation
+}
+
+func (r *RegenerateSessionRequest) Validate() error {
+	if len(r.Content) > MaxQueryContentLength {
+		return fmt.Errorf("content exceeds maximum length of %d", MaxQueryContentLength)
+	}
+	return nil
+}
+
+// BlockToPDFRequest is the request to export a whole training block to PDF.
+// @Description Request payload for exporting all sessions of a training block into one PDF
+type BlockToPDFRequest struct {
+	Horizontal      bool     `json:"horizontal" example:"false"`                                 // Horizontal indicates if the PDF should be in landscape orientation
+	LargeFont       bool     `json:"large_font" example:"true"`                                  // LargeFont indicates if the PDF should use a larger font size
+	Language        Language `json:"language,omitempty" example:"en"`                            // Language specifies the language for the PDF content
+	FrontendBaseURL string   `json:"frontend_base_url,omitempty" example:"https://swim-gen.app"` // FrontendBaseURL is the base URL for drill links in the PDF
+}
@@ -0,0 +1,74 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodize(t *testing.T) {
+	assert.Equal(t, []models.BlockWeek{
+		{Week: 1, Phase: models.PhaseBase, Volume: 2800},
+		{Week: 2, Phase: models.PhaseBase, Volume: 3000},
+		{Week: 3, Phase: models.PhaseBase, Volume: 3200},
+		{Week: 4, Phase: models.PhaseBase, Volume: 2700, Recovery: true},
+		{Week: 5, Phase: models.PhaseBuild, Volume: 3600},
+		{Week: 6, Phase: models.PhaseBuild, Volume: 3800},
+		{Week: 7, Phase: models.PhaseBuild, Volume: 4000},
+		{Week: 8, Phase: models.PhaseTaper, Volume: 3000},
+		{Week: 9, Phase: models.PhaseTaper, Volume: 2400},
+		{Week: 10, Phase: models.PhaseRace, Volume: 2000},
+	}, models.Periodize(10, 4000))
+}
+
+func TestPeriodizePhaseLengths(t *testing.T) {
+	count := func(weeks []models.BlockWeek) map[models.Phase]int {
+		phases := map[models.Phase]int{}
+		for _, w := range weeks {
+			phases[w.Phase]++
+		}
+		return phases
+	}
+
+	for weeks, want := range map[int]map[models.Phase]int{
+		4:  {models.PhaseBase: 1, models.PhaseBuild: 1, models.PhaseTaper: 1, models.PhaseRace: 1},
+		8:  {models.PhaseBase: 3, models.PhaseBuild: 2, models.PhaseTaper: 2, models.PhaseRace: 1},
+		12: {models.PhaseBase: 5, models.PhaseBuild: 4, models.PhaseTaper: 2, models.PhaseRace: 1},
+	} {
+		schedule := models.Periodize(weeks, 3000)
+		require.Len(t, schedule, weeks)
+		assert.Equal(t, want, count(schedule), "%d weeks", weeks)
+	}
+
+	// The peak is reached in the last build week, recovery weeks come every fourth week
+	schedule := models.Periodize(12, 3000)
+	assert.Equal(t, 3000, schedule[8].Volume)
+	assert.True(t, schedule[3].Recovery)
+	assert.True(t, schedule[7].Recovery)
+	assert.False(t, schedule[8].Recovery)
+}
+
+func TestTrainingBlockRequestValidate(t *testing.T) {
+	valid := func() *models.TrainingBlockRequest {
+		return &models.TrainingBlockRequest{Title: "Meisterschaften", Goal: "400m Freistil", Weeks: 10, SessionsPerWeek: 3, PeakVolume: 4000}
+	}
+	assert.NoError(t, valid().Validate())
+
+	for name, modify := range map[string]func(*models.TrainingBlockRequest){
+		"missing title":       func(r *models.TrainingBlockRequest) { r.Title = " " },
+		"missing goal":        func(r *models.TrainingBlockRequest) { r.Goal = "" },
+		"too few weeks":       func(r *models.TrainingBlockRequest) { r.Weeks = models.MinBlockWeeks - 1 },
+		"too many weeks":      func(r *models.TrainingBlockRequest) { r.Weeks = models.MaxBlockWeeks + 1 },
+		"no sessions":         func(r *models.TrainingBlockRequest) { r.SessionsPerWeek = 0 },
+		"too many sessions":   func(r *models.TrainingBlockRequest) { r.Weeks, r.SessionsPerWeek = 16, 4 },
+		"peak volume too low": func(r *models.TrainingBlockRequest) { r.PeakVolume = 50 },
+		"target volume set":   func(r *models.TrainingBlockRequest) { r.Constraints = &models.PlanConstraints{TargetVolume: 3000} },
+		"invalid constraints": func(r *models.TrainingBlockRequest) { r.Constraints = &models.PlanConstraints{MaxDuration: -1} },
+	} {
+		r := valid()
+		modify(r)
+		assert.Error(t, r.Validate(), name)
+	}
+}
@@ -211,7 +211,7 @@ type Row struct {
 	Amount     int             `json:"Amount" example:"4" jsonschema_description:"Amount of repetitions"`
 	Multiplier string          `json:"Multiplier" example:"x" jsonschema_description:"Multiplier for the distance (e.g. 'x' or 'times')"`
 	Distance   int             `json:"Distance" example:"100" jsonschema_description:"Distance in meters. For parent rows with SubRows, this is auto-calculated as sum of subRows distances"`
-	Break      string          `json:"Break" example:"20" jsonschema_description:"Break time typically in seconds. This needs to be a string, as other times are possible"`
+	Break      Interval        `json:"Break" swaggertype:"string" example:"20" jsonschema_description:"Break after each repetition: rest in seconds like \"20\", a send-off time like \"@1:45\" or free text. This needs to be a string"`
 	Content    string          `json:"Content" example:"Freestyle swim" jsonschema_description:"Content or description of the row"`
 	Intensity  string          `json:"Intensity" example:"Z1" jsonschema_description:"Intensity level of the activity"`
 	Sum        int             `json:"Sum" example:"400" jsonschema_description:"Total volume or sum for the row"`
@@ -303,7 +303,7 @@ func (r Row) writeSubRows(outputStr *strings.Builder, depth int) {
 			len(r.SubRows),
 			r.Content,
 			child.conciseDescription(),
-			displayOrDash(child.Break),
+			displayOrDash(child.Break.String()),
 			displayOrDash(child.Intensity),
 			child.Sum,
 			displayOrDash(child.equipmentString()),
@@ -331,6 +331,11 @@ func displayOrDash(value string) string {
 	return value
 }
 
+// IsTotal reports whether the row is the total row at the end of a table.
+func (r Row) IsTotal() bool {
+	return strings.Contains(r.Content, "Gesamt") || strings.Contains(r.Content, "Total")
+}
+
 func (r *Row) UpdateSum() {
 	if len(r.SubRows) > 0 {
 		dis := 0
@@ -362,7 +367,7 @@ func (t *Table) AddSum() {
 	// Only add the total row if it doesn't already exist (e.g. from LLM restructuring)
 	if len(*t) > 0 {
 		lastRow := (*t)[len(*t)-1]
-		if strings.Contains(lastRow.Content, "Gesamt") || strings.Contains(lastRow.Content, "Total") {
+		if lastRow.IsTotal() {
 			return
 		}
 	}
@@ -375,7 +380,7 @@ func (t *Table) UpdateSum() {
 	total := 0
 	for i := range *t {
 		row := &(*t)[i]
-		if strings.Contains(row.Content, "Gesamt") || strings.Contains(row.Content, "Total") {
+		if row.IsTotal() {
 			row.Sum = total
 		} else {
 			row.UpdateSum()
@@ -395,7 +400,7 @@ func (t *Table) FlattenSingleParentRow() {
 
 	parentIndex := -1
 	for i, row := range *t {
-		if strings.Contains(row.Content, "Gesamt") || strings.Contains(row.Content, "Total") {
+		if row.IsTotal() {
 			continue
 		}
 		if parentIndex != -1 {
@@ -516,8 +521,8 @@ func (t *Table) validateRows(depth int, totalRows *int) error {
 		if len(row.Intensity) > MaxRowIntensityLen {
 			return fmt.Errorf("row %d intensity exceeds maximum length of %d", i, MaxRowIntensityLen)
 		}
-		if len(row.Break) > MaxRowBreakLen {
-			return fmt.Errorf("row %d break exceeds maximum length of %d", i, MaxRowBreakLen)
+		if err := row.Break.Validate(); err != nil {
+			return fmt.Errorf("row %d: %w", i, err)
 		}
 
 		if len(row.SubRows) > 0 {
@@ -560,7 +565,7 @@ func (t *Table) FlattenTable(indent string) []string {
 func (t *Table) GetTotalVolume() int {
 	total := 0
 	for _, row := range *t {
-		if !strings.Contains(row.Content, "Gesamt") && !strings.Contains(row.Content, "Total") {
+		if !row.IsTotal() {
 			total += row.Sum
 		}
 	}
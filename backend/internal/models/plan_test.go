@@ -40,13 +40,19 @@ func TestUploadedPlanResponseDatabaseColumns(t *testing.T) {
 	}
 }
 
+func TestRowIsTotal(t *testing.T) {
+	assert.True(t, models.Row{Content: "Gesamt", Sum: 800}.IsTotal())
+	assert.True(t, models.Row{Content: "Total", Sum: 800}.IsTotal())
+	assert.False(t, models.Row{Amount: 4, Distance: 100, Content: "Kraul", Sum: 400}.IsTotal())
+}
+
 func TestUpdateSum(t *testing.T) {
 	table := models.Table{
 		{
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "Kraul-Beine",
 			Intensity:  "GA1",
 			Sum:        0,
@@ -55,7 +61,7 @@ func TestUpdateSum(t *testing.T) {
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   50,
-			Break:      "20",
+			Break:      models.Rest(20),
 			Content:    "Unterwasser-Sculling",
 			Intensity:  "TÜ",
 			Sum:        0,
@@ -64,7 +70,6 @@ func TestUpdateSum(t *testing.T) {
 			Amount:     0,
 			Multiplier: "",
 			Distance:   0,
-			Break:      "",
 			Content:    "Gesamt",
 			Intensity:  "",
 			Sum:        0,
@@ -85,20 +90,20 @@ func TestUpdateSumWithSubRows(t *testing.T) {
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   100000, // Wrong distance that should be recalculated based on subRows
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "Kraul-Beine",
 			Intensity:  "GA1",
 			Sum:        0,
 			SubRows: []models.Row{
-				{Amount: 1, Distance: 50, Break: "15", Content: "Freestyle", Intensity: "GA1", Sum: 0},
-				{Amount: 1, Distance: 50, Break: "15", Content: "Rücken", Intensity: "GA1", Sum: 0},
+				{Amount: 1, Distance: 50, Break: models.Rest(15), Content: "Freestyle", Intensity: "GA1", Sum: 0},
+				{Amount: 1, Distance: 50, Break: models.Rest(15), Content: "Rücken", Intensity: "GA1", Sum: 0},
 			},
 		},
 		{
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   50,
-			Break:      "20",
+			Break:      models.Rest(20),
 			Content:    "Unterwasser-Sculling",
 			Intensity:  "TÜ",
 			Sum:        0,
@@ -107,7 +112,6 @@ func TestUpdateSumWithSubRows(t *testing.T) {
 			Amount:     0,
 			Multiplier: "",
 			Distance:   0,
-			Break:      "",
 			Content:    "Gesamt",
 			Intensity:  "",
 			Sum:        0,
@@ -137,13 +141,13 @@ func TestUpdateSum_NestedRows(t *testing.T) {
 			Amount:     8,
 			Multiplier: "x",
 			Distance:   0,
-			Break:      "20",
+			Break:      models.Rest(20),
 			Content:    "Main Set",
 			Intensity:  "GA1",
 			Sum:        0,
 			SubRows: []models.Row{
-				{Amount: 1, Distance: 800, Break: "10", Content: "Freestyle", Intensity: "GA1", Sum: 0},
-				{Amount: 1, Distance: 200, Break: "0", Content: "Kick", Intensity: "GA1", Sum: 0},
+				{Amount: 1, Distance: 800, Break: models.Rest(10), Content: "Freestyle", Intensity: "GA1", Sum: 0},
+				{Amount: 1, Distance: 200, Break: models.Rest(0), Content: "Kick", Intensity: "GA1", Sum: 0},
 			},
 		},
 	}
@@ -161,13 +165,13 @@ func TestUpdateSum_NestedRowsWithIncorrectSums(t *testing.T) {
 			Amount:     6,
 			Multiplier: "x",
 			Distance:   0,
-			Break:      "15",
+			Break:      models.Rest(15),
 			Content:    "Main Set",
 			Intensity:  "GA2",
 			Sum:        0,
 			SubRows: []models.Row{
-				{Amount: 1, Distance: 400, Break: "10", Content: "Kraul", Intensity: "GA2", Sum: 9999},
-				{Amount: 1, Distance: 100, Break: "5", Content: "Brust", Intensity: "GA2", Sum: 9999},
+				{Amount: 1, Distance: 400, Break: models.Rest(10), Content: "Kraul", Intensity: "GA2", Sum: 9999},
+				{Amount: 1, Distance: 100, Break: models.Rest(5), Content: "Brust", Intensity: "GA2", Sum: 9999},
 			},
 		},
 	}
@@ -181,21 +185,21 @@ func TestUpdateSum_NestedRowsWithIncorrectSums(t *testing.T) {
 
 func TestUpdateSum_MixedRows(t *testing.T) {
 	table := models.Table{
-		{Amount: 4, Distance: 100, Break: "20", Content: "Warmup", Intensity: "Rekom", Sum: 400},
+		{Amount: 4, Distance: 100, Break: models.Rest(20), Content: "Warmup", Intensity: "Rekom", Sum: 400},
 		{
 			Amount:     6,
 			Multiplier: "x",
 			Distance:   0,
-			Break:      "15",
+			Break:      models.Rest(15),
 			Content:    "Main Set",
 			Intensity:  "GA2",
 			Sum:        0,
 			SubRows: []models.Row{
-				{Amount: 1, Distance: 400, Break: "10", Content: "Kraul", Intensity: "GA2", Sum: 0},
-				{Amount: 1, Distance: 100, Break: "5", Content: "Brust", Intensity: "GA2", Sum: 0},
+				{Amount: 1, Distance: 400, Break: models.Rest(10), Content: "Kraul", Intensity: "GA2", Sum: 0},
+				{Amount: 1, Distance: 100, Break: models.Rest(5), Content: "Brust", Intensity: "GA2", Sum: 0},
 			},
 		},
-		{Amount: 1, Distance: 200, Break: "0", Content: "Cooldown", Intensity: "Rekom", Sum: 200},
+		{Amount: 1, Distance: 200, Break: models.Rest(0), Content: "Cooldown", Intensity: "Rekom", Sum: 200},
 	}
 	table.UpdateSum()
 
@@ -456,7 +460,7 @@ func TestValidate_ExceedsFieldLengths(t *testing.T) {
 
 	t.Run("Break too long", func(t *testing.T) {
 		table := models.Table{
-			{Amount: 1, Break: strings.Repeat("x", 51), Distance: 50, Content: "Lap"},
+			{Amount: 1, Break: models.FreeInterval(strings.Repeat("x", 51)), Distance: 50, Content: "Lap"},
 		}
 		err := table.Validate()
 		assert.Error(t, err)
@@ -546,7 +550,7 @@ func TestRowString_Nested(t *testing.T) {
 		Amount:     8,
 		Multiplier: "x",
 		Distance:   1000,
-		Break:      "20",
+		Break:      models.Rest(20),
 		Content:    "Main Set",
 		Intensity:  "GA1",
 		Sum:        8000,
@@ -566,7 +570,7 @@ func TestRow_WithEquipment(t *testing.T) {
 		Amount:     4,
 		Multiplier: "x",
 		Distance:   100,
-		Break:      "20",
+		Break:      models.Rest(20),
 		Content:    "Kraul-Beine",
 		Intensity:  "GA1",
 		Equipment:  []models.EquipmentType{models.EquipmentFins},
@@ -581,7 +585,7 @@ func TestRow_EquipmentEmpty(t *testing.T) {
 		Amount:     4,
 		Multiplier: "x",
 		Distance:   100,
-		Break:      "20",
+		Break:      models.Rest(20),
 		Content:    "Kraul",
 		Intensity:  "GA1",
 	}
@@ -595,7 +599,7 @@ func TestRow_EquipmentMultiple(t *testing.T) {
 		Amount:     4,
 		Multiplier: "x",
 		Distance:   100,
-		Break:      "20",
+		Break:      models.Rest(20),
 		Content:    "Technikübung",
 		Intensity:  "TÜ",
 		Equipment:  []models.EquipmentType{models.EquipmentFins, models.EquipmentBuoy},
@@ -611,7 +615,7 @@ func TestRow_EquipmentWithSubRows(t *testing.T) {
 		Amount:     4,
 		Multiplier: "x",
 		Distance:   0,
-		Break:      "20",
+		Break:      models.Rest(20),
 		Content:    "Main Set",
 		Intensity:  "GA1",
 		Sum:        400,
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/invopop/jsonschema"
+)
+
+// PreferenceField is a field of the training preferences a fact learned from chat can set.
+type PreferenceField string
+
+const (
+	PreferenceInjury         PreferenceField = "injury"
+	PreferenceDislikedDrill  PreferenceField = "disliked_drill"
+	PreferenceEquipment      PreferenceField = "equipment"
+	PreferencePoolLength     PreferenceField = "pool_length"
+	PreferenceSessionMinutes PreferenceField = "session_minutes"
+)
+
+var preferenceFields = []PreferenceField{PreferenceInjury, PreferenceDislikedDrill, PreferenceEquipment, PreferencePoolLength, PreferenceSessionMinutes}
+
+const (
+	// MaxPreferenceItems is the maximum number of entries per preference list.
+	MaxPreferenceItems = 20
+	// MaxPreferenceLength is the maximum length of a single preference entry.
+	MaxPreferenceLength = 200
+)
+
+// TrainingPreferences are the long-term preferences of a swimmer. They are part
+// of the profile used to personalize generated and refined plans.
+// @Description Long-term training preferences of the user
+type TrainingPreferences struct {
+	Injuries       []string        `json:"injuries" db:"injuries" example:"Schulterverletzung"`               // Injuries are injuries or complaints the plans have to take into account
+	DislikedDrills []string        `json:"disliked_drills" db:"disliked_drills" example:"Abschlagschwimmen"`  // DislikedDrills are drills or exercises the plans should avoid
+	Equipment      []EquipmentType `json:"equipment" db:"equipment" example:"Flossen,Pull buoy"`              // Equipment is the equipment the swimmer usually has available
+	PoolLength     *int            `json:"pool_length,omitempty" db:"pool_length" example:"25" enums:"25,50"` // PoolLength is the length of the pool the swimmer usually trains in
+	SessionMinutes *int            `json:"session_minutes,omitempty" db:"session_minutes" example:"60"`       // SessionMinutes is the usual length of a training session
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// Validate checks the bounds and the enum values of the preferences.
+func (p *TrainingPreferences) Validate() error {
+	for name, items := range map[string][]string{"injuries": p.Injuries, "disliked drills": p.DislikedDrills} {
+		if len(items) > MaxPreferenceItems {
+			return fmt.Errorf("at most %d %s are allowed", MaxPreferenceItems, name)
+		}
+		for _, item := range items {
+			if strings.TrimSpace(item) == "" || len(item) > MaxPreferenceLength {
+				return fmt.Errorf("%s must not be empty or longer than %d characters", name, MaxPreferenceLength)
+			}
+		}
+	}
+	for _, e := range p.Equipment {
+		if !slices.Contains(equipmentTypes, e) {
+			return fmt.Errorf("unknown equipment %q", e)
+		}
+	}
+	if p.PoolLength != nil && *p.PoolLength != 25 && *p.PoolLength != 50 {
+		return fmt.Errorf("pool length must be 25 or 50")
+	}
+	if p.SessionMinutes != nil && (*p.SessionMinutes <= 0 || *p.SessionMinutes > MaxConstraintDuration) {
+		return fmt.Errorf("session length must be between 1 and %d minutes", MaxConstraintDuration)
+	}
+	return nil
+}
+
+// IsZero reports whether no preference is set.
+func (p *TrainingPreferences) IsZero() bool {
+	return p == nil || (len(p.Injuries) == 0 && len(p.DislikedDrills) == 0 && len(p.Equipment) == 0 && p.PoolLength == nil && p.SessionMinutes == nil)
+}
+
+// Apply sets the field of the fact in the preferences. Facts already contained
+// in the preferences leave them unchanged.
+func (p *TrainingPreferences) Apply(fact PreferenceFact) error {
+	if err := fact.Validate(); err != nil {
+		return err
+	}
+	value := strings.TrimSpace(fact.Value)
+	switch fact.Field {
+	case PreferenceInjury:
+		p.Injuries = appendPreference(p.Injuries, value)
+	case PreferenceDislikedDrill:
+		p.DislikedDrills = appendPreference(p.DislikedDrills, value)
+	case PreferenceEquipment:
+		if !slices.Contains(p.Equipment, EquipmentType(value)) {
+			p.Equipment = append(p.Equipment, EquipmentType(value))
+		}
+	case PreferencePoolLength:
+		length, _ := strconv.Atoi(value)
+		p.PoolLength = &length
+	case PreferenceSessionMinutes:
+		minutes, _ := strconv.Atoi(value)
+		p.SessionMinutes = &minutes
+	}
+	return p.Validate()
+}
+
+// Contains reports whether the preferences already hold the fact.
+func (p *TrainingPreferences) Contains(fact PreferenceFact) bool {
+	if p == nil {
+		return false
+	}
+	value := strings.TrimSpace(fact.Value)
+	switch fact.Field {
+	case PreferenceInjury:
+		return containsPreference(p.Injuries, value)
+	case PreferenceDislikedDrill:
+		return containsPreference(p.DislikedDrills, value)
+	case PreferenceEquipment:
+		return slices.Contains(p.Equipment, EquipmentType(value))
+	case PreferencePoolLength:
+		return p.PoolLength != nil && strconv.Itoa(*p.PoolLength) == value
+	case PreferenceSessionMinutes:
+		return p.SessionMinutes != nil && strconv.Itoa(*p.SessionMinutes) == value
+	}
+	return false
+}
+
+func appendPreference(items []string, value string) []string {
+	if containsPreference(items, value) {
+		return items
+	}
+	return append(items, value)
+}
+
+func containsPreference(items []string, value string) bool {
+	return slices.ContainsFunc(items, func(item string) bool { return strings.EqualFold(item, value) })
+}
+
+// PreferenceFact is a single preference of the swimmer, e.g. learned from a chat message.
+type PreferenceFact struct {
+	Field PreferenceField `json:"field" jsonschema:"enum=injury,enum=disliked_drill,enum=equipment,enum=pool_length,enum=session_minutes" jsonschema_description:"Field of the training preferences the fact belongs to"`
+	Value string          `json:"value" jsonschema_description:"Short value of the fact. Equipment uses the names Flossen, Kickboard, Handpaddles, Pull buoy or Schnorchel, pool_length 25 or 50 and session_minutes a number of minutes."`
+}
+
+// Validate checks that the value fits the field of the fact.
+func (f PreferenceFact) Validate() error {
+	value := strings.TrimSpace(f.Value)
+	if value == "" || len(value) > MaxPreferenceLength {
+		return fmt.Errorf("preference value must not be empty or longer than %d characters", MaxPreferenceLength)
+	}
+	switch f.Field {
+	case PreferenceInjury, PreferenceDislikedDrill:
+		return nil
+	case PreferenceEquipment:
+		if !slices.Contains(equipmentTypes, EquipmentType(value)) {
+			return fmt.Errorf("unknown equipment %q", value)
+		}
+		return nil
+	case PreferencePoolLength:
+		if value != "25" && value != "50" {
+			return fmt.Errorf("pool length must be 25 or 50")
+		}
+		return nil
+	case PreferenceSessionMinutes:
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes <= 0 || minutes > MaxConstraintDuration {
+			return fmt.Errorf("session length must be between 1 and %d minutes", MaxConstraintDuration)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown preference field %q, must be one of %v", f.Field, preferenceFields)
+}
+
+// PreferenceFacts are the facts extracted from a chat message.
+type PreferenceFacts struct {
+	Facts []PreferenceFact `json:"facts" jsonschema_description:"Long-term preferences stated by the swimmer, empty if the message contains none"`
+}
+
+// PreferenceFactsSchema generates the JSON schema for PreferenceFacts
+func PreferenceFactsSchema() (map[string]any, error) {
+	schema := jsonschema.Reflect(&PreferenceFacts{})
+
+	jsonSchema, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON schema: %w", err)
+	}
+	var result map[string]any
+	return result, json.Unmarshal(jsonSchema, &result)
+}
+
+// PreferenceProposal is a fact learned from a chat message, which becomes part
+// of the training preferences once the user accepts it.
+// @Description Training preference proposed from a chat message, waiting for confirmation
+type PreferenceProposal struct {
+	ProposalID string          `json:"proposal_id" db:"proposal_id"`
+	PlanID     *string         `json:"plan_id,omitempty" db:"plan_id"`       // PlanID is the plan of the conversation the fact was learned in
+	MessageID  *string         `json:"message_id,omitempty" db:"message_id"` // MessageID is the chat message the fact was learned from
+	Field      PreferenceField `json:"field" db:"field" example:"injury" enums:"injury,disliked_drill,equipment,pool_length,session_minutes"`
+	Value      string          `json:"value" db:"value" example:"Schulterverletzung"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Fact returns the fact proposed for the preferences.
+func (p *PreferenceProposal) Fact() PreferenceFact {
+	return PreferenceFact{Field: p.Field, Value: p.Value}
+}
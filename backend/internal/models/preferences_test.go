@@ -0,0 +1,57 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrainingPreferencesApply(t *testing.T) {
+	preferences := &models.TrainingPreferences{Injuries: []string{"Schulterverletzung"}}
+
+	require.NoError(t, preferences.Apply(models.PreferenceFact{Field: models.PreferenceInjury, Value: " schulterverletzung "}))
+	assert.Equal(t, []string{"Schulterverletzung"}, preferences.Injuries)
+
+	require.NoError(t, preferences.Apply(models.PreferenceFact{Field: models.PreferenceDislikedDrill, Value: "Delfin"}))
+	require.NoError(t, preferences.Apply(models.PreferenceFact{Field: models.PreferenceEquipment, Value: "Flossen"}))
+	require.NoError(t, preferences.Apply(models.PreferenceFact{Field: models.PreferencePoolLength, Value: "50"}))
+	require.NoError(t, preferences.Apply(models.PreferenceFact{Field: models.PreferenceSessionMinutes, Value: "75"}))
+
+	assert.Equal(t, []string{"Delfin"}, preferences.DislikedDrills)
+	assert.Equal(t, []models.EquipmentType{models.EquipmentFins}, preferences.Equipment)
+	assert.Equal(t, 50, *preferences.PoolLength)
+	assert.Equal(t, 75, *preferences.SessionMinutes)
+	assert.True(t, preferences.Contains(models.PreferenceFact{Field: models.PreferenceDislikedDrill, Value: "delfin"}))
+	assert.False(t, preferences.Contains(models.PreferenceFact{Field: models.PreferencePoolLength, Value: "25"}))
+}
+
+func TestTrainingPreferencesRejectInvalidFacts(t *testing.T) {
+	preferences := &models.TrainingPreferences{}
+
+	for _, fact := range []models.PreferenceFact{
+		{Field: models.PreferenceInjury, Value: " "},
+		{Field: models.PreferenceEquipment, Value: "Schwimmbrille"},
+		{Field: models.PreferencePoolLength, Value: "33"},
+		{Field: models.PreferenceSessionMinutes, Value: "eine Stunde"},
+		{Field: "mood", Value: "gut"},
+	} {
+		assert.Error(t, preferences.Apply(fact), fact)
+	}
+	assert.True(t, preferences.IsZero())
+}
+
+func TestTrainingPreferencesValidate(t *testing.T) {
+	sessionMinutes := 601
+	assert.Error(t, (&models.TrainingPreferences{SessionMinutes: &sessionMinutes}).Validate())
+
+	injuries := make([]string, models.MaxPreferenceItems+1)
+	for i := range injuries {
+		injuries[i] = "Knie"
+	}
+	assert.Error(t, (&models.TrainingPreferences{Injuries: injuries}).Validate())
+
+	poolLength := 25
+	assert.NoError(t, (&models.TrainingPreferences{Injuries: []string{"Knie"}, PoolLength: &poolLength}).Validate())
+}
@@ -24,7 +24,7 @@ func SanitizeRows(table *Table) {
 		row := &(*table)[i]
 		row.Content = SanitizeString(row.Content)
 		row.Multiplier = SanitizeString(row.Multiplier)
-		row.Break = SanitizeString(row.Break)
+		row.Break.Text = SanitizeString(row.Break.Text)
 		row.Intensity = SanitizeString(row.Intensity)
 		if len(row.SubRows) > 0 {
 			subTable := Table(row.SubRows)
@@ -0,0 +1,273 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ShorthandFragment is a part of a shorthand plan that could not be parsed.
+// @Description A part of the uploaded text that was not understood by the shorthand parser
+type ShorthandFragment struct {
+	Line   int    `json:"line" example:"3"`                  // Line is the 1-based line number of the fragment
+	Text   string `json:"text" example:"4x"`                 // Text is the fragment as written in the input
+	Reason string `json:"reason" example:"missing distance"` // Reason describes why the fragment was rejected
+}
+
+// ShorthandResult is the outcome of ParseShorthand.
+type ShorthandResult struct {
+	Table    Table
+	Unparsed []ShorthandFragment
+}
+
+// Abbreviations which describe the content of a row. The remaining entries of
+// Abbreviations are intensities, not about swimming or ambiguous, like "S" for
+// Schmetterling or Sprint.
+var shorthandContentKeys = []string{"K", "Kr", "Freistil", "F", "Fr", "R", "B", "Br", "Be", "D", "TÜ", "TS"}
+
+// Abbreviations which are used as intensity, optionally followed by a zone number, e.g. "GA1".
+var shorthandIntensityKeys = []string{"GA", "SA", "TA", "WA", "LZA", "Rekom", "BZ", "LT", "Z"}
+
+var shorthandEquipment = map[string]EquipmentType{
+	"flossen":     EquipmentFins,
+	"fins":        EquipmentFins,
+	"kickboard":   EquipmentKickboard,
+	"brett":       EquipmentKickboard,
+	"board":       EquipmentKickboard,
+	"paddles":     EquipmentPaddles,
+	"handpaddles": EquipmentPaddles,
+	"paddel":      EquipmentPaddles,
+	"pullbuoy":    EquipmentBuoy,
+	"pull-buoy":   EquipmentBuoy,
+	"pull":        EquipmentBuoy,
+	"buoy":        EquipmentBuoy,
+	"pullboy":     EquipmentBuoy,
+	"schnorchel":  EquipmentSnorkel,
+	"snorkel":     EquipmentSnorkel,
+}
+
+var (
+	shorthandBullet     = regexp.MustCompile(`^(?:[-•*]|\d+[.)])\s+`)
+	shorthandRepetition = regexp.MustCompile(`^(\d+)\s*[x×*]\s*`)
+	shorthandDistance   = regexp.MustCompile(`^(\d+)\s*m?(?:\s+|$)`)
+	shorthandSendOff    = regexp.MustCompile(`^@(\d{1,2}:\d{2}|\d+)$`)
+	shorthandRest       = regexp.MustCompile(`(?i)^(?:p:?|r:?)?(\d{1,2}:\d{2}|\d+)(?:s|"|'')?$`)
+	shorthandRestPrefix = regexp.MustCompile(`(?i)^(?:p|r):?\d|^\d+(?:s|"|'')$`)
+	shorthandZone       = regexp.MustCompile(`^\d?$`)
+	shorthandTime       = regexp.MustCompile(`^\d{1,2}:\d{2}$`)
+)
+
+// ParseShorthand parses a plan written in common coach shorthand, one set per line:
+//
+//	400 Fr GA1
+//	4x100 Fr @1:45 GA2 Paddles
+//	8 x 50m Be P:20
+//	3x(4x50 Be + 200 Lagen) P30
+//
+// Repetitions use "x", "×" or "*". "@1:45" is a send-off time, "P20", "P:1:00",
+// "r20" or "20s" a rest. Abbreviations of strokes are expanded via
+// Abbreviations, intensities like "GA1" and equipment keywords fill their
+// columns and other words are kept as content. Parts that are not understood are
+// returned as unparsed fragments. The resulting table contains a total row.
+func ParseShorthand(text string) *ShorthandResult {
+	res := &ShorthandResult{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = shorthandBullet.ReplaceAllString(line, "")
+		if line == "" {
+			continue
+		}
+		p := &shorthandLine{line: i + 1, res: res}
+		// Sets joined by "+" outside of parentheses are independent rows
+		for _, part := range splitTopLevel(line, '+') {
+			if row, ok := p.parseRow(strings.TrimSpace(part), 0); ok {
+				res.Table = append(res.Table, row)
+			}
+		}
+	}
+	if len(res.Table) > 0 {
+		res.Table.UpdateSum()
+		res.Table.AddSum()
+	}
+	return res
+}
+
+type shorthandLine struct {
+	line int
+	res  *ShorthandResult
+}
+
+func (p *shorthandLine) unparsed(text, reason string) {
+	p.res.Unparsed = append(p.res.Unparsed, ShorthandFragment{Line: p.line, Text: text, Reason: reason})
+}
+
+// parseRow parses "[N x] distance words..." or "[N x] (set + set) words...".
+func (p *shorthandLine) parseRow(s string, depth int) (Row, bool) {
+	row := Row{Amount: 1, Multiplier: "x"}
+	rest := s
+	if m := shorthandRepetition.FindStringSubmatch(rest); m != nil {
+		row.Amount, _ = strconv.Atoi(m[1])
+		rest = rest[len(m[0]):]
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "("):
+		if depth > 0 {
+			p.unparsed(s, "sets can only be nested once")
+			return Row{}, false
+		}
+		end := closingParen(rest)
+		if end < 0 {
+			p.unparsed(s, "missing closing parenthesis")
+			return Row{}, false
+		}
+		for _, part := range splitTopLevel(rest[1:end], '+') {
+			if sub, ok := p.parseRow(strings.TrimSpace(part), depth+1); ok {
+				row.SubRows = append(row.SubRows, sub)
+			}
+		}
+		if len(row.SubRows) == 0 {
+			return Row{}, false
+		}
+		rest = rest[end+1:]
+	default:
+		m := shorthandDistance.FindStringSubmatch(rest)
+		if m == nil {
+			p.unparsed(s, "missing distance")
+			return Row{}, false
+		}
+		row.Distance, _ = strconv.Atoi(m[1])
+		rest = rest[len(m[0]):]
+	}
+
+	if row.Amount == 0 || row.Amount > MaxRowAmount || row.Distance > MaxRowDistance {
+		p.unparsed(s, "amount or distance out of range")
+		return Row{}, false
+	}
+	p.parseAttributes(&row, rest)
+	return row, true
+}
+
+// parseAttributes assigns the words following the distance to the row.
+func (p *shorthandLine) parseAttributes(row *Row, s string) {
+	var content []string
+	words := strings.Fields(s)
+	for i := 0; i < len(words); i++ {
+		word := strings.Trim(words[i], ",;")
+		if word == "" {
+			continue
+		}
+		lower := strings.ToLower(word)
+
+		// "Pause 20" and "rest 20"
+		if (lower == "pause" || lower == "rest") && i+1 < len(words) {
+			if m := shorthandRest.FindStringSubmatch(words[i+1]); m != nil {
+				p.setBreak(row, ParseInterval(m[1]), word+" "+words[i+1])
+				i++
+				continue
+			}
+		}
+
+		switch {
+		case shorthandSendOff.MatchString(word):
+			p.setBreak(row, ParseInterval(word), word)
+		case shorthandRestPrefix.MatchString(word) && shorthandRest.MatchString(word):
+			p.setBreak(row, ParseInterval(shorthandRest.FindStringSubmatch(word)[1]), word)
+		case isShorthandIntensity(word):
+			row.Intensity = word
+		case shorthandEquipment[lower] != "":
+			addEquipment(row, shorthandEquipment[lower])
+		case shorthandContent(word) != "":
+			content = append(content, shorthandContent(word))
+		case isNumeric(word):
+			p.unparsed(word, "number without meaning")
+		case shorthandTime.MatchString(word):
+			p.unparsed(word, "time without @ or P")
+		default:
+			content = append(content, word)
+		}
+	}
+	row.Content = strings.Join(content, " ")
+}
+
+func (p *shorthandLine) setBreak(row *Row, value Interval, text string) {
+	if !row.Break.IsZero() {
+		p.unparsed(text, "second break")
+		return
+	}
+	row.Break = value
+}
+
+func addEquipment(row *Row, e EquipmentType) {
+	for _, existing := range row.Equipment {
+		if existing == e {
+			return
+		}
+	}
+	row.Equipment = append(row.Equipment, e)
+}
+
+func isShorthandIntensity(word string) bool {
+	for _, key := range shorthandIntensityKeys {
+		if len(word) >= len(key) && strings.EqualFold(word[:len(key)], key) && shorthandZone.MatchString(word[len(key):]) {
+			// A single "Z" is no intensity
+			return key != "Z" || len(word) > 1
+		}
+	}
+	return false
+}
+
+// shorthandContent returns the expanded stroke abbreviation or an empty string.
+func shorthandContent(word string) string {
+	for _, key := range shorthandContentKeys {
+		if strings.EqualFold(word, key) {
+			return Abbreviations[key]
+		}
+	}
+	return ""
+}
+
+func isNumeric(word string) bool {
+	_, err := strconv.Atoi(strings.TrimSuffix(word, "m"))
+	return err == nil
+}
+
+// closingParen returns the index of the parenthesis closing the one at s[0].
+func closingParen(s string) int {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s at sep outside of parentheses.
+func splitTopLevel(s string, sep rune) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + len(string(sep))
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
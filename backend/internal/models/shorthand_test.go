@@ -0,0 +1,108 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShorthandSingleLines(t *testing.T) {
+	tests := []struct {
+		input string
+		want  models.Row
+	}{
+		{
+			input: "400 Fr GA1",
+			want:  models.Row{Amount: 1, Multiplier: "x", Distance: 400, Content: "Kraulschwimmen", Intensity: "GA1", Sum: 400},
+		},
+		{
+			input: "4x100 Fr @1:45 GA2 Paddles",
+			want:  models.Row{Amount: 4, Multiplier: "x", Distance: 100, Break: models.SendOff(105), Content: "Kraulschwimmen", Intensity: "GA2", Sum: 400, Equipment: []models.EquipmentType{models.EquipmentPaddles}},
+		},
+		{
+			input: "8 × 50m Be P:20 Flossen",
+			want:  models.Row{Amount: 8, Multiplier: "x", Distance: 50, Break: models.Rest(20), Content: "Beinarbeit", Sum: 400, Equipment: []models.EquipmentType{models.EquipmentFins}},
+		},
+		{
+			input: "- 6*200 B locker Pause 1:00 Pull buoy",
+			want:  models.Row{Amount: 6, Multiplier: "x", Distance: 200, Break: models.Rest(60), Content: "Brustschwimmen locker", Sum: 1200, Equipment: []models.EquipmentType{models.EquipmentBuoy}},
+		},
+		{
+			input: "2. 10x25 D 15s Rekom",
+			want:  models.Row{Amount: 10, Multiplier: "x", Distance: 25, Break: models.Rest(15), Content: "Schmetterling/Delfinschwimmen", Intensity: "Rekom", Sum: 250},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			res := models.ParseShorthand(tt.input)
+
+			assert.Empty(t, res.Unparsed)
+			require.Len(t, res.Table, 2)
+			assert.Equal(t, tt.want, res.Table[0])
+			assert.Equal(t, "Gesamt", res.Table[1].Content)
+			assert.Equal(t, tt.want.Sum, res.Table[1].Sum)
+		})
+	}
+}
+
+func TestParseShorthandNestedSet(t *testing.T) {
+	res := models.ParseShorthand("3x(4x50 Be + 200 Lagen) P30 GA1")
+
+	assert.Empty(t, res.Unparsed)
+	require.Len(t, res.Table, 2)
+	set := res.Table[0]
+	assert.Equal(t, 3, set.Amount)
+	assert.Equal(t, models.Rest(30), set.Break)
+	assert.Equal(t, "GA1", set.Intensity)
+	assert.Equal(t, 400, set.Distance)
+	assert.Equal(t, 1200, set.Sum)
+	require.Len(t, set.SubRows, 2)
+	assert.Equal(t, models.Row{Amount: 4, Multiplier: "x", Distance: 50, Content: "Beinarbeit", Sum: 200}, set.SubRows[0])
+	assert.Equal(t, models.Row{Amount: 1, Multiplier: "x", Distance: 200, Content: "Lagen", Sum: 200}, set.SubRows[1])
+	require.NoError(t, res.Table.Validate())
+}
+
+func TestParseShorthandPlan(t *testing.T) {
+	input := `400 K Einschwimmen
+Hauptteil:
+4x100 Fr @1:45 GA2
+200 Fr + 100 R
+4x 1:30
+
+200 locker`
+
+	res := models.ParseShorthand(input)
+
+	require.Len(t, res.Table, 6)
+	assert.Equal(t, 1300, res.Table[5].Sum)
+	assert.Equal(t, []models.ShorthandFragment{
+		{Line: 2, Text: "Hauptteil:", Reason: "missing distance"},
+		{Line: 5, Text: "4x 1:30", Reason: "missing distance"},
+	}, res.Unparsed)
+}
+
+func TestParseShorthandReportsFragments(t *testing.T) {
+	tests := []struct {
+		input  string
+		text   string
+		reason string
+	}{
+		{"4x100 Fr 1:30", "1:30", "time without @ or P"},
+		{"4x100 Fr 50", "50", "number without meaning"},
+		{"4x100 @1:45 P20", "P20", "second break"},
+		{"2x(4x50 + 100", "2x(4x50 + 100", "missing closing parenthesis"},
+		{"2x(2x(50 + 50))", "2x(50 + 50)", "sets can only be nested once"},
+		{"0x100 Fr", "0x100 Fr", "amount or distance out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			res := models.ParseShorthand(tt.input)
+
+			require.Len(t, res.Unparsed, 1)
+			assert.Equal(t, tt.text, res.Unparsed[0].Text)
+			assert.Equal(t, tt.reason, res.Unparsed[0].Reason)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/page"
+	"github.com/johnfercher/maroto/v2/pkg/components/row"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+)
+
+// Converts all sessions of a training block to one PDF.
+//
+// The first page shows the goal and the weeks of the block with their phase and
+// volume, every session starts on a new page. The durations in the footers are
+// estimated with the CSS times of the profile, which may be nil.
+func BlockToPDF(block *models.TrainingBlock, profile *models.UserProfile, ho, lf bool, lang models.Language, baseURL string) ([]byte, error) {
+	l := standardLayout(ho, lf)
+	m := maroto.New(l.config().Build())
+	titleProps := props.Text{Size: 18, Style: fontstyle.Bold, Align: align.Center, Bottom: 6, VerticalPadding: 2}
+	goalProps := props.Text{Size: 10, Style: fontstyle.Italic, Align: align.Center, Bottom: 6, VerticalPadding: 2}
+	if lf {
+		titleProps.Size = 22
+		titleProps.Bottom = 8
+		goalProps.Size = 14
+	}
+
+	m.AddAutoRow(col.New().Add(text.New(block.Title, titleProps)))
+	m.AddAutoRow(col.New().Add(text.New(block.Goal, goalProps)))
+	m.AddRows(getScheduleRows(block.Schedule, lf, lang)...)
+
+	for _, session := range block.Sessions {
+		sessionTitle := fmt.Sprintf("%s – %s", sessionLabel(session, lang), session.Title)
+		rows := []core.Row{row.New().Add(col.New().Add(text.New(sessionTitle, titleProps)))}
+		rows = append(rows, getRows(session.Table, models.EstimateDuration(session.Table, profile), l.table, lang, baseURL)...)
+		m.AddPages(page.New().Add(rows...))
+		addPlanDescription(m, session.Description, l, lang)
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	return document.GetBytes(), nil
+}
+
+// getScheduleRows returns the overview table of the weeks of a block.
+func getScheduleRows(schedule []models.BlockWeek, lf bool, lang models.Language) []core.Row {
+	headerProps := props.Text{Style: fontstyle.Bold, Align: align.Center, Top: 2, Bottom: 2, VerticalPadding: 1}
+	p := props.Text{Align: align.Center, Top: 2, Bottom: 2, VerticalPadding: 1}
+	// The columns share the grid of the standard layout in the ratio 1:2:2
+	unit := 5
+	if lf {
+		headerProps.Size = 12
+		p.Size = 16
+		unit = 20
+	}
+
+	header := []string{"Week", "Phase", "Volume per session"}
+	if lang == models.LanguageDE {
+		header = []string{"Woche", "Phase", "Umfang je Einheit"}
+	}
+	rows := []core.Row{
+		row.New().Add(
+			text.NewCol(unit, header[0], headerProps),
+			text.NewCol(2*unit, header[1], headerProps),
+			text.NewCol(2*unit, header[2], headerProps),
+		).WithStyle(&props.Cell{BackgroundColor: darkGray}),
+	}
+	for i, week := range schedule {
+		r := row.New().Add(
+			text.NewCol(unit, strconv.Itoa(week.Week), p),
+			text.NewCol(2*unit, phaseName(week, lang), p),
+			text.NewCol(2*unit, fmt.Sprintf("%dm", week.Volume), p),
+		)
+		if i%2 == 1 {
+			r.WithStyle(&props.Cell{BackgroundColor: lightGray})
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func sessionLabel(session models.BlockSession, lang models.Language) string {
+	if lang == models.LanguageDE {
+		return fmt.Sprintf("Woche %d, Einheit %d", session.Week, session.Session)
+	}
+	return fmt.Sprintf("Week %d, session %d", session.Week, session.Session)
+}
+
+func phaseName(week models.BlockWeek, lang models.Language) string {
+	names := map[models.Phase][2]string{
+		models.PhaseBase:  {"Base", "Grundlage"},
+		models.PhaseBuild: {"Build", "Aufbau"},
+		models.PhaseTaper: {"Taper", "Tapering"},
+		models.PhaseRace:  {"Race week", "Wettkampfwoche"},
+	}
+	i := 0
+	if lang == models.LanguageDE {
+		i = 1
+	}
+	name := names[week.Phase][i]
+	if week.Recovery {
+		name += [2]string{" (recovery)", " (Entlastung)"}[i]
+	}
+	return name
+}
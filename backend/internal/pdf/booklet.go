@@ -0,0 +1,158 @@
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/page"
+	"github.com/johnfercher/maroto/v2/pkg/components/row"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+)
+
+// Converts the plans to one PDF booklet.
+//
+// The booklet starts with a cover page and a table of contents listing the
+// volume and duration of every plan. Every plan starts on a new page and the
+// drills of the appendix are described at the end. The durations are estimated
+// with the CSS times of the profile, which may be nil.
+func BookletToPDF(title string, plans []*models.Plan, appendix *DrillAppendix, profile *models.UserProfile, ho, lf bool, lang models.Language, baseURL string) ([]byte, error) {
+	l := standardLayout(ho, lf)
+	m := maroto.New(l.config().
+		WithPageNumber(props.PageNumber{Pattern: "{current} / {total}", Place: props.Bottom, Size: 8}).
+		Build())
+	titleProps := props.Text{Size: 18, Style: fontstyle.Bold, Align: align.Center, Bottom: 6, VerticalPadding: 2}
+	headerProps := props.Text{Size: 8, Style: fontstyle.Italic, Align: align.Right, Bottom: 2, Color: &props.Color{Red: 120, Green: 120, Blue: 120}}
+	if lf {
+		titleProps.Size = 22
+		titleProps.Bottom = 8
+		headerProps.Size = 12
+	}
+	if err := m.RegisterHeader(row.New().Add(col.New().Add(text.New(title, headerProps)))); err != nil {
+		return nil, err
+	}
+
+	durations := make([]*models.DurationEstimate, len(plans))
+	for i, plan := range plans {
+		durations[i] = models.EstimateDuration(plan.Table, profile)
+	}
+
+	m.AddRows(getCoverRows(title, plans, lf, lang)...)
+	tocRows := []core.Row{row.New().Add(col.New().Add(text.New(bookletLabels(lang).contents, titleProps)))}
+	tocRows = append(tocRows, getContentsRows(plans, durations, lf, lang)...)
+	m.AddPages(page.New().Add(tocRows...))
+
+	for i, plan := range plans {
+		planTitle := fmt.Sprintf("%d. %s", i+1, plan.Title)
+		rows := []core.Row{row.New().Add(col.New().Add(text.New(planTitle, titleProps)))}
+		rows = append(rows, getRows(plan.Table, durations[i], l.table, lang, baseURL)...)
+		m.AddPages(page.New().Add(rows...))
+		addPlanDescription(m, plan.Description, l, lang)
+	}
+
+	addDrillAppendix(m, appendix, l, lang)
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	return document.GetBytes(), nil
+}
+
+type bookletText struct {
+	sessions, total, contents string
+	header                    [4]string
+}
+
+func bookletLabels(lang models.Language) bookletText {
+	if lang == models.LanguageDE {
+		return bookletText{
+			sessions: "Einheiten",
+			total:    "Gesamt",
+			contents: "Inhalt",
+			header:   [4]string{"Nr.", "Einheit", "Umfang", "Dauer"},
+		}
+	}
+	return bookletText{
+		sessions: "sessions",
+		total:    "Total",
+		contents: "Contents",
+		header:   [4]string{"No.", "Session", "Volume", "Duration"},
+	}
+}
+
+// getCoverRows returns the cover page with the title, the number of plans and
+// their total volume.
+func getCoverRows(title string, plans []*models.Plan, lf bool, lang models.Language) []core.Row {
+	coverProps := props.Text{Size: 28, Style: fontstyle.Bold, Align: align.Center, Bottom: 8, VerticalPadding: 2}
+	summaryProps := props.Text{Size: 12, Style: fontstyle.Italic, Align: align.Center}
+	if lf {
+		coverProps.Size = 34
+		summaryProps.Size = 16
+	}
+
+	volume := 0
+	for _, plan := range plans {
+		volume += plan.Table.GetTotalVolume()
+	}
+	summary := fmt.Sprintf("%d %s · %d m", len(plans), bookletLabels(lang).sessions, volume)
+
+	return []core.Row{
+		row.New(70),
+		row.New().Add(col.New().Add(text.New(title, coverProps))),
+		row.New().Add(col.New().Add(text.New(summary, summaryProps))),
+	}
+}
+
+// getContentsRows returns the table of contents with the volume and duration
+// of every plan and the total volume.
+func getContentsRows(plans []*models.Plan, durations []*models.DurationEstimate, lf bool, lang models.Language) []core.Row {
+	headerProps := props.Text{Style: fontstyle.Bold, Align: align.Center, Top: 2, Bottom: 2, VerticalPadding: 1}
+	p := props.Text{Align: align.Center, Top: 2, Bottom: 2, VerticalPadding: 1}
+	titleProps := props.Text{Align: align.Left, Top: 2, Bottom: 2, Left: 2, VerticalPadding: 1}
+	// The columns share the grid of the standard layout in the ratio 2:13:5:5
+	unit := 1
+	if lf {
+		headerProps.Size = 12
+		p.Size = 16
+		titleProps.Size = 16
+		unit = 4
+	}
+
+	labels := bookletLabels(lang)
+	rows := []core.Row{
+		row.New().Add(
+			text.NewCol(2*unit, labels.header[0], headerProps),
+			text.NewCol(13*unit, labels.header[1], headerProps),
+			text.NewCol(5*unit, labels.header[2], headerProps),
+			text.NewCol(5*unit, labels.header[3], headerProps),
+		).WithStyle(&props.Cell{BackgroundColor: darkGray}),
+	}
+	total := 0
+	for i, plan := range plans {
+		volume := plan.Table.GetTotalVolume()
+		total += volume
+		r := row.New().Add(
+			text.NewCol(2*unit, fmt.Sprintf("%d", i+1), p),
+			text.NewCol(13*unit, plan.Title, titleProps),
+			text.NewCol(5*unit, fmt.Sprintf("%d m", volume), p),
+			text.NewCol(5*unit, fmt.Sprintf("%d min", (durations[i].TotalSeconds+30)/60), p),
+		)
+		if i%2 == 1 {
+			r.WithStyle(&props.Cell{BackgroundColor: lightGray})
+		}
+		rows = append(rows, r)
+	}
+	rows = append(rows, row.New().Add(
+		text.NewCol(15*unit, labels.total, headerProps),
+		text.NewCol(5*unit, fmt.Sprintf("%d m", total), headerProps),
+		col.New(5*unit),
+	).WithStyle(&props.Cell{BackgroundColor: darkGray}))
+	return rows
+}
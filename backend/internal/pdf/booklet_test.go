@@ -0,0 +1,52 @@
+package pdf_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookletToPDF(t *testing.T) {
+	plans := []*models.Plan{
+		{
+			Title:       "Kraul Schwelle",
+			Description: "Gleichmäßiges Tempo halten.",
+			Table: models.Table{
+				{Amount: 1, Multiplier: "x", Distance: 400, Content: "Einschwimmen mit [Catch-up](/drill/catch-up)", Sum: 400},
+				{Amount: 8, Multiplier: "x", Distance: 100, Break: models.Rest(20), Content: "Kraul", Intensity: "GA2", Sum: 800},
+				{Content: "Gesamt", Sum: 1200},
+			},
+		},
+		{
+			Title: "Rücken Technik",
+			Table: models.Table{
+				{Amount: 4, Multiplier: "x", Distance: 50, Break: models.Rest(15), Content: "[Einarmig Rücken](/drill/single-arm-backstroke.webp)", Intensity: "GA1", Sum: 200},
+				{Content: "Gesamt", Sum: 200},
+			},
+		},
+	}
+	appendix := &pdf.DrillAppendix{
+		Drills: []models.Drill{
+			{Title: "Catch-up", ImgName: "catch-up.webp", ShortDescription: "Eine Hand wartet vorne, bis die andere sie erreicht.", Targets: []string{"Wasserlage", "Armzug"}},
+		},
+		BaseURL: "https://swim-gen.com",
+	}
+
+	for _, lf := range []bool{false, true} {
+		bookletPDF, err := pdf.BookletToPDF("Sommertraining", plans, appendix, nil, false, lf, models.LanguageDE, "")
+		require.NoError(t, err, "BookletToPDF should not return an error")
+		assert.NotEmpty(t, bookletPDF, "BookletToPDF should return non-empty PDF bytes")
+
+		if os.Getenv("GENERATE_PDF") != "" {
+			filename := "test_booklet.pdf"
+			if lf {
+				filename = "test_booklet_lf.pdf"
+			}
+			assert.NoError(t, writePDF(filename, bookletPDF))
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package pdf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/5pirit5eal/swim-gen/internal/blob"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+)
+
+// layoutVersion is part of the cache key of plan PDFs. Bump it whenever the
+// layout changes, so PDFs with the old layout are not reused.
+const layoutVersion = 2
+
+// CacheKey returns a hash over everything the PDF of the plan is generated
+// from: table, title, description, duration, the drills of the appendix, the
+// named layout and the layout options. Exports with the same key produce the same PDF.
+func CacheKey(plan *models.Plan, duration *models.DurationEstimate, name models.PDFLayout, appendix *DrillAppendix, ho, lf bool, lang models.Language, baseURL string) (string, error) {
+	if duration == nil {
+		duration = models.EstimateDuration(plan.Table, nil)
+	}
+	// The standard layout is the layout of exports without a name
+	if name == models.PDFLayoutStandard {
+		name = ""
+	}
+	var drills []models.Drill
+	var drillURL string
+	if appendix != nil {
+		drills, drillURL = appendix.Drills, appendix.BaseURL
+	}
+	content, err := json.Marshal(struct {
+		Version     int                      `json:"version"`
+		Title       string                   `json:"title"`
+		Description string                   `json:"description"`
+		Table       models.Table             `json:"table"`
+		Duration    *models.DurationEstimate `json:"duration"`
+		Horizontal  bool                     `json:"horizontal"`
+		LargeFont   bool                     `json:"large_font"`
+		Language    models.Language          `json:"language"`
+		BaseURL     string                   `json:"base_url"`
+		Drills      []models.Drill           `json:"drills,omitempty"`
+		DrillURL    string                   `json:"drill_url,omitempty"`
+		Layout      models.PDFLayout         `json:"layout,omitempty"`
+	}{layoutVersion, plan.Title, plan.Description, plan.Table, duration, ho, lf, lang, baseURL, drills, drillURL, name})
+	if err != nil {
+		return "", fmt.Errorf("error encoding PDF content: %w", err)
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// CachedStoragePath returns the storage path of the PDF with the cache key.
+func CachedStoragePath(key, title string) string {
+	return path.Join("cache", key, Filename(title))
+}
+
+// UploadCachedPDF returns a signed URL of the PDF stored under the object name.
+// The PDF is only generated and uploaded if the object does not exist yet. The
+// returned flag tells whether an existing object was reused.
+func UploadCachedPDF(ctx context.Context, store blob.BlobStore, objectName string, generate func() ([]byte, error)) (string, bool, error) {
+	exists, err := store.Exists(ctx, objectName)
+	if err != nil {
+		return "", false, err
+	}
+	if exists {
+		uri, err := store.SignedURL(ctx, objectName, signedURLExpiry)
+		return uri, true, err
+	}
+
+	pdfData, err := generate()
+	if err != nil {
+		return "", false, err
+	}
+	uri, err := UploadPDF(ctx, store, objectName, pdfData)
+	return uri, false, err
+}
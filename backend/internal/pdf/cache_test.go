@@ -0,0 +1,90 @@
+package pdf_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/blob"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheKey(t *testing.T) {
+	plan := &models.Plan{
+		Title: "Sprint Set",
+		Table: models.Table{
+			{Amount: 4, Multiplier: "x", Distance: 50, Break: models.Rest(30), Content: "Kraul", Intensity: "GA2", Sum: 200},
+		},
+	}
+	key, err := pdf.CacheKey(plan, nil, "", nil, false, false, models.LanguageDE, "")
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+
+	same, err := pdf.CacheKey(&models.Plan{Title: plan.Title, Table: plan.Table}, nil, "", nil, false, false, models.LanguageDE, "")
+	require.NoError(t, err)
+	assert.Equal(t, key, same, "same content must produce the same key")
+
+	standard, err := pdf.CacheKey(plan, nil, models.PDFLayoutStandard, nil, false, false, models.LanguageDE, "")
+	require.NoError(t, err)
+	assert.Equal(t, key, standard, "the standard layout must produce the key of no layout")
+
+	variants := map[string]func() (string, error){
+		"title": func() (string, error) {
+			return pdf.CacheKey(&models.Plan{Title: "Other", Table: plan.Table}, nil, "", nil, false, false, models.LanguageDE, "")
+		},
+		"table": func() (string, error) {
+			table := models.Table{{Amount: 2, Multiplier: "x", Distance: 50, Break: models.Rest(30), Content: "Kraul", Intensity: "GA2", Sum: 100}}
+			return pdf.CacheKey(&models.Plan{Title: plan.Title, Table: table}, nil, "", nil, false, false, models.LanguageDE, "")
+		},
+		"horizontal": func() (string, error) { return pdf.CacheKey(plan, nil, "", nil, true, false, models.LanguageDE, "") },
+		"large font": func() (string, error) { return pdf.CacheKey(plan, nil, "", nil, false, true, models.LanguageDE, "") },
+		"language":   func() (string, error) { return pdf.CacheKey(plan, nil, "", nil, false, false, models.LanguageEN, "") },
+		"base url": func() (string, error) {
+			return pdf.CacheKey(plan, nil, "", nil, false, false, models.LanguageDE, "https://swim.example")
+		},
+		"layout": func() (string, error) {
+			return pdf.CacheKey(plan, nil, models.PDFLayoutPoolside, nil, false, false, models.LanguageDE, "")
+		},
+		"drill appendix": func() (string, error) {
+			appendix := &pdf.DrillAppendix{Drills: []models.Drill{{Title: "Seestern", ImgName: "seestern.webp"}}}
+			return pdf.CacheKey(plan, nil, "", appendix, false, false, models.LanguageDE, "")
+		},
+	}
+	for name, variant := range variants {
+		t.Run(name, func(t *testing.T) {
+			other, err := variant()
+			require.NoError(t, err)
+			assert.NotEqual(t, key, other)
+		})
+	}
+}
+
+func TestCachedStoragePath(t *testing.T) {
+	assert.Equal(t, "cache/abc/technik-tueftler.pdf", pdf.CachedStoragePath("abc", "Technik-Tüftler"))
+	assert.Equal(t, "cache/abc/training-plan.pdf", pdf.CachedStoragePath("abc", ""))
+}
+
+func TestUploadCachedPDFReusesObject(t *testing.T) {
+	store, err := blob.NewFileStore(t.TempDir(), "http://localhost:8080", []byte("secret"), 0)
+	require.NoError(t, err)
+
+	generated := 0
+	generate := func() ([]byte, error) {
+		generated++
+		return []byte("%PDF-1.4"), nil
+	}
+
+	uri, cached, err := pdf.UploadCachedPDF(context.Background(), store, "cache/abc/plan.pdf", generate)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.True(t, strings.HasPrefix(uri, "http://localhost:8080/files/"))
+
+	uri, cached, err = pdf.UploadCachedPDF(context.Background(), store, "cache/abc/plan.pdf", generate)
+	require.NoError(t, err)
+	assert.True(t, cached)
+	assert.True(t, strings.HasPrefix(uri, "http://localhost:8080/files/"))
+	assert.Equal(t, 1, generated, "the cached PDF must not be generated again")
+}
@@ -0,0 +1,188 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/johnfercher/maroto/v2/pkg/components/code"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	mimage "github.com/johnfercher/maroto/v2/pkg/components/image"
+	"github.com/johnfercher/maroto/v2/pkg/components/page"
+	"github.com/johnfercher/maroto/v2/pkg/components/row"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/consts/extension"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	markdownLinkRegex = regexp.MustCompile(`\[[^\]]+\]\(([^)]+)\)`)
+	// drillURLRegex matches the drill links of generated plans, e.g. /drill/{id}
+	// or https://swim-gen.com/drills/{id}, like the frontend does.
+	drillURLRegex = regexp.MustCompile(`(?:^|/)drills?/([^/?#]+)`)
+)
+
+// DrillAppendix describes the drills linked in the plans of a PDF on an extra
+// page. Links are useless on paper, so every drill gets a QR code to its page.
+type DrillAppendix struct {
+	Drills []models.Drill
+	// ImageDir holds the drill images named by Drill.ImgName, drills without
+	// an image file are printed without image.
+	ImageDir string
+	// BaseURL is the URL of the frontend the QR codes link to, without it the
+	// QR codes are left out.
+	BaseURL string
+}
+
+// ReferencedDrills returns the ids of the drills linked in the row contents of
+// the tables, in the order of their first link.
+func ReferencedDrills(tables ...models.Table) []string {
+	var ids []string
+	var collect func(rows []models.Row)
+	collect = func(rows []models.Row) {
+		for _, r := range rows {
+			for _, link := range markdownLinkRegex.FindAllStringSubmatch(r.Content, -1) {
+				match := drillURLRegex.FindStringSubmatch(link[1])
+				if match == nil {
+					continue
+				}
+				id := drillID(match[1])
+				if !slices.Contains(ids, id) {
+					ids = append(ids, id)
+				}
+			}
+			collect(r.SubRows)
+		}
+	}
+	for _, table := range tables {
+		collect(table)
+	}
+	return ids
+}
+
+// drillID strips the image extension of the img_name of a drill.
+func drillID(imgName string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(imgName, ".png"), ".webp")
+}
+
+// DrillURL returns the URL of the page of the drill in the frontend.
+func DrillURL(baseURL string, drill models.Drill) string {
+	return strings.TrimSuffix(baseURL, "/") + "/drill/" + drillID(drill.ImgName)
+}
+
+// addDrillAppendix adds a new page describing the drills of the appendix. It
+// adds nothing if the appendix is nil or empty.
+func addDrillAppendix(m core.Maroto, appendix *DrillAppendix, l layout, lang models.Language) {
+	if appendix == nil || len(appendix.Drills) == 0 {
+		return
+	}
+	rows := []core.Row{row.New().Add(col.New().Add(text.New(drillLabels(lang).appendix, l.title)))}
+	rows = append(rows, getDrillRows(appendix, l.gridSize/25, l.largeFont, lang)...)
+	m.AddPages(page.New().Add(rows...))
+}
+
+// getDrillRows describes every drill with its title, image, short description,
+// QR code and targets. The columns take unit grid columns per 25th of the page.
+func getDrillRows(appendix *DrillAppendix, unit int, lf bool, lang models.Language) []core.Row {
+	drillTitleProps := props.Text{Size: 12, Style: fontstyle.Bold, Top: 4, Bottom: 1, VerticalPadding: 1}
+	descriptionProps := props.Text{Size: 10, Top: 1, Left: 2, Right: 2, VerticalPadding: 1}
+	targetProps := props.Text{Size: 10, Style: fontstyle.Italic, Top: 1, Bottom: 2, VerticalPadding: 1}
+	imageProps := props.Rect{Center: true, Percent: 95}
+	// The image, description and QR code share the grid of the layout in the ratio 8:12:5
+	height := 35.0
+	if lf {
+		drillTitleProps.Size = 16
+		descriptionProps.Size = 14
+		targetProps.Size = 14
+		height = 45
+	}
+
+	labels := drillLabels(lang)
+	var rows []core.Row
+	for i, drill := range appendix.Drills {
+		rows = append(rows, row.New().Add(col.New().Add(text.New(fmt.Sprintf("%d. %s", i+1, drill.Title), drillTitleProps))))
+
+		imageCol := col.New(8 * unit)
+		if img, err := loadDrillImage(appendix.ImageDir, drill.ImgName); err == nil {
+			imageCol = mimage.NewFromBytesCol(8*unit, img, extension.Jpg, imageProps)
+		}
+		qrCol := col.New(5 * unit)
+		if appendix.BaseURL != "" {
+			qrCol = code.NewQrCol(5*unit, DrillURL(appendix.BaseURL, drill), imageProps)
+		}
+		rows = append(rows, row.New(height).Add(imageCol, text.NewCol(12*unit, drill.ShortDescription, descriptionProps), qrCol))
+
+		if len(drill.Targets) > 0 {
+			targets := fmt.Sprintf("%s: %s", labels.targets, strings.Join(drill.Targets, ", "))
+			rows = append(rows, row.New().Add(col.New().Add(text.New(targets, targetProps))))
+		}
+	}
+	return rows
+}
+
+// drillImageSize is the maximum width and height of drill images in pixels,
+// enough for print at the size of the appendix while keeping PDFs small.
+const drillImageSize = 400
+
+// loadDrillImage reads the image of the drill from the directory and converts
+// it to a downscaled JPEG, since PDFs can not embed the WebP images of the drills.
+func loadDrillImage(dir, imgName string) ([]byte, error) {
+	if dir == "" || imgName == "" {
+		return nil, fmt.Errorf("no drill image")
+	}
+	name := filepath.Base(imgName)
+	if filepath.Ext(name) == "" {
+		name += ".webp"
+	}
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("error reading drill image: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var src image.Image
+	if filepath.Ext(name) == ".webp" {
+		src, err = webp.Decode(f)
+	} else {
+		src, _, err = image.Decode(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error decoding drill image: %w", err)
+	}
+
+	// Draw on white, transparent parts would turn black in a JPEG
+	bounds := src.Bounds()
+	scale := min(1, float64(drillImageSize)/float64(max(bounds.Dx(), bounds.Dy())))
+	dst := image.NewRGBA(image.Rect(0, 0, max(int(float64(bounds.Dx())*scale), 1), max(int(float64(bounds.Dy())*scale), 1)))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("error encoding drill image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type drillText struct {
+	appendix, targets string
+}
+
+func drillLabels(lang models.Language) drillText {
+	if lang == models.LanguageDE {
+		return drillText{appendix: "Übungen", targets: "Ziele"}
+	}
+	return drillText{appendix: "Drills", targets: "Targets"}
+}
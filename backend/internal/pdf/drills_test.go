@@ -0,0 +1,73 @@
+package pdf_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPlanPDFWithDrills(t *testing.T) {
+	plan := &models.Plan{
+		Title: "Wassergefühl",
+		Table: models.Table{
+			{Amount: 4, Multiplier: "x", Distance: 25, Break: models.Rest(20), Content: "[Seestern](/drill/seestern) und gleiten", Intensity: "GA1", Sum: 100},
+			{Content: "Gesamt", Sum: 100},
+		},
+	}
+	appendix := &pdf.DrillAppendix{
+		Drills: []models.Drill{{
+			Title:            "Der Seestern",
+			ImgName:          "seestern.webp",
+			ShortDescription: "Übung zur Erfahrung des Wasserwiderstands und der Gleitfähigkeit.",
+			Targets:          []string{"Gleiten", "Wasserwiderstand", "Wassergefühl"},
+		}},
+		ImageDir: "../../../data/images",
+		BaseURL:  "https://swim-gen.com",
+	}
+
+	withoutDrills, err := pdf.PlanToPDF(plan, nil, false, false, models.LanguageDE, "")
+	require.NoError(t, err)
+
+	for _, lf := range []bool{false, true} {
+		planPDF, err := pdf.ExportPlanPDF(plan, nil, models.PDFLayoutStandard, appendix, false, lf, models.LanguageDE, "")
+		require.NoError(t, err, "ExportPlanPDF should not return an error")
+		assert.Greater(t, len(planPDF), len(withoutDrills), "the appendix should embed the image and QR code")
+
+		if os.Getenv("GENERATE_PDF") != "" {
+			filename := "test_drill_appendix.pdf"
+			if lf {
+				filename = "test_drill_appendix_lf.pdf"
+			}
+			assert.NoError(t, writePDF(filename, planPDF))
+		}
+	}
+
+	// Missing images and an empty base URL leave out image and QR code
+	appendix.ImageDir, appendix.BaseURL = t.TempDir(), ""
+	_, err = pdf.ExportPlanPDF(plan, nil, models.PDFLayoutStandard, appendix, false, false, models.LanguageEN, "")
+	assert.NoError(t, err)
+}
+
+func TestDrillURL(t *testing.T) {
+	assert.Equal(t, "https://swim-gen.com/drill/seestern", pdf.DrillURL("https://swim-gen.com/", models.Drill{ImgName: "seestern.webp"}))
+	assert.Equal(t, "/drill/zipper", pdf.DrillURL("", models.Drill{ImgName: "zipper.png"}))
+}
+
+func TestReferencedDrills(t *testing.T) {
+	table := models.Table{
+		{Content: "[Catch-up](/drill/catch-up) und [Abschlag](https://swim-gen.com/drills/abschlag.png)"},
+		{Content: "Siehe [Video](https://example.com/video)", SubRows: []models.Row{
+			{Content: "[Catch-up](drill/catch-up)"},
+			{Content: "[Sculling](/drill/sculling?lang=de)"},
+		}},
+		{Content: "Gesamt"},
+	}
+	other := models.Table{{Content: "[Zipper](/drill/zipper.webp)"}}
+
+	assert.Equal(t, []string{"catch-up", "abschlag", "sculling", "zipper"}, pdf.ReferencedDrills(table, other))
+	assert.Empty(t, pdf.ReferencedDrills(models.Table{{Content: "Kraul"}}))
+}
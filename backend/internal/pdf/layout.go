@@ -0,0 +1,282 @@
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/row"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/config"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/border"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/consts/orientation"
+	"github.com/johnfercher/maroto/v2/pkg/consts/pagesize"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+)
+
+var (
+	black         = &props.Color{Red: 0, Green: 0, Blue: 0}
+	white         = &props.Color{Red: 255, Green: 255, Blue: 255}
+	darkGray      = &props.Color{Red: 200, Green: 200, Blue: 200}
+	lightGray     = &props.Color{Red: 240, Green: 240, Blue: 240}
+	veryLightGray = &props.Color{Red: 248, Green: 248, Blue: 248}
+)
+
+// layout is the page setup and the text styles of a plan PDF.
+type layout struct {
+	pageSize   pagesize.Type // pageSize defaults to A4
+	horizontal bool
+	sideMargin float64
+	topMargin  float64
+	gridSize   int
+	// largeFont enlarges the texts of the drill appendix
+	largeFont   bool
+	title       props.Text
+	label       props.Text
+	description props.Text
+	table       tableStyle
+}
+
+// tableStyle is the look of the plan table.
+type tableStyle struct {
+	widths columnWidths
+	// header is used for the header and footer cells
+	header props.Text
+	cell   props.Text
+	// Cell styles of the header and footer, of rows at even and odd index and
+	// of sub rows at odd index. They may be nil.
+	headerRow    *props.Cell
+	row          *props.Cell
+	alternateRow *props.Cell
+	alternateSub *props.Cell
+	// splits is the number of empty columns right of the volume, in which
+	// coaches note split times
+	splits     int
+	splitWidth int
+	// lanes is the number of copies of the table side by side, separated by
+	// laneGap
+	lanes   int
+	laneGap int
+}
+
+// getLayout returns the layout with the name. Horizontal and large font only
+// apply to the standard layout, the other layouts have a fixed page setup.
+func getLayout(name models.PDFLayout, ho, lf bool) layout {
+	switch name {
+	case models.PDFLayoutCompact:
+		return compactLayout()
+	case models.PDFLayoutPoolside:
+		return poolsideLayout()
+	case models.PDFLayoutCoach:
+		return coachLayout()
+	case models.PDFLayoutTwoLane:
+		return twoLaneLayout()
+	default:
+		return standardLayout(ho, lf)
+	}
+}
+
+// standardLayout is the A4 layout used by all PDFs without a named layout.
+func standardLayout(ho, lf bool) layout {
+	l := layout{
+		horizontal:  ho,
+		sideMargin:  10,
+		topMargin:   15,
+		gridSize:    25,
+		largeFont:   lf,
+		title:       props.Text{Size: 18, Style: fontstyle.Bold, Align: align.Center, Bottom: 6, VerticalPadding: 2},
+		label:       props.Text{Size: 10, Style: fontstyle.Bold, Top: 10, Bottom: 2, VerticalPadding: 1},
+		description: props.Text{Size: 10, Style: fontstyle.Italic, Bottom: 4, VerticalPadding: 2},
+		table:       getTableStyle(lf),
+	}
+	if ho {
+		l.topMargin = 5
+	}
+	if lf {
+		l.gridSize = 100
+		l.title.Size = 22
+		l.title.Bottom = 8
+		l.label.Size = 14
+		l.label.Top = 12
+		l.description.Size = 14
+	}
+	return l
+}
+
+// getTableStyle returns the table style of the standard layout.
+func getTableStyle(lf bool) tableStyle {
+	st := tableStyle{
+		widths:       getColumnWidths(lf),
+		header:       props.Text{Style: fontstyle.Bold, Align: align.Center, Top: 2, Bottom: 2, VerticalPadding: 1},
+		cell:         props.Text{Align: align.Center, Top: 2, Bottom: 2, VerticalPadding: 1},
+		headerRow:    &props.Cell{BackgroundColor: darkGray},
+		alternateRow: &props.Cell{BackgroundColor: lightGray},
+		alternateSub: &props.Cell{BackgroundColor: veryLightGray},
+	}
+	if lf {
+		st.header.Top = 3
+		st.header.Bottom = 3
+		st.header.Size = 12
+		st.header.VerticalPadding = 1.5
+		st.cell.Size = 16
+		st.cell.Top = 3
+		st.cell.Bottom = 3
+		st.cell.VerticalPadding = 1.5
+	}
+	return st
+}
+
+// compactLayout fits the plan on an A5 card with small print.
+func compactLayout() layout {
+	l := standardLayout(false, false)
+	l.pageSize = pagesize.A5
+	l.sideMargin = 6
+	l.topMargin = 6
+	l.title.Size = 13
+	l.title.Bottom = 3
+	l.label.Size = 8
+	l.label.Top = 5
+	l.label.Bottom = 1
+	l.description.Size = 8
+	l.description.Bottom = 2
+	l.table.header = props.Text{Size: 7, Style: fontstyle.Bold, Align: align.Center, Top: 1, Bottom: 1, VerticalPadding: 0.5}
+	l.table.cell = props.Text{Size: 7, Align: align.Center, Top: 1, Bottom: 1, VerticalPadding: 0.5}
+	return l
+}
+
+// poolsideLayout is a high contrast sheet to read from the water: large bold
+// black text on white, divided by black lines instead of gray backgrounds.
+func poolsideLayout() layout {
+	l := standardLayout(false, true)
+	l.title.Size = 26
+	l.label.Size = 16
+	l.description.Size = 16
+	l.description.Style = fontstyle.BoldItalic
+	l.table.header.Size = 14
+	l.table.header.Color = white
+	l.table.cell.Size = 18
+	l.table.cell.Style = fontstyle.Bold
+	divider := &props.Cell{BorderType: border.Bottom, BorderColor: black, BorderThickness: 0.6}
+	l.table.headerRow = &props.Cell{BackgroundColor: black}
+	l.table.row = divider
+	l.table.alternateRow = divider
+	l.table.alternateSub = divider
+	return l
+}
+
+// coachLayout is a landscape sheet with empty split time columns and room to
+// write in every row.
+func coachLayout() layout {
+	l := standardLayout(true, false)
+	l.gridSize = 50
+	l.table.widths = columnWidths{amount: 4, multiplier: 1, distance: 4, breakTime: 4, description: 13, intensity: 4, volume: 4}
+	l.table.cell.Top = 4
+	l.table.cell.Bottom = 4
+	l.table.splits = 4
+	l.table.splitWidth = 4
+	return l
+}
+
+// twoLaneLayout prints the plan twice side by side on a landscape page, one
+// copy for each lane, to be cut apart.
+func twoLaneLayout() layout {
+	l := standardLayout(true, false)
+	l.sideMargin = 8
+	l.gridSize = 50
+	l.title.Size = 14
+	l.title.Bottom = 3
+	l.label.Size = 9
+	l.label.Top = 6
+	l.description.Size = 9
+	l.description.Bottom = 2
+	l.table.widths = columnWidths{amount: 3, multiplier: 1, distance: 3, breakTime: 3, description: 8, intensity: 3, volume: 3}
+	l.table.header = props.Text{Size: 8, Style: fontstyle.Bold, Align: align.Center, Top: 1.5, Bottom: 1.5, VerticalPadding: 1}
+	l.table.cell = props.Text{Size: 8, Align: align.Center, Top: 1.5, Bottom: 1.5, VerticalPadding: 1}
+	l.table.lanes = 2
+	l.table.laneGap = 2
+	return l
+}
+
+// config returns the maroto configuration of the page setup.
+func (l layout) config() config.Builder {
+	cfg := config.NewBuilder().
+		WithMaxGridSize(l.gridSize).
+		WithLeftMargin(l.sideMargin).
+		WithTopMargin(l.topMargin).
+		WithBottomMargin(l.topMargin).
+		WithRightMargin(l.sideMargin)
+
+	if l.pageSize != "" {
+		cfg = cfg.WithPageSize(l.pageSize)
+	}
+	if l.horizontal {
+		cfg = cfg.WithOrientation(orientation.Horizontal)
+	}
+
+	return cfg
+}
+
+// textRow returns a row with the text spanning the table of every lane.
+func (l layout) textRow(value string, p props.Text) core.Row {
+	return l.table.newRow(nil, func() []core.Col {
+		return []core.Col{col.New(l.table.width()).Add(text.New(value, p))}
+	})
+}
+
+// width is the number of grid columns of one copy of the table.
+func (st tableStyle) width() int {
+	w := st.widths
+	return w.amount + w.multiplier + w.distance + w.breakTime + w.description + w.intensity + w.volume + st.splits*st.splitWidth
+}
+
+// newRow returns a row holding the columns once for every lane. The style is
+// applied to the whole row, or with several lanes to the columns of each lane,
+// so the gap between the lanes stays blank.
+func (st tableStyle) newRow(style *props.Cell, cols func() []core.Col) core.Row {
+	r := row.New()
+	if st.lanes < 2 {
+		r.Add(cols()...)
+		if style != nil {
+			r.WithStyle(style)
+		}
+		return r
+	}
+
+	for lane := range st.lanes {
+		if lane > 0 {
+			r.Add(col.New(st.laneGap))
+		}
+		for _, c := range cols() {
+			if style != nil {
+				c.WithStyle(style)
+			}
+			r.Add(c)
+		}
+	}
+	return r
+}
+
+// splitCols returns the split time columns of a table row, framed for writing
+// or with the labels of the header.
+func (st tableStyle) splitCols(header bool, lang models.Language) []core.Col {
+	frame := &props.Cell{BorderType: border.Full, BorderColor: darkGray, BorderThickness: 0.3}
+	cols := make([]core.Col, st.splits)
+	for i := range cols {
+		if header {
+			cols[i] = text.NewCol(st.splitWidth, splitLabel(i+1, lang), st.header)
+		} else {
+			cols[i] = col.New(st.splitWidth).WithStyle(frame)
+		}
+	}
+	return cols
+}
+
+func splitLabel(n int, lang models.Language) string {
+	if lang == models.LanguageDE {
+		return fmt.Sprintf("Zeit %d", n)
+	}
+	return fmt.Sprintf("Split %d", n)
+}
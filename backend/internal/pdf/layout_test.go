@@ -0,0 +1,120 @@
+package pdf_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+var (
+	pdfStreamRegex   = regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`)
+	pdfMediaBoxRegex = regexp.MustCompile(`/MediaBox \[[^\]]*\]`)
+)
+
+// pdfLayout returns the page sizes and the drawing operations of the pages.
+// Unlike the PDF bytes they do not depend on the order of the font objects.
+func pdfLayout(document []byte) string {
+	var sb strings.Builder
+	for _, mediaBox := range pdfMediaBoxRegex.FindAll(document, -1) {
+		sb.Write(mediaBox)
+		sb.WriteString("\n")
+	}
+	for i, stream := range pdfStreamRegex.FindAllSubmatch(document, -1) {
+		sb.WriteString("--- stream " + strconv.Itoa(i) + "\n")
+		sb.Write(stream[1])
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// assertGolden compares the layout of the PDF with the golden file, which is
+// written instead with -update.
+func assertGolden(t *testing.T, name string, document []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name+".golden")
+	got := pdfLayout(document)
+	if *update {
+		require.NoError(t, os.WriteFile(golden, []byte(got), 0644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err, "golden file missing, run the test with -update")
+	assert.Equal(t, string(want), got, "layout differs from %s, run the test with -update if the change is intended", golden)
+}
+
+func layoutTestPlan() *models.Plan {
+	return &models.Plan{
+		Title:       "Schwelle Kraul",
+		Description: "Gleichmäßiges Tempo halten.",
+		Table: models.Table{
+			{Amount: 1, Multiplier: "x", Distance: 400, Content: "Einschwimmen", Sum: 400},
+			{Amount: 3, Multiplier: "x", Distance: 200, Break: models.Rest(20), Content: "Technik", Intensity: "GA1", Sum: 600, SubRows: []models.Row{
+				{Amount: 1, Multiplier: "x", Distance: 100, Content: "[Abschlag](/drill/abschlagschwimmen)", Sum: 100},
+				{Amount: 1, Multiplier: "x", Distance: 100, Content: "Kraul", Sum: 100},
+			}},
+			{Amount: 8, Multiplier: "x", Distance: 100, Break: models.Rest(15), Content: "Kraul", Intensity: "GA2", Equipment: []models.EquipmentType{models.EquipmentPaddles}, Sum: 800},
+			{Amount: 1, Multiplier: "x", Distance: 200, Content: "Ausschwimmen", Intensity: "REKOM", Sum: 200},
+			{Content: "Gesamt", Sum: 2000},
+		},
+	}
+}
+
+func TestStandardLayoutGolden(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		ho, lf bool
+	}{
+		{"standard", false, false},
+		{"standard_horizontal", true, false},
+		{"standard_large_font", false, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := pdf.PlanToPDF(layoutTestPlan(), nil, tt.ho, tt.lf, models.LanguageDE, "")
+			require.NoError(t, err)
+			assertGolden(t, tt.name, document)
+		})
+	}
+}
+
+func TestLayoutGolden(t *testing.T) {
+	for _, layout := range []models.PDFLayout{
+		models.PDFLayoutCompact,
+		models.PDFLayoutPoolside,
+		models.PDFLayoutCoach,
+		models.PDFLayoutTwoLane,
+	} {
+		t.Run(string(layout), func(t *testing.T) {
+			document, err := pdf.ExportPlanPDF(layoutTestPlan(), nil, layout, nil, false, false, models.LanguageDE, "")
+			require.NoError(t, err)
+			if os.Getenv("GENERATE_PDF") != "" {
+				require.NoError(t, writePDF("test_layout_"+string(layout)+".pdf", document))
+			}
+			assertGolden(t, string(layout), document)
+		})
+	}
+}
+
+func TestLayoutIgnoresPageOptions(t *testing.T) {
+	// Only the standard layout can be turned and enlarged
+	plain, err := pdf.ExportPlanPDF(layoutTestPlan(), nil, models.PDFLayoutCompact, nil, false, false, models.LanguageDE, "")
+	require.NoError(t, err)
+	options, err := pdf.ExportPlanPDF(layoutTestPlan(), nil, models.PDFLayoutCompact, nil, true, true, models.LanguageDE, "")
+	require.NoError(t, err)
+	assert.Equal(t, pdfLayout(plain), pdfLayout(options))
+
+	standard, err := pdf.ExportPlanPDF(layoutTestPlan(), nil, models.PDFLayoutStandard, nil, true, false, models.LanguageDE, "")
+	require.NoError(t, err)
+	horizontal, err := pdf.PlanToPDF(layoutTestPlan(), nil, true, false, models.LanguageDE, "")
+	require.NoError(t, err)
+	assert.Equal(t, pdfLayout(horizontal), pdfLayout(standard))
+}
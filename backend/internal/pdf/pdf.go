@@ -1,29 +1,24 @@
 package pdf
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"io"
 	"path"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
+	"github.com/5pirit5eal/swim-gen/internal/blob"
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/google/uuid"
 	"github.com/johnfercher/maroto/v2"
 	"github.com/johnfercher/maroto/v2/pkg/components/col"
-	"github.com/johnfercher/maroto/v2/pkg/components/row"
 	"github.com/johnfercher/maroto/v2/pkg/components/text"
-	"github.com/johnfercher/maroto/v2/pkg/config"
 	"github.com/johnfercher/maroto/v2/pkg/consts/align"
 	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
-	"github.com/johnfercher/maroto/v2/pkg/consts/orientation"
 	"github.com/johnfercher/maroto/v2/pkg/core"
 	"github.com/johnfercher/maroto/v2/pkg/props"
 )
@@ -49,7 +44,7 @@ func parseMarkdownLinks(content, baseURL string, p props.Text) []core.Component
 func GenerateEasyReadablePDF(table *models.Table, ho bool, lang models.Language, baseURL string) ([]byte, error) {
 	m := getMaroto(ho, true)
 
-	m.AddRows(getRows(*table, true, lang, baseURL)...)
+	m.AddRows(getRows(*table, models.EstimateDuration(*table, nil), getTableStyle(true), lang, baseURL)...)
 
 	document, err := m.Generate()
 	if err != nil {
@@ -60,20 +55,19 @@ func GenerateEasyReadablePDF(table *models.Table, ho bool, lang models.Language,
 }
 
 func GenerateFullPDF(plan *models.Plan, ho bool, lang models.Language, baseURL string) ([]byte, error) {
-	return generatePlanPDF(plan, ho, false, lang, baseURL)
+	return generatePlanPDF(plan, nil, nil, standardLayout(ho, false), lang, baseURL)
 }
 
-func generatePlanPDF(plan *models.Plan, ho, largeFont bool, lang models.Language, baseURL string) ([]byte, error) {
-	m := getMaroto(ho, largeFont)
-	titleProps := props.Text{Size: 18, Style: fontstyle.Bold, Align: align.Center, Bottom: 6, VerticalPadding: 2}
-	if largeFont {
-		titleProps.Size = 22
-		titleProps.Bottom = 8
+func generatePlanPDF(plan *models.Plan, duration *models.DurationEstimate, appendix *DrillAppendix, l layout, lang models.Language, baseURL string) ([]byte, error) {
+	if duration == nil {
+		duration = models.EstimateDuration(plan.Table, nil)
 	}
+	m := maroto.New(l.config().Build())
 
-	m.AddAutoRow(col.New().Add(text.New(plan.Title, titleProps)))
-	m.AddRows(getRows(plan.Table, largeFont, lang, baseURL)...)
-	addPlanDescription(m, plan.Description, largeFont, lang)
+	m.AddRows(l.textRow(plan.Title, l.title))
+	m.AddRows(getRows(plan.Table, duration, l.table, lang, baseURL)...)
+	addPlanDescription(m, plan.Description, l, lang)
+	addDrillAppendix(m, appendix, l, lang)
 
 	document, err := m.Generate()
 	if err != nil {
@@ -87,11 +81,19 @@ func generatePlanPDF(plan *models.Plan, ho, largeFont bool, lang models.Language
 //
 // Uses maroto to create a PDF document with the plan data.
 // The PDF is returned as a byte slice, which can be saved to a file or sent to cloud storage.
-func PlanToPDF(plan *models.Plan, ho, lf bool, lang models.Language, baseURL string) ([]byte, error) {
-	return generatePlanPDF(plan, ho, lf, lang, baseURL)
+// The duration is printed in the footer, if it is nil it is estimated with the default pace.
+func PlanToPDF(plan *models.Plan, duration *models.DurationEstimate, ho, lf bool, lang models.Language, baseURL string) ([]byte, error) {
+	return generatePlanPDF(plan, duration, nil, standardLayout(ho, lf), lang, baseURL)
+}
+
+// Converts the given plan to a PDF like PlanToPDF with the named layout,
+// followed by a page describing the drills of the appendix if it is not nil.
+// Horizontal and large font only apply to the standard layout.
+func ExportPlanPDF(plan *models.Plan, duration *models.DurationEstimate, name models.PDFLayout, appendix *DrillAppendix, ho, lf bool, lang models.Language, baseURL string) ([]byte, error) {
+	return generatePlanPDF(plan, duration, appendix, getLayout(name, ho, lf), lang, baseURL)
 }
 
-func addPlanDescription(m core.Maroto, description string, largeFont bool, lang models.Language) {
+func addPlanDescription(m core.Maroto, description string, l layout, lang models.Language) {
 	if strings.TrimSpace(description) == "" {
 		return
 	}
@@ -101,62 +103,20 @@ func addPlanDescription(m core.Maroto, description string, largeFont bool, lang
 		label = "Trainernotizen"
 	}
 
-	labelProps := props.Text{Size: 10, Style: fontstyle.Bold, Top: 10, Bottom: 2, VerticalPadding: 1}
-	descriptionProps := props.Text{Size: 10, Style: fontstyle.Italic, Bottom: 4, VerticalPadding: 2}
-	if largeFont {
-		labelProps.Size = 14
-		labelProps.Top = 12
-		descriptionProps.Size = 14
-	}
-
-	m.AddAutoRow(col.New().Add(text.New(label, labelProps)))
-	m.AddAutoRow(col.New().Add(text.New(fmt.Sprintf("\"%s\"", description), descriptionProps)))
+	m.AddRows(l.textRow(label, l.label))
+	m.AddRows(l.textRow(fmt.Sprintf("\"%s\"", description), l.description))
 }
 
-// Uploads the given pdf to cloud storage and returns the URI of the uploaded file.
-func UploadPDF(ctx context.Context, serviceAccount, bucketName, objectName string, pdfData []byte) (string, error) {
-	// Creates a client.
-	client, err := storage.NewClient(ctx)
-	if err != nil {
-		return "", fmt.Errorf("storage.NewClient: %w", err)
-	}
-	defer func() { _ = client.Close() }()
-
-	// Creates a Bucket instance.
-	bucket := client.Bucket(bucketName)
-
-	buf := bytes.NewBuffer(pdfData)
-	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
-	defer cancel()
+// signedURLExpiry is the validity of the URLs of uploaded PDFs.
+const signedURLExpiry = 15 * time.Minute
 
-	// Creates an object writer.
-	wc := bucket.Object(objectName).NewWriter(ctx)
-	wc.ContentType = "application/pdf"
-
-	// Uploads the PDF data.
-	if _, err := io.Copy(wc, buf); err != nil {
-		return "", fmt.Errorf("io.Copy: %w", err)
-	}
-	// Close and flush the contents
-	err = wc.Close()
-	if err != nil {
-		return "", fmt.Errorf("Writer.Close: %w", err)
+// Uploads the given pdf to the blob store and returns a signed URL of the
+// uploaded file, valid for 15 minutes.
+func UploadPDF(ctx context.Context, store blob.BlobStore, objectName string, pdfData []byte) (string, error) {
+	if err := store.Put(ctx, objectName, "application/pdf", pdfData); err != nil {
+		return "", err
 	}
-
-	// Generate signed url (V4 Signing)
-	opts := storage.SignedURLOptions{
-		GoogleAccessID: serviceAccount,
-		Scheme:         storage.SigningSchemeV4,
-		Method:         "GET",
-		Expires:        time.Now().Add(15 * time.Minute),
-	}
-
-	url, err := bucket.SignedURL(objectName, &opts)
-	if err != nil {
-		return "", fmt.Errorf("storage.SignedURL: %w", err)
-	}
-
-	return url, nil
+	return store.SignedURL(ctx, objectName, signedURLExpiry)
 }
 
 func GenerateFilename() string {
@@ -164,11 +124,7 @@ func GenerateFilename() string {
 }
 
 func GenerateStoragePath(userID, planID, title string) string {
-	sanitizedTitle := sanitizeFilename(title)
-	if sanitizedTitle == "" {
-		sanitizedTitle = "training-plan"
-	}
-	filename := sanitizedTitle + ".pdf"
+	filename := Filename(title)
 
 	if userID != "" || planID != "" {
 		hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%s", userID, planID)))
@@ -179,6 +135,15 @@ func GenerateStoragePath(userID, planID, title string) string {
 	return GenerateFilename()
 }
 
+// Filename returns the file name of a PDF with the title.
+func Filename(title string) string {
+	sanitizedTitle := sanitizeFilename(title)
+	if sanitizedTitle == "" {
+		sanitizedTitle = "training-plan"
+	}
+	return sanitizedTitle + ".pdf"
+}
+
 func sanitizeFilename(name string) string {
 	name = strings.ToLower(name)
 
@@ -212,99 +177,76 @@ func sanitizeFilename(name string) string {
 }
 
 func getMaroto(ho, largeFont bool) core.Maroto {
-	gridSize := 25
-	if largeFont {
-		gridSize = 100
-	}
-
-	cfg := config.NewBuilder().
-		WithMaxGridSize(gridSize).
-		WithLeftMargin(10).
-		WithTopMargin(15).
-		WithBottomMargin(15).
-		WithRightMargin(10)
-
-	if ho {
-		cfg = cfg.WithTopMargin(5).WithBottomMargin(5).WithOrientation(orientation.Horizontal)
-	}
-
-	m := maroto.New(cfg.Build())
-	return m
+	return maroto.New(standardLayout(ho, largeFont).config().Build())
 }
 
-// Convert table rows to maroto rows
-// lf indicates if large font should be used
+// Convert table rows to maroto rows in the style of the layout
 // baseURL is prepended to relative URLs in markdown links
-func getRows(table models.Table, lf bool, lang models.Language, baseURL string) []core.Row {
+// The estimated duration is shown in the footer row
+func getRows(table models.Table, duration *models.DurationEstimate, st tableStyle, lang models.Language, baseURL string) []core.Row {
 	if len(table) < 2 {
 		return make([]core.Row, 0)
 	}
-	widths := getColumnWidths(lf)
-
-	// A row consists of 7 columns based on models.Row
-	headerRow := row.New()
-	headerProps := props.Text{Style: fontstyle.Bold, Align: align.Center, Top: 2, Bottom: 2, VerticalPadding: 1}
-	if lf {
-		headerProps.Top = 3
-		headerProps.Bottom = 3
-		headerProps.Size = 12
-		headerProps.VerticalPadding = 1.5
-	}
-	darkGray := &props.Color{Red: 200, Green: 200, Blue: 200}
-
-	for i, title := range table.Header(lang) {
-		switch i {
-		case 0:
-			headerRow.Add(text.NewCol(widths.amount, title, headerProps))
-		case 1:
-			headerRow.Add(text.NewCol(widths.multiplier, title, headerProps))
-		case 2:
-			headerRow.Add(text.NewCol(widths.distance, title, headerProps))
-		case 3:
-			headerRow.Add(text.NewCol(widths.breakTime, title, headerProps))
-		case 4:
-			headerRow.Add(text.NewCol(widths.description, title, headerProps))
-		case 5:
-			headerRow.Add(text.NewCol(widths.intensity, title, headerProps))
-		case 6:
-			headerRow.Add(text.NewCol(widths.volume, title, headerProps))
+	widths := st.widths
+	headerProps := st.header
+
+	// A row consists of 7 columns based on models.Row, followed by the split times
+	headerRow := st.newRow(st.headerRow, func() []core.Col {
+		cols := make([]core.Col, 0, 7+st.splits)
+		for i, title := range table.Header(lang) {
+			switch i {
+			case 0:
+				cols = append(cols, text.NewCol(widths.amount, title, headerProps))
+			case 1:
+				cols = append(cols, text.NewCol(widths.multiplier, title, headerProps))
+			case 2:
+				cols = append(cols, text.NewCol(widths.distance, title, headerProps))
+			case 3:
+				cols = append(cols, text.NewCol(widths.breakTime, title, headerProps))
+			case 4:
+				cols = append(cols, text.NewCol(widths.description, title, headerProps))
+			case 5:
+				cols = append(cols, text.NewCol(widths.intensity, title, headerProps))
+			case 6:
+				cols = append(cols, text.NewCol(widths.volume, title, headerProps))
+			}
 		}
-	}
-	headerRow.WithStyle(&props.Cell{BackgroundColor: darkGray})
+		return append(cols, st.splitCols(true, lang)...)
+	})
 
 	rows := []core.Row{headerRow}
-	p := props.Text{Align: align.Center, Top: 2, Bottom: 2, VerticalPadding: 1}
-	if lf {
-		p.Size = 16
-		p.Top = 3
-		p.Bottom = 3
-		p.VerticalPadding = 1.5
-	}
+	p := st.cell
 	rowIndex := 0
 	for i, content := range table {
 		// Skip the last row if it's a footer/total row
 		if i == len(table)-1 {
-			sloganProps := props.Text{Size: headerProps.Size, Align: align.Left, Top: p.Top, Bottom: p.Bottom, Left: 2, Style: fontstyle.BoldItalic, VerticalPadding: headerProps.VerticalPadding}
+			sloganProps := props.Text{Size: headerProps.Size, Align: align.Left, Top: p.Top, Bottom: p.Bottom, Left: 2, Style: fontstyle.BoldItalic, VerticalPadding: headerProps.VerticalPadding, Color: headerProps.Color}
+			durationProps := props.Text{Size: headerProps.Size, Align: align.Right, Top: p.Top, Bottom: p.Bottom, Right: 2, VerticalPadding: headerProps.VerticalPadding, Color: headerProps.Color}
 			footer := table.Footer(lang)
-			footerRow := row.New()
-			footerRow.Add(
-				text.NewCol(widths.amount+widths.multiplier+widths.distance, footer[0], sloganProps),
-				col.New(widths.breakTime+widths.description),
-				text.NewCol(widths.intensity, footer[4], headerProps),
-				text.NewCol(widths.volume, footer[6], headerProps),
-			).WithStyle(&props.Cell{BackgroundColor: darkGray})
+			footerRow := st.newRow(st.headerRow, func() []core.Col {
+				cols := []core.Col{
+					text.NewCol(widths.amount+widths.multiplier+widths.distance, footer[0], sloganProps),
+					text.NewCol(widths.breakTime+widths.description, duration.Label(lang), durationProps),
+					text.NewCol(widths.intensity, footer[4], headerProps),
+					text.NewCol(widths.volume, footer[6], headerProps),
+				}
+				if st.splits > 0 {
+					cols = append(cols, col.New(st.splits*st.splitWidth))
+				}
+				return cols
+			})
 			rows = append(rows, footerRow)
 			break
 		}
 
 		// Add main row
-		mainRow := createRow(content, p, lang, widths, rowIndex%2 == 1)
+		mainRow := createRow(content, st, lang, rowIndex%2 == 1)
 		rows = append(rows, mainRow)
 		rowIndex++
 
 		// Add subrows recursively
 		if len(content.SubRows) > 0 {
-			subRows := createSubRows(content.SubRows, p, lang, baseURL, widths, rowIndex)
+			subRows := createSubRows(content.SubRows, st, lang, baseURL, rowIndex)
 			rows = append(rows, subRows...)
 			// Update rowIndex based on number of subrows added
 			rowIndex += len(subRows)
@@ -313,7 +255,7 @@ func getRows(table models.Table, lf bool, lang models.Language, baseURL string)
 	return rows
 }
 
-// createRow creates a standard row for the PDF
+// columnWidths are the grid columns of the fields of a table row
 type columnWidths struct {
 	amount      int
 	multiplier  int
@@ -332,28 +274,27 @@ func getColumnWidths(largeFont bool) columnWidths {
 	return columnWidths{amount: 3, multiplier: 1, distance: 3, breakTime: 3, description: 9, intensity: 3, volume: 3}
 }
 
-func createRow(content models.Row, p props.Text, lang models.Language, widths columnWidths, alternateStyle bool) core.Row {
-	lightGray := &props.Color{Red: 240, Green: 240, Blue: 240}
-	row := row.New()
-	row.Add(col.New(widths.amount).Add(text.New(strconv.Itoa(content.Amount), p)))
-	row.Add(col.New(widths.multiplier).Add(text.New(content.Multiplier, p)))
-	row.Add(col.New(widths.distance).Add(text.New(strconv.Itoa(content.Distance), p)))
-	row.Add(col.New(widths.breakTime).Add(text.New(content.Break, p)))
-
-	// Parse markdown links in content and render as text/link components
-	contentCol := col.New(widths.description)
-	segments := parseMarkdownLinks(contentWithEquipment(content, lang), "", p)
-	contentCol.Add(segments...)
-	row.Add(contentCol)
-
-	row.Add(col.New(widths.intensity).Add(text.New(content.Intensity, p)))
-	row.Add(col.New(widths.volume).Add(text.New(strconv.Itoa(content.Sum), p)))
-
+// createRow creates a standard row for the PDF
+func createRow(content models.Row, st tableStyle, lang models.Language, alternateStyle bool) core.Row {
+	p, widths := st.cell, st.widths
+	style := st.row
 	if alternateStyle {
-		row.WithStyle(&props.Cell{BackgroundColor: lightGray})
-	}
-
-	return row
+		style = st.alternateRow
+	}
+
+	return st.newRow(style, func() []core.Col {
+		cols := []core.Col{
+			col.New(widths.amount).Add(text.New(strconv.Itoa(content.Amount), p)),
+			col.New(widths.multiplier).Add(text.New(content.Multiplier, p)),
+			col.New(widths.distance).Add(text.New(strconv.Itoa(content.Distance), p)),
+			col.New(widths.breakTime).Add(text.New(content.Break.String(), p)),
+			// Parse markdown links in content and render as text/link components
+			col.New(widths.description).Add(parseMarkdownLinks(contentWithEquipment(content, lang), "", p)...),
+			col.New(widths.intensity).Add(text.New(content.Intensity, p)),
+			col.New(widths.volume).Add(text.New(strconv.Itoa(content.Sum), p)),
+		}
+		return append(cols, st.splitCols(false, lang)...)
+	})
 }
 
 // aggregateSubRowContent recursively aggregates nested subrow content into a string
@@ -389,23 +330,12 @@ func aggregateSubRowContent(subRows []models.Row, lang models.Language, baseURL
 
 // createSubRows creates visual rows for subrows with indentation
 // Nested subrows are aggregated into the parent subrow's content instead of creating new rows
-func createSubRows(subRows []models.Row, p props.Text, lang models.Language, baseURL string, widths columnWidths, startRowIndex int) []core.Row {
+func createSubRows(subRows []models.Row, st tableStyle, lang models.Language, baseURL string, startRowIndex int) []core.Row {
 	rows := make([]core.Row, 0)
-	veryLightGray := &props.Color{Red: 248, Green: 248, Blue: 248}
+	p, widths := st.cell, st.widths
 
 	for i, subRow := range subRows {
-		row := row.New()
-		// Empty amount field for subrows
-		row.Add(col.New(widths.amount).Add(text.New("", p)))
-		// Empty multiplier field for subrows
-		row.Add(col.New(widths.multiplier).Add(text.New("", p)))
-		// Distance value in distance column
-		row.Add(col.New(widths.distance).Add(text.New(strconv.Itoa(subRow.Distance), p)))
-		// Empty break field for aggregated subrows
-		row.Add(col.New(widths.breakTime).Add(text.New("", p)))
-
 		// Content with indent indicator and aggregated nested subrow content
-		contentCol := col.New(widths.description)
 		indentText := contentWithEquipment(subRow, lang)
 		if len(subRow.SubRows) > 0 {
 			aggregated := aggregateSubRowContent(subRow.SubRows, lang, baseURL, p)
@@ -413,21 +343,29 @@ func createSubRows(subRows []models.Row, p props.Text, lang models.Language, bas
 				indentText = fmt.Sprintf("%s (%s)", indentText, aggregated)
 			}
 		}
-		segments := parseMarkdownLinks(indentText, baseURL, p)
-		contentCol.Add(segments...)
-		row.Add(contentCol)
-
-		// Empty intensity field for aggregated subrows
-		row.Add(col.New(widths.intensity).Add(text.New("", p)))
-		// Empty sum field for subrows
-		row.Add(col.New(widths.volume).Add(text.New("", p)))
 
 		// Alternate background colors for subrows
+		style := st.row
 		if (startRowIndex+i)%2 == 1 {
-			row.WithStyle(&props.Cell{BackgroundColor: veryLightGray})
+			style = st.alternateSub
 		}
 
-		rows = append(rows, row)
+		rows = append(rows, st.newRow(style, func() []core.Col {
+			cols := []core.Col{
+				// Empty amount and multiplier fields for subrows
+				col.New(widths.amount).Add(text.New("", p)),
+				col.New(widths.multiplier).Add(text.New("", p)),
+				// Distance value in distance column
+				col.New(widths.distance).Add(text.New(strconv.Itoa(subRow.Distance), p)),
+				// Empty break field for aggregated subrows
+				col.New(widths.breakTime).Add(text.New("", p)),
+				col.New(widths.description).Add(parseMarkdownLinks(indentText, baseURL, p)...),
+				// Empty intensity and sum fields for aggregated subrows
+				col.New(widths.intensity).Add(text.New("", p)),
+				col.New(widths.volume).Add(text.New("", p)),
+			}
+			return append(cols, st.splitCols(false, lang)...)
+		}))
 	}
 
 	return rows
@@ -1,6 +1,8 @@
 package pdf
 
 import (
+	"bytes"
+	"image/jpeg"
 	"testing"
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
@@ -65,3 +67,20 @@ func TestLargeFontColumnWidths(t *testing.T) {
 	assert.Equal(t, 43, large.description)
 	assert.Equal(t, 100, large.amount+large.multiplier+large.distance+large.breakTime+large.description+large.intensity+large.volume)
 }
+
+func TestLoadDrillImage(t *testing.T) {
+	img, err := loadDrillImage("../../../data/images", "seestern.webp")
+	if assert.NoError(t, err) {
+		decoded, err := jpeg.Decode(bytes.NewReader(img))
+		assert.NoError(t, err)
+		assert.Equal(t, drillImageSize, decoded.Bounds().Dx())
+	}
+
+	_, err = loadDrillImage("../../../data/images", "unknown.webp")
+	assert.Error(t, err)
+	_, err = loadDrillImage("", "seestern.webp")
+	assert.Error(t, err)
+	// Only the file name is used, so paths can not leave the directory
+	_, err = loadDrillImage("../../../data/images", "../images/seestern.webp")
+	assert.NoError(t, err)
+}
@@ -25,7 +25,6 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   200,
-			Break:      "",
 			Content:    "Einschwimmen",
 			Intensity:  "",
 			Sum:        200,
@@ -34,7 +33,7 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "Kraul-Beine m. Kurzflossen + Schnorchel jeweils 50m Streamline + 50m Schultern an der Wasseroberfläche halten",
 			Intensity:  "GA1",
 			Sum:        200,
@@ -43,7 +42,7 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   50,
-			Break:      "20",
+			Break:      models.Rest(20),
 			Content:    "Unterwasser-Sculling mit Schnorchel Beinarbeit-Timing beachten",
 			Intensity:  "TÜ",
 			Sum:        200,
@@ -52,7 +51,7 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   50,
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "Kraul Flossen, Paddles, Schnorchel 3 Del-Kicks Unterwasser + Züge zählen und „distance per stroke“",
 			Intensity:  "TÜ",
 			Sum:        200,
@@ -61,7 +60,7 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     6,
 			Multiplier: "x",
 			Distance:   30,
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "15m Kraul-WASSER-Start „Bursts“ + 15m lo.",
 			Intensity:  "S",
 			Sum:        180,
@@ -70,7 +69,6 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "",
 			Content:    "Locker schwimmen als aktive Pause",
 			Intensity:  "ReKom",
 			Sum:        100,
@@ -79,7 +77,7 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "60",
+			Break:      models.Rest(60),
 			Content:    "15m Spurt Breakout + 85m locker",
 			Intensity:  "S",
 			Sum:        100,
@@ -88,7 +86,7 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "120",
+			Break:      models.Rest(120),
 			Content:    "25m „easy-Speed-95%“ + 75m locker",
 			Intensity:  "S",
 			Sum:        100,
@@ -97,7 +95,7 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "180",
+			Break:      models.Rest(180),
 			Content:    "35m Spurt Tempoaufbau + 65m locker",
 			Intensity:  "S",
 			Sum:        100,
@@ -106,7 +104,6 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "",
 			Content:    "50m Spurt „alle Punkte umsetzen“ + 50m locker schwimmen als aktive Pause",
 			Intensity:  "S/WA",
 			Sum:        100,
@@ -115,7 +112,6 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   400,
-			Break:      "",
 			Content:    "Locker beliebig mit Kurzflossen",
 			Intensity:  "ReKom",
 			Sum:        400,
@@ -124,7 +120,6 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "",
 			Content:    "Kraul/Rücken-Beine",
 			Intensity:  "ReKom/GA1",
 			Sum:        400,
@@ -133,7 +128,6 @@ func TestTableToPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   200,
-			Break:      "",
 			Content:    "Ausschwimmen",
 			Intensity:  "ReKom",
 			Sum:        200,
@@ -176,7 +170,6 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   200,
-			Break:      "",
 			Content:    "Einschwimmen",
 			Intensity:  "",
 			Sum:        200,
@@ -185,7 +178,7 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "Kraul-Beine m. Kurzflossen + Schnorchel jeweils 50m Streamline + 50m Schultern an der Wasseroberfläche halten",
 			Intensity:  "GA1",
 			Sum:        200,
@@ -194,7 +187,7 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   50,
-			Break:      "20",
+			Break:      models.Rest(20),
 			Content:    "Unterwasser-Sculling mit Schnorchel Beinarbeit-Timing beachten",
 			Intensity:  "TÜ",
 			Sum:        200,
@@ -203,7 +196,7 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   50,
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "Kraul Flossen, Paddles, Schnorchel 3 Del-Kicks Unterwasser + Züge zählen und „distance per stroke“",
 			Intensity:  "TÜ",
 			Sum:        200,
@@ -212,7 +205,7 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     6,
 			Multiplier: "x",
 			Distance:   30,
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "15m Kraul-WASSER-Start „Bursts“ + 15m lo.",
 			Intensity:  "S",
 			Sum:        180,
@@ -221,7 +214,6 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "",
 			Content:    "Locker schwimmen als aktive Pause",
 			Intensity:  "ReKom",
 			Sum:        100,
@@ -230,7 +222,7 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "60",
+			Break:      models.Rest(60),
 			Content:    "15m Spurt Breakout + 85m locker",
 			Intensity:  "S",
 			Sum:        100,
@@ -239,7 +231,7 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "120",
+			Break:      models.Rest(120),
 			Content:    "25m „easy-Speed-95%“ + 75m locker",
 			Intensity:  "S",
 			Sum:        100,
@@ -248,7 +240,7 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "180",
+			Break:      models.Rest(180),
 			Content:    "35m Spurt Tempoaufbau + 65m locker",
 			Intensity:  "S",
 			Sum:        100,
@@ -257,7 +249,6 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "",
 			Content:    "50m Spurt „alle Punkte umsetzen“ + 50m locker schwimmen als aktive Pause",
 			Intensity:  "S/WA",
 			Sum:        100,
@@ -266,7 +257,6 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   400,
-			Break:      "",
 			Content:    "Locker beliebig mit Kurzflossen",
 			Intensity:  "ReKom",
 			Sum:        400,
@@ -275,7 +265,6 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "",
 			Content:    "Kraul/Rücken-Beine",
 			Intensity:  "ReKom/GA1",
 			Sum:        400,
@@ -284,7 +273,6 @@ func TestPlantoPDF(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   200,
-			Break:      "",
 			Content:    "Ausschwimmen",
 			Intensity:  "ReKom",
 			Sum:        200,
@@ -300,11 +288,11 @@ um dich optimal auf die Sprints vorzubereiten.`,
 		Table: table,
 	}
 
-	planPDF, err := pdf.PlanToPDF(plan, false, false, models.LanguageDE, "")
+	planPDF, err := pdf.PlanToPDF(plan, nil, false, false, models.LanguageDE, "")
 	assert.NoError(t, err, "PlanToPDF should not return an error")
 	assert.NotEmpty(t, planPDF, "PlanToPDF should return non-empty PDF bytes")
 
-	largeFontPDF, err := pdf.PlanToPDF(plan, false, true, models.LanguageDE, "")
+	largeFontPDF, err := pdf.PlanToPDF(plan, nil, false, true, models.LanguageDE, "")
 	assert.NoError(t, err, "PlanToPDF with large font should not return an error")
 	assert.NotEmpty(t, largeFontPDF, "PlanToPDF with large font should return non-empty PDF bytes")
 
@@ -395,7 +383,6 @@ func TestHyperlinks(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "",
 			Content:    "See [this drill](https://example.com/drill1) for technique",
 			Intensity:  "GA1",
 			Sum:        100,
@@ -405,7 +392,7 @@ func TestHyperlinks(t *testing.T) {
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   50,
-			Break:      "30",
+			Break:      models.Rest(30),
 			Content:    "Practice [drill A](/drills/a) and [drill B](/drills/b) alternating",
 			Intensity:  "TÜ",
 			Sum:        100,
@@ -415,7 +402,7 @@ func TestHyperlinks(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   75,
-			Break:      "20",
+			Break:      models.Rest(20),
 			Content:    "This is a very long instruction that should wrap to multiple lines in the PDF and then includes a hyperlink at the end [click here](/info)",
 			Intensity:  "GA1",
 			Sum:        300,
@@ -425,7 +412,6 @@ func TestHyperlinks(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   200,
-			Break:      "",
 			Content:    "Warm up slowly (see [video tutorial](/video) for guidance)",
 			Intensity:  "ReKom",
 			Sum:        200,
@@ -435,7 +421,7 @@ func TestHyperlinks(t *testing.T) {
 			Amount:     3,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "45",
+			Break:      models.Rest(45),
 			Content:    "Sprint section [details](/sprint)",
 			Intensity:  "S",
 			Sum:        300,
@@ -445,7 +431,7 @@ func TestHyperlinks(t *testing.T) {
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   150,
-			Break:      "60",
+			Break:      models.Rest(60),
 			Content:    "Check [form guide](/form) and maintain proper technique throughout",
 			Intensity:  "GA2",
 			Sum:        300,
@@ -455,7 +441,6 @@ func TestHyperlinks(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "",
 			Content:    "Cool down with easy swimming",
 			Intensity:  "ReKom",
 			Sum:        100,
@@ -465,7 +450,7 @@ func TestHyperlinks(t *testing.T) {
 			Amount:     5,
 			Multiplier: "x",
 			Distance:   50,
-			Break:      "15",
+			Break:      models.Rest(15),
 			Content:    "This exercise combines [technique A](/tech-a) with [technique B](/tech-b) for optimal results. Make sure to review both beforehand.",
 			Intensity:  "TÜ",
 			Sum:        250,
@@ -513,7 +498,7 @@ func TestHyperlinks(t *testing.T) {
 					Description: "Testing various hyperlink scenarios in PDF generation",
 					Table:       table,
 				}
-				pdfBytes, err = pdf.PlanToPDF(plan, tt.horizontal, false, models.LanguageEN, baseURL)
+				pdfBytes, err = pdf.PlanToPDF(plan, nil, tt.horizontal, false, models.LanguageEN, baseURL)
 			}
 
 			if err != nil {
@@ -560,7 +545,7 @@ func TestSubRows(t *testing.T) {
 			Amount:     8,
 			Multiplier: "x",
 			Distance:   1000, // Will be calculated from subrows
-			Break:      "60",
+			Break:      models.Rest(60),
 			Content:    "Hauptset - Ausdauer",
 			Intensity:  "GA2",
 			Sum:        8000,
@@ -569,7 +554,7 @@ func TestSubRows(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   800,
-					Break:      "30",
+					Break:      models.Rest(30),
 					Content:    "Kraul locker",
 					Intensity:  "GA1",
 					Sum:        800,
@@ -578,7 +563,6 @@ func TestSubRows(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   200,
-					Break:      "",
 					Content:    "Rücken Technik",
 					Intensity:  "TÜ",
 					Sum:        200,
@@ -590,7 +574,7 @@ func TestSubRows(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   400,
-			Break:      "45",
+			Break:      models.Rest(45),
 			Content:    "Sprint-Vorbereitung",
 			Intensity:  "GA2",
 			Sum:        1600,
@@ -599,7 +583,7 @@ func TestSubRows(t *testing.T) {
 					Amount:     2,
 					Multiplier: "x",
 					Distance:   150,
-					Break:      "20",
+					Break:      models.Rest(20),
 					Content:    "Kraul mit Flossen",
 					Intensity:  "GA2",
 					Sum:        300,
@@ -608,7 +592,6 @@ func TestSubRows(t *testing.T) {
 					Amount:     2,
 					Multiplier: "x",
 					Distance:   50,
-					Break:      "",
 					Content:    "Sprint Kraul",
 					Intensity:  "S",
 					Sum:        100,
@@ -620,7 +603,6 @@ func TestSubRows(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   400,
-			Break:      "",
 			Content:    "Locker ausschwimmen",
 			Intensity:  "ReKom",
 			Sum:        400,
@@ -630,7 +612,7 @@ func TestSubRows(t *testing.T) {
 			Amount:     3,
 			Multiplier: "x",
 			Distance:   600,
-			Break:      "90",
+			Break:      models.Rest(90),
 			Content:    "Technik-Block",
 			Intensity:  "TÜ",
 			Sum:        1800,
@@ -639,7 +621,7 @@ func TestSubRows(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   200,
-					Break:      "30",
+					Break:      models.Rest(30),
 					Content:    "Sculling",
 					Intensity:  "TÜ",
 					Sum:        200,
@@ -662,7 +644,7 @@ func TestSubRows(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   200,
-					Break:      "30",
+					Break:      models.Rest(30),
 					Content:    "Seitlage",
 					Intensity:  "TÜ",
 					Sum:        200,
@@ -671,7 +653,6 @@ func TestSubRows(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   200,
-					Break:      "",
 					Content:    "Atemtechnik",
 					Intensity:  "TÜ",
 					Sum:        200,
@@ -721,7 +702,7 @@ func TestSubRows(t *testing.T) {
 					Description: "Testing subrow rendering in PDF generation",
 					Table:       table,
 				}
-				pdfBytes, err = pdf.PlanToPDF(plan, tt.horizontal, false, models.LanguageDE, baseURL)
+				pdfBytes, err = pdf.PlanToPDF(plan, nil, tt.horizontal, false, models.LanguageDE, baseURL)
 			}
 
 			if err != nil {
@@ -760,7 +741,7 @@ func TestSubRowsWithHyperlinks(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   300,
-			Break:      "45",
+			Break:      models.Rest(45),
 			Content:    "Technik-Set mit Links [Anleitung](/technik)",
 			Intensity:  "TÜ",
 			Sum:        1200,
@@ -769,7 +750,7 @@ func TestSubRowsWithHyperlinks(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   100,
-					Break:      "20",
+					Break:      models.Rest(20),
 					Content:    "Kraul [Video](/video1)",
 					Intensity:  "GA1",
 					Sum:        100,
@@ -778,7 +759,7 @@ func TestSubRowsWithHyperlinks(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   100,
-					Break:      "20",
+					Break:      models.Rest(20),
 					Content:    "Rücken [Tutorial](/tutorial)",
 					Intensity:  "GA1",
 					Sum:        100,
@@ -787,7 +768,6 @@ func TestSubRowsWithHyperlinks(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   100,
-					Break:      "",
 					Content:    "Brust [Guide](/guide)",
 					Intensity:  "GA1",
 					Sum:        100,
@@ -798,7 +778,6 @@ func TestSubRowsWithHyperlinks(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   200,
-			Break:      "",
 			Content:    "Ausschwimmen",
 			Intensity:  "ReKom",
 			Sum:        200,
@@ -829,7 +808,7 @@ func TestDeeplyNestedSubRows(t *testing.T) {
 			Amount:     2,
 			Multiplier: "x",
 			Distance:   800,
-			Break:      "60",
+			Break:      models.Rest(60),
 			Content:    "Hauptset",
 			Intensity:  "GA2",
 			Sum:        1600,
@@ -838,7 +817,7 @@ func TestDeeplyNestedSubRows(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   400,
-					Break:      "30",
+					Break:      models.Rest(30),
 					Content:    "Ausdauer",
 					Intensity:  "GA1",
 					Sum:        400,
@@ -875,7 +854,6 @@ func TestDeeplyNestedSubRows(t *testing.T) {
 					Amount:     1,
 					Multiplier: "x",
 					Distance:   400,
-					Break:      "",
 					Content:    "Sprint",
 					Intensity:  "S",
 					Sum:        400,
@@ -886,7 +864,6 @@ func TestDeeplyNestedSubRows(t *testing.T) {
 			Amount:     1,
 			Multiplier: "x",
 			Distance:   200,
-			Break:      "",
 			Content:    "Ausschwimmen",
 			Intensity:  "ReKom",
 			Sum:        200,
@@ -911,3 +888,30 @@ func TestDeeplyNestedSubRows(t *testing.T) {
 	t.Log("Generated test_deeply_nested.pdf - please verify aggregated content format:")
 	t.Log("Expected: ↳ Ausdauer (200m Kraul (100m locker + 100m technisch) + 200m Rücken)")
 }
+
+func TestBlockToPDF(t *testing.T) {
+	table := models.Table{
+		{Amount: 1, Multiplier: "x", Distance: 400, Content: "Einschwimmen", Sum: 400},
+		{Amount: 8, Multiplier: "x", Distance: 100, Break: models.Rest(20), Content: "Kraul", Intensity: "GA2", Sum: 800},
+		{Content: "Gesamt", Sum: 1200},
+	}
+	block := &models.TrainingBlock{
+		Title:           "Bezirksmeisterschaften",
+		Goal:            "Bestzeit über 400m Freistil",
+		Weeks:           4,
+		SessionsPerWeek: 1,
+		Schedule:        models.Periodize(4, 3000),
+	}
+	for _, week := range block.Schedule {
+		block.Sessions = append(block.Sessions, models.BlockSession{
+			Week: week.Week, Session: 1, Phase: week.Phase, TargetVolume: week.Volume,
+			Title: "Kraul Schwelle", Description: "Gleichmäßiges Tempo halten.", Table: table,
+		})
+	}
+
+	for _, lf := range []bool{false, true} {
+		blockPDF, err := pdf.BlockToPDF(block, nil, false, lf, models.LanguageDE, "")
+		assert.NoError(t, err, "BlockToPDF should not return an error")
+		assert.NotEmpty(t, blockPDF, "BlockToPDF should return non-empty PDF bytes")
+	}
+}
@@ -0,0 +1,98 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/go-chi/httplog/v2"
+)
+
+// GetDifficultyCalibration learns from the difficulty ratings of the latest
+// swum plans of the user whether plans are too hard or too easy for them.
+func (db *RAGDB) GetDifficultyCalibration(ctx context.Context, userID string) (*models.DifficultyCalibration, error) {
+	logger := httplog.LogEntry(ctx)
+
+	feedback, err := db.GetAllFeedbackFromUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying feedback: %w", err)
+	}
+	feedback = calibrationFeedback(feedback)
+
+	planIDs := make([]string, len(feedback))
+	for i, f := range feedback {
+		planIDs[i] = f.PlanID
+	}
+	var plans []struct {
+		PlanID string       `db:"plan_id"`
+		Table  models.Table `db:"plan_table"`
+	}
+	if err := pgxscan.Select(ctx, db.Conn, &plans, fmt.Sprintf(`
+		SELECT plan_id::text AS plan_id, plan_table FROM %s WHERE plan_id = ANY($1::uuid[])
+	`, PlanTableName), planIDs); err != nil {
+		logger.Error("Error querying rated plans", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying rated plans: %w", err)
+	}
+	tables := make(map[string]models.Table, len(plans))
+	for _, plan := range plans {
+		tables[plan.PlanID] = plan.Table
+	}
+
+	return models.CalibrateDifficulty(calibrationSamples(feedback, tables)), nil
+}
+
+// calibrationFeedback returns the latest feedback on swum plans with a
+// difficulty rating, newest first.
+func calibrationFeedback(feedback []*models.Feedback) []*models.Feedback {
+	var result []*models.Feedback
+	for _, f := range feedback {
+		if f.WasSwam && f.DifficultyRating != nil {
+			result = append(result, f)
+		}
+	}
+	slices.SortFunc(result, func(a, b *models.Feedback) int { return b.UpdatedAt.Compare(a.UpdatedAt) })
+	return result[:min(len(result), models.MaxCalibrationSamples)]
+}
+
+// calibrationSamples pairs the ratings with the volume and intensity of the
+// rated plans. Plans without volume are skipped.
+func calibrationSamples(feedback []*models.Feedback, tables map[string]models.Table) []models.CalibrationSample {
+	var samples []models.CalibrationSample
+	for _, f := range feedback {
+		table, ok := tables[f.PlanID]
+		if !ok {
+			continue
+		}
+		volume, hardVolume := table.IntensityMix()
+		if volume == 0 {
+			continue
+		}
+		samples = append(samples, models.CalibrationSample{Difficulty: *f.DifficultyRating, Volume: volume, HardVolume: hardVolume})
+	}
+	return samples
+}
+
+// formatCalibration describes the load adjustment learned from the difficulty
+// ratings for the prompts, empty if plans need no adjustment.
+func formatCalibration(c *models.DifficultyCalibration) string {
+	if !c.IsAdjusting() {
+		return ""
+	}
+
+	feeling := "zu schwer"
+	change := "Reduziere"
+	if c.Verdict == models.CalibrationTooEasy {
+		feeling, change = "zu leicht", "Erhöhe"
+	}
+	formatted := fmt.Sprintf(`
+Persönliche Belastungskalibrierung aus %d geschwommenen und bewerteten Plänen:
+- Die bisherigen Pläne (Ø %dm, davon %.0f%% im Schwellenbereich oder schneller) waren %s (Ø Schwierigkeit %.1f von 10, Ziel %d).
+- %s die Belastung auf etwa das %.2f-fache: ohne vorgegebene Gesamtdistanz ca. %dm bei ähnlichem Intensitätsanteil.
+`, c.Samples, c.AverageVolume, c.AverageHardShare*100, feeling, c.AverageDifficulty, c.TargetDifficulty, change, c.LoadFactor, c.RecommendedVolume)
+	if c.Verdict == models.CalibrationTooHard && c.AverageHardShare >= 0.3 {
+		formatted += "- Verringere bevorzugt den Anteil intensiver Strecken.\n"
+	}
+	return formatted
+}
@@ -0,0 +1,55 @@
+package rag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibrationFeedbackUsesLatestSwumRatings(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	difficulty := 7
+	feedback := []*models.Feedback{
+		{PlanID: "old", WasSwam: true, DifficultyRating: &difficulty, UpdatedAt: now.AddDate(0, 0, -2)},
+		{PlanID: "not-swum", DifficultyRating: &difficulty, UpdatedAt: now},
+		{PlanID: "unrated", WasSwam: true, UpdatedAt: now},
+		{PlanID: "new", WasSwam: true, DifficultyRating: &difficulty, UpdatedAt: now.AddDate(0, 0, -1)},
+	}
+
+	result := calibrationFeedback(feedback)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, "new", result[0].PlanID)
+	assert.Equal(t, "old", result[1].PlanID)
+
+	samples := calibrationSamples(result, map[string]models.Table{
+		"new": {{Amount: 4, Distance: 100, Content: "Kraul", Intensity: "SA"}},
+		"old": {},
+	})
+	assert.Equal(t, []models.CalibrationSample{{Difficulty: 7, Volume: 400, HardVolume: 400}}, samples)
+}
+
+func TestFormatUserProfileIncludesCalibration(t *testing.T) {
+	profile := &models.UserProfile{Calibration: &models.DifficultyCalibration{
+		Samples:           6,
+		Verdict:           models.CalibrationTooHard,
+		TargetDifficulty:  6,
+		AverageDifficulty: 8.5,
+		AverageVolume:     3000,
+		AverageHardShare:  0.35,
+		LoadFactor:        0.8,
+		RecommendedVolume: 2400,
+	}}
+
+	formatted := (&RAGDB{}).FormatUserProfile(profile)
+
+	assert.Contains(t, formatted, "aus 6 geschwommenen und bewerteten Plänen")
+	assert.Contains(t, formatted, "Ø 3000m, davon 35% im Schwellenbereich oder schneller) waren zu schwer (Ø Schwierigkeit 8.5 von 10, Ziel 6)")
+	assert.Contains(t, formatted, "Reduziere die Belastung auf etwa das 0.80-fache: ohne vorgegebene Gesamtdistanz ca. 2400m")
+	assert.Contains(t, formatted, "Anteil intensiver Strecken")
+
+	profile.Calibration.Verdict = models.CalibrationBalanced
+	assert.NotContains(t, (&RAGDB{}).FormatUserProfile(profile), "Belastungskalibrierung")
+}
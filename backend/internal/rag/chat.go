@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/go-chi/httplog/v2"
@@ -15,42 +17,117 @@ import (
 var (
 	ErrChatPlanRequired = errors.New("chat plan is required")
 	ErrChatPlanNotFound = errors.New("chat plan not found")
+	ErrChatEditInvalid  = errors.New("only user messages can be edited")
 )
 
+// preferenceTimeout bounds the proposal of preferences, which runs after the
+// chat response was returned.
+const preferenceTimeout = time.Minute
+
 type chatDependencies struct {
 	getPlanForUser  func(context.Context, string, string) (*models.Plan, error)
 	getConversation func(context.Context, string, string) ([]models.Message, error)
+	getBranch       func(context.Context, string, string, string) ([]models.Message, error)
 	buildContext    func(context.Context, string, *models.Plan) ([]schema.Document, error)
 	queryMode       func()
-	chatRefine      func(context.Context, string, *models.Plan, string, string, any, []schema.Document) (*models.ChatResponse, error)
-	addMessage      func(context.Context, string, string, models.Role, string, *string, *models.Plan) (*models.Message, error)
-	upsertPlan      func(context.Context, models.Plan, string) (string, error)
+	chatRefine      func(context.Context, string, string, *models.Plan, string, string, string, any, *models.PlanConstraints, []schema.Document) (*models.ChatResponse, error)
+	// chatRetry asks the LLM again if the plan misses the constraints. It does not
+	// stream, as the first response was already sent to the client.
+	chatRetry      func(context.Context, string, string, *models.Plan, string, string, string, any, *models.PlanConstraints, []schema.Document) (*models.ChatResponse, error)
+	getUserProfile func(context.Context, string) (*models.UserProfile, error)
+	addMessage     func(context.Context, string, string, models.Role, string, *string, *models.Plan) (*models.Message, error)
+	branchMessage  func(context.Context, string, string, string) (*models.Message, error)
+	upsertPlan     func(context.Context, models.Plan, string) (string, error)
+	getSummary     func(context.Context, string, string) (*models.ConversationSummary, error)
+	summarize      func(context.Context, string, string) (string, error)
+	saveSummary    func(context.Context, *models.ConversationSummary) error
+	// proposePreferences stores the long-term preferences found in a user message
+	// as proposals for the user to confirm.
+	proposePreferences func(context.Context, string, string, string, string, *models.TrainingPreferences) ([]models.PreferenceProposal, error)
+	// background runs work that must not delay the chat response.
+	background func(func())
 }
 
 // ChatWithContext is the main stateless chat method for plan refinement through conversation.
 // It retrieves conversation history from memory, builds context, calls the LLM, and stores the interaction.
+// If editMessageID is set, userMessage replaces that user message on a new branch
+// of the conversation, which continues from the plan before the edited message.
 func (db *RAGDB) ChatWithContext(
 	ctx context.Context,
-	planID, userID, userMessage string,
+	planID, userID, userMessage, editMessageID string,
 	lang models.Language,
 	poolLength any,
+	constraints *models.PlanConstraints,
 ) (*models.Plan, *models.Message, error) {
-	return db.chatWithContext(ctx, planID, userID, userMessage, lang, poolLength, chatDependencies{
-		getPlanForUser:  db.GetPlanForUser,
-		getConversation: db.Memory.GetConversation,
-		buildContext:    db.buildChatContext,
-		queryMode:       db.Client.QueryMode,
-		chatRefine:      db.Client.ChatRefine,
-		addMessage:      db.Memory.AddMessage,
-		upsertPlan:      db.UpsertPlan,
-	})
+	return db.chatWithContext(ctx, planID, userID, userMessage, editMessageID, lang, poolLength, constraints, db.chatDependencies())
+}
+
+// ChatWithContextStream works like ChatWithContext but forwards the conversational
+// response text to onToken while it is generated. If the plan misses the
+// constraints and is generated again, onReplace receives the response of the
+// retry, which replaces the streamed text. The plan and messages are only
+// persisted once the complete response has been received and validated.
+func (db *RAGDB) ChatWithContextStream(
+	ctx context.Context,
+	planID, userID, userMessage, editMessageID string,
+	lang models.Language,
+	poolLength any,
+	constraints *models.PlanConstraints,
+	onToken, onReplace func(string) error,
+) (*models.Plan, *models.Message, error) {
+	deps := db.chatDependencies()
+	deps.chatRefine = func(ctx context.Context, summary, history string, plan *models.Plan, message, lang, profile string, poolLength any, constraints *models.PlanConstraints, docs []schema.Document) (*models.ChatResponse, error) {
+		return db.Client.ChatRefineStream(ctx, summary, history, plan, message, lang, profile, poolLength, constraints, docs, onToken)
+	}
+	deps.chatRetry = replacingRetry(deps.chatRetry, onReplace)
+	return db.chatWithContext(ctx, planID, userID, userMessage, editMessageID, lang, poolLength, constraints, deps)
+}
+
+// replacingRetry passes the response of every retry with a plan to onReplace, as
+// it becomes the response of the chat instead of the streamed one.
+func replacingRetry(
+	retry func(context.Context, string, string, *models.Plan, string, string, string, any, *models.PlanConstraints, []schema.Document) (*models.ChatResponse, error),
+	onReplace func(string) error,
+) func(context.Context, string, string, *models.Plan, string, string, string, any, *models.PlanConstraints, []schema.Document) (*models.ChatResponse, error) {
+	return func(ctx context.Context, summary, history string, plan *models.Plan, message, lang, profile string, poolLength any, constraints *models.PlanConstraints, docs []schema.Document) (*models.ChatResponse, error) {
+		response, err := retry(ctx, summary, history, plan, message, lang, profile, poolLength, constraints, docs)
+		if err != nil || response.Plan == nil {
+			return response, err
+		}
+		if err := onReplace(response.Response); err != nil {
+			return nil, fmt.Errorf("failed to replace streamed response: %w", err)
+		}
+		return response, nil
+	}
+}
+
+func (db *RAGDB) chatDependencies() chatDependencies {
+	return chatDependencies{
+		getPlanForUser:     db.GetPlanForUser,
+		getConversation:    db.Memory.GetConversation,
+		getBranch:          db.Memory.GetBranch,
+		buildContext:       db.buildChatContext,
+		queryMode:          db.Client.QueryMode,
+		chatRefine:         db.Client.ChatRefine,
+		chatRetry:          db.Client.ChatRefine,
+		getUserProfile:     db.GetGenerationProfile,
+		addMessage:         db.Memory.AddMessage,
+		branchMessage:      db.Memory.BranchMessage,
+		upsertPlan:         db.UpsertPlan,
+		getSummary:         db.Memory.GetSummary,
+		summarize:          db.Client.SummarizeConversation,
+		saveSummary:        db.Memory.SaveSummary,
+		proposePreferences: db.ProposePreferences,
+		background:         func(f func()) { go f() },
+	}
 }
 
 func (db *RAGDB) chatWithContext(
 	ctx context.Context,
-	planID, userID, userMessage string,
+	planID, userID, userMessage, editMessageID string,
 	lang models.Language,
 	poolLength any,
+	constraints *models.PlanConstraints,
 	deps chatDependencies,
 ) (*models.Plan, *models.Message, error) {
 	logger := httplog.LogEntry(ctx)
@@ -77,21 +154,50 @@ func (db *RAGDB) chatWithContext(
 		return nil, nil, fmt.Errorf("plan must exist for chat interaction: %w", err)
 	}
 
-	// 2. Retrieve conversation history (limited by config).
-	conversation, err := deps.getConversation(ctx, planID, userID)
+	// 2. Retrieve conversation history (limited by config). An edited message
+	// branches off after the messages before it.
+	var conversation []models.Message
+	if editMessageID == "" {
+		conversation, err = deps.getConversation(ctx, planID, userID)
+	} else {
+		conversation, err = deps.getBranch(ctx, planID, userID, editMessageID)
+	}
 	if err != nil {
 		logger.Error("Failed to retrieve conversation history", httplog.ErrAttr(err))
 		return nil, nil, fmt.Errorf("failed to retrieve conversation: %w", err)
 	}
+	if editMessageID != "" {
+		edited := slices.IndexFunc(conversation, func(msg models.Message) bool { return msg.ID == editMessageID })
+		if edited < 0 || conversation[edited].Role != models.RoleUser {
+			return nil, nil, ErrChatEditInvalid
+		}
+		conversation = conversation[:edited]
+		// The new branch refines the plan as it was before the edited message.
+		for i := len(conversation) - 1; i >= 0; i-- {
+			if conversation[i].PlanSnapshot != nil {
+				currentPlan = conversation[i].PlanSnapshot
+				break
+			}
+		}
+	}
 
-	// Apply history limit
+	// Apply history limit, the older messages are replaced by their summary
+	var summary string
 	if len(conversation) > db.cfg.Chat.HistoryLimit {
+		older := conversation[:len(conversation)-db.cfg.Chat.HistoryLimit]
 		// Keep only the most recent N messages
 		conversation = conversation[len(conversation)-db.cfg.Chat.HistoryLimit:]
 		logger.Debug("Applied history limit", "total_messages", len(conversation), "limit", db.cfg.Chat.HistoryLimit)
+		summary = summarizeHistory(ctx, planID, userID, older, deps)
 	}
 
-	// 3. Build context
+	// 3. Build context, the profile includes the confirmed training preferences
+	profile, err := deps.getUserProfile(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to get user profile, continuing without it", httplog.ErrAttr(err))
+		profile = nil
+	}
+	userProfile := db.FormatUserProfile(profile)
 	conversationHistory := formatConversationHistory(conversation)
 	contextDocs, err := deps.buildContext(ctx, userMessage, currentPlan)
 	if err != nil {
@@ -103,33 +209,54 @@ func (db *RAGDB) chatWithContext(
 	deps.queryMode() // Set embedder to query mode for any similarity searches
 	chatResponse, err := deps.chatRefine(
 		ctx,
+		summary,
 		conversationHistory,
 		currentPlan,
 		userMessage,
 		string(lang),
+		userProfile,
 		poolLength,
+		constraints,
 		contextDocs,
 	)
 	if err != nil {
 		logger.Error("Failed to generate chat response", httplog.ErrAttr(err))
 		return nil, nil, fmt.Errorf("failed to generate chat response: %w", err)
 	}
-
-	// 5. Store user message in memory
-	var lastMessageID *string
-	if len(conversation) > 0 {
-		lastMessageID = &conversation[len(conversation)-1].ID
+	if chatResponse.Plan != nil && !constraints.IsZero() {
+		chatResponse.Plan = enforceConstraints(ctx, constraints, profile, chatResponse.Plan, func(feedback string) (*models.GeneratedPlan, error) {
+			retry, err := deps.chatRetry(ctx, summary, conversationHistory, currentPlan, userMessage+feedback, string(lang), userProfile, poolLength, constraints, contextDocs)
+			if err != nil {
+				return nil, err
+			}
+			if retry.Plan == nil {
+				return nil, errors.New("retried chat response contains no plan")
+			}
+			chatResponse.Response = retry.Response
+			return retry.Plan, nil
+		})
 	}
 
-	userMsg, err := deps.addMessage(
-		ctx,
-		planID,
-		userID,
-		models.RoleUser,
-		userMessage,
-		lastMessageID,
-		nil, // No plan snapshot for user messages
-	)
+	// 5. Store user message in memory, edits as sibling of the edited message
+	var userMsg *models.Message
+	if editMessageID != "" {
+		userMsg, err = deps.branchMessage(ctx, editMessageID, userID, userMessage)
+	} else {
+		var lastMessageID *string
+		if len(conversation) > 0 {
+			lastMessageID = &conversation[len(conversation)-1].ID
+		}
+
+		userMsg, err = deps.addMessage(
+			ctx,
+			planID,
+			userID,
+			models.RoleUser,
+			userMessage,
+			lastMessageID,
+			nil, // No plan snapshot for user messages
+		)
+	}
 	if err != nil {
 		logger.Error("Failed to store user message", httplog.ErrAttr(err))
 		return nil, nil, fmt.Errorf("failed to store user message: %w", err)
@@ -171,10 +298,67 @@ func (db *RAGDB) chatWithContext(
 		return nil, nil, fmt.Errorf("failed to store AI message: %w", err)
 	}
 
+	// 7. Propose long-term preferences stated in the message in the background,
+	// so the extra LLM call neither delays nor fails the answer
+	var preferences *models.TrainingPreferences
+	if profile != nil {
+		preferences = profile.Preferences
+	}
+	deps.background(func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), preferenceTimeout)
+		defer cancel()
+		if _, err := deps.proposePreferences(ctx, userID, planID, userMsg.ID, userMessage, preferences); err != nil {
+			logger.Warn("Failed to propose preferences from chat message", httplog.ErrAttr(err))
+		}
+	})
+
 	logger.Debug("Chat interaction completed successfully", "plan_id", planID)
 	return updatedPlan, aiMsg, nil
 }
 
+// summarizeHistory returns the rolling summary of the messages older than the
+// history limit. The stored summary is reused while it covers exactly these
+// messages and extended by the messages that dropped out of the history since.
+// On a different branch the summary is rebuilt from the older messages. Failures
+// only lose the summary, not the chat response.
+func summarizeHistory(ctx context.Context, planID, userID string, older []models.Message, deps chatDependencies) string {
+	logger := httplog.LogEntry(ctx)
+	lastID := older[len(older)-1].ID
+
+	stored, err := deps.getSummary(ctx, planID, userID)
+	if err != nil {
+		logger.Warn("Failed to get conversation summary, rebuilding it", httplog.ErrAttr(err))
+		stored = nil
+	}
+	if stored != nil && stored.LastMessageID == lastID {
+		return stored.Summary
+	}
+
+	var previous string
+	pending := older
+	if stored != nil {
+		if i := slices.IndexFunc(older, func(msg models.Message) bool { return msg.ID == stored.LastMessageID }); i >= 0 {
+			previous, pending = stored.Summary, older[i+1:]
+		}
+	}
+
+	summary, err := deps.summarize(ctx, previous, formatConversationHistory(pending))
+	if err != nil {
+		logger.Warn("Failed to summarize conversation, continuing with the previous summary", httplog.ErrAttr(err))
+		return previous
+	}
+	if err := deps.saveSummary(ctx, &models.ConversationSummary{
+		PlanID:        planID,
+		UserID:        userID,
+		Summary:       summary,
+		LastMessageID: lastID,
+	}); err != nil {
+		logger.Warn("Failed to save conversation summary", httplog.ErrAttr(err))
+	}
+	logger.Debug("Summarized conversation", "summarized_messages", len(pending))
+	return summary
+}
+
 // buildChatContext retrieves similar plans from the vector store to provide reference context.
 // This is called when additional context beyond the conversation history might be helpful.
 func (db *RAGDB) buildChatContext(ctx context.Context, userQuery string, currentPlan *models.Plan) ([]schema.Document, error) {
@@ -32,7 +32,7 @@ func testChatDependencies(t *testing.T) (chatDependencies, *chatDependencyCalls)
 		queryMode: func() {
 			calls.queryMode++
 		},
-		chatRefine: func(_ context.Context, _ string, _ *models.Plan, _, _ string, _ any, _ []schema.Document) (*models.ChatResponse, error) {
+		chatRefine: func(_ context.Context, _, _ string, _ *models.Plan, _, _, _ string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
 			calls.chatRefine++
 			return &models.ChatResponse{Response: "response"}, nil
 		},
@@ -44,6 +44,15 @@ func testChatDependencies(t *testing.T) (chatDependencies, *chatDependencyCalls)
 			calls.upsertPlan = append(calls.upsertPlan, []string{plan.PlanID, userID})
 			return plan.PlanID, nil
 		},
+		getUserProfile: func(context.Context, string) (*models.UserProfile, error) {
+			return nil, nil
+		},
+		proposePreferences: func(_ context.Context, _, _, messageID, _ string, _ *models.TrainingPreferences) ([]models.PreferenceProposal, error) {
+			calls.proposePreferences = append(calls.proposePreferences, messageID)
+			return nil, nil
+		},
+		// Background work runs before the chat returns, so the tests see its calls.
+		background: func(f func()) { f() },
 	}
 	return deps, calls
 }
@@ -56,6 +65,8 @@ type chatDependencyCalls struct {
 	chatRefine      int
 	addMessage      int
 	upsertPlan      [][]string
+	// proposePreferences holds the ids of the messages preferences were proposed from.
+	proposePreferences []string
 }
 
 func TestChatWithContextStopsBeforeDownstreamWorkForUnauthorizedPlan(t *testing.T) {
@@ -70,8 +81,10 @@ func TestChatWithContextStopsBeforeDownstreamWorkForUnauthorizedPlan(t *testing.
 		"00000000-0000-0000-0000-000000000001",
 		"user-b",
 		"change it",
+		"",
 		models.LanguageEN,
 		25,
+		nil,
 		deps,
 	)
 
@@ -82,13 +95,14 @@ func TestChatWithContextStopsBeforeDownstreamWorkForUnauthorizedPlan(t *testing.
 	assert.Zero(t, calls.chatRefine)
 	assert.Zero(t, calls.addMessage)
 	assert.Empty(t, calls.upsertPlan)
+	assert.Empty(t, calls.proposePreferences)
 }
 
 func TestChatWithContextRequiresPlanBeforeAnyDependency(t *testing.T) {
 	deps := chatDependencies{}
 	db := &RAGDB{}
 
-	_, _, err := db.chatWithContext(context.Background(), "", "user-a", "hello", models.LanguageEN, 25, deps)
+	_, _, err := db.chatWithContext(context.Background(), "", "user-a", "hello", "", models.LanguageEN, 25, nil, deps)
 
 	require.ErrorIs(t, err, ErrChatPlanRequired)
 }
@@ -104,8 +118,10 @@ func TestChatWithContextUsesAuthenticatedUserAndPlanForOwnerPath(t *testing.T) {
 		planID,
 		userID,
 		"make it harder",
+		"",
 		models.LanguageEN,
 		25,
+		nil,
 		deps,
 	)
 
@@ -119,13 +135,14 @@ func TestChatWithContextUsesAuthenticatedUserAndPlanForOwnerPath(t *testing.T) {
 	assert.Equal(t, 1, calls.chatRefine)
 	assert.Equal(t, 2, calls.addMessage)
 	assert.Empty(t, calls.upsertPlan)
+	assert.Equal(t, []string{"message"}, calls.proposePreferences)
 }
 
 func TestChatWithContextPreservesPlanUpdateAndSnapshotScope(t *testing.T) {
 	deps, calls := testChatDependencies(t)
 	planID := "00000000-0000-0000-0000-000000000001"
 	userID := "user-a"
-	deps.chatRefine = func(_ context.Context, _ string, _ *models.Plan, _, _ string, _ any, _ []schema.Document) (*models.ChatResponse, error) {
+	deps.chatRefine = func(_ context.Context, _, _ string, _ *models.Plan, _, _, _ string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
 		calls.chatRefine++
 		return &models.ChatResponse{
 			Response: "updated",
@@ -140,7 +157,7 @@ func TestChatWithContextPreservesPlanUpdateAndSnapshotScope(t *testing.T) {
 	}
 
 	db := &RAGDB{}
-	updatedPlan, _, err := db.chatWithContext(context.Background(), planID, userID, "update", models.LanguageEN, 25, deps)
+	updatedPlan, _, err := db.chatWithContext(context.Background(), planID, userID, "update", "", models.LanguageEN, 25, nil, deps)
 
 	require.NoError(t, err)
 	assert.Equal(t, planID, updatedPlan.PlanID)
@@ -158,8 +175,355 @@ func TestChatWithContextPropagatesUnexpectedAuthorizationError(t *testing.T) {
 		return nil, backendErr
 	}
 
-	_, _, err := (&RAGDB{}).chatWithContext(context.Background(), "plan", "user", "hello", models.LanguageEN, 25, deps)
+	_, _, err := (&RAGDB{}).chatWithContext(context.Background(), "plan", "user", "hello", "", models.LanguageEN, 25, nil, deps)
 
 	require.Error(t, err)
 	assert.ErrorIs(t, err, backendErr)
 }
+
+func constrainedPlan(amount int) *models.GeneratedPlan {
+	plan := &models.GeneratedPlan{Title: "Plan", Table: models.Table{
+		{Amount: 1, Multiplier: "x", Distance: 200, Content: "Einschwimmen"},
+		{Amount: amount, Multiplier: "x", Distance: 100, Content: "Kraul", Intensity: "GA1", Equipment: []models.EquipmentType{models.EquipmentPaddles}},
+	}}
+	plan.Table.UpdateSum()
+	plan.Table.AddSum()
+	return plan
+}
+
+func TestChatWithContextRetriesWhenConstraintsAreMissed(t *testing.T) {
+	deps, calls := testChatDependencies(t)
+	deps.chatRefine = func(_ context.Context, _, _ string, _ *models.Plan, _, _, _ string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
+		calls.chatRefine++
+		return &models.ChatResponse{Response: "first", Plan: constrainedPlan(2)}, nil
+	}
+	var feedback string
+	deps.chatRetry = func(_ context.Context, _, _ string, _ *models.Plan, message, _, _ string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
+		feedback = message
+		return &models.ChatResponse{Response: "second", Plan: constrainedPlan(8)}, nil
+	}
+	var aiContent string
+	deps.addMessage = func(_ context.Context, planID, userID string, role models.Role, content string, _ *string, _ *models.Plan) (*models.Message, error) {
+		aiContent = content
+		return &models.Message{ID: "message", PlanID: planID, UserID: userID, Role: role, Content: content}, nil
+	}
+
+	constraints := &models.PlanConstraints{TargetVolume: 1000}
+	updatedPlan, _, err := (&RAGDB{}).chatWithContext(context.Background(), "plan", "user", "longer", "", models.LanguageEN, 25, constraints, deps)
+
+	require.NoError(t, err)
+	assert.Contains(t, feedback, "longer")
+	assert.Contains(t, feedback, "Der Gesamtumfang beträgt 400m, gefordert sind 1000m.")
+	assert.Equal(t, 1000, updatedPlan.Table.GetTotalVolume())
+	assert.Equal(t, "second", aiContent)
+}
+
+func TestChatWithContextScalesPlanWhenRetryMissesConstraints(t *testing.T) {
+	deps, _ := testChatDependencies(t)
+	deps.chatRefine = func(_ context.Context, _, _ string, _ *models.Plan, _, _, _ string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
+		return &models.ChatResponse{Response: "first", Plan: constrainedPlan(2)}, nil
+	}
+	deps.chatRetry = func(context.Context, string, string, *models.Plan, string, string, string, any, *models.PlanConstraints, []schema.Document) (*models.ChatResponse, error) {
+		return nil, errors.New("model unavailable")
+	}
+
+	constraints := &models.PlanConstraints{TargetVolume: 1000, Equipment: []models.EquipmentType{}}
+	updatedPlan, _, err := (&RAGDB{}).chatWithContext(context.Background(), "plan", "user", "longer", "", models.LanguageEN, 25, constraints, deps)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1000, updatedPlan.Table.GetTotalVolume())
+	assert.Equal(t, 8, updatedPlan.Table[1].Amount)
+	assert.Nil(t, updatedPlan.Table[1].Equipment)
+	assert.Equal(t, 1000, updatedPlan.Table[2].Sum)
+}
+
+func TestChatWithContextBranchesEditedMessage(t *testing.T) {
+	deps, calls := testChatDependencies(t)
+	before := &models.Plan{PlanID: "plan", Title: "Before edit"}
+	branch := []models.Message{
+		{ID: "user-1", Role: models.RoleUser, Content: "Ein Plan"},
+		{ID: "ai-1", Role: models.RoleAI, Content: "Hier", PlanSnapshot: before},
+		{ID: "user-2", Role: models.RoleUser, Content: "Mach es schwerer"},
+		{ID: "ai-2", Role: models.RoleAI, Content: "Schwerer", PlanSnapshot: &models.Plan{PlanID: "plan", Title: "Harder"}},
+	}
+	deps.getConversation = func(context.Context, string, string) ([]models.Message, error) {
+		t.Fatal("edits read the branch of the edited message")
+		return nil, nil
+	}
+	var gotBranch []string
+	deps.getBranch = func(_ context.Context, planID, userID, messageID string) ([]models.Message, error) {
+		gotBranch = []string{planID, userID, messageID}
+		return branch, nil
+	}
+	var refinedPlan *models.Plan
+	var history string
+	deps.chatRefine = func(_ context.Context, _, receivedHistory string, plan *models.Plan, _, _, _ string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
+		history, refinedPlan = receivedHistory, plan
+		return &models.ChatResponse{Response: "leichter"}, nil
+	}
+	var branched []string
+	deps.branchMessage = func(_ context.Context, messageID, userID, content string) (*models.Message, error) {
+		branched = []string{messageID, userID, content}
+		return &models.Message{ID: "user-3", Role: models.RoleUser, Content: content}, nil
+	}
+	var aiPrevious *string
+	var aiSnapshot *models.Plan
+	deps.addMessage = func(_ context.Context, planID, userID string, role models.Role, content string, previous *string, snapshot *models.Plan) (*models.Message, error) {
+		calls.addMessage++
+		assert.Equal(t, models.RoleAI, role)
+		aiPrevious, aiSnapshot = previous, snapshot
+		return &models.Message{ID: "ai-3", PlanID: planID, UserID: userID, Role: role, Content: content, PreviousMessageID: previous}, nil
+	}
+
+	db := &RAGDB{}
+	db.cfg.Chat.HistoryLimit = 10
+	updatedPlan, aiMessage, err := db.chatWithContext(context.Background(), "plan", "user", "Mach es leichter", "user-2", models.LanguageDE, 25, nil, deps)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"plan", "user", "user-2"}, gotBranch)
+	assert.Same(t, before, refinedPlan)
+	assert.Contains(t, history, "Ein Plan")
+	assert.NotContains(t, history, "Mach es schwerer")
+	assert.Equal(t, []string{"user-2", "user", "Mach es leichter"}, branched)
+	assert.Equal(t, 1, calls.addMessage)
+	require.NotNil(t, aiPrevious)
+	assert.Equal(t, "user-3", *aiPrevious)
+	assert.Same(t, before, aiSnapshot)
+	assert.Same(t, before, updatedPlan)
+	assert.Equal(t, "ai-3", aiMessage.ID)
+}
+
+func TestChatWithContextRejectsEditsOfAIMessages(t *testing.T) {
+	deps, calls := testChatDependencies(t)
+	deps.getBranch = func(context.Context, string, string, string) ([]models.Message, error) {
+		return []models.Message{
+			{ID: "user-1", Role: models.RoleUser, Content: "Ein Plan"},
+			{ID: "ai-1", Role: models.RoleAI, Content: "Hier"},
+		}, nil
+	}
+
+	_, _, err := (&RAGDB{}).chatWithContext(context.Background(), "plan", "user", "anders", "ai-1", models.LanguageDE, 25, nil, deps)
+
+	require.ErrorIs(t, err, ErrChatEditInvalid)
+	assert.Zero(t, calls.chatRefine)
+	assert.Zero(t, calls.addMessage)
+}
+
+func longConversation() []models.Message {
+	return []models.Message{
+		{ID: "user-1", Role: models.RoleUser, Content: "Kein Delfin, ich habe eine Schulterverletzung"},
+		{ID: "ai-1", Role: models.RoleAI, Content: "Verstanden", PlanSnapshot: &models.Plan{PlanID: "plan", Title: "Ohne Delfin"}},
+		{ID: "user-2", Role: models.RoleUser, Content: "Etwas kürzer"},
+		{ID: "ai-2", Role: models.RoleAI, Content: "Kürzer"},
+		{ID: "user-3", Role: models.RoleUser, Content: "Mehr Beine"},
+		{ID: "ai-3", Role: models.RoleAI, Content: "Mit Beinen"},
+	}
+}
+
+func TestChatWithContextSummarizesMessagesBeyondHistoryLimit(t *testing.T) {
+	deps, _ := testChatDependencies(t)
+	deps.getConversation = func(context.Context, string, string) ([]models.Message, error) {
+		return longConversation(), nil
+	}
+	deps.getSummary = func(_ context.Context, planID, userID string) (*models.ConversationSummary, error) {
+		assert.Equal(t, []string{"plan", "user"}, []string{planID, userID})
+		return nil, nil
+	}
+	var previous, summarized string
+	deps.summarize = func(_ context.Context, previousSummary, messages string) (string, error) {
+		previous, summarized = previousSummary, messages
+		return "Kein Delfin wegen Schulterverletzung", nil
+	}
+	var saved *models.ConversationSummary
+	deps.saveSummary = func(_ context.Context, summary *models.ConversationSummary) error {
+		saved = summary
+		return nil
+	}
+	var summary, history string
+	deps.chatRefine = func(_ context.Context, receivedSummary, receivedHistory string, _ *models.Plan, _, _, _ string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
+		summary, history = receivedSummary, receivedHistory
+		return &models.ChatResponse{Response: "response"}, nil
+	}
+
+	db := &RAGDB{}
+	db.cfg.Chat.HistoryLimit = 2
+	_, _, err := db.chatWithContext(context.Background(), "plan", "user", "Noch mehr Beine", "", models.LanguageDE, 25, nil, deps)
+
+	require.NoError(t, err)
+	assert.Empty(t, previous)
+	assert.Contains(t, summarized, "Schulterverletzung")
+	assert.Contains(t, summarized, "Kürzer")
+	assert.NotContains(t, summarized, "Mehr Beine")
+	require.NotNil(t, saved)
+	assert.Equal(t, models.ConversationSummary{PlanID: "plan", UserID: "user", Summary: "Kein Delfin wegen Schulterverletzung", LastMessageID: "ai-2"}, *saved)
+	assert.Equal(t, "Kein Delfin wegen Schulterverletzung", summary)
+	assert.Contains(t, history, "Mehr Beine")
+	assert.NotContains(t, history, "Schulterverletzung")
+}
+
+func TestChatWithContextReusesAndExtendsStoredSummary(t *testing.T) {
+	deps, _ := testChatDependencies(t)
+	deps.getConversation = func(context.Context, string, string) ([]models.Message, error) {
+		return longConversation(), nil
+	}
+	var summary string
+	deps.chatRefine = func(_ context.Context, receivedSummary, _ string, _ *models.Plan, _, _, _ string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
+		summary = receivedSummary
+		return &models.ChatResponse{Response: "response"}, nil
+	}
+	deps.saveSummary = func(context.Context, *models.ConversationSummary) error { return nil }
+	db := &RAGDB{}
+	db.cfg.Chat.HistoryLimit = 2
+
+	// The stored summary covers all older messages.
+	deps.getSummary = func(context.Context, string, string) (*models.ConversationSummary, error) {
+		return &models.ConversationSummary{Summary: "Kein Delfin", LastMessageID: "ai-2"}, nil
+	}
+	deps.summarize = func(context.Context, string, string) (string, error) {
+		t.Fatal("a summary covering the older messages is reused")
+		return "", nil
+	}
+	_, _, err := db.chatWithContext(context.Background(), "plan", "user", "Noch mehr Beine", "", models.LanguageDE, 25, nil, deps)
+	require.NoError(t, err)
+	assert.Equal(t, "Kein Delfin", summary)
+
+	// Messages that dropped out of the history since are added to the summary.
+	deps.getSummary = func(context.Context, string, string) (*models.ConversationSummary, error) {
+		return &models.ConversationSummary{Summary: "Kein Delfin", LastMessageID: "ai-1"}, nil
+	}
+	var previous, summarized string
+	deps.summarize = func(_ context.Context, previousSummary, messages string) (string, error) {
+		previous, summarized = previousSummary, messages
+		return "Kein Delfin, kürzer", nil
+	}
+	_, _, err = db.chatWithContext(context.Background(), "plan", "user", "Noch mehr Beine", "", models.LanguageDE, 25, nil, deps)
+	require.NoError(t, err)
+	assert.Equal(t, "Kein Delfin", previous)
+	assert.Contains(t, summarized, "Etwas kürzer")
+	assert.NotContains(t, summarized, "Schulterverletzung")
+	assert.Equal(t, "Kein Delfin, kürzer", summary)
+
+	// A summary of another branch is rebuilt from the older messages.
+	deps.getSummary = func(context.Context, string, string) (*models.ConversationSummary, error) {
+		return &models.ConversationSummary{Summary: "Andere Abzweigung", LastMessageID: "other"}, nil
+	}
+	_, _, err = db.chatWithContext(context.Background(), "plan", "user", "Noch mehr Beine", "", models.LanguageDE, 25, nil, deps)
+	require.NoError(t, err)
+	assert.Empty(t, previous)
+	assert.Contains(t, summarized, "Schulterverletzung")
+}
+
+func TestChatWithContextContinuesWhenSummarizationFails(t *testing.T) {
+	deps, calls := testChatDependencies(t)
+	deps.getConversation = func(context.Context, string, string) ([]models.Message, error) {
+		return longConversation(), nil
+	}
+	deps.getSummary = func(context.Context, string, string) (*models.ConversationSummary, error) {
+		return nil, errors.New("database unavailable")
+	}
+	deps.summarize = func(context.Context, string, string) (string, error) {
+		return "", errors.New("model unavailable")
+	}
+	deps.saveSummary = func(context.Context, *models.ConversationSummary) error {
+		t.Fatal("failed summaries are not saved")
+		return nil
+	}
+
+	db := &RAGDB{}
+	db.cfg.Chat.HistoryLimit = 2
+	_, aiMessage, err := db.chatWithContext(context.Background(), "plan", "user", "Noch mehr Beine", "", models.LanguageDE, 25, nil, deps)
+
+	require.NoError(t, err)
+	assert.Equal(t, "response", aiMessage.Content)
+	assert.Equal(t, 1, calls.chatRefine)
+}
+
+func TestChatWithContextUsesProfileAndProposesPreferences(t *testing.T) {
+	deps, _ := testChatDependencies(t)
+	preferences := &models.TrainingPreferences{Injuries: []string{"Schulterverletzung"}}
+	deps.getUserProfile = func(_ context.Context, userID string) (*models.UserProfile, error) {
+		assert.Equal(t, "user", userID)
+		return &models.UserProfile{Preferences: preferences}, nil
+	}
+	var profile string
+	deps.chatRefine = func(_ context.Context, _, _ string, _ *models.Plan, _, _, receivedProfile string, _ any, _ *models.PlanConstraints, _ []schema.Document) (*models.ChatResponse, error) {
+		profile = receivedProfile
+		return &models.ChatResponse{Response: "response"}, nil
+	}
+	deps.addMessage = func(_ context.Context, planID, userID string, role models.Role, content string, _ *string, _ *models.Plan) (*models.Message, error) {
+		return &models.Message{ID: string(role) + "-message", PlanID: planID, UserID: userID, Role: role, Content: content}, nil
+	}
+	var proposed []string
+	var knownPreferences *models.TrainingPreferences
+	deps.proposePreferences = func(_ context.Context, userID, planID, messageID, message string, received *models.TrainingPreferences) ([]models.PreferenceProposal, error) {
+		proposed, knownPreferences = []string{userID, planID, messageID, message}, received
+		return nil, errors.New("model unavailable")
+	}
+
+	_, aiMessage, err := (&RAGDB{}).chatWithContext(context.Background(), "plan", "user", "Ich mag kein Delfin", "", models.LanguageDE, 25, nil, deps)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ai-message", aiMessage.ID)
+	assert.Contains(t, profile, "Schulterverletzung")
+	assert.Equal(t, []string{"user", "plan", "user-message", "Ich mag kein Delfin"}, proposed)
+	assert.Same(t, preferences, knownPreferences)
+}
+
+func TestChatWithContextProposesPreferencesAfterTheResponse(t *testing.T) {
+	deps, calls := testChatDependencies(t)
+	var pending []func()
+	deps.background = func(f func()) { pending = append(pending, f) }
+	var proposalErr error
+	var deadline bool
+	deps.proposePreferences = func(ctx context.Context, _, _, messageID, _ string, _ *models.TrainingPreferences) ([]models.PreferenceProposal, error) {
+		proposalErr = ctx.Err()
+		_, deadline = ctx.Deadline()
+		calls.proposePreferences = append(calls.proposePreferences, messageID)
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, err := (&RAGDB{}).chatWithContext(ctx, "plan", "user", "Ich mag kein Delfin", "", models.LanguageDE, 25, nil, deps)
+	require.NoError(t, err)
+	assert.Empty(t, calls.proposePreferences)
+
+	// The request ends before the proposal runs.
+	cancel()
+	require.Len(t, pending, 1)
+	pending[0]()
+
+	assert.Equal(t, []string{"message"}, calls.proposePreferences)
+	assert.NoError(t, proposalErr)
+	assert.True(t, deadline)
+}
+
+func TestReplacingRetryReplacesStreamedResponse(t *testing.T) {
+	var replaced []string
+	onReplace := func(response string) error {
+		replaced = append(replaced, response)
+		return nil
+	}
+	respond := func(response *models.ChatResponse, err error) func(context.Context, string, string, *models.Plan, string, string, string, any, *models.PlanConstraints, []schema.Document) (*models.ChatResponse, error) {
+		return func(context.Context, string, string, *models.Plan, string, string, string, any, *models.PlanConstraints, []schema.Document) (*models.ChatResponse, error) {
+			return response, err
+		}
+	}
+
+	retry := replacingRetry(respond(&models.ChatResponse{Response: "shorter", Plan: &models.GeneratedPlan{}}, nil), onReplace)
+	response, err := retry(context.Background(), "", "", nil, "", "", "", 25, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "shorter", response.Response)
+	assert.Equal(t, []string{"shorter"}, replaced)
+
+	// Retries without a plan are discarded, so the streamed text stays.
+	_, err = replacingRetry(respond(&models.ChatResponse{Response: "no plan"}, nil), onReplace)(context.Background(), "", "", nil, "", "", "", 25, nil, nil)
+	require.NoError(t, err)
+	_, err = replacingRetry(respond(nil, errors.New("model unavailable")), onReplace)(context.Background(), "", "", nil, "", "", "", 25, nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, []string{"shorter"}, replaced)
+
+	_, err = replacingRetry(respond(&models.ChatResponse{Plan: &models.GeneratedPlan{}}, nil), func(string) error {
+		return errors.New("client gone")
+	})(context.Background(), "", "", nil, "", "", "", 25, nil, nil)
+	assert.ErrorContains(t, err, "client gone")
+}
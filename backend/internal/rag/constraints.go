@@ -0,0 +1,55 @@
+package rag
+
+import (
+	"context"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/go-chi/httplog/v2"
+)
+
+// enforceConstraints makes sure the generated plan meets the constraints. If it
+// misses them, regenerate is called once with the violations as feedback for the
+// LLM. Volume, duration and equipment violations which remain are fixed by
+// models.PlanConstraints.Apply, excluded strokes are only logged.
+func enforceConstraints(
+	ctx context.Context,
+	constraints *models.PlanConstraints,
+	profile *models.UserProfile,
+	plan *models.GeneratedPlan,
+	regenerate func(feedback string) (*models.GeneratedPlan, error),
+) *models.GeneratedPlan {
+	logger := httplog.LogEntry(ctx)
+
+	violations := constraints.Check(plan.Table, profile)
+	if len(violations) == 0 {
+		return plan
+	}
+
+	logger.Info("Generated plan violates constraints, asking again", "violations", violations)
+	retry, err := regenerate(constraintFeedback(violations))
+	if err != nil {
+		logger.Warn("Failed to regenerate plan, fixing the first plan", httplog.ErrAttr(err))
+	} else {
+		plan = retry
+		violations = constraints.Check(plan.Table, profile)
+		if len(violations) == 0 {
+			return plan
+		}
+	}
+
+	logger.Info("Fixing constraint violations of the plan", "violations", violations)
+	constraints.Apply(&plan.Table, profile)
+	if remaining := constraints.Check(plan.Table, profile); len(remaining) > 0 {
+		logger.Warn("Plan still violates constraints", "violations", remaining)
+	}
+	return plan
+}
+
+// constraintFeedback is appended to the request of the swimmer when the plan is
+// generated again.
+func constraintFeedback(violations []string) string {
+	return "\n\nDer vorherige Plan hat die verbindlichen Vorgaben nicht erfüllt:\n- " +
+		strings.Join(violations, "\n- ") +
+		"\nPasse den Plan so an, dass alle Vorgaben erfüllt sind."
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/georgysavva/scany/v2/pgxscan"
@@ -58,6 +59,30 @@ func (db *RAGDB) GetPlanForUser(ctx context.Context, planID, userID string) (*mo
 	return &plan, nil
 }
 
+// GetPlansForUser returns the plans of the user history in the order of the ids.
+// It fails with ErrPlanNotFound if any plan is not owned by the user.
+func (db *RAGDB) GetPlansForUser(ctx context.Context, planIDs []string, userID string) ([]*models.Plan, error) {
+	var plans []*models.Plan
+	err := pgxscan.Select(ctx, db.Conn, &plans, fmt.Sprintf(`
+		SELECT p.plan_id, p.title, p.description, p.plan_table
+		FROM %s p
+		WHERE p.plan_id = ANY($1::uuid[])
+		  AND EXISTS (
+			SELECT 1 FROM %s h WHERE h.plan_id = p.plan_id AND h.user_id = $2
+			UNION ALL
+			SELECT 1 FROM %s d WHERE d.plan_id = p.plan_id AND d.user_id = $2
+		  )
+		ORDER BY array_position($1::uuid[], p.plan_id)
+	`, PlanTableName, HistoryTableName, DonatedPlanTable), planIDs, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying plans: %w", err)
+	}
+	if len(plans) != len(planIDs) {
+		return nil, ErrPlanNotFound
+	}
+	return plans, nil
+}
+
 func (db *RAGDB) UpsertPlan(ctx context.Context, plan models.Plan, userID string) (string, error) {
 	logger := httplog.LogEntry(ctx)
 
@@ -184,49 +209,61 @@ func (db *RAGDB) AddPlanToHistory(ctx context.Context, plan *models.Plan, userID
 	return nil
 }
 
-func (db *RAGDB) SharePlan(ctx context.Context, planID, userID string, method models.SharingMethod) (string, error) {
-	logger := httplog.LogEntry(ctx)
-
+// SharePlan creates the link share of an owned plan. A nil expiresAt keeps the
+// expiry of an active share, sharing a revoked plan again creates a new link.
+func (db *RAGDB) SharePlan(ctx context.Context, planID, userID string, method models.SharingMethod, expiresAt *time.Time) (string, error) {
 	switch method {
 	case models.SharingMethodLink:
-		// Authorize and create the share in one statement. A conflict only
-		// returns the existing link when it belongs to the same user.
-		urlHash := uuid.NewSHA1(uuid.NameSpaceURL, []byte(planID+userID)).String()
-		row := db.Conn.QueryRow(ctx,
-			`INSERT INTO shared_plans (user_id, plan_id, url_hash)
-			 SELECT $1, $2, $3
-			 WHERE EXISTS (
-				 SELECT 1 FROM history
-				 WHERE history.plan_id = $2 AND history.user_id = $1
-				 UNION ALL
-				 SELECT 1 FROM donations
-				 WHERE donations.plan_id = $2 AND donations.user_id = $1
-			 )
-			 ON CONFLICT (plan_id) DO UPDATE
-			 SET url_hash = shared_plans.url_hash
-			 WHERE shared_plans.user_id = EXCLUDED.user_id
-			 RETURNING url_hash`,
-			userID, planID, urlHash,
-		)
-		err := row.Scan(&urlHash)
+		urlHash, err := shareLink(ctx, db.Conn, planID, userID, expiresAt)
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				return "", ErrShareNotFound
-			}
-			logger.Error("Error sharing plan", httplog.ErrAttr(err))
-			return "", fmt.Errorf("failed to share plan: %w", err)
+			return "", err
 		}
-
-		logger.Debug("Plan shared successfully", "plan_id", planID, "user_id", userID)
+		httplog.LogEntry(ctx).Debug("Plan shared successfully", "plan_id", planID, "user_id", userID)
 		return urlHash, nil
 	case models.SharingMethodEmail:
-		// proceed
-		return "", fmt.Errorf("email sharing not implemented yet")
+		return "", fmt.Errorf("email shares need a recipient, use ShareByEmail")
 	default:
 		return "", fmt.Errorf("unsupported sharing method: %s", method)
 	}
 }
 
+// shareLink creates or renews the link share of an owned plan and returns its url hash.
+func shareLink(ctx context.Context, q pgxscan.Querier, planID, userID string, expiresAt *time.Time) (string, error) {
+	// Authorize and create the share in one statement. A conflict only
+	// returns the existing link when it belongs to the same user. Revoked
+	// links never become valid again, their shares get a new hash instead.
+	urlHash := uuid.NewSHA1(uuid.NameSpaceURL, []byte(planID+userID)).String()
+	err := pgxscan.Get(ctx, q, &urlHash,
+		`INSERT INTO shared_plans (user_id, plan_id, url_hash, expires_at)
+		 SELECT $1, $2, $3, $4
+		 WHERE EXISTS (
+			 SELECT 1 FROM history
+			 WHERE history.plan_id = $2 AND history.user_id = $1
+			 UNION ALL
+			 SELECT 1 FROM donations
+			 WHERE donations.plan_id = $2 AND donations.user_id = $1
+		 )
+		 ON CONFLICT (plan_id) DO UPDATE
+		 SET url_hash = CASE WHEN shared_plans.revoked_at IS NULL THEN shared_plans.url_hash ELSE gen_random_uuid() END,
+			 expires_at = CASE
+				 WHEN shared_plans.revoked_at IS NOT NULL OR shared_plans.expires_at <= now() THEN EXCLUDED.expires_at
+				 ELSE COALESCE(EXCLUDED.expires_at, shared_plans.expires_at)
+			 END,
+			 revoked_at = NULL
+		 WHERE shared_plans.user_id = EXCLUDED.user_id
+		 RETURNING url_hash`,
+		userID, planID, urlHash, expiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrShareNotFound
+		}
+		httplog.LogEntry(ctx).Error("Error sharing plan", httplog.ErrAttr(err))
+		return "", fmt.Errorf("failed to share plan: %w", err)
+	}
+	return urlHash, nil
+}
+
 // DeletePlan removes a plan owned by the user.
 // Ownership is established exclusively through history (generated plans) or donations (uploaded plans).
 // A shared_plans or shared_history relationship does not grant deletion privileges.
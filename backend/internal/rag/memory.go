@@ -11,7 +11,22 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-const MemoryTableName = "memory"
+const (
+	MemoryTableName        = "memory"
+	MemoryBranchTableName  = "memory_branches"
+	MemorySummaryTableName = "memory_summaries"
+)
+
+// messageColumns selects the columns of models.Message from the memory rows m,
+// including the ids of the siblings of each message.
+var messageColumns = fmt.Sprintf(`
+	m.id, m.plan_id, m.user_id, m.role, m.content, m.previous_message_id, m.next_message_id, m.plan_snapshot, m.created_at,
+	ARRAY(
+		SELECT s.id::text FROM %s s
+		WHERE s.plan_id = m.plan_id AND s.user_id = m.user_id
+			AND s.previous_message_id IS NOT DISTINCT FROM m.previous_message_id
+		ORDER BY s.created_at, s.id
+	) AS siblings`, MemoryTableName)
 
 var (
 	ErrMemoryNotFound   = errors.New("memory resource not found")
@@ -68,6 +83,7 @@ func (s *MemoryStore) AddMessage(ctx context.Context, planID, userID string, rol
 	}
 
 	// If PreviousMessageID is not provided, try to find the last message in the conversation
+	explicitPrevious := previousMessageID != nil
 	if previousMessageID == nil {
 		lastMsg, err := s.GetLastMessage(ctx, tx, planID, userID)
 		if err != nil {
@@ -111,7 +127,8 @@ func (s *MemoryStore) AddMessage(ctx context.Context, planID, userID string, rol
 		return nil, fmt.Errorf("failed to insert message: %w", err)
 	}
 
-	// Update the previous message's next_message_id if it exists
+	// Update the previous message's next_message_id if it exists. If the previous
+	// message already had a next message, the new message starts a new branch.
 	if previousMessageID != nil {
 		updateQuery := fmt.Sprintf(`
 			UPDATE %s
@@ -127,20 +144,17 @@ func (s *MemoryStore) AddMessage(ctx context.Context, planID, userID string, rol
 		}
 	}
 
+	// A message added to an older message makes its branch the active one.
+	if explicitPrevious {
+		if _, err := s.activateBranch(ctx, tx, planID, userID, newMessageID); err != nil {
+			return nil, err
+		}
+	}
+
 	// If a valid plan snapshot is provided, update the plan table
 	if planSnapshot != nil {
-		upsertPlanQuery := fmt.Sprintf(`
-			INSERT INTO %s (plan_id, title, description, plan_table)
-			VALUES ($1, $2, $3, $4)
-			ON CONFLICT (plan_id) DO UPDATE
-			SET title = EXCLUDED.title,
-				description = EXCLUDED.description,
-				plan_table = EXCLUDED.plan_table,
-				updated_at = now()
-		`, PlanTableName)
-		_, err = tx.Exec(ctx, upsertPlanQuery, planID, planSnapshot.Title, planSnapshot.Description, planSnapshot.Table)
-		if err != nil {
-			return nil, fmt.Errorf("failed to upsert plan: %w", err)
+		if err := upsertPlanSnapshot(ctx, tx, planID, planSnapshot); err != nil {
+			return nil, err
 		}
 	}
 
@@ -159,10 +173,9 @@ func (s *MemoryStore) AddMessage(ctx context.Context, planID, userID string, rol
 	}, nil
 }
 
-// GetConversation retrieves the full conversation for a plan, ordered by the linked list.
+// GetConversation retrieves the active branch of the conversation for a plan,
+// ordered by the linked list from its root message.
 // Note: For large conversations, we might want to paginate or limit this.
-// For now, we fetch all and sort in Go or use a recursive CTE.
-// Recursive CTE is better for ordering linked lists in SQL.
 func (s *MemoryStore) GetConversation(ctx context.Context, planID, userID string) ([]models.Message, error) {
 	ownsPlan, err := s.userOwnsPlan(ctx, s.db, planID, userID)
 	if err != nil {
@@ -172,53 +185,288 @@ func (s *MemoryStore) GetConversation(ctx context.Context, planID, userID string
 		return nil, ErrMemoryNotFound
 	}
 
-	query := `
-		WITH RECURSIVE conversation AS (
-			SELECT id, plan_id, user_id, role, content, previous_message_id, next_message_id, plan_snapshot, created_at, 0 AS depth, ARRAY[id] AS path
-			FROM memory
-			WHERE plan_id = $1 AND user_id = $2 AND previous_message_id IS NULL
+	messages, err := s.activeBranch(ctx, s.db, planID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	return messages, nil
+}
 
-			UNION ALL
+// GetBranch retrieves the branch of the conversation through a message: its
+// previous messages and the messages following it on its last active branch.
+// The active branch of the conversation is not changed.
+func (s *MemoryStore) GetBranch(ctx context.Context, planID, userID, messageID string) ([]models.Message, error) {
+	ownsPlan, err := s.userOwnsPlan(ctx, s.db, planID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify plan ownership: %w", err)
+	}
+	if !ownsPlan {
+		return nil, ErrMemoryNotFound
+	}
 
-			SELECT m.id, m.plan_id, m.user_id, m.role, m.content, m.previous_message_id, m.next_message_id, m.plan_snapshot, m.created_at, c.depth + 1, c.path || m.id
-			FROM memory m
-			INNER JOIN conversation c ON m.previous_message_id = c.id
-				AND m.plan_id = c.plan_id
-				AND m.user_id = c.user_id
-			WHERE m.plan_id = $1 AND m.user_id = $2 AND NOT m.id = ANY(c.path)
-		)
-		SELECT id, plan_id, user_id, role, content, previous_message_id, next_message_id, plan_snapshot, created_at
-		FROM conversation
-		ORDER BY depth;
-	`
+	messages, err := s.branch(ctx, s.db, planID, userID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branch: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, ErrMemoryNotFound
+	}
+	return messages, nil
+}
 
-	messages := make([]models.Message, 0)
-	if err := pgxscan.Select(ctx, s.db, &messages, query, planID, userID); err != nil {
-		return nil, fmt.Errorf("failed to get conversation: %w", err)
+// GetConversationTree retrieves the messages of all branches of the conversation
+// ordered by their creation. The tree is given by their previous message ids.
+func (s *MemoryStore) GetConversationTree(ctx context.Context, planID, userID string) ([]models.Message, error) {
+	ownsPlan, err := s.userOwnsPlan(ctx, s.db, planID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify plan ownership: %w", err)
+	}
+	if !ownsPlan {
+		return nil, ErrMemoryNotFound
 	}
 
+	messages := make([]models.Message, 0)
+	if err := pgxscan.Select(ctx, s.db, &messages, fmt.Sprintf(`
+		SELECT %s
+		FROM %s m
+		WHERE m.plan_id = $1 AND m.user_id = $2
+		ORDER BY m.created_at, m.id
+	`, messageColumns, MemoryTableName), planID, userID); err != nil {
+		return nil, fmt.Errorf("failed to get conversation tree: %w", err)
+	}
 	return messages, nil
 }
 
-// GetLastMessage retrieves the last message in the conversation (where next_message_id is NULL).
+// BranchMessage adds a sibling of a message with the same role and previous
+// message but new content, e.g. an edited user message. The new message
+// becomes the end of the active branch, the original branch is kept.
+func (s *MemoryStore) BranchMessage(ctx context.Context, messageID, userID, content string) (*models.Message, error) {
+	if content == "" {
+		return nil, fmt.Errorf("%w: content is required", ErrMemoryValidation)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var original models.Message
+	err = tx.QueryRow(ctx, fmt.Sprintf(`
+		SELECT plan_id, role, previous_message_id
+		FROM %s WHERE id = $1 AND user_id = $2
+	`, MemoryTableName), messageID, userID).Scan(&original.PlanID, &original.Role, &original.PreviousMessageID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get message to branch: %w", err)
+	}
+	ownsPlan, err := s.userOwnsPlan(ctx, tx, original.PlanID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify plan ownership: %w", err)
+	}
+	if !ownsPlan {
+		return nil, ErrMemoryNotFound
+	}
+
+	var newMessageID string
+	err = tx.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %s (plan_id, user_id, role, content, previous_message_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, MemoryTableName), original.PlanID, userID, original.Role, content, original.PreviousMessageID).Scan(&newMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert message: %w", err)
+	}
+
+	branch, err := s.activateBranch(ctx, tx, original.PlanID, userID, newMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// The new message has no next message yet, so it ends the branch.
+	return &branch[len(branch)-1], nil
+}
+
+// SelectBranch makes the branch through a message the active branch of its
+// conversation. The latest plan snapshot of the branch becomes the current plan.
+func (s *MemoryStore) SelectBranch(ctx context.Context, messageID, userID string) ([]models.Message, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var planID string
+	err = tx.QueryRow(ctx, fmt.Sprintf(`SELECT plan_id FROM %s WHERE id = $1 AND user_id = $2`, MemoryTableName),
+		messageID, userID).Scan(&planID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get message to select: %w", err)
+	}
+	ownsPlan, err := s.userOwnsPlan(ctx, tx, planID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify plan ownership: %w", err)
+	}
+	if !ownsPlan {
+		return nil, ErrMemoryNotFound
+	}
+
+	branch, err := s.activateBranch(ctx, tx, planID, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(branch) - 1; i >= 0; i-- {
+		if branch[i].PlanSnapshot != nil {
+			if err := upsertPlanSnapshot(ctx, tx, planID, branch[i].PlanSnapshot); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return branch, nil
+}
+
+// GetLastMessage retrieves the last message on the active branch of the conversation.
 // It accepts a querier (tx or pool) to support transactions.
 func (s *MemoryStore) GetLastMessage(ctx context.Context, q pgxscan.Querier, planID, userID string) (*models.Message, error) {
-	query := `
-		SELECT id, plan_id, user_id, role, content, previous_message_id, next_message_id, plan_snapshot, created_at
-		FROM memory
-		WHERE plan_id = $1 AND user_id = $2 AND next_message_id IS NULL
-		LIMIT 1
-	`
+	messages, err := s.activeBranch(ctx, q, planID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last message: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	return &messages[len(messages)-1], nil
+}
 
-	var message models.Message
-	if err := pgxscan.Get(ctx, q, &message, query, planID, userID); err != nil {
+// activeBranch returns the messages of the active branch, starting at the
+// selected root message or the latest root message if none was selected.
+func (s *MemoryStore) activeBranch(ctx context.Context, q pgxscan.Querier, planID, userID string) ([]models.Message, error) {
+	var rootID string
+	err := pgxscan.Get(ctx, q, &rootID, fmt.Sprintf(`
+		SELECT m.id
+		FROM %s m
+		LEFT JOIN %s b ON b.plan_id = m.plan_id AND b.user_id = m.user_id AND b.root_message_id = m.id
+		WHERE m.plan_id = $1 AND m.user_id = $2 AND m.previous_message_id IS NULL
+		ORDER BY b.root_message_id IS NULL, m.created_at DESC
+		LIMIT 1
+	`, MemoryTableName, MemoryBranchTableName), planID, userID)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
+			return []models.Message{}, nil
 		}
-		return nil, fmt.Errorf("failed to get last message: %w", err)
+		return nil, err
 	}
+	return s.branch(ctx, q, planID, userID, rootID)
+}
+
+// branch returns the previous messages of a message, the message itself and
+// the messages following it via their next message ids.
+// The paths prevent malformed cycles from causing an unbounded query.
+func (s *MemoryStore) branch(ctx context.Context, q pgxscan.Querier, planID, userID, messageID string) ([]models.Message, error) {
+	query := fmt.Sprintf(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, previous_message_id, 0 AS depth, ARRAY[id] AS path
+			FROM %[1]s
+			WHERE id = $3 AND plan_id = $1 AND user_id = $2
+
+			UNION ALL
+
+			SELECT m.id, m.previous_message_id, a.depth - 1, a.path || m.id
+			FROM %[1]s m
+			INNER JOIN ancestors a ON m.id = a.previous_message_id
+			WHERE m.plan_id = $1 AND m.user_id = $2 AND NOT m.id = ANY(a.path)
+		), descendants AS (
+			SELECT id, next_message_id, 0 AS depth, ARRAY[id] AS path
+			FROM %[1]s
+			WHERE id = $3 AND plan_id = $1 AND user_id = $2
+
+			UNION ALL
 
-	return &message, nil
+			SELECT m.id, m.next_message_id, d.depth + 1, d.path || m.id
+			FROM %[1]s m
+			INNER JOIN descendants d ON m.id = d.next_message_id AND m.previous_message_id = d.id
+			WHERE m.plan_id = $1 AND m.user_id = $2 AND NOT m.id = ANY(d.path)
+		), branch AS (
+			SELECT id, depth FROM ancestors
+			UNION
+			SELECT id, depth FROM descendants
+		)
+		SELECT %[2]s
+		FROM branch b
+		INNER JOIN %[1]s m ON m.id = b.id
+		ORDER BY b.depth
+	`, MemoryTableName, messageColumns)
+
+	messages := make([]models.Message, 0)
+	if err := pgxscan.Select(ctx, q, &messages, query, planID, userID, messageID); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// activateBranch points the previous messages of a message to the branch
+// through it and selects its root message, so that it is on the active branch.
+func (s *MemoryStore) activateBranch(ctx context.Context, tx pgx.Tx, planID, userID, messageID string) ([]models.Message, error) {
+	branch, err := s.branch(ctx, tx, planID, userID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branch: %w", err)
+	}
+	if len(branch) == 0 {
+		return nil, ErrMemoryNotFound
+	}
+
+	updateNext := fmt.Sprintf(`UPDATE %s SET next_message_id = $1 WHERE id = $2 AND plan_id = $3 AND user_id = $4`, MemoryTableName)
+	for i := range branch[:len(branch)-1] {
+		nextID := branch[i+1].ID
+		if branch[i].NextMessageID != nil && *branch[i].NextMessageID == nextID {
+			continue
+		}
+		if _, err := tx.Exec(ctx, updateNext, nextID, branch[i].ID, planID, userID); err != nil {
+			return nil, fmt.Errorf("failed to update branch: %w", err)
+		}
+		branch[i].NextMessageID = &nextID
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (plan_id, user_id, root_message_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (plan_id, user_id) DO UPDATE
+		SET root_message_id = EXCLUDED.root_message_id,
+			updated_at = now()
+	`, MemoryBranchTableName), planID, userID, branch[0].ID); err != nil {
+		return nil, fmt.Errorf("failed to select branch: %w", err)
+	}
+	return branch, nil
+}
+
+// upsertPlanSnapshot makes a plan snapshot of the conversation the current plan.
+func upsertPlanSnapshot(ctx context.Context, tx pgx.Tx, planID string, snapshot *models.Plan) error {
+	_, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (plan_id, title, description, plan_table)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (plan_id) DO UPDATE
+		SET title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			plan_table = EXCLUDED.plan_table,
+			updated_at = now()
+	`, PlanTableName), planID, snapshot.Title, snapshot.Description, snapshot.Table)
+	if err != nil {
+		return fmt.Errorf("failed to upsert plan: %w", err)
+	}
+	return nil
 }
 
 // DeleteConversation deletes all messages for a plan owned by the user.
@@ -239,7 +487,8 @@ func (s *MemoryStore) DeleteConversation(ctx context.Context, planID, userID str
 	return nil
 }
 
-// DeleteMessage deletes a single message and repairs the linked list.
+// DeleteMessage deletes a single message and repairs the linked list. The
+// messages following it on all branches are attached to its previous message.
 func (s *MemoryStore) DeleteMessage(ctx context.Context, messageID, userID string) error {
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -261,28 +510,24 @@ func (s *MemoryStore) DeleteMessage(ctx context.Context, messageID, userID strin
 		return fmt.Errorf("failed to get message to delete: %w", err)
 	}
 
-	// Update previous message to point to next message
+	// Update previous message to point to next message, if the message is on its active branch
 	if msg.PreviousMessageID != nil {
-		updatePrev := fmt.Sprintf(`UPDATE %s SET next_message_id = $1 WHERE id = $2 AND plan_id = $3 AND user_id = $4`, MemoryTableName)
-		result, err := tx.Exec(ctx, updatePrev, msg.NextMessageID, *msg.PreviousMessageID, msg.PlanID, userID)
-		if err != nil {
+		updatePrev := fmt.Sprintf(`UPDATE %s SET next_message_id = $1 WHERE id = $2 AND plan_id = $3 AND user_id = $4 AND next_message_id = $5`, MemoryTableName)
+		if _, err := tx.Exec(ctx, updatePrev, msg.NextMessageID, *msg.PreviousMessageID, msg.PlanID, userID, messageID); err != nil {
 			return fmt.Errorf("failed to update previous message: %w", err)
 		}
-		if result.RowsAffected() != 1 {
-			return fmt.Errorf("failed to update previous message: %w", ErrMemoryNotFound)
+	} else if msg.NextMessageID != nil {
+		// The next message becomes the root of the branch.
+		updateRoot := fmt.Sprintf(`UPDATE %s SET root_message_id = $1 WHERE plan_id = $2 AND user_id = $3 AND root_message_id = $4`, MemoryBranchTableName)
+		if _, err := tx.Exec(ctx, updateRoot, *msg.NextMessageID, msg.PlanID, userID, messageID); err != nil {
+			return fmt.Errorf("failed to update branch root: %w", err)
 		}
 	}
 
-	// Update next message to point to previous message
-	if msg.NextMessageID != nil {
-		updateNext := fmt.Sprintf(`UPDATE %s SET previous_message_id = $1 WHERE id = $2 AND plan_id = $3 AND user_id = $4`, MemoryTableName)
-		result, err := tx.Exec(ctx, updateNext, msg.PreviousMessageID, *msg.NextMessageID, msg.PlanID, userID)
-		if err != nil {
-			return fmt.Errorf("failed to update next message: %w", err)
-		}
-		if result.RowsAffected() != 1 {
-			return fmt.Errorf("failed to update next message: %w", ErrMemoryNotFound)
-		}
+	// Update following messages to point to previous message
+	updateNext := fmt.Sprintf(`UPDATE %s SET previous_message_id = $1 WHERE previous_message_id = $2 AND plan_id = $3 AND user_id = $4`, MemoryTableName)
+	if _, err := tx.Exec(ctx, updateNext, msg.PreviousMessageID, messageID, msg.PlanID, userID); err != nil {
+		return fmt.Errorf("failed to update next messages: %w", err)
 	}
 
 	// Delete the message
@@ -302,7 +547,8 @@ func (s *MemoryStore) DeleteMessage(ctx context.Context, messageID, userID strin
 	return nil
 }
 
-// DeleteMessagesAfter deletes the given message and all subsequent messages in the conversation.
+// DeleteMessagesAfter deletes the given message and all subsequent messages on
+// all of its branches. Prefer branching with BranchMessage, which keeps them.
 func (s *MemoryStore) DeleteMessagesAfter(ctx context.Context, messageID, userID string) error {
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -338,35 +584,31 @@ func (s *MemoryStore) DeleteMessagesAfter(ctx context.Context, messageID, userID
 		}
 	}
 
-	// Recursive query to find all subsequent messages (including the target message).
+	// Recursive query to find all subsequent messages on all branches (including the target message).
 	// The path prevents malformed cycles from causing an unbounded query.
 	deleteQuery := fmt.Sprintf(`
-		WITH RECURSIVE chain AS (
-			SELECT id, next_message_id, ARRAY[id] AS path
+		WITH RECURSIVE subtree AS (
+			SELECT id, ARRAY[id] AS path
 			FROM %s
 			WHERE id = $1 AND plan_id = $2 AND user_id = $3
 
 			UNION ALL
 
-			SELECT m.id, m.next_message_id, c.path || m.id
+			SELECT m.id, t.path || m.id
 			FROM %s m
-			INNER JOIN chain c ON m.previous_message_id = c.id
-			WHERE m.plan_id = $2 AND m.user_id = $3 AND NOT m.id = ANY(c.path)
+			INNER JOIN subtree t ON m.previous_message_id = t.id
+			WHERE m.plan_id = $2 AND m.user_id = $3 AND NOT m.id = ANY(t.path)
 		)
 
 		DELETE FROM %s
-		WHERE plan_id = $2 AND user_id = $3 AND id IN (SELECT id FROM chain)
+		WHERE plan_id = $2 AND user_id = $3 AND id IN (SELECT id FROM subtree)
 	`, MemoryTableName, MemoryTableName, MemoryTableName)
 
 	if msg.PreviousMessageID != nil {
-		updatePrev := fmt.Sprintf(`UPDATE %s SET next_message_id = NULL WHERE id = $1 AND plan_id = $2 AND user_id = $3`, MemoryTableName)
-		result, err := tx.Exec(ctx, updatePrev, *msg.PreviousMessageID, msg.PlanID, userID)
-		if err != nil {
+		updatePrev := fmt.Sprintf(`UPDATE %s SET next_message_id = NULL WHERE id = $1 AND plan_id = $2 AND user_id = $3 AND next_message_id = $4`, MemoryTableName)
+		if _, err := tx.Exec(ctx, updatePrev, *msg.PreviousMessageID, msg.PlanID, userID, messageID); err != nil {
 			return fmt.Errorf("failed to update previous message: %w", err)
 		}
-		if result.RowsAffected() != 1 {
-			return fmt.Errorf("failed to update previous message: %w", ErrMemoryNotFound)
-		}
 	}
 
 	result, err := tx.Exec(ctx, deleteQuery, messageID, msg.PlanID, userID)
@@ -377,9 +619,64 @@ func (s *MemoryStore) DeleteMessagesAfter(ctx context.Context, messageID, userID
 		return ErrMemoryNotFound
 	}
 
+	// Continue the active branch with the latest remaining sibling, if any.
+	if msg.PreviousMessageID != nil {
+		updatePrev := fmt.Sprintf(`
+			UPDATE %s p SET next_message_id = (
+				SELECT s.id FROM %s s
+				WHERE s.previous_message_id = p.id AND s.plan_id = p.plan_id AND s.user_id = p.user_id
+				ORDER BY s.created_at DESC
+				LIMIT 1
+			)
+			WHERE p.id = $1 AND p.plan_id = $2 AND p.user_id = $3 AND p.next_message_id IS NULL
+		`, MemoryTableName, MemoryTableName)
+		if _, err := tx.Exec(ctx, updatePrev, *msg.PreviousMessageID, msg.PlanID, userID); err != nil {
+			return fmt.Errorf("failed to update previous message: %w", err)
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
+
+// GetSummary retrieves the rolling summary of the conversation, nil if none was saved yet.
+func (s *MemoryStore) GetSummary(ctx context.Context, planID, userID string) (*models.ConversationSummary, error) {
+	var summary models.ConversationSummary
+	err := pgxscan.Get(ctx, s.db, &summary, fmt.Sprintf(`
+		SELECT plan_id, user_id, summary, last_message_id, updated_at
+		FROM %s
+		WHERE plan_id = $1 AND user_id = $2
+	`, MemorySummaryTableName), planID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// SaveSummary stores the summary of the conversation up to its last message,
+// which has to belong to the conversation.
+func (s *MemoryStore) SaveSummary(ctx context.Context, summary *models.ConversationSummary) error {
+	result, err := s.db.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (plan_id, user_id, summary, last_message_id)
+		SELECT plan_id, user_id, $3, id
+		FROM %s
+		WHERE id = $4 AND plan_id = $1 AND user_id = $2
+		ON CONFLICT (plan_id, user_id) DO UPDATE
+		SET summary = EXCLUDED.summary,
+			last_message_id = EXCLUDED.last_message_id,
+			updated_at = now()
+	`, MemorySummaryTableName, MemoryTableName), summary.PlanID, summary.UserID, summary.Summary, summary.LastMessageID)
+	if err != nil {
+		return fmt.Errorf("failed to save summary: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrMemoryNotFound
+	}
+	return nil
+}
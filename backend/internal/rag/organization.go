@@ -0,0 +1,654 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/go-chi/httplog/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	OrganizationTableName           = "organizations"
+	OrganizationMemberTableName     = "organization_members"
+	SquadTableName                  = "squads"
+	SquadMemberTableName            = "squad_members"
+	PlanAssignmentTableName         = "plan_assignments"
+	OrganizationPlanTableName       = "organization_plans"
+	OrganizationInvitationTableName = "organization_invitations"
+)
+
+var (
+	ErrOrganizationNotFound           = errors.New("organization not found or user is not a member")
+	ErrOrganizationForbidden          = errors.New("only coaches may manage the organization")
+	ErrMemberNotFound                 = errors.New("user not found or not a member of the organization")
+	ErrSquadNotFound                  = errors.New("squad not found in the organization")
+	ErrLastCoach                      = errors.New("the last coach can not leave the organization or become a swimmer")
+	ErrOrganizationInvitationNotFound = errors.New("organization invitation not found")
+)
+
+// memberRole returns the role of the user in the organization. Every query on an
+// organization is authorized with it, as the backend bypasses the RLS policies.
+func memberRole(ctx context.Context, q pgxscan.Querier, orgID, userID string) (models.MemberRole, error) {
+	var role models.MemberRole
+	err := pgxscan.Get(ctx, q, &role, fmt.Sprintf(
+		`SELECT role FROM %s WHERE org_id = $1 AND user_id = $2`, OrganizationMemberTableName), orgID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrOrganizationNotFound
+	}
+	return role, err
+}
+
+// requireCoach returns ErrOrganizationForbidden if the user is not a coach of the organization.
+func requireCoach(ctx context.Context, q pgxscan.Querier, orgID, userID string) error {
+	role, err := memberRole(ctx, q, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if role != models.MemberRoleCoach {
+		return ErrOrganizationForbidden
+	}
+	return nil
+}
+
+// checkCoachesLeft returns ErrLastCoach if a member with the role current would
+// leave the coaches of the organization empty by taking the role next. An empty
+// next role removes the member.
+func checkCoachesLeft(current, next models.MemberRole, coaches int) error {
+	if current == models.MemberRoleCoach && next != models.MemberRoleCoach && coaches <= 1 {
+		return ErrLastCoach
+	}
+	return nil
+}
+
+// lockCoaches locks the coaches of the organization and returns their number, so
+// that two coaches can not leave or be demoted at the same time.
+func lockCoaches(ctx context.Context, tx pgx.Tx, orgID string) (int, error) {
+	var coaches []string
+	if err := pgxscan.Select(ctx, tx, &coaches, fmt.Sprintf(
+		`SELECT user_id FROM %s WHERE org_id = $1 AND role = $2 FOR UPDATE`, OrganizationMemberTableName),
+		orgID, models.MemberRoleCoach); err != nil {
+		return 0, fmt.Errorf("error querying coaches: %w", err)
+	}
+	return len(coaches), nil
+}
+
+// CreateOrganization creates an organization with the user as its first coach.
+func (db *RAGDB) CreateOrganization(ctx context.Context, name, userID string) (*models.Organization, error) {
+	logger := httplog.LogEntry(ctx)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	org := &models.Organization{Name: name, Role: models.MemberRoleCoach}
+	if err = tx.QueryRow(ctx, fmt.Sprintf(
+		`INSERT INTO %s (name, created_by) VALUES ($1, $2) RETURNING org_id, created_at`, OrganizationTableName),
+		name, userID).Scan(&org.OrgID, &org.CreatedAt); err != nil {
+		logger.Error("Error inserting organization", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error inserting organization: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, fmt.Sprintf(
+		`INSERT INTO %s (org_id, user_id, role) VALUES ($1, $2, $3)`, OrganizationMemberTableName),
+		org.OrgID, userID, models.MemberRoleCoach); err != nil {
+		logger.Error("Error adding coach to organization", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error adding coach to organization: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	logger.Debug("Organization created successfully", "org_id", org.OrgID)
+	return org, nil
+}
+
+// GetOrganizations returns the organizations of the user with the role of the user.
+func (db *RAGDB) GetOrganizations(ctx context.Context, userID string) ([]*models.Organization, error) {
+	logger := httplog.LogEntry(ctx)
+
+	var orgs []*models.Organization
+	err := pgxscan.Select(ctx, db.Conn, &orgs, fmt.Sprintf(`
+		SELECT o.org_id, o.name, m.role, m.share_feedback, o.created_at
+		FROM %s o
+		JOIN %s m ON m.org_id = o.org_id
+		WHERE m.user_id = $1
+		ORDER BY o.name`, OrganizationTableName, OrganizationMemberTableName), userID)
+	if err != nil {
+		logger.Error("Error querying organizations", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying organizations: %w", err)
+	}
+
+	if len(orgs) == 0 {
+		orgs = []*models.Organization{}
+	}
+	return orgs, nil
+}
+
+// GetOrganizationMembers returns the members of an organization of the user.
+// Coaches also see the users who have not accepted their invitation yet.
+func (db *RAGDB) GetOrganizationMembers(ctx context.Context, orgID, userID string) ([]*models.OrganizationMember, error) {
+	logger := httplog.LogEntry(ctx)
+
+	role, err := memberRole(ctx, db.Conn, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []*models.OrganizationMember
+	err = pgxscan.Select(ctx, db.Conn, &members, fmt.Sprintf(`
+		SELECT m.user_id, coalesce(p.username, '') AS username, m.role, 'active' AS status, m.created_at
+		FROM %s m
+		LEFT JOIN profiles p ON p.user_id = m.user_id
+		WHERE m.org_id = $1
+		UNION ALL
+		SELECT i.user_id, coalesce(p.username, '') AS username, i.role, 'invited' AS status, i.created_at
+		FROM %s i
+		LEFT JOIN profiles p ON p.user_id = i.user_id
+		WHERE i.org_id = $1 AND $2 = 'coach'
+		ORDER BY status, role, username`, OrganizationMemberTableName, OrganizationInvitationTableName), orgID, role)
+	if err != nil {
+		logger.Error("Error querying organization members", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying organization members: %w", err)
+	}
+	return members, nil
+}
+
+// AddOrganizationMember invites the user with the username to the organization
+// or changes the role of an existing member. Invited users only become members
+// once they accept the invitation. Only coaches may add members and the last
+// coach can not become a swimmer.
+func (db *RAGDB) AddOrganizationMember(ctx context.Context, orgID, coachID, username string, role models.MemberRole) (*models.OrganizationMember, error) {
+	logger := httplog.LogEntry(ctx)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err = requireCoach(ctx, tx, orgID, coachID); err != nil {
+		return nil, err
+	}
+
+	var memberID string
+	err = pgxscan.Get(ctx, tx, &memberID, `SELECT user_id FROM profiles WHERE lower(username) = lower($1)`, username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMemberNotFound
+		}
+		logger.Error("Error querying profile", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying profile: %w", err)
+	}
+
+	current, err := memberRole(ctx, tx, orgID, memberID)
+	if err != nil && !errors.Is(err, ErrOrganizationNotFound) {
+		logger.Error("Error querying member role", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying member role: %w", err)
+	}
+
+	var member models.OrganizationMember
+	if current == "" {
+		err = pgxscan.Get(ctx, tx, &member, fmt.Sprintf(`
+			WITH invited AS (
+				INSERT INTO %s (org_id, user_id, role, invited_by)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (org_id, user_id) DO UPDATE SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by
+				RETURNING user_id, role, created_at
+			)
+			SELECT i.user_id, p.username, i.role, 'invited' AS status, i.created_at
+			FROM invited i
+			JOIN profiles p ON p.user_id = i.user_id`, OrganizationInvitationTableName), orgID, memberID, role, coachID)
+		if err != nil {
+			logger.Error("Error inviting organization member", httplog.ErrAttr(err))
+			return nil, fmt.Errorf("error inviting organization member: %w", err)
+		}
+		if err = tx.Commit(ctx); err != nil {
+			logger.Error("Error committing transaction", httplog.ErrAttr(err))
+			return nil, fmt.Errorf("error committing transaction: %w", err)
+		}
+		logger.Debug("Organization member invited successfully", "org_id", orgID, "member_id", member.UserID)
+		return &member, nil
+	}
+
+	if current == models.MemberRoleCoach && role != models.MemberRoleCoach {
+		coaches, err := lockCoaches(ctx, tx, orgID)
+		if err != nil {
+			logger.Error("Error locking coaches", httplog.ErrAttr(err))
+			return nil, err
+		}
+		if err = checkCoachesLeft(current, role, coaches); err != nil {
+			return nil, err
+		}
+	}
+
+	err = pgxscan.Get(ctx, tx, &member, fmt.Sprintf(`
+		WITH updated AS (
+			UPDATE %s SET role = $3
+			WHERE org_id = $1 AND user_id = $2
+			RETURNING user_id, role, created_at
+		)
+		SELECT u.user_id, p.username, u.role, 'active' AS status, u.created_at
+		FROM updated u
+		JOIN profiles p ON p.user_id = u.user_id`, OrganizationMemberTableName), orgID, memberID, role)
+	if err != nil {
+		logger.Error("Error updating organization member", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error updating organization member: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	logger.Debug("Organization member updated successfully", "org_id", orgID, "member_id", member.UserID)
+	return &member, nil
+}
+
+// RemoveOrganizationMember removes a member from the organization and its squads.
+// Coaches may remove every member and withdraw invitations, swimmers only remove
+// themselves. The last coach can not be removed.
+func (db *RAGDB) RemoveOrganizationMember(ctx context.Context, orgID, userID, memberID string) error {
+	logger := httplog.LogEntry(ctx)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if userID != memberID {
+		if err = requireCoach(ctx, tx, orgID, userID); err != nil {
+			return err
+		}
+	}
+	role, err := memberRole(ctx, tx, orgID, memberID)
+	if errors.Is(err, ErrOrganizationNotFound) && userID != memberID {
+		tag, err := tx.Exec(ctx, fmt.Sprintf(
+			`DELETE FROM %s WHERE org_id = $1 AND user_id = $2`, OrganizationInvitationTableName), orgID, memberID)
+		if err != nil {
+			logger.Error("Error withdrawing organization invitation", httplog.ErrAttr(err))
+			return fmt.Errorf("error withdrawing organization invitation: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrMemberNotFound
+		}
+		if err = tx.Commit(ctx); err != nil {
+			logger.Error("Error committing transaction", httplog.ErrAttr(err))
+			return fmt.Errorf("error committing transaction: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		if errors.Is(err, ErrOrganizationNotFound) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+
+	if role == models.MemberRoleCoach {
+		coaches, err := lockCoaches(ctx, tx, orgID)
+		if err != nil {
+			logger.Error("Error locking coaches", httplog.ErrAttr(err))
+			return err
+		}
+		if err = checkCoachesLeft(role, "", coaches); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(ctx, fmt.Sprintf(
+		`DELETE FROM %s WHERE org_id = $1 AND user_id = $2`, OrganizationMemberTableName), orgID, memberID); err != nil {
+		logger.Error("Error removing organization member", httplog.ErrAttr(err))
+		return fmt.Errorf("error removing organization member: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateSquad creates a squad with the given members, which must be members of
+// the organization. Only coaches may create squads.
+func (db *RAGDB) CreateSquad(ctx context.Context, orgID, coachID, name string, members []string) (*models.Squad, error) {
+	logger := httplog.LogEntry(ctx)
+
+	slices.Sort(members)
+	members = slices.Compact(members)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err = requireCoach(ctx, tx, orgID, coachID); err != nil {
+		return nil, err
+	}
+
+	var known int
+	if err = tx.QueryRow(ctx, fmt.Sprintf(
+		`SELECT count(*) FROM %s WHERE org_id = $1 AND user_id::text = ANY($2)`, OrganizationMemberTableName),
+		orgID, members).Scan(&known); err != nil {
+		logger.Error("Error checking squad members", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error checking squad members: %w", err)
+	}
+	if known != len(members) {
+		return nil, ErrMemberNotFound
+	}
+
+	squad := &models.Squad{OrgID: orgID, Name: name, Members: members}
+	if squad.Members == nil {
+		squad.Members = []string{}
+	}
+	if err = tx.QueryRow(ctx, fmt.Sprintf(
+		`INSERT INTO %s (org_id, name) VALUES ($1, $2) RETURNING squad_id, created_at`, SquadTableName),
+		orgID, name).Scan(&squad.SquadID, &squad.CreatedAt); err != nil {
+		logger.Error("Error inserting squad", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error inserting squad: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (squad_id, org_id, user_id)
+		SELECT $1, $2, unnest($3::uuid[])`, SquadMemberTableName),
+		squad.SquadID, orgID, members); err != nil {
+		logger.Error("Error inserting squad members", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error inserting squad members: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	logger.Debug("Squad created successfully", "org_id", orgID, "squad_id", squad.SquadID)
+	return squad, nil
+}
+
+// GetSquads returns the squads of an organization of the user.
+func (db *RAGDB) GetSquads(ctx context.Context, orgID, userID string) ([]*models.Squad, error) {
+	logger := httplog.LogEntry(ctx)
+
+	if _, err := memberRole(ctx, db.Conn, orgID, userID); err != nil {
+		return nil, err
+	}
+
+	var squads []*models.Squad
+	err := pgxscan.Select(ctx, db.Conn, &squads, fmt.Sprintf(`
+		SELECT s.squad_id, s.org_id, s.name, s.created_at,
+			coalesce(array_agg(sm.user_id::text ORDER BY sm.user_id) FILTER (WHERE sm.user_id IS NOT NULL), '{}') AS members
+		FROM %s s
+		LEFT JOIN %s sm ON sm.squad_id = s.squad_id
+		WHERE s.org_id = $1
+		GROUP BY s.squad_id
+		ORDER BY s.name`, SquadTableName, SquadMemberTableName), orgID)
+	if err != nil {
+		logger.Error("Error querying squads", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying squads: %w", err)
+	}
+
+	if len(squads) == 0 {
+		squads = []*models.Squad{}
+	}
+	return squads, nil
+}
+
+// AssignPlan assigns a plan of the coach to a squad of the organization. The
+// swimmers of the squad can read the plan and give feedback on it.
+func (db *RAGDB) AssignPlan(ctx context.Context, orgID, squadID, planID, coachID string) error {
+	logger := httplog.LogEntry(ctx)
+
+	if err := requireCoach(ctx, db.Conn, orgID, coachID); err != nil {
+		return err
+	}
+
+	var squadExists bool
+	if err := db.Conn.QueryRow(ctx, fmt.Sprintf(
+		`SELECT EXISTS (SELECT 1 FROM %s WHERE squad_id = $1 AND org_id = $2)`, SquadTableName),
+		squadID, orgID).Scan(&squadExists); err != nil {
+		logger.Error("Error checking squad", httplog.ErrAttr(err))
+		return fmt.Errorf("error checking squad: %w", err)
+	}
+	if !squadExists {
+		return ErrSquadNotFound
+	}
+
+	// Authorize the plan and assign it in one statement
+	var assigned bool
+	err := db.Conn.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %s (plan_id, squad_id, assigned_by)
+		SELECT $1, $2, $3
+		WHERE EXISTS (
+			SELECT 1 FROM %s WHERE plan_id = $1 AND user_id = $3
+			UNION ALL
+			SELECT 1 FROM %s WHERE plan_id = $1 AND user_id = $3
+		)
+		ON CONFLICT (plan_id, squad_id) DO UPDATE SET assigned_by = EXCLUDED.assigned_by
+		RETURNING true`, PlanAssignmentTableName, HistoryTableName, DonatedPlanTable),
+		planID, squadID, coachID).Scan(&assigned)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPlanNotFound
+		}
+		logger.Error("Error assigning plan", httplog.ErrAttr(err))
+		return fmt.Errorf("error assigning plan: %w", err)
+	}
+
+	logger.Debug("Plan assigned successfully", "org_id", orgID, "squad_id", squadID, "plan_id", planID)
+	return nil
+}
+
+// SharePlanWithOrganization shares a plan of the user with all members of the
+// organization, without creating a public link.
+func (db *RAGDB) SharePlanWithOrganization(ctx context.Context, orgID, planID, userID string) error {
+	logger := httplog.LogEntry(ctx)
+
+	if _, err := memberRole(ctx, db.Conn, orgID, userID); err != nil {
+		return err
+	}
+
+	var shared bool
+	err := db.Conn.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %s (org_id, plan_id, shared_by)
+		SELECT $1, $2, $3
+		WHERE EXISTS (
+			SELECT 1 FROM %s WHERE plan_id = $2 AND user_id = $3
+			UNION ALL
+			SELECT 1 FROM %s WHERE plan_id = $2 AND user_id = $3
+		)
+		ON CONFLICT (org_id, plan_id) DO UPDATE SET shared_by = %s.shared_by
+		RETURNING true`, OrganizationPlanTableName, HistoryTableName, DonatedPlanTable, OrganizationPlanTableName),
+		orgID, planID, userID).Scan(&shared)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPlanNotFound
+		}
+		logger.Error("Error sharing plan with organization", httplog.ErrAttr(err))
+		return fmt.Errorf("error sharing plan with organization: %w", err)
+	}
+
+	logger.Debug("Plan shared with organization successfully", "org_id", orgID, "plan_id", planID)
+	return nil
+}
+
+// GetOrganizationPlans returns the plans shared with the organization and the
+// plans assigned to its squads. Swimmers only see the assignments of their own
+// squads, coaches see all of them.
+func (db *RAGDB) GetOrganizationPlans(ctx context.Context, orgID, userID string) ([]*models.OrganizationPlan, error) {
+	logger := httplog.LogEntry(ctx)
+
+	role, err := memberRole(ctx, db.Conn, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []*models.OrganizationPlan
+	err = pgxscan.Select(ctx, db.Conn, &plans, fmt.Sprintf(`
+		SELECT op.org_id, NULL::uuid AS squad_id, p.plan_id, p.title, p.description, p.plan_table, pr.username AS shared_by, op.created_at
+		FROM %[1]s op
+		JOIN %[2]s p ON p.plan_id = op.plan_id
+		LEFT JOIN profiles pr ON pr.user_id = op.shared_by
+		WHERE op.org_id = $1
+		UNION ALL
+		SELECT s.org_id, pa.squad_id, p.plan_id, p.title, p.description, p.plan_table, pr.username AS shared_by, pa.created_at
+		FROM %[3]s pa
+		JOIN %[4]s s ON s.squad_id = pa.squad_id
+		JOIN %[2]s p ON p.plan_id = pa.plan_id
+		LEFT JOIN profiles pr ON pr.user_id = pa.assigned_by
+		WHERE s.org_id = $1
+		  AND ($3 = 'coach' OR EXISTS (
+			SELECT 1 FROM %[5]s sm WHERE sm.squad_id = pa.squad_id AND sm.user_id = $2
+		  ))
+		ORDER BY created_at DESC`,
+		OrganizationPlanTableName, PlanTableName, PlanAssignmentTableName, SquadTableName, SquadMemberTableName),
+		orgID, userID, role)
+	if err != nil {
+		logger.Error("Error querying organization plans", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying organization plans: %w", err)
+	}
+
+	if len(plans) == 0 {
+		plans = []*models.OrganizationPlan{}
+	}
+	return plans, nil
+}
+
+// GetPlanAssignmentStatus returns the swimmers of the squads the plan is assigned
+// to and whether they marked the plan as swum in their feedback. Ratings and the
+// time of the feedback are only returned for swimmers who share their feedback
+// with the organization. Only coaches may see the status.
+func (db *RAGDB) GetPlanAssignmentStatus(ctx context.Context, orgID, planID, coachID string) ([]*models.SwimmerPlanStatus, error) {
+	logger := httplog.LogEntry(ctx)
+
+	if err := requireCoach(ctx, db.Conn, orgID, coachID); err != nil {
+		return nil, err
+	}
+
+	var status []*models.SwimmerPlanStatus
+	err := pgxscan.Select(ctx, db.Conn, &status, fmt.Sprintf(`
+		SELECT sm.user_id, coalesce(pr.username, '') AS username, pa.squad_id,
+			coalesce(f.was_swam, false) AS was_swam,
+			CASE WHEN om.share_feedback THEN f.rating END AS rating,
+			CASE WHEN om.share_feedback THEN f.difficulty_rating END AS difficulty_rating,
+			CASE WHEN om.share_feedback THEN f.updated_at END AS feedback_at
+		FROM %s pa
+		JOIN %s s ON s.squad_id = pa.squad_id
+		JOIN %s sm ON sm.squad_id = pa.squad_id
+		JOIN %s om ON om.org_id = s.org_id AND om.user_id = sm.user_id
+		LEFT JOIN profiles pr ON pr.user_id = sm.user_id
+		LEFT JOIN %s f ON f.plan_id = pa.plan_id AND f.user_id = sm.user_id
+		WHERE pa.plan_id = $1 AND s.org_id = $2
+		ORDER BY username`, PlanAssignmentTableName, SquadTableName, SquadMemberTableName, OrganizationMemberTableName, FeedbackTable),
+		planID, orgID)
+	if err != nil {
+		logger.Error("Error querying plan assignment status", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying plan assignment status: %w", err)
+	}
+
+	if len(status) == 0 {
+		status = []*models.SwimmerPlanStatus{}
+	}
+	return status, nil
+}
+
+// GetOrganizationInvitations returns the open invitations of the user.
+func (db *RAGDB) GetOrganizationInvitations(ctx context.Context, userID string) ([]*models.OrganizationInvitation, error) {
+	logger := httplog.LogEntry(ctx)
+
+	var invitations []*models.OrganizationInvitation
+	err := pgxscan.Select(ctx, db.Conn, &invitations, fmt.Sprintf(`
+		SELECT i.invitation_id, i.org_id, o.name AS org_name, i.role, coalesce(p.username, '') AS invited_by, i.created_at
+		FROM %s i
+		JOIN %s o ON o.org_id = i.org_id
+		LEFT JOIN profiles p ON p.user_id = i.invited_by
+		WHERE i.user_id = $1
+		ORDER BY i.created_at DESC`, OrganizationInvitationTableName, OrganizationTableName), userID)
+	if err != nil {
+		logger.Error("Error querying organization invitations", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying organization invitations: %w", err)
+	}
+
+	if len(invitations) == 0 {
+		invitations = []*models.OrganizationInvitation{}
+	}
+	return invitations, nil
+}
+
+// AcceptOrganizationInvitation makes the invited user a member of the
+// organization with the role of the invitation. Only the invited user can accept
+// it, unknown and foreign invitations return ErrOrganizationInvitationNotFound.
+func (db *RAGDB) AcceptOrganizationInvitation(ctx context.Context, invitationID, userID string) (*models.Organization, error) {
+	logger := httplog.LogEntry(ctx)
+
+	// Move the invitation to the members in one statement
+	var org models.Organization
+	err := pgxscan.Get(ctx, db.Conn, &org, fmt.Sprintf(`
+		WITH accepted AS (
+			DELETE FROM %[1]s WHERE invitation_id = $1 AND user_id = $2
+			RETURNING org_id, user_id, role
+		), joined AS (
+			INSERT INTO %[2]s (org_id, user_id, role)
+			SELECT org_id, user_id, role FROM accepted
+			ON CONFLICT (org_id, user_id) DO UPDATE SET role = %[2]s.role
+			RETURNING org_id, role, share_feedback
+		)
+		SELECT o.org_id, o.name, j.role, j.share_feedback, o.created_at
+		FROM joined j
+		JOIN %[3]s o ON o.org_id = j.org_id`, OrganizationInvitationTableName, OrganizationMemberTableName, OrganizationTableName),
+		invitationID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrganizationInvitationNotFound
+		}
+		logger.Error("Error accepting organization invitation", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error accepting organization invitation: %w", err)
+	}
+
+	logger.Debug("Organization invitation accepted successfully", "org_id", org.OrgID, "invitation_id", invitationID)
+	return &org, nil
+}
+
+// DeclineOrganizationInvitation removes an invitation of the user.
+func (db *RAGDB) DeclineOrganizationInvitation(ctx context.Context, invitationID, userID string) error {
+	tag, err := db.Conn.Exec(ctx, fmt.Sprintf(
+		`DELETE FROM %s WHERE invitation_id = $1 AND user_id = $2`, OrganizationInvitationTableName),
+		invitationID, userID)
+	if err != nil {
+		httplog.LogEntry(ctx).Error("Error declining organization invitation", httplog.ErrAttr(err))
+		return fmt.Errorf("error declining organization invitation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOrganizationInvitationNotFound
+	}
+	return nil
+}
+
+// SetFeedbackSharing sets whether the ratings of the member on assigned plans
+// are shown to the coaches of the organization.
+func (db *RAGDB) SetFeedbackSharing(ctx context.Context, orgID, userID string, share bool) error {
+	tag, err := db.Conn.Exec(ctx, fmt.Sprintf(
+		`UPDATE %s SET share_feedback = $3 WHERE org_id = $1 AND user_id = $2`, OrganizationMemberTableName),
+		orgID, userID, share)
+	if err != nil {
+		httplog.LogEntry(ctx).Error("Error updating feedback sharing", httplog.ErrAttr(err))
+		return fmt.Errorf("error updating feedback sharing: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
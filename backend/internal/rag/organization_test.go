@@ -0,0 +1,34 @@
+package rag
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCoachesLeft(t *testing.T) {
+	coach, swimmer := models.MemberRoleCoach, models.MemberRoleSwimmer
+
+	tests := []struct {
+		name    string
+		current models.MemberRole
+		next    models.MemberRole
+		coaches int
+		err     error
+	}{
+		{"last coach demoted", coach, swimmer, 1, ErrLastCoach},
+		{"last coach removed", coach, "", 1, ErrLastCoach},
+		{"coach demoted with another coach", coach, swimmer, 2, nil},
+		{"coach removed with another coach", coach, "", 2, nil},
+		{"last coach stays coach", coach, coach, 1, nil},
+		{"swimmer promoted", swimmer, coach, 1, nil},
+		{"swimmer removed", swimmer, "", 1, nil},
+		{"new member", "", swimmer, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, checkCoachesLeft(tt.current, tt.next, tt.coaches), tt.err)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package rag
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/go-chi/httplog/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	TrainingBlockTableName = "training_blocks"
+	BlockSessionTableName  = "training_block_sessions"
+)
+
+const (
+	// blockConcurrency limits the plans of a block which are generated in
+	// parallel, so a block with MaxBlockSessions sessions takes eight rounds.
+	blockConcurrency = 6
+	// blockTimeout bounds the generation of a block below the 10 minute request
+	// timeout of the BFF, so the client receives an error instead of a dropped
+	// connection and the quota of the block is refunded.
+	blockTimeout = 9 * time.Minute
+)
+
+var ErrBlockNotFound = errors.New("training block not found or user does not own the block")
+
+// phaseFocus describes the training focus of a phase for the LLM.
+var phaseFocus = map[models.Phase]string{
+	models.PhaseBase:  "Grundlagenphase: Schwerpunkt auf aerober Grundlagenausdauer (GA1), Technik und Umfang, nur wenige intensive Anteile.",
+	models.PhaseBuild: "Aufbauphase: Schwerpunkt auf Schwellentraining (GA2), wettkampfspezifischen Serien und Tempoarbeit bei hohem Umfang.",
+	models.PhaseTaper: "Tapering: Umfang deutlich reduziert, Intensität mit kurzen wettkampfnahen Serien erhalten, lange Pausen.",
+	models.PhaseRace:  "Wettkampfwoche: geringer Umfang, Aktivierung mit kurzen Sprints, Starts und Wenden, locker und erholt bleiben.",
+}
+
+// sessionGenerator generates the plan of a single session.
+type sessionGenerator func(ctx context.Context, query string, constraints *models.PlanConstraints) (*models.Plan, error)
+
+// GenerateTrainingBlock generates a periodized block with one plan per session and
+// stores it for the user. The plans are added to the history of the user as well.
+func (db *RAGDB) GenerateTrainingBlock(ctx context.Context, req *models.TrainingBlockRequest, profile *models.UserProfile, userID string) (*models.TrainingBlock, error) {
+	logger := httplog.LogEntry(ctx)
+
+	block := &models.TrainingBlock{
+		BlockID:         uuid.NewString(),
+		Title:           req.Title,
+		Goal:            req.Goal,
+		Weeks:           req.Weeks,
+		SessionsPerWeek: req.SessionsPerWeek,
+		PeakVolume:      req.PeakVolume,
+		Language:        cmp.Or(req.Language, models.LanguageDE),
+		Constraints:     req.Constraints,
+		Schedule:        models.Periodize(req.Weeks, req.PeakVolume),
+	}
+	if req.PoolLength != nil {
+		block.PoolLength = fmt.Sprint(req.PoolLength)
+	}
+
+	generateCtx, cancel := context.WithTimeout(ctx, blockTimeout)
+	defer cancel()
+	sessions, err := generateBlockSessions(generateCtx, block, db.sessionGenerator(block, profile))
+	if err != nil {
+		logger.Error("Error generating training block", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error generating training block: %w", err)
+	}
+	block.Sessions = sessions
+
+	if err := db.saveTrainingBlock(ctx, block, userID); err != nil {
+		return nil, err
+	}
+
+	logger.Debug("Training block generated successfully", "block_id", block.BlockID, "sessions", len(block.Sessions))
+	return block, nil
+}
+
+// GetTrainingBlocks returns the blocks of the user without their sessions, the newest first.
+func (db *RAGDB) GetTrainingBlocks(ctx context.Context, userID string) ([]*models.TrainingBlock, error) {
+	logger := httplog.LogEntry(ctx)
+
+	var blocks []*models.TrainingBlock
+	err := pgxscan.Select(ctx, db.Conn, &blocks, fmt.Sprintf(`
+		SELECT block_id, title, goal, weeks, sessions_per_week, peak_volume, language, pool_length, constraints, created_at, updated_at
+		FROM %s
+		WHERE user_id = $1
+		ORDER BY created_at DESC`, TrainingBlockTableName), userID)
+	if err != nil {
+		logger.Error("Error querying training blocks", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying training blocks: %w", err)
+	}
+
+	if len(blocks) == 0 {
+		blocks = []*models.TrainingBlock{}
+	}
+	for _, block := range blocks {
+		block.Schedule = models.Periodize(block.Weeks, block.PeakVolume)
+	}
+	return blocks, nil
+}
+
+// GetTrainingBlock returns a block of the user with the plans of its sessions.
+func (db *RAGDB) GetTrainingBlock(ctx context.Context, blockID, userID string) (*models.TrainingBlock, error) {
+	logger := httplog.LogEntry(ctx)
+
+	var block models.TrainingBlock
+	err := pgxscan.Get(ctx, db.Conn, &block, fmt.Sprintf(`
+		SELECT block_id, title, goal, weeks, sessions_per_week, peak_volume, language, pool_length, constraints, created_at, updated_at
+		FROM %s
+		WHERE block_id = $1 AND user_id = $2`, TrainingBlockTableName), blockID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBlockNotFound
+		}
+		logger.Error("Error querying training block", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying training block: %w", err)
+	}
+
+	err = pgxscan.Select(ctx, db.Conn, &block.Sessions, fmt.Sprintf(`
+		SELECT s.week, s.session, s.phase, s.target_volume, s.plan_id, p.title, p.description, p.plan_table
+		FROM %s s
+		JOIN %s p ON s.plan_id = p.plan_id
+		WHERE s.block_id = $1
+		ORDER BY s.week, s.session`, BlockSessionTableName, PlanTableName), blockID)
+	if err != nil {
+		logger.Error("Error querying training block sessions", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying training block sessions: %w", err)
+	}
+
+	block.Schedule = models.Periodize(block.Weeks, block.PeakVolume)
+	return &block, nil
+}
+
+// RegenerateBlockSession generates the plan of a single session of the block
+// again. The new plan gets its own id and replaces the old one in the block, the
+// old plan is left untouched since it may be rated, shared or part of a chat.
+func (db *RAGDB) RegenerateBlockSession(ctx context.Context, blockID, userID string, week, session int, wishes string, profile *models.UserProfile) (*models.BlockSession, error) {
+	logger := httplog.LogEntry(ctx)
+
+	block, err := db.GetTrainingBlock(ctx, blockID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if week < 1 || week > len(block.Schedule) || session < 1 || session > block.SessionsPerWeek {
+		return nil, ErrBlockNotFound
+	}
+
+	generated, err := generateBlockSession(ctx, block, block.Schedule[week-1], session, wishes, db.sessionGenerator(block, profile))
+	if err != nil {
+		logger.Error("Error regenerating training block session", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error regenerating training block session: %w", err)
+	}
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err = insertBlockSession(ctx, tx, blockID, userID, generated); err != nil {
+		logger.Error("Error inserting training block session", httplog.ErrAttr(err))
+		return nil, err
+	}
+
+	if _, err = tx.Exec(ctx, fmt.Sprintf(
+		`UPDATE %s SET updated_at = now() WHERE block_id = $1`, TrainingBlockTableName), blockID); err != nil {
+		logger.Error("Error updating training block", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error updating training block: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	logger.Debug("Training block session regenerated successfully", "block_id", blockID, "week", week, "session", session)
+	return generated, nil
+}
+
+// sessionGenerator returns a generator which creates the sessions of the block
+// like a query for a new plan.
+func (db *RAGDB) sessionGenerator(block *models.TrainingBlock, profile *models.UserProfile) sessionGenerator {
+	var poolLength any
+	if block.PoolLength != "" {
+		poolLength = block.PoolLength
+	}
+	return func(ctx context.Context, query string, constraints *models.PlanConstraints) (*models.Plan, error) {
+		return db.Query(ctx, query, block.Language, profile, nil, "generate", poolLength, constraints)
+	}
+}
+
+// generateBlockSessions generates all sessions of the block ordered by week and session.
+func generateBlockSessions(ctx context.Context, block *models.TrainingBlock, generate sessionGenerator) ([]models.BlockSession, error) {
+	sessions := make([]models.BlockSession, len(block.Schedule)*block.SessionsPerWeek)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(blockConcurrency)
+	for w, week := range block.Schedule {
+		for s := range block.SessionsPerWeek {
+			g.Go(func() error {
+				session, err := generateBlockSession(gctx, block, week, s+1, "", generate)
+				if err != nil {
+					return fmt.Errorf("week %d, session %d: %w", week.Week, s+1, err)
+				}
+				sessions[w*block.SessionsPerWeek+s] = *session
+				return nil
+			})
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// generateBlockSession generates the plan of a session with the volume of its week.
+func generateBlockSession(ctx context.Context, block *models.TrainingBlock, week models.BlockWeek, session int, wishes string, generate sessionGenerator) (*models.BlockSession, error) {
+	constraints := models.PlanConstraints{}
+	if block.Constraints != nil {
+		constraints = *block.Constraints
+	}
+	constraints.TargetVolume = week.Volume
+
+	plan, err := generate(ctx, sessionQuery(block, week, session, wishes), &constraints)
+	if err != nil {
+		return nil, err
+	}
+	plan.Table.UpdateSum()
+
+	return &models.BlockSession{
+		Week:         week.Week,
+		Session:      session,
+		Phase:        week.Phase,
+		TargetVolume: week.Volume,
+		PlanID:       uuid.NewString(),
+		Title:        plan.Title,
+		Description:  plan.Description,
+		Table:        plan.Table,
+	}, nil
+}
+
+// sessionQuery is the request for the plan of a session, it places the session in
+// the block so that the LLM picks the matching focus.
+func sessionQuery(block *models.TrainingBlock, week models.BlockWeek, session int, wishes string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Trainingseinheit für einen %d-wöchigen Trainingsblock mit dem Ziel: %s\n", block.Weeks, block.Goal)
+	fmt.Fprintf(&sb, "Woche %d von %d, Einheit %d von %d dieser Woche.\n", week.Week, block.Weeks, session, block.SessionsPerWeek)
+	sb.WriteString(phaseFocus[week.Phase])
+	if week.Recovery {
+		sb.WriteString("\nEntlastungswoche: reduzierte Belastung mit mehr Technik und Regeneration.")
+	}
+	if block.SessionsPerWeek > 1 {
+		sb.WriteString("\nGestalte die Einheiten einer Woche abwechslungsreich mit unterschiedlichen Schwerpunkten.")
+	}
+	if wishes = strings.TrimSpace(wishes); wishes != "" {
+		fmt.Fprintf(&sb, "\nZusätzliche Wünsche: %s", wishes)
+	}
+	return sb.String()
+}
+
+// saveTrainingBlock stores the block and adds the plans of its sessions to the
+// history of the user in one transaction.
+func (db *RAGDB) saveTrainingBlock(ctx context.Context, block *models.TrainingBlock, userID string) error {
+	logger := httplog.LogEntry(ctx)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err = tx.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %s (block_id, user_id, title, goal, weeks, sessions_per_week, peak_volume, language, pool_length, constraints)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at, updated_at`, TrainingBlockTableName),
+		block.BlockID, userID, block.Title, block.Goal, block.Weeks, block.SessionsPerWeek, block.PeakVolume,
+		block.Language, block.PoolLength, block.Constraints,
+	).Scan(&block.CreatedAt, &block.UpdatedAt); err != nil {
+		logger.Error("Error inserting training block", httplog.ErrAttr(err))
+		return fmt.Errorf("error inserting training block: %w", err)
+	}
+
+	for i := range block.Sessions {
+		if err = insertBlockSession(ctx, tx, block.BlockID, userID, &block.Sessions[i]); err != nil {
+			logger.Error("Error inserting training block session", httplog.ErrAttr(err))
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// insertBlockSession inserts the plan of the session, adds it to the history of
+// the user and links it to the block, replacing the plan the session had before.
+func insertBlockSession(ctx context.Context, tx pgx.Tx, blockID, userID string, session *models.BlockSession) error {
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (plan_id, title, description, plan_table)
+		VALUES ($1, $2, $3, $4)`, PlanTableName),
+		session.PlanID, session.Title, session.Description, session.Table); err != nil {
+		return fmt.Errorf("error inserting plan: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(
+		`INSERT INTO %s (user_id, plan_id) VALUES ($1, $2)`, HistoryTableName),
+		userID, session.PlanID); err != nil {
+		return fmt.Errorf("error adding plan to user history: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (block_id, week, session, phase, target_volume, plan_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (block_id, week, session)
+		DO UPDATE SET phase = EXCLUDED.phase, target_volume = EXCLUDED.target_volume, plan_id = EXCLUDED.plan_id`, BlockSessionTableName),
+		blockID, session.Week, session.Session, session.Phase, session.TargetVolume, session.PlanID); err != nil {
+		return fmt.Errorf("error linking plan to training block: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBlock() *models.TrainingBlock {
+	return &models.TrainingBlock{
+		Title:           "Meisterschaften",
+		Goal:            "Bestzeit über 400m Freistil",
+		Weeks:           4,
+		SessionsPerWeek: 2,
+		PeakVolume:      3000,
+		Constraints:     &models.PlanConstraints{MaxDuration: 60},
+		Schedule:        models.Periodize(4, 3000),
+	}
+}
+
+func TestGenerateBlockSessions(t *testing.T) {
+	block := testBlock()
+	var mu sync.Mutex
+	volumes := map[int]int{}
+	generate := func(_ context.Context, query string, constraints *models.PlanConstraints) (*models.Plan, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		volumes[constraints.TargetVolume]++
+		assert.Equal(t, 60, constraints.MaxDuration)
+		return &models.Plan{
+			Title: query[:10],
+			Table: models.Table{{Amount: 1, Distance: constraints.TargetVolume, Content: "Kraul"}, {Content: "Gesamt"}},
+		}, nil
+	}
+
+	sessions, err := generateBlockSessions(context.Background(), block, generate)
+	require.NoError(t, err)
+	require.Len(t, sessions, 8)
+
+	for i, session := range sessions {
+		week := block.Schedule[i/2]
+		assert.Equal(t, week.Week, session.Week)
+		assert.Equal(t, i%2+1, session.Session)
+		assert.Equal(t, week.Phase, session.Phase)
+		assert.Equal(t, week.Volume, session.TargetVolume)
+		assert.NotEmpty(t, session.PlanID)
+		// The sums are updated
+		assert.Equal(t, week.Volume, session.Table[len(session.Table)-1].Sum)
+	}
+	assert.Len(t, volumes, 4)
+	// The constraints of the block are not modified
+	assert.Zero(t, block.Constraints.TargetVolume)
+}
+
+func TestGenerateBlockSessionsFails(t *testing.T) {
+	generate := func(context.Context, string, *models.PlanConstraints) (*models.Plan, error) {
+		return nil, errors.New("quota exceeded")
+	}
+
+	_, err := generateBlockSessions(context.Background(), testBlock(), generate)
+
+	assert.ErrorContains(t, err, "quota exceeded")
+}
+
+func TestSessionQuery(t *testing.T) {
+	block := testBlock()
+
+	query := sessionQuery(block, models.BlockWeek{Week: 2, Phase: models.PhaseBuild, Volume: 2800, Recovery: true}, 2, " Mehr Wenden ")
+
+	assert.Contains(t, query, "4-wöchigen Trainingsblock mit dem Ziel: Bestzeit über 400m Freistil")
+	assert.Contains(t, query, "Woche 2 von 4, Einheit 2 von 2 dieser Woche.")
+	assert.Contains(t, query, phaseFocus[models.PhaseBuild])
+	assert.Contains(t, query, "Entlastungswoche")
+	assert.Contains(t, query, "abwechslungsreich")
+	assert.Contains(t, query, "Zusätzliche Wünsche: Mehr Wenden")
+
+	block.SessionsPerWeek = 1
+	query = sessionQuery(block, block.Schedule[3], 1, "")
+	assert.Contains(t, query, phaseFocus[models.PhaseRace])
+	assert.NotContains(t, query, "Entlastungswoche")
+	assert.NotContains(t, query, "abwechslungsreich")
+	assert.NotContains(t, query, "Wünsche")
+}
@@ -0,0 +1,225 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/go-chi/httplog/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	PreferencesTableName        = "training_preferences"
+	PreferenceProposalTableName = "preference_proposals"
+)
+
+var (
+	ErrProposalNotFound   = errors.New("preference proposal not found")
+	ErrPreferencesInvalid = errors.New("invalid training preferences")
+)
+
+// GetPreferences returns the training preferences of the user. Users without
+// preferences get empty ones.
+func (db *RAGDB) GetPreferences(ctx context.Context, userID string) (*models.TrainingPreferences, error) {
+	return getPreferences(ctx, db.Conn, userID)
+}
+
+func getPreferences(ctx context.Context, q pgxscan.Querier, userID string) (*models.TrainingPreferences, error) {
+	var preferences models.TrainingPreferences
+	err := pgxscan.Get(ctx, q, &preferences, fmt.Sprintf(`
+		SELECT injuries, disliked_drills, equipment, pool_length, session_minutes, updated_at
+		FROM %s
+		WHERE user_id = $1
+	`, PreferencesTableName), userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.TrainingPreferences{Injuries: []string{}, DislikedDrills: []string{}, Equipment: []models.EquipmentType{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying training preferences: %w", err)
+	}
+	return &preferences, nil
+}
+
+// SavePreferences replaces the training preferences of the user.
+func (db *RAGDB) SavePreferences(ctx context.Context, userID string, preferences *models.TrainingPreferences) (*models.TrainingPreferences, error) {
+	logger := httplog.LogEntry(ctx)
+
+	saved, err := savePreferences(ctx, db.Conn, userID, preferences)
+	if err != nil {
+		logger.Error("Error saving training preferences", httplog.ErrAttr(err))
+		return nil, err
+	}
+	return saved, nil
+}
+
+func savePreferences(ctx context.Context, q pgxscan.Querier, userID string, p *models.TrainingPreferences) (*models.TrainingPreferences, error) {
+	var saved models.TrainingPreferences
+	err := pgxscan.Get(ctx, q, &saved, fmt.Sprintf(`
+		INSERT INTO %s (user_id, injuries, disliked_drills, equipment, pool_length, session_minutes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET injuries = EXCLUDED.injuries,
+			disliked_drills = EXCLUDED.disliked_drills,
+			equipment = EXCLUDED.equipment,
+			pool_length = EXCLUDED.pool_length,
+			session_minutes = EXCLUDED.session_minutes,
+			updated_at = now()
+		RETURNING injuries, disliked_drills, equipment, pool_length, session_minutes, updated_at
+	`, PreferencesTableName), userID, nonNil(p.Injuries), nonNil(p.DislikedDrills), nonNil(p.Equipment), p.PoolLength, p.SessionMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("error saving training preferences: %w", err)
+	}
+	return &saved, nil
+}
+
+// nonNil stores omitted lists as empty arrays.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// GetPreferenceProposals returns the facts learned from chat messages that wait
+// for the confirmation of the user, oldest first.
+func (db *RAGDB) GetPreferenceProposals(ctx context.Context, userID string) ([]models.PreferenceProposal, error) {
+	logger := httplog.LogEntry(ctx)
+
+	proposals := []models.PreferenceProposal{}
+	if err := pgxscan.Select(ctx, db.Conn, &proposals, fmt.Sprintf(`
+		SELECT proposal_id, plan_id, message_id, field, value, created_at
+		FROM %s
+		WHERE user_id = $1 AND status = 'pending'
+		ORDER BY created_at, proposal_id
+	`, PreferenceProposalTableName), userID); err != nil {
+		logger.Error("Error querying preference proposals", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying preference proposals: %w", err)
+	}
+	return proposals, nil
+}
+
+// AcceptPreferenceProposal adds the proposed fact to the training preferences
+// of the user and returns the updated preferences.
+func (db *RAGDB) AcceptPreferenceProposal(ctx context.Context, proposalID, userID string) (*models.TrainingPreferences, error) {
+	logger := httplog.LogEntry(ctx)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Accepted proposals are deleted, so the fact can be proposed again once the
+	// user removed it from the preferences.
+	var proposal models.PreferenceProposal
+	err = pgxscan.Get(ctx, tx, &proposal, fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE proposal_id = $1 AND user_id = $2 AND status = 'pending'
+		RETURNING proposal_id, plan_id, message_id, field, value, created_at
+	`, PreferenceProposalTableName), proposalID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrProposalNotFound
+	}
+	if err != nil {
+		logger.Error("Error deleting preference proposal", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error deleting preference proposal: %w", err)
+	}
+
+	preferences, err := getPreferences(ctx, tx, userID)
+	if err != nil {
+		logger.Error("Error querying training preferences", httplog.ErrAttr(err))
+		return nil, err
+	}
+	if err := preferences.Apply(proposal.Fact()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPreferencesInvalid, err)
+	}
+	saved, err := savePreferences(ctx, tx, userID, preferences)
+	if err != nil {
+		logger.Error("Error saving training preferences", httplog.ErrAttr(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	logger.Info("Preference proposal accepted", "field", proposal.Field)
+	return saved, nil
+}
+
+// RejectPreferenceProposal dismisses a proposed fact. Rejected facts are kept,
+// so they are not proposed again.
+func (db *RAGDB) RejectPreferenceProposal(ctx context.Context, proposalID, userID string) error {
+	logger := httplog.LogEntry(ctx)
+
+	result, err := db.Conn.Exec(ctx, fmt.Sprintf(`
+		UPDATE %s SET status = 'rejected'
+		WHERE proposal_id = $1 AND user_id = $2 AND status = 'pending'
+	`, PreferenceProposalTableName), proposalID, userID)
+	if err != nil {
+		logger.Error("Error rejecting preference proposal", httplog.ErrAttr(err))
+		return fmt.Errorf("error rejecting preference proposal: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrProposalNotFound
+	}
+	return nil
+}
+
+// ProposePreferences extracts long-term preferences from a chat message of the
+// user and stores the new ones as proposals for the user to confirm.
+func (db *RAGDB) ProposePreferences(ctx context.Context, userID, planID, messageID, message string, preferences *models.TrainingPreferences) ([]models.PreferenceProposal, error) {
+	logger := httplog.LogEntry(ctx)
+
+	facts, err := db.Client.ExtractPreferences(ctx, message, formatPreferences(preferences))
+	if err != nil {
+		return nil, err
+	}
+	facts = newPreferenceFacts(preferences, facts)
+
+	proposals := []models.PreferenceProposal{}
+	for _, fact := range facts {
+		var proposal models.PreferenceProposal
+		// Pending and rejected facts are not proposed again.
+		err := pgxscan.Get(ctx, db.Conn, &proposal, fmt.Sprintf(`
+			INSERT INTO %s (user_id, plan_id, message_id, field, value)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, field, lower(value)) DO NOTHING
+			RETURNING proposal_id, plan_id, message_id, field, value, created_at
+		`, PreferenceProposalTableName), userID, planID, messageID, fact.Field, fact.Value)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error inserting preference proposal: %w", err)
+		}
+		proposals = append(proposals, proposal)
+	}
+	logger.Debug("Preferences proposed", "facts", len(facts), "proposals", len(proposals))
+	return proposals, nil
+}
+
+// newPreferenceFacts returns the valid facts which are not part of the
+// preferences yet, without duplicates.
+func newPreferenceFacts(preferences *models.TrainingPreferences, facts []models.PreferenceFact) []models.PreferenceFact {
+	var result []models.PreferenceFact
+	for _, fact := range facts {
+		fact.Value = strings.TrimSpace(fact.Value)
+		if fact.Validate() != nil || preferences.Contains(fact) {
+			continue
+		}
+		if slices.ContainsFunc(result, func(f models.PreferenceFact) bool {
+			return f.Field == fact.Field && strings.EqualFold(f.Value, fact.Value)
+		}) {
+			continue
+		}
+		result = append(result, fact)
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package rag
+
+import (
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPreferenceFactsSkipsKnownInvalidAndDuplicateFacts(t *testing.T) {
+	preferences := &models.TrainingPreferences{Injuries: []string{"Schulterverletzung"}}
+
+	facts := newPreferenceFacts(preferences, []models.PreferenceFact{
+		{Field: models.PreferenceInjury, Value: "schulterverletzung"},
+		{Field: models.PreferenceDislikedDrill, Value: " Delfin "},
+		{Field: models.PreferenceDislikedDrill, Value: "delfin"},
+		{Field: models.PreferenceEquipment, Value: "Schwimmbrille"},
+		{Field: models.PreferencePoolLength, Value: "25"},
+	})
+
+	assert.Equal(t, []models.PreferenceFact{
+		{Field: models.PreferenceDislikedDrill, Value: "Delfin"},
+		{Field: models.PreferencePoolLength, Value: "25"},
+	}, facts)
+	assert.Empty(t, newPreferenceFacts(nil, nil))
+}
+
+func TestFormatUserProfileIncludesPreferences(t *testing.T) {
+	poolLength := 50
+	profile := &models.UserProfile{Preferences: &models.TrainingPreferences{
+		Injuries:       []string{"Schulterverletzung"},
+		DislikedDrills: []string{"Delfin"},
+		Equipment:      []models.EquipmentType{models.EquipmentFins, models.EquipmentBuoy},
+		PoolLength:     &poolLength,
+	}}
+
+	formatted := (&RAGDB{}).FormatUserProfile(profile)
+
+	assert.Contains(t, formatted, "belastende Übungen vermeiden: Schulterverletzung")
+	assert.Contains(t, formatted, "nicht verwenden: Delfin")
+	assert.Contains(t, formatted, "Flossen, Pull buoy")
+	assert.Contains(t, formatted, "Übliche Beckenlänge: 50m")
+	assert.NotContains(t, formatted, "Trainingsdauer")
+	assert.NotContains(t, (&RAGDB{}).FormatUserProfile(&models.UserProfile{}), "Trainingsvorlieben")
+}
@@ -21,8 +21,11 @@ const (
 )
 
 // Query searches for documents in the database based on the provided query and filter.
-func (db *RAGDB) Query(ctx context.Context, query string, lang models.Language, userProfile string, filter map[string]any, method string, poolLength any) (*models.Plan, error) {
+// The profile is optional and personalizes the generation. Generated plans are
+// checked against the constraints, chosen plans are returned as they are.
+func (db *RAGDB) Query(ctx context.Context, query string, lang models.Language, profile *models.UserProfile, filter map[string]any, method string, poolLength any, constraints *models.PlanConstraints) (*models.Plan, error) {
 	logger := httplog.LogEntry(ctx)
+	userProfile := db.FormatUserProfile(profile)
 	// Set the embedder to query mode
 	db.Client.QueryMode()
 	searchQuery := buildSearchQuery(query, userProfile)
@@ -34,9 +37,9 @@ func (db *RAGDB) Query(ctx context.Context, query string, lang models.Language,
 	case searchQuery == "" && filter != nil:
 		planDocs, err = db.PlanStore.Search(ctx, 5, vectorstores.WithFilters(filter))
 	case searchQuery != "" && filter == nil:
-		planDocs, err = db.PlanStore.SimilaritySearch(ctx, searchQuery, 5)
+		planDocs, err = db.searchPlans(ctx, searchQuery, 5)
 	case searchQuery != "" && filter != nil:
-		planDocs, err = db.PlanStore.SimilaritySearch(ctx, searchQuery, 5, vectorstores.WithFilters(filter))
+		planDocs, err = db.searchPlans(ctx, searchQuery, 5, vectorstores.WithFilters(filter))
 	}
 	if err != nil {
 		logger.Error("Error searching for plan documents", httplog.ErrAttr(err))
@@ -55,11 +58,18 @@ func (db *RAGDB) Query(ctx context.Context, query string, lang models.Language,
 			return nil, fmt.Errorf("error searching for drill documents: %w", err)
 		}
 
-		plan, err = db.Client.GeneratePlan(ctx, query, string(lang), userProfile, poolLength, planDocs, drillDocs)
+		var generated *models.GeneratedPlan
+		generated, err = db.Client.GeneratePlan(ctx, query, string(lang), userProfile, poolLength, constraints, planDocs, drillDocs)
 		if err != nil {
 			logger.Error("Error generating plan", httplog.ErrAttr(err))
 			return nil, fmt.Errorf("error generating plan: %w", err)
 		}
+		if !constraints.IsZero() {
+			generated = enforceConstraints(ctx, constraints, profile, generated, func(feedback string) (*models.GeneratedPlan, error) {
+				return db.Client.GeneratePlan(ctx, query+feedback, string(lang), userProfile, poolLength, constraints, planDocs, drillDocs)
+			})
+		}
+		plan = generated
 	case "choose":
 		if len(planDocs) == 0 {
 			return nil, fmt.Errorf("no documents in database matching query and filters")
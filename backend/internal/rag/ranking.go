@@ -0,0 +1,273 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/go-chi/httplog/v2"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+const (
+	// ratingPriorMean and ratingPriorCount shrink the mean rating of plans with
+	// few ratings towards a neutral rating.
+	ratingPriorMean  = 3.0
+	ratingPriorCount = 2.0
+	// exportHalfScore is the number of exports at which the export score is 0.5.
+	exportHalfScore = 10.0
+)
+
+// RankingWeights weight the signals of the hybrid score used to re-rank
+// retrieved reference plans.
+type RankingWeights struct {
+	Similarity          float64
+	Feedback            float64
+	Exports             float64
+	Recency             float64
+	RecencyHalfLifeDays int
+}
+
+// NewRankingWeights returns the ranking weights of the configuration.
+func NewRankingWeights(cfg config.Config) RankingWeights {
+	return RankingWeights{
+		Similarity:          cfg.Ranking.SimilarityWeight,
+		Feedback:            cfg.Ranking.FeedbackWeight,
+		Exports:             cfg.Ranking.ExportWeight,
+		Recency:             cfg.Ranking.RecencyWeight,
+		RecencyHalfLifeDays: cfg.Ranking.RecencyHalfLifeDays,
+	}
+}
+
+// IsSimilarityOnly reports whether the weights keep the order of the vector search.
+func (w RankingWeights) IsSimilarityOnly() bool {
+	return w.Feedback <= 0 && w.Exports <= 0 && w.Recency <= 0
+}
+
+// PlanSignals are the usage signals of a plan the ranking is based on.
+type PlanSignals struct {
+	PlanID    string    `db:"plan_id"`
+	Ratings   int       `db:"ratings"`
+	RatingSum int       `db:"rating_sum"`
+	Swam      int       `db:"swam"`
+	Exports   int       `db:"exports"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// feedbackScore is the mean rating of the plan, shrunk towards a neutral rating
+// for few ratings and scaled to 0..1. Plans swum by the raters score higher.
+func (s PlanSignals) feedbackScore() float64 {
+	rating := (float64(s.RatingSum) + ratingPriorMean*ratingPriorCount) / (float64(s.Ratings) + ratingPriorCount)
+	swam := (float64(s.Swam) + 0.5*ratingPriorCount) / (float64(s.Ratings) + ratingPriorCount)
+	return 0.75*(rating-1)/4 + 0.25*swam
+}
+
+func (s PlanSignals) exportScore() float64 {
+	return float64(s.Exports) / (float64(s.Exports) + exportHalfScore)
+}
+
+func (s PlanSignals) recencyScore(now time.Time, halfLifeDays int) float64 {
+	if halfLifeDays <= 0 || s.CreatedAt.IsZero() {
+		return 0.5
+	}
+	age := now.Sub(s.CreatedAt).Hours() / 24
+	return math.Pow(0.5, math.Max(age, 0)/float64(halfLifeDays))
+}
+
+// planScore combines the similarity of the document with the signals of its
+// plan. Plans without signals get neutral scores.
+func planScore(doc schema.Document, signals PlanSignals, found bool, w RankingWeights, now time.Time) float64 {
+	similarity := math.Min(math.Max(float64(doc.Score), 0), 1)
+	feedback, exports, recency := 0.5, 0.0, 0.5
+	if found {
+		feedback = signals.feedbackScore()
+		exports = signals.exportScore()
+		recency = signals.recencyScore(now, w.RecencyHalfLifeDays)
+	}
+	total := w.Similarity + w.Feedback + w.Exports + w.Recency
+	if total <= 0 {
+		return similarity
+	}
+	return (w.Similarity*similarity + w.Feedback*feedback + w.Exports*exports + w.Recency*recency) / total
+}
+
+// rankPlans orders the documents by their hybrid score and returns the best
+// limit of them. The similarity scores of the documents are kept.
+func rankPlans(docs []schema.Document, signals map[string]PlanSignals, w RankingWeights, now time.Time, limit int) []schema.Document {
+	type scored struct {
+		doc   schema.Document
+		score float64
+	}
+	ranked := make([]scored, len(docs))
+	for i, doc := range docs {
+		planID, _ := doc.Metadata["plan_id"].(string)
+		s, found := signals[planID]
+		ranked[i] = scored{doc: doc, score: planScore(doc, s, found, w, now)}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	result := make([]schema.Document, 0, min(limit, len(ranked)))
+	for _, r := range ranked[:min(limit, len(ranked))] {
+		result = append(result, r.doc)
+	}
+	return result
+}
+
+// GetPlanSignals aggregates the feedback and exports of the plans. Only feedback
+// last updated before the cutoff is counted if one is given.
+func (db *RAGDB) GetPlanSignals(ctx context.Context, planIDs []string, before *time.Time) (map[string]PlanSignals, error) {
+	var rows []PlanSignals
+	if err := pgxscan.Select(ctx, db.Conn, &rows, fmt.Sprintf(`
+		SELECT p.plan_id::text AS plan_id, coalesce(p.created_at, p.updated_at) AS created_at, p.exports,
+			count(f.rating)::int AS ratings,
+			coalesce(sum(f.rating), 0)::int AS rating_sum,
+			count(*) FILTER (WHERE f.was_swam)::int AS swam
+		FROM %s p
+		LEFT JOIN %s f ON f.plan_id = p.plan_id AND ($2::timestamptz IS NULL OR f.updated_at < $2)
+		WHERE p.plan_id = ANY($1::uuid[])
+		GROUP BY p.plan_id
+	`, PlanTableName, FeedbackTable), planIDs, before); err != nil {
+		return nil, fmt.Errorf("error querying plan signals: %w", err)
+	}
+	signals := make(map[string]PlanSignals, len(rows))
+	for _, row := range rows {
+		signals[row.PlanID] = row
+	}
+	return signals, nil
+}
+
+// searchPlans retrieves the reference plans for the query. With re-ranking
+// enabled a larger candidate set is retrieved by similarity and re-ranked by
+// feedback, exports and recency of the plans.
+func (db *RAGDB) searchPlans(ctx context.Context, query string, limit int, options ...vectorstores.Option) ([]schema.Document, error) {
+	logger := httplog.LogEntry(ctx)
+
+	weights := NewRankingWeights(db.cfg)
+	if weights.IsSimilarityOnly() || db.cfg.Ranking.Candidates <= limit {
+		return db.PlanStore.SimilaritySearch(ctx, query, limit, options...)
+	}
+
+	docs, err := db.PlanStore.SimilaritySearch(ctx, query, db.cfg.Ranking.Candidates, options...)
+	if err != nil {
+		return nil, err
+	}
+	signals, err := db.GetPlanSignals(ctx, documentPlanIDs(docs), nil)
+	if err != nil {
+		logger.Warn("Failed to get plan signals, keeping similarity order", httplog.ErrAttr(err))
+		return docs[:min(limit, len(docs))], nil
+	}
+	return rankPlans(docs, signals, weights, time.Now(), limit), nil
+}
+
+func documentPlanIDs(docs []schema.Document) []string {
+	planIDs := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if planID, ok := doc.Metadata["plan_id"].(string); ok && !slices.Contains(planIDs, planID) {
+			planIDs = append(planIDs, planID)
+		}
+	}
+	return planIDs
+}
+
+// RankingSample is a query of the offline evaluation with its retrieved
+// candidates. Signals are taken from feedback before the cutoff, labels from
+// feedback after it.
+type RankingSample struct {
+	Query      string
+	Candidates []schema.Document
+	Signals    map[string]PlanSignals
+	Labels     map[string]float64
+}
+
+// RankingEvaluation compares the hybrid ranking with the similarity order.
+type RankingEvaluation struct {
+	Queries        int     `json:"queries"`         // Queries is the number of queries with labeled candidates
+	SimilarityNDCG float64 `json:"similarity_ndcg"` // SimilarityNDCG is the mean NDCG@k of the similarity order
+	HybridNDCG     float64 `json:"hybrid_ndcg"`     // HybridNDCG is the mean NDCG@k of the hybrid ranking
+}
+
+// EvaluateRanking computes the mean NDCG@k of the similarity order and of the
+// hybrid ranking over the samples. Samples without labeled candidates are skipped.
+func EvaluateRanking(samples []RankingSample, w RankingWeights, k int, now time.Time) RankingEvaluation {
+	var eval RankingEvaluation
+	for _, sample := range samples {
+		var gains []float64
+		for _, doc := range sample.Candidates {
+			planID, _ := doc.Metadata["plan_id"].(string)
+			gains = append(gains, sample.Labels[planID])
+		}
+		sorted := slices.Sorted(slices.Values(gains))
+		slices.Reverse(sorted)
+		ideal := dcg(sorted, k)
+		if ideal == 0 {
+			continue
+		}
+		eval.Queries++
+		eval.SimilarityNDCG += dcg(gains, k) / ideal
+
+		var hybrid []float64
+		for _, doc := range rankPlans(sample.Candidates, sample.Signals, w, now, len(sample.Candidates)) {
+			planID, _ := doc.Metadata["plan_id"].(string)
+			hybrid = append(hybrid, sample.Labels[planID])
+		}
+		eval.HybridNDCG += dcg(hybrid, k) / ideal
+	}
+	if eval.Queries > 0 {
+		eval.SimilarityNDCG /= float64(eval.Queries)
+		eval.HybridNDCG /= float64(eval.Queries)
+	}
+	return eval
+}
+
+// dcg is the discounted cumulative gain of the first k gains.
+func dcg(gains []float64, k int) float64 {
+	var sum float64
+	for i := 0; i < k && i < len(gains); i++ {
+		sum += gains[i] / math.Log2(float64(i)+2)
+	}
+	return sum
+}
+
+// RankingSamples retrieves the candidates of the queries and labels them with
+// the feedback given after the cutoff. The label of a plan is its mean rating
+// above the lowest rating plus the share of raters who swam it.
+func (db *RAGDB) RankingSamples(ctx context.Context, queries []string, cutoff time.Time) ([]RankingSample, error) {
+	db.Client.QueryMode()
+	samples := make([]RankingSample, 0, len(queries))
+	for _, query := range queries {
+		docs, err := db.PlanStore.SimilaritySearch(ctx, query, max(db.cfg.Ranking.Candidates, 1))
+		if err != nil {
+			return nil, fmt.Errorf("error searching for plan documents: %w", err)
+		}
+		planIDs := documentPlanIDs(docs)
+		signals, err := db.GetPlanSignals(ctx, planIDs, &cutoff)
+		if err != nil {
+			return nil, err
+		}
+
+		var labels []struct {
+			PlanID string  `db:"plan_id"`
+			Gain   float64 `db:"gain"`
+		}
+		if err := pgxscan.Select(ctx, db.Conn, &labels, fmt.Sprintf(`
+			SELECT plan_id::text AS plan_id, (avg(rating - 1) + avg(CASE WHEN was_swam THEN 1 ELSE 0 END))::float8 AS gain
+			FROM %s
+			WHERE plan_id = ANY($1::uuid[]) AND updated_at >= $2
+			GROUP BY plan_id
+		`, FeedbackTable), planIDs, cutoff); err != nil {
+			return nil, fmt.Errorf("error querying feedback labels: %w", err)
+		}
+		sample := RankingSample{Query: query, Candidates: docs, Signals: signals, Labels: map[string]float64{}}
+		for _, label := range labels {
+			sample.Labels[label.PlanID] = label.Gain
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
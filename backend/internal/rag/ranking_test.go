@@ -0,0 +1,102 @@
+package rag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func rankingDocs(scores ...float32) []schema.Document {
+	ids := []string{"a", "b", "c", "d"}
+	docs := make([]schema.Document, len(scores))
+	for i, score := range scores {
+		docs[i] = schema.Document{Score: score, Metadata: map[string]any{"plan_id": ids[i]}}
+	}
+	return docs
+}
+
+func planIDs(docs []schema.Document) []string {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i], _ = doc.Metadata["plan_id"].(string)
+	}
+	return ids
+}
+
+func TestRankPlansPrefersWellRatedPlans(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	docs := rankingDocs(0.82, 0.80, 0.50)
+	signals := map[string]PlanSignals{
+		"a": {PlanID: "a", Ratings: 4, RatingSum: 5, CreatedAt: now},
+		"b": {PlanID: "b", Ratings: 4, RatingSum: 20, Swam: 4, CreatedAt: now},
+		"c": {PlanID: "c", Ratings: 10, RatingSum: 50, Swam: 10, Exports: 100, CreatedAt: now},
+	}
+	weights := RankingWeights{Similarity: 1, Feedback: 0.3, Exports: 0.1, Recency: 0.1, RecencyHalfLifeDays: 180}
+
+	ranked := rankPlans(docs, signals, weights, now, 2)
+
+	assert.Equal(t, []string{"b", "a"}, planIDs(ranked))
+	assert.Equal(t, float32(0.80), ranked[0].Score, "similarity scores are kept")
+}
+
+func TestRankPlansKeepsSimilarityOrderWithoutSignals(t *testing.T) {
+	docs := rankingDocs(0.9, 0.8, 0.7)
+	weights := RankingWeights{Similarity: 1, Feedback: 0.3, Exports: 0.1, Recency: 0.1, RecencyHalfLifeDays: 180}
+
+	ranked := rankPlans(docs, nil, weights, time.Now(), 5)
+
+	assert.Equal(t, []string{"a", "b", "c"}, planIDs(ranked))
+}
+
+func TestRankPlansPrefersRecentPlans(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	docs := rankingDocs(0.8, 0.8)
+	signals := map[string]PlanSignals{
+		"a": {PlanID: "a", CreatedAt: now.AddDate(-2, 0, 0)},
+		"b": {PlanID: "b", CreatedAt: now.AddDate(0, 0, -7)},
+	}
+
+	ranked := rankPlans(docs, signals, RankingWeights{Similarity: 1, Recency: 1, RecencyHalfLifeDays: 180}, now, 2)
+
+	assert.Equal(t, []string{"b", "a"}, planIDs(ranked))
+}
+
+func TestRankingIsSkippedWithoutSignalWeights(t *testing.T) {
+	var cfg config.Config
+	assert.True(t, NewRankingWeights(cfg).IsSimilarityOnly())
+
+	cfg.Ranking.SimilarityWeight = 1
+	cfg.Ranking.ExportWeight = 0.1
+	assert.False(t, NewRankingWeights(cfg).IsSimilarityOnly())
+}
+
+func TestDocumentPlanIDsSkipsDuplicates(t *testing.T) {
+	docs := append(rankingDocs(0.9, 0.8), rankingDocs(0.7)...)
+
+	assert.Equal(t, []string{"a", "b"}, documentPlanIDs(docs))
+}
+
+func TestEvaluateRankingComparesWithSimilarityOrder(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	samples := []RankingSample{
+		{
+			Query:      "Ausdauer",
+			Candidates: rankingDocs(0.82, 0.80),
+			Signals: map[string]PlanSignals{
+				"a": {PlanID: "a", Ratings: 4, RatingSum: 4},
+				"b": {PlanID: "b", Ratings: 4, RatingSum: 20, Swam: 4},
+			},
+			Labels: map[string]float64{"b": 4},
+		},
+		{Query: "ohne Feedback", Candidates: rankingDocs(0.9)},
+	}
+
+	eval := EvaluateRanking(samples, RankingWeights{Similarity: 1, Feedback: 0.3}, 5, now)
+
+	assert.Equal(t, 1, eval.Queries)
+	assert.InDelta(t, 1/1.585, eval.SimilarityNDCG, 0.001)
+	assert.InDelta(t, 1.0, eval.HybridNDCG, 0.001)
+}
@@ -200,7 +200,7 @@ func (db *RAGDB) NewCollector(ctx context.Context, visitedURLs *URLMap, syncGrou
 				Amount:     amount,
 				Multiplier: r.ChildText("td:nth-child(2)"),
 				Distance:   distance,
-				Break:      r.ChildText("td:nth-child(4)"),
+				Break:      models.ParseInterval(r.ChildText("td:nth-child(4)")),
 				Content:    r.ChildText("td:nth-child(5)"),
 				Intensity:  r.ChildText("td:nth-child(6)"),
 				SubRows:    []models.Row{},
@@ -13,7 +13,7 @@ func TestToLangChainDoc_ValidPlan(t *testing.T) {
 			Amount:     4,
 			Multiplier: "x",
 			Distance:   100,
-			Break:      "20",
+			Break:      models.Rest(20),
 			Content:    "Freestyle",
 			Intensity:  "GA1",
 			Sum:        400,
@@ -0,0 +1,321 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/go-chi/httplog/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+const ShareInvitationTableName = "share_invitations"
+
+// ShareThrottleWindow is the window in which the email shares of a sender are counted.
+const ShareThrottleWindow = 24 * time.Hour
+
+var (
+	ErrInvitationNotFound = errors.New("share invitation not found")
+	ErrShareExpired       = errors.New("share expired or revoked")
+	ErrShareThrottled     = errors.New("too many plans shared by email")
+)
+
+// ThrottledError is returned if the sender reached the limit of email shares.
+// It matches ErrShareThrottled with errors.Is.
+type ThrottledError struct {
+	// RetryAfter is the time until the oldest share of the window expires.
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrShareThrottled, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrShareThrottled
+}
+
+// ShareByEmail creates the link share of an owned plan and an invitation for
+// the recipient. The returned invitation holds the content of the email.
+// Senders may create at most limit invitations per ShareThrottleWindow, a
+// limit of 0 disables the throttling.
+func (db *RAGDB) ShareByEmail(ctx context.Context, planID, userID, recipient string, lang models.Language, limit int) (*models.ShareInvitation, error) {
+	logger := httplog.LogEntry(ctx)
+	if lang != models.LanguageDE {
+		lang = models.LanguageEN
+	}
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if limit > 0 {
+		// Serialize the shares of the sender, so concurrent requests can not exceed the limit.
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, ShareInvitationTableName+userID); err != nil {
+			logger.Error("Error locking share invitations", httplog.ErrAttr(err))
+			return nil, fmt.Errorf("error locking share invitations: %w", err)
+		}
+		var window struct {
+			Count  int        `db:"count"`
+			Oldest *time.Time `db:"oldest"`
+		}
+		if err := pgxscan.Get(ctx, tx, &window, fmt.Sprintf(`
+			SELECT count(*) AS count, min(created_at) AS oldest FROM %s
+			WHERE shared_by = $1 AND created_at > now() - make_interval(secs => $2)`, ShareInvitationTableName),
+			userID, ShareThrottleWindow.Seconds()); err != nil {
+			logger.Error("Error counting share invitations", httplog.ErrAttr(err))
+			return nil, fmt.Errorf("error counting share invitations: %w", err)
+		}
+		if window.Count >= limit && window.Oldest != nil {
+			return nil, &ThrottledError{RetryAfter: time.Until(window.Oldest.Add(ShareThrottleWindow))}
+		}
+	}
+
+	urlHash, err := shareLink(ctx, tx, planID, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation models.ShareInvitation
+	err = pgxscan.Get(ctx, tx, &invitation, fmt.Sprintf(`
+		WITH invitation AS (
+			INSERT INTO %s (plan_id, shared_by, recipient_email, language)
+			VALUES ($1, $2, $3, $4)
+			RETURNING *
+		)
+		SELECT i.invitation_id, i.plan_id, i.shared_by, COALESCE(pr.username, '') AS sender_name,
+			i.recipient_email, i.language, $5::text AS url_hash, p.title, p.description, p.plan_table,
+			i.created_at, i.expires_at
+		FROM invitation i
+		JOIN %s p ON p.plan_id = i.plan_id
+		LEFT JOIN %s pr ON pr.user_id = i.shared_by`,
+		ShareInvitationTableName, PlanTableName, ProfilesTableName),
+		planID, userID, recipient, lang, urlHash)
+	if err != nil {
+		logger.Error("Error creating share invitation", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error creating share invitation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	logger.Debug("Share invitation created successfully", "plan_id", planID, "invitation_id", invitation.InvitationID)
+	return &invitation, nil
+}
+
+// DeleteShareInvitation removes an invitation of the sender, e.g. if its email
+// could not be sent. It no longer counts towards the throttling.
+func (db *RAGDB) DeleteShareInvitation(ctx context.Context, invitationID, userID string) error {
+	if _, err := db.Conn.Exec(ctx, fmt.Sprintf(
+		`DELETE FROM %s WHERE invitation_id = $1 AND shared_by = $2`, ShareInvitationTableName),
+		invitationID, userID); err != nil {
+		httplog.LogEntry(ctx).Error("Error deleting share invitation", httplog.ErrAttr(err))
+		return fmt.Errorf("error deleting share invitation: %w", err)
+	}
+	return nil
+}
+
+// AcceptShareInvitation adds the plan of an invitation to the shared history of
+// the user. Only the account with the invited email address can accept an
+// invitation, and only once. Accepting it again is a no-op for that user.
+// Unknown, expired and foreign invitations, and invitations of revoked or
+// expired shares return ErrInvitationNotFound.
+func (db *RAGDB) AcceptShareInvitation(ctx context.Context, invitationID, userID string) (*models.AcceptInvitationResponse, error) {
+	logger := httplog.LogEntry(ctx)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var invitation struct {
+		PlanID     string  `db:"plan_id"`
+		SharedBy   string  `db:"shared_by"`
+		AcceptedBy *string `db:"accepted_by"`
+		URLHash    string  `db:"url_hash"`
+	}
+	err = pgxscan.Get(ctx, tx, &invitation, fmt.Sprintf(`
+		SELECT i.plan_id, i.shared_by, i.accepted_by, sp.url_hash::text AS url_hash
+		FROM %s i
+		JOIN shared_plans sp ON sp.plan_id = i.plan_id AND sp.user_id = i.shared_by
+			AND sp.revoked_at IS NULL AND (sp.expires_at IS NULL OR sp.expires_at > now())
+		JOIN auth.users u ON u.id = $2 AND lower(u.email) = lower(i.recipient_email)
+		WHERE i.invitation_id = $1 AND i.expires_at > now() AND i.shared_by <> $2
+		FOR UPDATE OF i`, ShareInvitationTableName),
+		invitationID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvitationNotFound
+		}
+		logger.Error("Error querying share invitation", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying share invitation: %w", err)
+	}
+	if invitation.AcceptedBy != nil && *invitation.AcceptedBy != userID {
+		return nil, ErrInvitationNotFound
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO shared_history (user_id, plan_id, share_method, shared_by)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (user_id, plan_id) DO NOTHING`,
+		userID, invitation.PlanID, models.SharingMethodEmail, invitation.SharedBy); err != nil {
+		logger.Error("Error adding plan to shared history", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error adding plan to shared history: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+		UPDATE %s SET accepted_by = $2, accepted_at = now()
+		WHERE invitation_id = $1 AND accepted_by IS NULL`, ShareInvitationTableName),
+		invitationID, userID); err != nil {
+		logger.Error("Error accepting share invitation", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error accepting share invitation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	logger.Debug("Share invitation accepted successfully", "plan_id", invitation.PlanID, "invitation_id", invitationID)
+	return &models.AcceptInvitationResponse{PlanID: invitation.PlanID, URLHash: invitation.URLHash}, nil
+}
+
+// GetSharedPlan resolves a share by its url hash and counts the view, unless
+// the viewer is the owner. Unknown shares return ErrShareNotFound, expired and
+// revoked shares ErrShareExpired. viewerID is empty for anonymous visitors.
+func (db *RAGDB) GetSharedPlan(ctx context.Context, urlHash, viewerID string) (*models.SharedPlan, error) {
+	logger := httplog.LogEntry(ctx)
+
+	var plan models.SharedPlan
+	err := pgxscan.Get(ctx, db.Conn, &plan, fmt.Sprintf(`
+		WITH share AS (
+			SELECT * FROM shared_plans WHERE url_hash = $1
+		), viewed AS (
+			UPDATE shared_plans sp
+			SET view_count = sp.view_count + 1, last_viewed_at = now()
+			FROM share
+			WHERE sp.plan_id = share.plan_id
+				AND share.revoked_at IS NULL
+				AND (share.expires_at IS NULL OR share.expires_at > now())
+				AND share.user_id IS DISTINCT FROM NULLIF($2, '')::uuid
+			RETURNING sp.view_count
+		)
+		SELECT share.url_hash::text AS url_hash, share.plan_id, p.title, p.description, p.plan_table,
+			share.user_id AS owner_id, COALESCE(pr.username, '') AS owner, share.created_at AS shared_at,
+			share.expires_at, share.revoked_at,
+			COALESCE((SELECT view_count FROM viewed), share.view_count) AS view_count
+		FROM share
+		JOIN %s p ON p.plan_id = share.plan_id
+		LEFT JOIN %s pr ON pr.user_id = share.user_id`,
+		PlanTableName, ProfilesTableName),
+		urlHash, viewerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrShareNotFound
+		}
+		logger.Error("Error querying shared plan", httplog.ErrAttr(err))
+		return nil, fmt.Errorf("error querying shared plan: %w", err)
+	}
+	if plan.RevokedAt != nil || (plan.ExpiresAt != nil && !plan.ExpiresAt.After(time.Now())) {
+		return nil, ErrShareExpired
+	}
+	return &plan, nil
+}
+
+// ImportSharedPlan copies the plan of an active share into the history of the
+// user and records the user as recipient of the share. It returns the id of
+// the copy, or of the plan itself if the user owns the share.
+func (db *RAGDB) ImportSharedPlan(ctx context.Context, urlHash, userID string) (string, error) {
+	logger := httplog.LogEntry(ctx)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		logger.Error("Error starting transaction", httplog.ErrAttr(err))
+		return "", fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var share struct {
+		PlanID  string `db:"plan_id"`
+		OwnerID string `db:"owner_id"`
+		Active  bool   `db:"active"`
+	}
+	err = pgxscan.Get(ctx, tx, &share, `
+		SELECT plan_id, user_id AS owner_id,
+			revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now()) AS active
+		FROM shared_plans WHERE url_hash = $1
+		FOR SHARE`, urlHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrShareNotFound
+		}
+		logger.Error("Error querying shared plan", httplog.ErrAttr(err))
+		return "", fmt.Errorf("error querying shared plan: %w", err)
+	}
+	if !share.Active {
+		return "", ErrShareExpired
+	}
+	if share.OwnerID == userID {
+		return share.PlanID, nil
+	}
+
+	var planID string
+	if err := pgxscan.Get(ctx, tx, &planID, fmt.Sprintf(`
+		INSERT INTO %[1]s (plan_id, title, description, plan_table)
+		SELECT gen_random_uuid(), title, description, plan_table FROM %[1]s WHERE plan_id = $1
+		RETURNING plan_id::text`, PlanTableName), share.PlanID); err != nil {
+		logger.Error("Error copying shared plan", httplog.ErrAttr(err))
+		return "", fmt.Errorf("error copying shared plan: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(
+		`INSERT INTO %s (user_id, plan_id) VALUES ($1, $2)`, HistoryTableName),
+		userID, planID); err != nil {
+		logger.Error("Error adding plan to user history", httplog.ErrAttr(err))
+		return "", fmt.Errorf("error adding plan to user history: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO shared_history (user_id, plan_id, share_method, shared_by)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (user_id, plan_id) DO NOTHING`,
+		userID, share.PlanID, models.SharingMethodLink, share.OwnerID); err != nil {
+		logger.Error("Error adding plan to shared history", httplog.ErrAttr(err))
+		return "", fmt.Errorf("error adding plan to shared history: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Error committing transaction", httplog.ErrAttr(err))
+		return "", fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	logger.Debug("Shared plan imported successfully", "plan_id", planID, "shared_plan_id", share.PlanID)
+	return planID, nil
+}
+
+// RevokeSharedPlan revokes the active share of the user with the url hash.
+// Recipients keep the plans they already received or imported.
+func (db *RAGDB) RevokeSharedPlan(ctx context.Context, urlHash, userID string) error {
+	tag, err := db.Conn.Exec(ctx,
+		`UPDATE shared_plans SET revoked_at = now()
+		 WHERE url_hash = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		urlHash, userID)
+	if err != nil {
+		httplog.LogEntry(ctx).Error("Error revoking shared plan", httplog.ErrAttr(err))
+		return fmt.Errorf("error revoking shared plan: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottledErrorMatchesSentinel(t *testing.T) {
+	err := fmt.Errorf("sharing failed: %w", &ThrottledError{RetryAfter: 90 * time.Minute})
+
+	assert.ErrorIs(t, err, ErrShareThrottled)
+	var throttled *ThrottledError
+	assert.True(t, errors.As(err, &throttled))
+	assert.Equal(t, 90*time.Minute, throttled.RetryAfter)
+	assert.EqualError(t, err, "sharing failed: too many plans shared by email, retry after 1h30m0s")
+}
@@ -23,14 +23,14 @@ type RAGDB struct {
 	PlanStore  *pgvector.Store
 	DrillStore *pgvector.Store
 	Memory     models.Memory
-	Client     *genai.GoogleGenAIClient
+	Client     genai.Provider
 	cfg        config.Config
 }
 
 func NewGoogleAIStore(ctx context.Context, cfg config.Config) (*RAGDB, error) {
-	slog.Info("Initializing Google AI store")
-	// Initialize the LLM client
-	client, err := genai.NewGoogleGenAIClient(ctx, cfg)
+	slog.Info("Initializing Google AI store", "llm_provider", cfg.LLM.Provider)
+	// Initialize the LLM client for the configured provider
+	client, err := genai.NewClient(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
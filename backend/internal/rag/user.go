@@ -3,6 +3,7 @@ package rag
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/georgysavva/scany/v2/pgxscan"
@@ -12,7 +13,8 @@ import (
 
 const ProfilesTableName string = "profiles"
 
-// Retrieves a user from the database by their ID
+// Retrieves a user from the database by their ID. The profile holds the CSS
+// times, but not the preferences and the calibration of generated plans.
 func (db *RAGDB) GetUserProfile(ctx context.Context, id string) (*models.UserProfile, error) {
 	logger := httplog.LogEntry(ctx)
 
@@ -26,6 +28,32 @@ func (db *RAGDB) GetUserProfile(ctx context.Context, id string) (*models.UserPro
 	return &user, nil
 }
 
+// GetGenerationProfile returns the profile of the user with the training
+// preferences and the difficulty calibration, which personalize generated plans.
+func (db *RAGDB) GetGenerationProfile(ctx context.Context, id string) (*models.UserProfile, error) {
+	logger := httplog.LogEntry(ctx)
+
+	user, err := db.GetUserProfile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The profile is still useful without the preferences.
+	preferences, err := db.GetPreferences(ctx, id)
+	if err != nil {
+		logger.Warn("Error querying training preferences", httplog.ErrAttr(err))
+	} else if !preferences.IsZero() {
+		user.Preferences = preferences
+	}
+	calibration, err := db.GetDifficultyCalibration(ctx, id)
+	if err != nil {
+		logger.Warn("Error calibrating difficulty", httplog.ErrAttr(err))
+	} else {
+		user.Calibration = calibration
+	}
+	return user, nil
+}
+
 // Deletes a user and all ther associated data from the database
 func (db *RAGDB) DeleteUser(ctx context.Context, id string) error {
 	logger := httplog.LogEntry(ctx)
@@ -131,5 +159,39 @@ Benutzerprofil Präferenzen:
 		}
 	}
 
+	formatted += formatPreferences(profile.Preferences)
+	formatted += formatCalibration(profile.Calibration)
+
 	return formatted
 }
+
+// formatPreferences describes the training preferences confirmed by the user
+// for the prompts, empty if none are set.
+func formatPreferences(p *models.TrainingPreferences) string {
+	if p.IsZero() {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\nVom Schwimmer bestätigte Trainingsvorlieben (immer berücksichtigen):\n")
+	if len(p.Injuries) > 0 {
+		fmt.Fprintf(&sb, "- Verletzungen und Beschwerden, belastende Übungen vermeiden: %s\n", strings.Join(p.Injuries, ", "))
+	}
+	if len(p.DislikedDrills) > 0 {
+		fmt.Fprintf(&sb, "- Ungeliebte Übungen, nicht verwenden: %s\n", strings.Join(p.DislikedDrills, ", "))
+	}
+	if len(p.Equipment) > 0 {
+		equipment := make([]string, len(p.Equipment))
+		for i, e := range p.Equipment {
+			equipment[i] = string(e)
+		}
+		fmt.Fprintf(&sb, "- Üblicherweise verfügbare Ausrüstung: %s\n", strings.Join(equipment, ", "))
+	}
+	if p.PoolLength != nil {
+		fmt.Fprintf(&sb, "- Übliche Beckenlänge: %dm\n", *p.PoolLength)
+	}
+	if p.SessionMinutes != nil {
+		fmt.Fprintf(&sb, "- Übliche Trainingsdauer: %d Minuten\n", *p.SessionMinutes)
+	}
+	return sb.String()
+}
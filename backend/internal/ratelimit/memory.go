@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the number of operations after which idle entries are removed.
+const sweepInterval = 1024
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+type quota struct {
+	period time.Time
+	used   int
+}
+
+// MemoryStore keeps buckets and quotas in process memory. It is suited for a
+// single instance and for tests, counters are lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	quotas  map[string]*quota
+	ops     int
+}
+
+// Ensure MemoryStore implements Store
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, quotas: map[string]*quota{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.perSecond())
+		b.updatedAt = now
+	}
+
+	if b.tokens < 1 {
+		return Result{Allowed: false, Remaining: 0, RetryAfter: limit.retryAfter(b.tokens)}, nil
+	}
+	b.tokens--
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+func (s *MemoryStore) UseQuota(_ context.Context, key string, limit, n int, now time.Time) (QuotaResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start, reset := quotaPeriod(now)
+	q, ok := s.quotas[key]
+	if !ok || !q.period.Equal(start) {
+		q = &quota{period: start}
+		s.quotas[key] = q
+	}
+	if q.used+n > limit {
+		return QuotaResult{Allowed: false, Used: q.used, Remaining: max(limit-q.used, 0), Reset: reset}, nil
+	}
+	q.used += n
+	return QuotaResult{Allowed: true, Used: q.used, Remaining: limit - q.used, Reset: reset}, nil
+}
+
+func (s *MemoryStore) RefundQuota(_ context.Context, key string, n int, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start, _ := quotaPeriod(now)
+	if q, ok := s.quotas[key]; ok && q.period.Equal(start) {
+		q.used = max(q.used-n, 0)
+	}
+	return nil
+}
+
+// sweep periodically removes full buckets and quotas of past months, as they
+// carry no state. The caller must hold the lock.
+func (s *MemoryStore) sweep(now time.Time) {
+	s.ops++
+	if s.ops%sweepInterval != 0 {
+		return
+	}
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updatedAt).Seconds()*b.limit.perSecond() >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	start, _ := quotaPeriod(now)
+	for key, q := range s.quotas {
+		if q.period.Before(start) {
+			delete(s.quotas, key)
+		}
+	}
+}
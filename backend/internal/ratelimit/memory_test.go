@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreTakeRefillsTokens(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Burst: 2, Rate: 1, Period: time.Second}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	for i := range 2 {
+		res, err := store.Take(context.Background(), "k", limit, now)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed, "request %d", i)
+	}
+	res, err := store.Take(context.Background(), "k", limit, now)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	res, err = store.Take(context.Background(), "k", limit, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res, err = store.Take(context.Background(), "other", limit, now)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "buckets must be independent")
+}
+
+func TestMemoryStoreQuotaResetsMonthly(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC)
+
+	q, err := store.UseQuota(context.Background(), "k", 1, 1, now)
+	require.NoError(t, err)
+	assert.True(t, q.Allowed)
+	assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), q.Reset)
+
+	q, err = store.UseQuota(context.Background(), "k", 1, 1, now)
+	require.NoError(t, err)
+	assert.False(t, q.Allowed)
+
+	require.NoError(t, store.RefundQuota(context.Background(), "k", 1, now))
+	q, err = store.UseQuota(context.Background(), "k", 1, 1, now)
+	require.NoError(t, err)
+	assert.True(t, q.Allowed, "refunded generation must be usable again")
+
+	q, err = store.UseQuota(context.Background(), "k", 1, 1, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, q.Allowed, "quota must reset in the next month")
+}
+
+func TestMemoryStoreQuotaUsesAllOrNothing(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	q, err := store.UseQuota(context.Background(), "k", 5, 3, now)
+	require.NoError(t, err)
+	assert.True(t, q.Allowed)
+	assert.Equal(t, 2, q.Remaining)
+
+	q, err = store.UseQuota(context.Background(), "k", 5, 3, now)
+	require.NoError(t, err)
+	assert.False(t, q.Allowed)
+	assert.Equal(t, 2, q.Remaining, "a denied request must not consume generations")
+
+	require.NoError(t, store.RefundQuota(context.Background(), "k", 3, now))
+	q, err = store.UseQuota(context.Background(), "k", 5, 5, now)
+	require.NoError(t, err)
+	assert.True(t, q.Allowed)
+}
@@ -0,0 +1,222 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httplog/v2"
+)
+
+// Config configures the limits enforced by the Limiter.
+type Config struct {
+	// User limits the request rate of authenticated users.
+	User Limit
+	// IP limits the request rate per client IP, for anonymous and authenticated requests.
+	IP Limit
+	// UserQuota is the number of monthly generations per authenticated user, 0 disables it.
+	UserQuota int
+	// AnonymousQuota is the number of monthly generations per IP for anonymous requests, 0 disables it.
+	AnonymousQuota int
+	// TrustedProxies is the number of proxies in front of the API which append
+	// the address they received the request from to X-Forwarded-For. With 0 the
+	// header is ignored and the remote address is used.
+	TrustedProxies int
+}
+
+type quotaCtxKey struct{}
+
+// requestQuota tracks the generations consumed by a request, so that they can
+// be refunded together if the request fails.
+type requestQuota struct {
+	store Store
+	key   string
+	limit int
+	now   time.Time
+	mu    sync.Mutex
+	used  int
+}
+
+// refund returns all generations consumed by the request. The refund outlives a
+// canceled request, since the client may have disconnected.
+func (q *requestQuota) refund(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.used == 0 {
+		return nil
+	}
+	if err := q.store.RefundQuota(context.WithoutCancel(ctx), q.key, q.used, q.now); err != nil {
+		return err
+	}
+	q.used = 0
+	return nil
+}
+
+// Refund returns the generations consumed by the request. Handlers call it if
+// they fail after the response status has been written, e.g. in the middle of
+// a stream, which the middleware can not detect. Requests without quota are
+// ignored.
+func Refund(ctx context.Context) {
+	q, ok := ctx.Value(quotaCtxKey{}).(*requestQuota)
+	if !ok {
+		return
+	}
+	if err := q.refund(ctx); err != nil {
+		httplog.LogEntry(ctx).Warn("Failed to refund generation quota", httplog.ErrAttr(err))
+	}
+}
+
+// UseQuota consumes n more generations for the request, for handlers which
+// generate several plans at once. If they exceed the monthly quota, a 429 is
+// written to w and the generation consumed by the middleware is refunded as
+// well. Requests without quota and store errors let the request pass like in
+// the middleware.
+func UseQuota(w http.ResponseWriter, r *http.Request, n int) bool {
+	q, ok := r.Context().Value(quotaCtxKey{}).(*requestQuota)
+	if !ok || n <= 0 {
+		return true
+	}
+	logger := httplog.LogEntry(r.Context())
+
+	q.mu.Lock()
+	quota, err := q.store.UseQuota(r.Context(), q.key, q.limit, n, q.now)
+	if err == nil && quota.Allowed {
+		q.used += n
+	}
+	q.mu.Unlock()
+	if err != nil {
+		logger.Warn("Quota store failed, skipping quota", httplog.ErrAttr(err))
+		return true
+	}
+	if !quota.Allowed {
+		if err := q.refund(r.Context()); err != nil {
+			logger.Warn("Failed to refund generation quota", httplog.ErrAttr(err))
+		}
+		logger.Info("Monthly generation quota exceeded", "key", q.key, "generations", n)
+		tooManyRequests(w, quota.Reset.Sub(q.now), "Monthly generation quota exceeded")
+		return false
+	}
+	w.Header().Set("X-Quota-Remaining", strconv.Itoa(quota.Remaining))
+	return true
+}
+
+type keyedLimit struct {
+	key   string
+	limit Limit
+}
+
+// Limiter enforces rate limits and generation quotas as HTTP middleware.
+type Limiter struct {
+	store Store
+	cfg   Config
+	now   func() time.Time
+}
+
+func New(store Store, cfg Config) *Limiter {
+	return &Limiter{store: store, cfg: cfg, now: time.Now}
+}
+
+// Middleware limits the wrapped handler. It expects the user id set by the
+// authentication middleware in the request context. Every request consumes one
+// generation of the quota, which is refunded if the handler responds with a
+// status outside 2xx or calls Refund. Store errors are logged and let the request pass.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := httplog.LogEntry(r.Context())
+		now := l.now()
+		userID, _ := r.Context().Value(models.UserIdCtxKey).(string)
+		ipKey := "ip:" + ClientIP(r, l.cfg.TrustedProxies)
+
+		buckets := []keyedLimit{{key: ipKey, limit: l.cfg.IP}}
+		if userID != "" {
+			buckets = append(buckets, keyedLimit{key: "user:" + userID, limit: l.cfg.User})
+		}
+		for _, b := range buckets {
+			if !b.limit.Enabled() {
+				continue
+			}
+			res, err := l.store.Take(r.Context(), "rate:"+b.key, b.limit, now)
+			if err != nil {
+				logger.Warn("Rate limit store failed, skipping limit", httplog.ErrAttr(err))
+				continue
+			}
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(b.limit.Burst))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			if !res.Allowed {
+				logger.Info("Rate limit exceeded", "key", b.key)
+				tooManyRequests(w, res.RetryAfter, "Too many requests")
+				return
+			}
+		}
+
+		quotaKey, quotaLimit := ipKey, l.cfg.AnonymousQuota
+		if userID != "" {
+			quotaKey, quotaLimit = "user:"+userID, l.cfg.UserQuota
+		}
+		if quotaLimit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		quota, err := l.store.UseQuota(r.Context(), "quota:"+quotaKey, quotaLimit, 1, now)
+		if err != nil {
+			logger.Warn("Quota store failed, skipping quota", httplog.ErrAttr(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("X-Quota-Limit", strconv.Itoa(quotaLimit))
+		w.Header().Set("X-Quota-Remaining", strconv.Itoa(quota.Remaining))
+		w.Header().Set("X-Quota-Reset", strconv.FormatInt(quota.Reset.Unix(), 10))
+		if !quota.Allowed {
+			logger.Info("Monthly generation quota exceeded", "key", quotaKey)
+			tooManyRequests(w, quota.Reset.Sub(now), "Monthly generation quota exceeded")
+			return
+		}
+
+		used := &requestQuota{store: l.store, key: "quota:" + quotaKey, limit: quotaLimit, now: now, used: 1}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), quotaCtxKey{}, used)))
+		// Handlers that write nothing respond with 200
+		if status := ww.Status(); status != 0 && (status < 200 || status >= 300) {
+			if err := used.refund(r.Context()); err != nil {
+				logger.Warn("Failed to refund generation quota", httplog.ErrAttr(err))
+			}
+		}
+	})
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration, msg string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	http.Error(w, msg, http.StatusTooManyRequests)
+}
+
+// ClientIP returns the client address of the request. Clients can send any
+// X-Forwarded-For header, so only the entries appended by the trusted proxies
+// are used: the client address is the one the outermost trusted proxy received
+// the request from. Without trusted proxies, or if the header has fewer entries,
+// it falls back to the remote address.
+func ClientIP(r *http.Request, trustedProxies int) string {
+	if trustedProxies > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(header, ",")...)
+		}
+		if len(hops) >= trustedProxies {
+			if ip := strings.TrimSpace(hops[len(hops)-trustedProxies]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
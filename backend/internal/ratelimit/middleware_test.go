@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func limitedRequest(userID, ip string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.Header.Set("X-Forwarded-For", "198.51.100.99, "+ip)
+	return req.WithContext(context.WithValue(req.Context(), models.UserIdCtxKey, userID))
+}
+
+func TestMiddlewareRateLimitsPerUser(t *testing.T) {
+	limiter := New(NewMemoryStore(), Config{User: Limit{Burst: 1, Rate: 1, Period: time.Minute}, TrustedProxies: 1})
+	handler := limiter.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "0", first.Header().Get("X-RateLimit-Remaining"))
+
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, limitedRequest("user-a", "203.0.113.2"))
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.Equal(t, "60", second.Header().Get("Retry-After"))
+
+	other := httptest.NewRecorder()
+	handler.ServeHTTP(other, limitedRequest("user-b", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, other.Code)
+}
+
+func TestMiddlewareRateLimitsPerIP(t *testing.T) {
+	limiter := New(NewMemoryStore(), Config{IP: Limit{Burst: 1, Rate: 1, Period: time.Minute}, TrustedProxies: 1})
+	handler := limiter.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), limitedRequest("", "203.0.113.1"))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, limitedRequest("user-a", "203.0.113.1"))
+
+	assert.Equal(t, http.StatusTooManyRequests, response.Code)
+}
+
+func TestMiddlewareEnforcesMonthlyQuotaAndRefundsFailures(t *testing.T) {
+	limiter := New(NewMemoryStore(), Config{UserQuota: 1, AnonymousQuota: 1, TrustedProxies: 1})
+	limiter.now = func() time.Time { return time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC) }
+	status := http.StatusInternalServerError
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	failed := httptest.NewRecorder()
+	handler.ServeHTTP(failed, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusInternalServerError, failed.Code)
+
+	status = http.StatusBadRequest
+	rejected := httptest.NewRecorder()
+	handler.ServeHTTP(rejected, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusBadRequest, rejected.Code)
+
+	status = http.StatusOK
+	ok := httptest.NewRecorder()
+	handler.ServeHTTP(ok, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, ok.Code, "failed and rejected requests must not consume the quota")
+	assert.Equal(t, "0", ok.Header().Get("X-Quota-Remaining"))
+	assert.Equal(t, "1793491200", ok.Header().Get("X-Quota-Reset"))
+
+	exceeded := httptest.NewRecorder()
+	handler.ServeHTTP(exceeded, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, exceeded.Code)
+	assert.Equal(t, "1296000", exceeded.Header().Get("Retry-After"))
+
+	anonymous := httptest.NewRecorder()
+	handler.ServeHTTP(anonymous, limitedRequest("", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, anonymous.Code, "anonymous quota is tracked per IP")
+}
+
+func TestMiddlewareRefundsFailedStreams(t *testing.T) {
+	limiter := New(NewMemoryStore(), Config{UserQuota: 1, TrustedProxies: 1})
+	failStream := true
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Streams write their status before they can fail
+		w.WriteHeader(http.StatusOK)
+		if failStream {
+			Refund(r.Context())
+			Refund(r.Context())
+		}
+	}))
+
+	failed := httptest.NewRecorder()
+	handler.ServeHTTP(failed, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, failed.Code)
+
+	failStream = false
+	ok := httptest.NewRecorder()
+	handler.ServeHTTP(ok, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, ok.Code, "failed stream must not consume the quota")
+	assert.Equal(t, "0", ok.Header().Get("X-Quota-Remaining"), "a repeated refund must not return more than was used")
+
+	exceeded := httptest.NewRecorder()
+	handler.ServeHTTP(exceeded, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, exceeded.Code)
+}
+
+func TestUseQuotaChargesEveryGeneration(t *testing.T) {
+	limiter := New(NewMemoryStore(), Config{UserQuota: 5, TrustedProxies: 1})
+	generations, status := 0, http.StatusOK
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !UseQuota(w, r, generations-1) {
+			return
+		}
+		w.WriteHeader(status)
+	}))
+
+	status, generations = http.StatusInternalServerError, 4
+	failed := httptest.NewRecorder()
+	handler.ServeHTTP(failed, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusInternalServerError, failed.Code)
+
+	status = http.StatusOK
+	charged := httptest.NewRecorder()
+	handler.ServeHTTP(charged, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, charged.Code, "a failed request must refund all its generations")
+	assert.Equal(t, "1", charged.Header().Get("X-Quota-Remaining"))
+
+	generations = 2
+	exceeded := httptest.NewRecorder()
+	handler.ServeHTTP(exceeded, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, exceeded.Code)
+
+	generations = 1
+	last := httptest.NewRecorder()
+	handler.ServeHTTP(last, limitedRequest("user-a", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, last.Code, "a denied request must refund the generation of the middleware")
+}
+
+func TestMiddlewareIgnoresSpoofedForwardedFor(t *testing.T) {
+	limiter := New(NewMemoryStore(), Config{IP: Limit{Burst: 1, Rate: 1, Period: time.Minute}, TrustedProxies: 1})
+	handler := limiter.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), limitedRequest("", "203.0.113.1"))
+	spoofed := limitedRequest("", "203.0.113.1")
+	spoofed.Header.Set("X-Forwarded-For", "192.0.2.77, 203.0.113.1")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, spoofed)
+
+	assert.Equal(t, http.StatusTooManyRequests, response.Code, "a new client supplied entry must not reset the limit")
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "192.0.2.1", ClientIP(req, 1))
+
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 10.0.0.1")
+	assert.Equal(t, "10.0.0.1", ClientIP(req, 1), "the entry appended by the proxy is used")
+	assert.Equal(t, "198.51.100.1", ClientIP(req, 2))
+	assert.Equal(t, "192.0.2.1", ClientIP(req, 0), "without trusted proxies the header is ignored")
+	assert.Equal(t, "192.0.2.1", ClientIP(req, 3), "a header shorter than the proxy chain is not trusted")
+
+	// A spoofed first entry does not change the key
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.1, 10.0.0.1")
+	assert.Equal(t, "10.0.0.1", ClientIP(req, 1))
+	assert.Equal(t, "198.51.100.1", ClientIP(req, 2))
+
+	// Proxies may append a second header instead of extending the first one
+	req.Header.Add("X-Forwarded-For", "10.0.0.2")
+	assert.Equal(t, "10.0.0.2", ClientIP(req, 1))
+}
@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	BucketTableName  = "private.api_rate_limit_buckets"
+	QuotaTableName   = "private.api_generation_quotas"
+	ProfileTableName = "public.profiles"
+)
+
+// userQuotaPrefix marks the quota keys of authenticated users. Their
+// generations are counted in profiles.monthly_generations, the counter shown on
+// the profile page, which is increased by a trigger for every plan added to the
+// history and reset by a cron job at the start of every month.
+const userQuotaPrefix = "quota:user:"
+
+// Conn is the subset of a pgx connection pool used by the PostgresStore.
+type Conn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// PostgresStore keeps buckets and quotas in Postgres, so limits are shared
+// between all instances of the API. All updates are single atomic statements.
+// Quotas of users are checked against the generation counter of their profile,
+// which the store only reads, those of anonymous clients are kept in the quota
+// table.
+type PostgresStore struct {
+	db Conn
+}
+
+// Ensure PostgresStore implements Store
+var _ Store = (*PostgresStore)(nil)
+
+func NewPostgresStore(db Conn) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	// Refill the bucket based on the elapsed time and take a token, but only if
+	// at least one token is available. Denied requests do not return a row.
+	var tokens float64
+	err := s.db.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %[1]s AS b (key, tokens, updated_at)
+		VALUES ($1, $2::float8 - 1, $4)
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4 - b.updated_at)), 0) * $3::float8) - 1,
+			updated_at = GREATEST(b.updated_at, $4)
+		WHERE LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4 - b.updated_at)), 0) * $3::float8) >= 1
+		RETURNING tokens
+	`, BucketTableName), key, limit.Burst, limit.perSecond(), now).Scan(&tokens)
+	if err == nil {
+		return Result{Allowed: true, Remaining: int(tokens)}, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	err = s.db.QueryRow(ctx, fmt.Sprintf(`
+		SELECT LEAST($2::float8, tokens + GREATEST(EXTRACT(EPOCH FROM ($4 - updated_at)), 0) * $3::float8)
+		FROM %s WHERE key = $1
+	`, BucketTableName), key, limit.Burst, limit.perSecond(), now).Scan(&tokens)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read rate limit bucket: %w", err)
+	}
+	return Result{Allowed: false, Remaining: 0, RetryAfter: limit.retryAfter(tokens)}, nil
+}
+
+func (s *PostgresStore) UseQuota(ctx context.Context, key string, limit, n int, now time.Time) (QuotaResult, error) {
+	start, reset := quotaPeriod(now)
+	if limit <= 0 || n > limit {
+		return QuotaResult{Allowed: false, Reset: reset}, nil
+	}
+
+	if userID, ok := strings.CutPrefix(key, userQuotaPrefix); ok {
+		used, err := s.profileGenerations(ctx, userID)
+		if err == nil {
+			// The generations of this request are counted by the profile
+			// trigger once their plans are added to the history.
+			if used+n <= limit {
+				return QuotaResult{Allowed: true, Used: used + n, Remaining: limit - used - n, Reset: reset}, nil
+			}
+			return QuotaResult{Allowed: false, Used: used, Remaining: max(limit-used, 0), Reset: reset}, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return QuotaResult{}, err
+		}
+		// Users without profile are counted like anonymous clients
+	}
+
+	var used int
+	err := s.db.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %[1]s AS q (key, period, used)
+		VALUES ($1, $2, $4)
+		ON CONFLICT (key, period) DO UPDATE SET used = q.used + $4
+		WHERE q.used + $4 <= $3
+		RETURNING used
+	`, QuotaTableName), key, start, limit, n).Scan(&used)
+	if err == nil {
+		return QuotaResult{Allowed: true, Used: used, Remaining: limit - used, Reset: reset}, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return QuotaResult{}, fmt.Errorf("failed to use generation quota: %w", err)
+	}
+
+	err = s.db.QueryRow(ctx, fmt.Sprintf(`SELECT used FROM %s WHERE key = $1 AND period = $2`, QuotaTableName), key, start).Scan(&used)
+	if err != nil {
+		return QuotaResult{}, fmt.Errorf("failed to read generation quota: %w", err)
+	}
+	return QuotaResult{Allowed: false, Used: used, Remaining: max(limit-used, 0), Reset: reset}, nil
+}
+
+// profileGenerations reads the monthly generation counter of the profile. It
+// returns pgx.ErrNoRows if the user has no profile.
+func (s *PostgresStore) profileGenerations(ctx context.Context, userID string) (int, error) {
+	var used int
+	err := s.db.QueryRow(ctx, fmt.Sprintf(`SELECT monthly_generations FROM %s WHERE user_id = $1`, ProfileTableName), userID).Scan(&used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read generation quota: %w", err)
+	}
+	return used, nil
+}
+
+func (s *PostgresStore) RefundQuota(ctx context.Context, key string, n int, now time.Time) error {
+	// Generations of users with profile are only counted once their plan is
+	// added to the history, so there is nothing to refund. Users without
+	// profile are counted in the quota table.
+	start, _ := quotaPeriod(now)
+	_, err := s.db.Exec(ctx, fmt.Sprintf(`
+		UPDATE %s SET used = GREATEST(used - $3, 0) WHERE key = $1 AND period = $2
+	`, QuotaTableName), key, start, n)
+	if err != nil {
+		return fmt.Errorf("failed to refund generation quota: %w", err)
+	}
+	return nil
+}
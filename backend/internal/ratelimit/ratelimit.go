@@ -0,0 +1,70 @@
+// Package ratelimit implements token bucket rate limits and monthly generation
+// quotas for the API with pluggable storage backends.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit configures a token bucket. Burst requests are allowed at once, after
+// which Rate tokens per Period are refilled. A zero Burst disables the limit.
+type Limit struct {
+	Burst  int
+	Rate   int
+	Period time.Duration
+}
+
+// Enabled reports whether the limit is active.
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Rate > 0 && l.Period > 0
+}
+
+// perSecond returns the refill rate in tokens per second.
+func (l Limit) perSecond() float64 {
+	return float64(l.Rate) / l.Period.Seconds()
+}
+
+// retryAfter returns the time until the bucket holds one full token again.
+func (l Limit) retryAfter(tokens float64) time.Duration {
+	missing := 1 - tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / l.perSecond() * float64(time.Second))
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// QuotaResult is the outcome of consuming a generation from a monthly quota.
+type QuotaResult struct {
+	Allowed   bool
+	Used      int
+	Remaining int
+	// Reset is the start of the next quota period.
+	Reset time.Time
+}
+
+// Store persists token buckets and quota counters.
+type Store interface {
+	// Take removes one token from the bucket identified by key.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// UseQuota consumes n generations of the quota identified by key for the
+	// month containing now. Either all n are consumed or, if they would exceed
+	// the limit, none.
+	UseQuota(ctx context.Context, key string, limit, n int, now time.Time) (QuotaResult, error)
+	// RefundQuota returns n previously consumed generations, e.g. if the request failed.
+	RefundQuota(ctx context.Context, key string, n int, now time.Time) error
+}
+
+// quotaPeriod returns the start of the month containing now and of the next month.
+func quotaPeriod(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/pdf"
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/5pirit5eal/swim-gen/internal/ratelimit"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+	"github.com/google/uuid"
+)
+
+// CreateTrainingBlockHandler generates a periodized multi-week training block.
+// @Summary Generate a training block
+// @Description Generate a multi-week block (base, build, taper, race week) with one plan per session and a weekly volume progression. The plans are added to the user's history. Every session counts as one generation of the monthly quota.
+// @Tags Training Blocks
+// @Accept json
+// @Produce json
+// @Param request body models.TrainingBlockRequest true "Training block parameters"
+// @Success 201 {object} models.TrainingBlock "Generated training block"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 429 {string} string "Monthly generation quota exceeded"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /blocks [post]
+func (rs *RAGService) CreateTrainingBlockHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Generating training block...")
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	br := &models.TrainingBlockRequest{}
+	if err := models.GetRequestJSON(req, br); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := br.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The rate limit middleware charged one generation, the other sessions are
+	// charged before they are generated
+	if !ratelimit.UseQuota(w, req, br.Weeks*br.SessionsPerWeek-1) {
+		return
+	}
+
+	profile := rs.generationProfile(req.Context(), userID, br.Preferences)
+	block, err := rs.db.GenerateTrainingBlock(req.Context(), br, profile, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	httplog.LogEntrySetField(req.Context(), "block_id", slog.StringValue(block.BlockID))
+	logger.Info("Training block generated successfully", "sessions", len(block.Sessions))
+	if err := models.WriteResponseJSON(w, http.StatusCreated, block); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetTrainingBlocksHandler lists the training blocks of the user.
+// @Summary List training blocks
+// @Description Get the training blocks of the authenticated user with their weekly schedule, without the session plans
+// @Tags Training Blocks
+// @Produce json
+// @Success 200 {array} models.TrainingBlock "Training blocks, newest first"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /blocks [get]
+func (rs *RAGService) GetTrainingBlocksHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Getting training blocks...")
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	blocks, err := rs.db.GetTrainingBlocks(req.Context(), userID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, blocks); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetTrainingBlockHandler returns a training block with all session plans.
+// @Summary Get a training block
+// @Description Get a training block of the authenticated user including the plans of all sessions
+// @Tags Training Blocks
+// @Produce json
+// @Param block_id path string true "Training block ID"
+// @Success 200 {object} models.TrainingBlock "Training block"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Training block not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /blocks/{block_id} [get]
+func (rs *RAGService) GetTrainingBlockHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Getting training block...")
+
+	userID, blockID, ok := blockRequest(w, req)
+	if !ok {
+		return
+	}
+
+	block, err := rs.db.GetTrainingBlock(req.Context(), blockID, userID)
+	if err != nil {
+		writeBlockError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, block); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// RegenerateBlockSessionHandler generates a single session of a training block again.
+// @Summary Regenerate a session of a training block
+// @Description Generate the plan of one session again with the phase and volume of its week. The session is linked to a new plan, the previous plan stays in the history unchanged.
+// @Tags Training Blocks
+// @Accept json
+// @Produce json
+// @Param block_id path string true "Training block ID"
+// @Param week path int true "Week of the session, starting at 1"
+// @Param session path int true "Session within the week, starting at 1"
+// @Param request body models.RegenerateSessionRequest false "Additional wishes for the session"
+// @Success 200 {object} models.BlockSession "Regenerated session"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Training block not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /blocks/{block_id}/sessions/{week}/{session}/regenerate [post]
+func (rs *RAGService) RegenerateBlockSessionHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Regenerating training block session...")
+
+	userID, blockID, ok := blockRequest(w, req)
+	if !ok {
+		return
+	}
+	week, err := strconv.Atoi(chi.URLParam(req, "week"))
+	if err != nil {
+		http.Error(w, "week must be a number", http.StatusBadRequest)
+		return
+	}
+	session, err := strconv.Atoi(chi.URLParam(req, "session"))
+	if err != nil {
+		http.Error(w, "session must be a number", http.StatusBadRequest)
+		return
+	}
+
+	rr := &models.RegenerateSessionRequest{}
+	if req.ContentLength != 0 {
+		if err := models.GetRequestJSON(req, rr); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := rr.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile := rs.generationProfile(req.Context(), userID, rr.Preferences)
+	regenerated, err := rs.db.RegenerateBlockSession(req.Context(), blockID, userID, week, session, rr.Content, profile)
+	if err != nil {
+		writeBlockError(w, err)
+		return
+	}
+
+	logger.Info("Training block session regenerated successfully", "plan_id", regenerated.PlanID)
+	if err := models.WriteResponseJSON(w, http.StatusOK, regenerated); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// BlockToPDFHandler exports all sessions of a training block into one PDF.
+// @Summary Export training block to PDF
+// @Description Generate a PDF with an overview of the weeks and one page per session of the training block
+// @Tags Training Blocks
+// @Accept json
+// @Produce json
+// @Param block_id path string true "Training block ID"
+// @Param request body models.BlockToPDFRequest true "PDF options"
+// @Success 200 {object} models.PlanToPDFResponse "PDF export response with URI"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Training block not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /blocks/{block_id}/export-pdf [post]
+func (rs *RAGService) BlockToPDFHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Exporting training block to PDF...")
+
+	userID, blockID, ok := blockRequest(w, req)
+	if !ok {
+		return
+	}
+
+	pr := &models.BlockToPDFRequest{}
+	if err := models.GetRequestJSON(req, pr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	block, err := rs.db.GetTrainingBlock(req.Context(), blockID, userID)
+	if err != nil {
+		writeBlockError(w, err)
+		return
+	}
+
+	profile, err := rs.db.GetUserProfile(req.Context(), userID)
+	if err != nil {
+		logger.Warn("Failed to get user profile, estimating durations with default pace", httplog.ErrAttr(err))
+	}
+
+	blockPDF, err := pdf.BlockToPDF(block, profile, pr.Horizontal, pr.LargeFont, pr.Language, pr.FrontendBaseURL)
+	if err != nil {
+		logger.Error("Block PDF generation failed", httplog.ErrAttr(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	storagePath := pdf.GenerateStoragePath(userID, block.BlockID, block.Title)
+	uri, err := pdf.UploadPDF(req.Context(), rs.blobs, storagePath, blockPDF)
+	if err != nil {
+		logger.Error("PDF upload failed", httplog.ErrAttr(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Training block exported successfully")
+	if err := models.WriteResponseJSON(w, http.StatusOK, &models.PlanToPDFResponse{URI: uri}); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// blockRequest reads the user and the block id of a request to a training block.
+// Unknown users and malformed ids are answered, in which case ok is false.
+func blockRequest(w http.ResponseWriter, req *http.Request) (userID, blockID string, ok bool) {
+	return resourceRequest(w, req, "block_id", "Training block not found")
+}
+
+// resourceRequest reads the user and the uuid in the URL parameter param.
+// Unknown users are answered with 401, malformed ids with 404 and notFound.
+func resourceRequest(w http.ResponseWriter, req *http.Request, param, notFound string) (userID, id string, ok bool) {
+	userID, ok = req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", "", false
+	}
+	id = chi.URLParam(req, param)
+	if _, err := uuid.Parse(id); err != nil {
+		http.Error(w, notFound, http.StatusNotFound)
+		return "", "", false
+	}
+	httplog.LogEntrySetField(req.Context(), param, slog.StringValue(id))
+	return userID, id, true
+}
+
+func writeBlockError(w http.ResponseWriter, err error) {
+	if errors.Is(err, rag.ErrBlockNotFound) {
+		http.Error(w, "Training block not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+// generationProfile returns the profile used to personalize generated plans. It
+// is nil if the user disabled the preferences or the profile can not be loaded.
+func (rs *RAGService) generationProfile(ctx context.Context, userID string, preferences *bool) *models.UserProfile {
+	if preferences != nil && !*preferences {
+		return nil
+	}
+	profile, err := rs.db.GetGenerationProfile(ctx, userID)
+	if err != nil {
+		httplog.LogEntry(ctx).Warn("Failed to get user profile, proceeding without it", httplog.ErrAttr(err))
+		return nil
+	}
+	return profile
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func blockHandlerRequest(method, body, userID string, params map[string]string) *http.Request {
+	req := memoryHandlerRequest(method, "/blocks", body, userID)
+	routeCtx := chi.NewRouteContext()
+	for key, value := range params {
+		routeCtx.URLParams.Add(key, value)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+func TestTrainingBlockHandlersRequireAuthentication(t *testing.T) {
+	service := &RAGService{}
+	params := map[string]string{"block_id": uuid.NewString(), "week": "1", "session": "1"}
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"create":     service.CreateTrainingBlockHandler,
+		"list":       service.GetTrainingBlocksHandler,
+		"get":        service.GetTrainingBlockHandler,
+		"regenerate": service.RegenerateBlockSessionHandler,
+		"export":     service.BlockToPDFHandler,
+	} {
+		response := httptest.NewRecorder()
+		handler(response, blockHandlerRequest(http.MethodPost, "{}", "", params))
+		assert.Equal(t, http.StatusUnauthorized, response.Code, name)
+	}
+}
+
+func TestTrainingBlockHandlersRejectMalformedBlockID(t *testing.T) {
+	service := &RAGService{}
+	params := map[string]string{"block_id": "invalid-uuid", "week": "1", "session": "1"}
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"get":        service.GetTrainingBlockHandler,
+		"regenerate": service.RegenerateBlockSessionHandler,
+		"export":     service.BlockToPDFHandler,
+	} {
+		response := httptest.NewRecorder()
+		handler(response, blockHandlerRequest(http.MethodPost, "{}", uuid.NewString(), params))
+		assert.Equal(t, http.StatusNotFound, response.Code, name)
+	}
+}
+
+func TestCreateTrainingBlockHandlerValidatesRequest(t *testing.T) {
+	service := &RAGService{}
+	body := `{"title":"Meisterschaften","goal":"400m Freistil","weeks":20,"sessions_per_week":3,"peak_volume":4000}`
+
+	response := httptest.NewRecorder()
+	service.CreateTrainingBlockHandler(response, blockHandlerRequest(http.MethodPost, body, uuid.NewString(), nil))
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "weeks must be between")
+}
+
+func TestRegenerateBlockSessionHandlerRejectsInvalidSession(t *testing.T) {
+	service := &RAGService{}
+	params := map[string]string{"block_id": uuid.NewString(), "week": "one", "session": "1"}
+
+	response := httptest.NewRecorder()
+	service.RegenerateBlockSessionHandler(response, blockHandlerRequest(http.MethodPost, "", uuid.NewString(), params))
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/pdf"
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/go-chi/httplog/v2"
+)
+
+// BookletToPDFHandler exports several plans into one PDF booklet.
+// @Summary Export training plans as PDF booklet
+// @Description Generate a PDF with a cover page, a table of contents, one page per plan and an appendix of the linked drills. The plans are taken from the history of the user or from a training block.
+// @Tags Training Plans
+// @Accept json
+// @Produce json,application/pdf
+// @Param request body models.BookletToPDFRequest true "Plans and PDF options"
+// @Param Accept header string false "application/pdf to receive the PDF instead of a signed URL"
+// @Success 200 {object} models.PlanToPDFResponse "PDF export response with URI"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Plan or training block not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /export-booklet [post]
+func (rs *RAGService) BookletToPDFHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Exporting plans to PDF booklet...")
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	br := &models.BookletToPDFRequest{}
+	if err := models.GetRequestJSON(req, br); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := br.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	title, plans, err := rs.bookletPlans(req.Context(), userID, br)
+	if err != nil {
+		switch {
+		case errors.Is(err, rag.ErrPlanNotFound):
+			http.Error(w, "Plan not found", http.StatusNotFound)
+		case errors.Is(err, rag.ErrBlockNotFound):
+			http.Error(w, "Training block not found", http.StatusNotFound)
+		default:
+			logger.Error("Failed to get booklet plans", httplog.ErrAttr(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	profile, err := rs.db.GetUserProfile(req.Context(), userID)
+	if err != nil {
+		logger.Warn("Failed to get user profile, estimating durations with default pace", httplog.ErrAttr(err))
+	}
+
+	tables := make([]models.Table, len(plans))
+	for i, plan := range plans {
+		tables[i] = plan.Table
+	}
+	appendix := rs.drillAppendix(req.Context(), br.Language, br.FrontendBaseURL, tables...)
+
+	bookletPDF, err := pdf.BookletToPDF(title, plans, appendix, profile, br.Horizontal, br.LargeFont, br.Language, br.FrontendBaseURL)
+	if err != nil {
+		logger.Error("Booklet PDF generation failed", httplog.ErrAttr(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if acceptsPDF(req) {
+		writeAttachment(w, req, "application/pdf", pdf.Filename(title), bookletPDF)
+		return
+	}
+
+	bookletID := br.BlockID
+	if bookletID == "" {
+		bookletID = strings.Join(br.PlanIDs, ",")
+	}
+	uri, err := pdf.UploadPDF(req.Context(), rs.blobs, pdf.GenerateStoragePath(userID, bookletID, title), bookletPDF)
+	if err != nil {
+		logger.Error("PDF upload failed", httplog.ErrAttr(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Booklet exported successfully", "plans", len(plans), "drills", len(appendix.Drills))
+	if err := models.WriteResponseJSON(w, http.StatusOK, &models.PlanToPDFResponse{URI: uri}); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// bookletPlans returns the title and the plans of the booklet, either the plans
+// of the history or the sessions of the training block.
+func (rs *RAGService) bookletPlans(ctx context.Context, userID string, br *models.BookletToPDFRequest) (string, []*models.Plan, error) {
+	title := br.Title
+	if br.BlockID == "" {
+		plans, err := rs.db.GetPlansForUser(ctx, br.PlanIDs, userID)
+		if err != nil {
+			return "", nil, err
+		}
+		if title == "" {
+			title = bookletTitle(br.Language)
+		}
+		return title, plans, nil
+	}
+
+	block, err := rs.db.GetTrainingBlock(ctx, br.BlockID, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if title == "" {
+		title = block.Title
+	}
+	plans := make([]*models.Plan, len(block.Sessions))
+	for i := range block.Sessions {
+		plans[i] = block.Sessions[i].Plan()
+	}
+	return title, plans, nil
+}
+
+func bookletTitle(lang models.Language) string {
+	if lang == models.LanguageDE {
+		return "Trainingspläne"
+	}
+	return "Training plans"
+}
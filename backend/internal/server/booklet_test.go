@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookletToPDFHandlerRequiresAuthentication(t *testing.T) {
+	response := httptest.NewRecorder()
+	(&RAGService{}).BookletToPDFHandler(response, memoryHandlerRequest(http.MethodPost, "/export-booklet", `{"block_id":"3fa85f64-5717-4562-b3fc-2c963f66afa6"}`, ""))
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestBookletToPDFHandlerRejectsInvalidRequests(t *testing.T) {
+	planID := "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	bodies := map[string]string{
+		"no plans":        `{}`,
+		"plans and block": `{"plan_ids":["` + planID + `"],"block_id":"` + planID + `"}`,
+		"invalid plan id": `{"plan_ids":["plan_123"]}`,
+		"duplicate plan":  `{"plan_ids":["` + planID + `","` + planID + `"]}`,
+		"invalid block":   `{"block_id":"block_123"}`,
+		"unknown field":   `{"block_id":"` + planID + `","plans":[]}`,
+	}
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			(&RAGService{}).BookletToPDFHandler(response, memoryHandlerRequest(http.MethodPost, "/export-booklet", body, "user-1"))
+			assert.Equal(t, http.StatusBadRequest, response.Code, response.Body.String())
+		})
+	}
+}
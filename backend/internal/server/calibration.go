@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/go-chi/httplog/v2"
+)
+
+// GetDifficultyCalibrationHandler returns the difficulty calibration of the user.
+// @Summary Get difficulty calibration
+// @Description Get how hard the swum plans of the user felt relative to their volume and intensity, learned from the difficulty ratings of the feedback. Generated plans adjust their load by it.
+// @Tags Feedback
+// @Produce json
+// @Success 200 {object} models.DifficultyCalibration "Difficulty calibration"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /calibration [get]
+func (rs *RAGService) GetDifficultyCalibrationHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	calibration, err := rs.db.GetDifficultyCalibration(req.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to calibrate difficulty", httplog.ErrAttr(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, calibration); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
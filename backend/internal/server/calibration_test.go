@@ -0,0 +1,15 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDifficultyCalibrationHandlerRequiresAuthentication(t *testing.T) {
+	response := httptest.NewRecorder()
+	(&RAGService{}).GetDifficultyCalibrationHandler(response, blockHandlerRequest(http.MethodGet, "", "", nil))
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+}
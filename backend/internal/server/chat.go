@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/5pirit5eal/swim-gen/internal/ratelimit"
 	"github.com/go-chi/httplog/v2"
 	"github.com/google/uuid"
 )
@@ -23,19 +25,141 @@ import (
 // @Success 200 {object} models.ChatResponsePayload "Successful chat response with updated plan"
 // @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
-// @Failure 404 {string} string "Plan not found"
+// @Failure 404 {string} string "Plan or edited message not found"
 // @Failure 500 {string} string "Internal server error"
 // @Security BearerAuth
 // @Router /chat [post]
 func (rs *RAGService) ChatHandler(w http.ResponseWriter, req *http.Request) {
 	logger := httplog.LogEntry(req.Context())
 
+	userID, chatReq, ok := parseChatRequest(w, req)
+	if !ok {
+		return
+	}
+
+	// Call ChatWithContext
+	updatedPlan, aiMessage, err := rs.db.ChatWithContext(
+		req.Context(),
+		chatReq.PlanID,
+		userID,
+		chatReq.Message,
+		chatReq.EditMessageID,
+		chatReq.Language,
+		chatReq.PoolLength,
+		chatReq.Constraints,
+	)
+	if err != nil {
+		logger.Error("Failed to process chat interaction", httplog.ErrAttr(err))
+		msg, status := chatErrorResponse(err)
+		http.Error(w, msg, status)
+		return
+	}
+
+	// Build response
+	response := rs.chatResponsePayload(req.Context(), userID, chatReq.PlanID, updatedPlan, aiMessage)
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Failed to encode response", httplog.ErrAttr(err))
+	}
+
+	logger.Info("Chat request completed successfully", "user_id", userID, "plan_id", response.PlanID)
+}
+
+// ChatStreamHandler works like ChatHandler but streams the conversational response
+// via server-sent events while it is generated.
+// Emits "token" events with partial response text, a "replace" event with the
+// complete response if the plan was generated again to meet the constraints, a
+// final "done" event with the validated plan and the persisted message ids, or an "error" event.
+// @Summary Chat with AI and stream the response
+// @Description Streams the AI trainer response token by token via server-sent events. A "replace" event replaces the streamed text if the plan was generated again to meet the constraints. The final "done" event contains the updated plan and the ids of the stored messages.
+// @Tags Chat
+// @Accept json
+// @Produce text/event-stream
+// @Param request body models.ChatRequest true "Chat request with message and plan ID"
+// @Success 200 {object} models.ChatStreamDone "Event stream of models.ChatStreamToken and models.ChatStreamReplace events followed by a models.ChatStreamDone or models.ChatStreamError event"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Plan or edited message not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /chat/stream [post]
+func (rs *RAGService) ChatStreamHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, chatReq, ok := parseChatRequest(w, req)
+	if !ok {
+		return
+	}
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		logger.Error("Streaming not supported by response writer", httplog.ErrAttr(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	updatedPlan, aiMessage, err := rs.db.ChatWithContextStream(
+		req.Context(),
+		chatReq.PlanID,
+		userID,
+		chatReq.Message,
+		chatReq.EditMessageID,
+		chatReq.Language,
+		chatReq.PoolLength,
+		chatReq.Constraints,
+		func(token string) error {
+			return sse.event("token", models.ChatStreamToken{Text: token})
+		},
+		func(response string) error {
+			return sse.event("replace", models.ChatStreamReplace{Text: response})
+		},
+	)
+	if err != nil {
+		logger.Error("Failed to process streamed chat interaction", httplog.ErrAttr(err))
+		msg, status := chatErrorResponse(err)
+		if !sse.started {
+			// Nothing was streamed yet, so a plain HTTP error can still be sent.
+			http.Error(w, msg, status)
+			return
+		}
+		// The stream already answered with 200, so the rate limit middleware can
+		// not see the failure and the generation is refunded here.
+		if status >= http.StatusInternalServerError {
+			ratelimit.Refund(req.Context())
+		}
+		if err := sse.event("error", models.ChatStreamError{Status: status, Error: msg}); err != nil {
+			logger.Error("Failed to write error event", httplog.ErrAttr(err))
+		}
+		return
+	}
+
+	done := models.ChatStreamDone{ChatResponsePayload: rs.chatResponsePayload(req.Context(), userID, chatReq.PlanID, updatedPlan, aiMessage)}
+	done.AIMessageID = aiMessage.ID
+	if aiMessage.PreviousMessageID != nil {
+		done.UserMessageID = *aiMessage.PreviousMessageID
+	}
+	if err := sse.event("done", done); err != nil {
+		logger.Error("Failed to write done event", httplog.ErrAttr(err))
+		return
+	}
+
+	logger.Info("Streamed chat request completed successfully", "user_id", userID, "plan_id", chatReq.PlanID)
+}
+
+// parseChatRequest authenticates and parses a chat request. If it returns false,
+// an error response has already been written.
+func parseChatRequest(w http.ResponseWriter, req *http.Request) (string, models.ChatRequest, bool) {
+	logger := httplog.LogEntry(req.Context())
+
 	// Get authenticated user ID
 	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
 	if !ok || userID == "" {
 		logger.Error("User ID not found in context")
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
+		return "", models.ChatRequest{}, false
 	}
 
 	logger.Info("Processing chat request...", "user_id", userID)
@@ -44,20 +168,27 @@ func (rs *RAGService) ChatHandler(w http.ResponseWriter, req *http.Request) {
 	if err := models.GetRequestJSON(req, &chatReq); err != nil {
 		logger.Error("Failed to decode request body", httplog.ErrAttr(err))
 		http.Error(w, "invalid request body", http.StatusBadRequest)
-		return
+		return "", models.ChatRequest{}, false
 	}
 	if err := chatReq.Validate(); err != nil {
 		logger.Error("Chat request validation failed", httplog.ErrAttr(err))
 		http.Error(w, "invalid request body", http.StatusBadRequest)
-		return
+		return "", models.ChatRequest{}, false
 	}
 	if chatReq.PlanID != "" {
 		if _, err := uuid.Parse(chatReq.PlanID); err != nil {
 			http.Error(w, "Plan not found", http.StatusNotFound)
-			return
+			return "", models.ChatRequest{}, false
 		}
 		httplog.LogEntrySetField(req.Context(), "plan_id", slog.StringValue(chatReq.PlanID))
 	}
+	if chatReq.EditMessageID != "" {
+		if _, err := uuid.Parse(chatReq.EditMessageID); err != nil {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return "", models.ChatRequest{}, false
+		}
+		httplog.LogEntrySetField(req.Context(), "edit_message_id", slog.StringValue(chatReq.EditMessageID))
+	}
 
 	// Set defaults
 	if chatReq.Language == "" {
@@ -74,31 +205,29 @@ func (rs *RAGService) ChatHandler(w http.ResponseWriter, req *http.Request) {
 		"pool_length", chatReq.PoolLength,
 	)
 
-	// Call ChatWithContext
-	updatedPlan, aiMessage, err := rs.db.ChatWithContext(
-		req.Context(),
-		chatReq.PlanID,
-		userID,
-		chatReq.Message,
-		chatReq.Language,
-		chatReq.PoolLength,
-	)
-	if err != nil {
-		logger.Error("Failed to process chat interaction", httplog.ErrAttr(err))
-		switch {
-		case errors.Is(err, rag.ErrChatPlanRequired):
-			http.Error(w, "plan_id is required", http.StatusBadRequest)
-		case errors.Is(err, rag.ErrChatPlanNotFound):
-			http.Error(w, "Plan not found", http.StatusNotFound)
-		default:
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
-		return
+	return userID, chatReq, true
+}
+
+// chatErrorResponse maps chat errors to a client facing message and status code.
+func chatErrorResponse(err error) (string, int) {
+	switch {
+	case errors.Is(err, rag.ErrChatPlanRequired):
+		return "plan_id is required", http.StatusBadRequest
+	case errors.Is(err, rag.ErrChatPlanNotFound):
+		return "Plan not found", http.StatusNotFound
+	case errors.Is(err, rag.ErrMemoryNotFound):
+		return "Message not found", http.StatusNotFound
+	case errors.Is(err, rag.ErrChatEditInvalid):
+		return "Only user messages can be edited", http.StatusBadRequest
+	default:
+		return "Internal server error", http.StatusInternalServerError
 	}
+}
 
-	// Build response
+// chatResponsePayload builds the response of a chat interaction.
+func (rs *RAGService) chatResponsePayload(ctx context.Context, userID, planID string, updatedPlan *models.Plan, aiMessage *models.Message) models.ChatResponsePayload {
 	response := models.ChatResponsePayload{
-		PlanID:   chatReq.PlanID,
+		PlanID:   planID,
 		Response: aiMessage.Content,
 	}
 
@@ -107,14 +236,7 @@ func (rs *RAGService) ChatHandler(w http.ResponseWriter, req *http.Request) {
 		response.Title = updatedPlan.Title
 		response.Description = updatedPlan.Description
 		response.Table = updatedPlan.Table
+		response.Duration = rs.estimateDuration(ctx, userID, updatedPlan.Table)
 	}
-
-	// Return response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logger.Error("Failed to encode response", httplog.ErrAttr(err))
-	}
-
-	logger.Info("Chat request completed successfully", "user_id", userID, "plan_id", response.PlanID)
+	return response
 }
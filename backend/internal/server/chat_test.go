@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,6 +27,17 @@ func TestChatHandlerRejectsInvalidPlanBeforeDatabaseAccess(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, response.Code)
 }
 
+func TestChatHandlerRejectsInvalidEditedMessageBeforeDatabaseAccess(t *testing.T) {
+	service := &RAGService{}
+
+	response := httptest.NewRecorder()
+	service.ChatHandler(response, memoryHandlerRequest(http.MethodPost, "/chat",
+		`{"plan_id":"00000000-0000-0000-0000-000000000001","message":"hello","edit_message_id":"not-a-uuid"}`, "user-a"))
+
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, "Message not found\n", response.Body.String())
+}
+
 func TestChatHandlerRejectsOversizedMessage(t *testing.T) {
 	service := &RAGService{}
 
@@ -37,3 +49,36 @@ func TestChatHandlerRejectsOversizedMessage(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, response.Code)
 	assert.Equal(t, "invalid request body\n", response.Body.String())
 }
+
+func TestChatStreamHandlerRequiresAuthentication(t *testing.T) {
+	service := &RAGService{}
+
+	response := httptest.NewRecorder()
+	service.ChatStreamHandler(response, memoryHandlerRequest(http.MethodPost, "/chat/stream", `{"plan_id":"00000000-0000-0000-0000-000000000001","message":"hello"}`, ""))
+
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestChatStreamHandlerRejectsInvalidPlanBeforeStreaming(t *testing.T) {
+	service := &RAGService{}
+
+	response := httptest.NewRecorder()
+	service.ChatStreamHandler(response, memoryHandlerRequest(http.MethodPost, "/chat/stream", `{"plan_id":"not-a-uuid","message":"hello"}`, "user-a"))
+
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.NotEqual(t, "text/event-stream", response.Header().Get("Content-Type"))
+}
+
+func TestSSEWriterWritesNamedJSONEvents(t *testing.T) {
+	response := httptest.NewRecorder()
+	sse, err := newSSEWriter(response)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sse.event("token", models.ChatStreamToken{Text: "Hallo"}))
+	assert.NoError(t, sse.event("done", models.ChatStreamDone{AIMessageID: "ai"}))
+
+	assert.Equal(t, "text/event-stream", response.Header().Get("Content-Type"))
+	assert.Contains(t, response.Body.String(), "event: token\ndata: {\"text\":\"Hallo\"}\n\n")
+	assert.Contains(t, response.Body.String(), "event: done\ndata: ")
+	assert.Contains(t, response.Body.String(), `"ai_message_id":"ai"`)
+}
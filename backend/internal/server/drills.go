@@ -1,12 +1,14 @@
 package server
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/pdf"
 	"github.com/5pirit5eal/swim-gen/internal/rag"
 	"github.com/go-chi/httplog/v2"
 )
@@ -188,3 +190,27 @@ func (rs *RAGService) GetDrillOptionsHandler(w http.ResponseWriter, req *http.Re
 		logger.Error("Failed to write response", httplog.ErrAttr(err))
 	}
 }
+
+// drillAppendix loads the drills linked in the tables in the language of the
+// PDF. Drills which can not be loaded are left out of the appendix. The QR codes
+// link to the frontend at baseURL, or at the configured frontend URL.
+func (rs *RAGService) drillAppendix(ctx context.Context, lang models.Language, baseURL string, tables ...models.Table) *pdf.DrillAppendix {
+	drillLang := string(models.LanguageEN)
+	if lang == models.LanguageDE {
+		drillLang = string(models.LanguageDE)
+	}
+	if baseURL == "" {
+		baseURL = rs.cfg.Mail.FrontendURL
+	}
+
+	appendix := &pdf.DrillAppendix{ImageDir: rs.cfg.PDF.DrillImageDir, BaseURL: baseURL}
+	for _, id := range pdf.ReferencedDrills(tables...) {
+		drill, err := rs.db.GetDrillByImgName(ctx, id, drillLang)
+		if err != nil {
+			httplog.LogEntry(ctx).Warn("Failed to get drill, leaving it out of the appendix", "drill_id", id, httplog.ErrAttr(err))
+			continue
+		}
+		appendix.Drills = append(appendix.Drills, *drill)
+	}
+	return appendix
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/5pirit5eal/swim-gen/internal/export"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+)
+
+// ExportPlanHandler handles the export of a plan to an interchange format.
+// @Summary Export training plan as CSV, Markdown or JSON
+// @Description Serialize a training plan as CSV, Markdown table or versioned portable JSON document. The files can be uploaded again via /add.
+// @Tags Training Plans
+// @Accept json
+// @Produce text/csv,text/markdown,application/vnd.swim-gen.plan+json
+// @Param format path string true "Export format" Enums(csv, md, json)
+// @Param plan body models.PlanExportRequest true "Training plan data to export"
+// @Success 200 {file} binary "Exported plan"
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /export/{format} [post]
+func (rs *RAGService) ExportPlanHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	format, err := export.ParseFormat(chi.URLParam(req, "format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logger.Info("Exporting plan...", "format", format)
+
+	// Parse HTTP request from JSON.
+	qr := &models.PlanExportRequest{}
+	if err := models.GetRequestJSON(req, qr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := qr.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := export.Export(&models.Plan{
+		Title:       qr.Title,
+		Description: qr.Description,
+		Table:       qr.Table,
+	}, format, qr.Language)
+	if err != nil {
+		logger.Error("Export failed", httplog.ErrAttr(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rs.incrementExportCount(req, qr.PlanID)
+
+	logger.Info("Plan exported successfully")
+	writeAttachment(w, req, format.MediaType(), exportFilename(qr.Title, format.Extension()), data)
+}
+
+// ExportSchemaHandler returns the JSON Schema of the portable plan document.
+// @Summary Get the JSON Schema of exported plans
+// @Description Returns the JSON Schema describing the current version of the portable JSON plan document
+// @Tags Training Plans
+// @Produce json
+// @Success 200 {object} map[string]any "JSON Schema"
+// @Failure 500 {string} string "Internal server error"
+// @Router /export/schema [get]
+func (rs *RAGService) ExportSchemaHandler(w http.ResponseWriter, req *http.Request) {
+	schema, err := export.JSONSchema()
+	if err != nil {
+		httplog.LogEntry(req.Context()).Error("Failed to generate JSON schema", httplog.ErrAttr(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(schema)
+}
+
+// uploadPlanRequestFromExport builds an upload request from an exported CSV,
+// Markdown or JSON plan sent as request body. Sharing and language are read
+// from the allow_sharing and language query parameters.
+func uploadPlanRequestFromExport(req *http.Request, format export.Format) (*models.UploadPlanRequest, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, req.Body, models.MaxJSONBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	plan, err := export.Import(body, format)
+	if err != nil {
+		return nil, err
+	}
+
+	allowSharing := false
+	if v := req.URL.Query().Get("allow_sharing"); v != "" {
+		if allowSharing, err = strconv.ParseBool(v); err != nil {
+			return nil, err
+		}
+	}
+	return &models.UploadPlanRequest{
+		Title:        plan.Title,
+		Description:  plan.Description,
+		Table:        plan.Table,
+		Language:     models.Language(req.URL.Query().Get("language")),
+		AllowSharing: allowSharing,
+	}, nil
+}
+
+// incrementExportCount counts an export of the plan, failures are only logged.
+func (rs *RAGService) incrementExportCount(req *http.Request, planID string) {
+	if planID == "" {
+		return
+	}
+	var userID string
+	if val := req.Context().Value(models.UserIdCtxKey); val != nil {
+		if uid, ok := val.(string); ok {
+			userID = uid
+		}
+	}
+
+	httplog.LogEntrySetField(req.Context(), "plan_id", slog.StringValue(planID))
+	if err := rs.db.IncrementExportCount(req.Context(), userID, planID); err != nil {
+		httplog.LogEntry(req.Context()).Error("Failed to increment export count", httplog.ErrAttr(err))
+	}
+}
+
+// writeAttachment sends data as file download.
+func writeAttachment(w http.ResponseWriter, req *http.Request, contentType, filename string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		httplog.LogEntry(req.Context()).Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// acceptsPDF reports whether the client asked for the PDF itself instead of a
+// signed URL with an Accept header of application/pdf.
+func acceptsPDF(req *http.Request) bool {
+	for _, accept := range req.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err == nil && mediaType == "application/pdf" && params["q"] != "0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exportFilename derives a file name from the plan title, keeping only characters
+// that are safe on all file systems.
+func exportFilename(title, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		if unicode.IsSpace(r) {
+			return '_'
+		}
+		return -1
+	}, title)
+	if name == "" {
+		name = "plan"
+	}
+	return name + ext
+}
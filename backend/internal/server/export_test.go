@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/export"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportRequest(format, body string) *http.Request {
+	request := memoryHandlerRequest(http.MethodPost, "/export/"+format, body, "")
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("format", format)
+	return request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+func TestExportPlanHandlerReturnsMarkdownFile(t *testing.T) {
+	service := &RAGService{}
+	body := `{"title":"Sprint Set","description":"Kurz","language":"de","table":[{"Amount":4,"Multiplier":"x","Distance":50,"Break":"30","Content":"Kraul","Intensity":"GA2","Sum":200}]}`
+	response := httptest.NewRecorder()
+
+	service.ExportPlanHandler(response, exportRequest("md", body))
+
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Equal(t, "text/markdown; charset=utf-8", response.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=Sprint_Set.md", response.Header().Get("Content-Disposition"))
+	assert.Contains(t, response.Body.String(), "| Anzahl |  | Strecke(m) |")
+}
+
+func TestExportPlanHandlerRejectsUnknownFormat(t *testing.T) {
+	service := &RAGService{}
+	response := httptest.NewRecorder()
+
+	service.ExportPlanHandler(response, exportRequest("xlsx", `{"title":"Plan","table":[]}`))
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestUploadPlanRequestFromExport(t *testing.T) {
+	body := "# Sprint Set\n\nKurz\n\n| Amount |  | Distance(m) | Break(s) | Content | Intensity | Volume | Equipment |\n|---|---|---|---|---|---|---|---|\n| 4 | x | 50 | 30 | Kraul | GA2 | 200 |  |\n"
+	request := httptest.NewRequest(http.MethodPost, "/add?allow_sharing=true&language=en", strings.NewReader(body))
+
+	upload, err := uploadPlanRequestFromExport(request, export.FormatMarkdown)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Sprint Set", upload.Title)
+	assert.Equal(t, "Kurz", upload.Description)
+	assert.True(t, upload.AllowSharing)
+	require.Len(t, upload.Table, 2)
+	assert.Equal(t, 200, upload.Table[1].Sum)
+}
+
+func TestUploadPlanHandlerRejectsInvalidExportedPlan(t *testing.T) {
+	service := &RAGService{}
+	request := memoryHandlerRequest(http.MethodPost, "/add", "1.1,1,x,100,,Kraul,,,\n", "user")
+	request.Header.Set("Content-Type", "text/csv")
+	response := httptest.NewRecorder()
+
+	service.UploadPlanHandler(response, request)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/blob"
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+)
+
+// newBlobStore creates the storage of exported PDFs selected in cfg.Bucket.Backend.
+func newBlobStore(ctx context.Context, cfg config.Config) (blob.BlobStore, error) {
+	var store blob.BlobStore
+	var err error
+	switch cfg.Bucket.Backend {
+	case "", "gcs":
+		store, err = blob.NewGCSStore(ctx, cfg.Bucket.Name, cfg.Bucket.ServiceAccount)
+	case "s3":
+		store, err = blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.Bucket.Endpoint,
+			Region:    cfg.Bucket.Region,
+			Bucket:    cfg.Bucket.Name,
+			AccessKey: cfg.Bucket.AccessKey,
+			SecretKey: cfg.Bucket.SecretKey,
+		})
+	case "filesystem":
+		store, err = blob.NewFileStore(cfg.Bucket.Directory, cfg.Bucket.PublicURL, []byte(cfg.Bucket.SigningKey),
+			time.Duration(cfg.Bucket.MaxAgeHours)*time.Hour)
+	default:
+		return nil, fmt.Errorf("unknown bucket backend %q", cfg.Bucket.Backend)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error initializing %s bucket backend: %w", cfg.Bucket.Backend, err)
+	}
+	slog.Info("Initialized blob store", "backend", cfg.Bucket.Backend)
+	return store, nil
+}
+
+// FileHandler serves the exported files of the filesystem bucket backend.
+// @Summary Download an exported file
+// @Description Download a file exported to the local filesystem storage. The token is part of the signed URL returned by the export and expires after 15 minutes.
+// @Tags Export
+// @Produce application/pdf
+// @Param token path string true "Signed file token"
+// @Success 200 {file} file "Exported file"
+// @Failure 404 {string} string "File not found"
+// @Failure 410 {string} string "Link expired"
+// @Router /files/{token} [get]
+func (rs *RAGService) FileHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	store, ok := rs.blobs.(*blob.FileStore)
+	if !ok {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+
+	file, name, err := store.Open(chi.URLParam(req, "token"))
+	switch {
+	case errors.Is(err, blob.ErrTokenExpired):
+		http.Error(w, "Link expired", http.StatusGone)
+		return
+	case err != nil:
+		if !errors.Is(err, blob.ErrInvalidToken) {
+			logger.Warn("Failed to open exported file", httplog.ErrAttr(err))
+		}
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		logger.Error("Failed to stat exported file", httplog.ErrAttr(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", path.Base(name)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.ServeContent(w, req, path.Base(name), info.ModTime(), file)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/blob"
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/5pirit5eal/swim-gen/internal/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileHandlerServesUploadedPDF(t *testing.T) {
+	store, err := blob.NewFileStore(t.TempDir(), "http://localhost:8080", []byte("secret"), 0)
+	require.NoError(t, err)
+	service := &RAGService{blobs: store}
+
+	uri, err := pdf.UploadPDF(context.Background(), store, "abc/plan.pdf", []byte("%PDF-1.4"))
+	require.NoError(t, err)
+	token := strings.TrimPrefix(uri, "http://localhost:8080/files/")
+
+	response := httptest.NewRecorder()
+	service.FileHandler(response, blockHandlerRequest(http.MethodGet, "", "", map[string]string{"token": token}))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "%PDF-1.4", response.Body.String())
+	assert.Equal(t, "application/pdf", response.Header().Get("Content-Type"))
+	assert.Contains(t, response.Header().Get("Content-Disposition"), `filename="plan.pdf"`)
+
+	response = httptest.NewRecorder()
+	service.FileHandler(response, blockHandlerRequest(http.MethodGet, "", "", map[string]string{"token": token + "x"}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	expired, err := store.SignedURL(context.Background(), "abc/plan.pdf", -time.Minute)
+	require.NoError(t, err)
+	response = httptest.NewRecorder()
+	service.FileHandler(response, blockHandlerRequest(http.MethodGet, "", "", map[string]string{"token": strings.TrimPrefix(expired, "http://localhost:8080/files/")}))
+	assert.Equal(t, http.StatusGone, response.Code)
+}
+
+func TestFileHandlerRequiresFilesystemBackend(t *testing.T) {
+	store, err := blob.NewS3Store(blob.S3Config{Bucket: "bucket", AccessKey: "key", SecretKey: "secret"})
+	require.NoError(t, err)
+	response := httptest.NewRecorder()
+	(&RAGService{blobs: store}).FileHandler(response, blockHandlerRequest(http.MethodGet, "", "", map[string]string{"token": "a.b"}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestNewBlobStoreSelectsBackend(t *testing.T) {
+	var cfg config.Config
+	cfg.Bucket.Backend = "filesystem"
+	cfg.Bucket.Directory = t.TempDir()
+	store, err := newBlobStore(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.IsType(t, &blob.FileStore{}, store)
+
+	cfg.Bucket.Backend = "s3"
+	_, err = newBlobStore(context.Background(), cfg)
+	assert.ErrorContains(t, err, "s3 bucket backend")
+
+	cfg.Bucket.Backend = "azure"
+	_, err = newBlobStore(context.Background(), cfg)
+	assert.ErrorContains(t, err, "unknown bucket backend")
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -11,7 +12,7 @@ import (
 )
 
 // GeneratePromptHandler handles the request to generate a prompt for the LLM.
-// It uses the GoogleGenAIClient to generate a prompt based on the provided language.
+// It uses the configured LLM client to generate a prompt based on the provided language.
 // @Summary Generate a prompt for the LLM
 // @Description Generate a prompt for the LLM based on the provided language
 // @Tags Training Plans
@@ -34,7 +35,7 @@ func (rs *RAGService) GeneratePromptHandler(w http.ResponseWriter, req *http.Req
 		return
 	}
 
-	// Generate the prompt using the GoogleGenAIClient
+	// Generate the prompt using the LLM client
 	prompt, err := rs.db.Client.GeneratePrompt(req.Context(), *gpr)
 	if err != nil {
 		logger.Error("Error generating prompt", httplog.ErrAttr(err))
@@ -81,7 +82,7 @@ func (rs *RAGService) QueryHandler(w http.ResponseWriter, req *http.Request) {
 
 	userId := req.Context().Value(models.UserIdCtxKey).(string)
 
-	var userProfileStr string
+	var profile *models.UserProfile
 	// Check if preferences should be used (default to true)
 	usePreferences := true
 	if qr.Preferences != nil {
@@ -89,15 +90,13 @@ func (rs *RAGService) QueryHandler(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if usePreferences && userId != "" {
-		profile, err := rs.db.GetUserProfile(req.Context(), userId)
+		profile, err = rs.db.GetGenerationProfile(req.Context(), userId)
 		if err != nil {
 			logger.Warn("Failed to get user profile, proceeding without it", httplog.ErrAttr(err))
-		} else {
-			userProfileStr = rs.db.FormatUserProfile(profile)
 		}
 	}
 
-	p, err := rs.db.Query(req.Context(), qr.Content, qr.Language, userProfileStr, qr.Filter, qr.Method, qr.PoolLength)
+	p, err := rs.db.Query(req.Context(), qr.Content, qr.Language, profile, qr.Filter, qr.Method, qr.PoolLength, qr.Constraints)
 	if err != nil {
 		if strings.HasPrefix(err.Error(), "unsupported method:") {
 			http.Error(w, "Method may only be 'choose' or 'generate', invalid choice.", http.StatusBadRequest)
@@ -141,6 +140,7 @@ func (rs *RAGService) QueryHandler(w http.ResponseWriter, req *http.Request) {
 		Title:       p.Title,
 		Description: p.Description,
 		Table:       p.Table,
+		Duration:    models.EstimateDuration(p.Table, profile),
 	}
 
 	logger.Info("Answer generated successfully")
@@ -148,3 +148,17 @@ func (rs *RAGService) QueryHandler(w http.ResponseWriter, req *http.Request) {
 		logger.Error("Failed to write response", httplog.ErrAttr(err))
 	}
 }
+
+// estimateDuration estimates the duration of the table with the CSS times of the
+// user. Without a profile the default pace is used.
+func (rs *RAGService) estimateDuration(ctx context.Context, userID string, table models.Table) *models.DurationEstimate {
+	var profile *models.UserProfile
+	if userID != "" {
+		var err error
+		profile, err = rs.db.GetUserProfile(ctx, userID)
+		if err != nil {
+			httplog.LogEntry(ctx).Warn("Failed to get user profile, estimating duration with default pace", httplog.ErrAttr(err))
+		}
+	}
+	return models.EstimateDuration(table, profile)
+}
@@ -133,9 +133,10 @@ func (rs *RAGService) AddMessageHandler(w http.ResponseWriter, req *http.Request
 }
 
 // DeleteMessagesAfterHandler handles the deletion of a message and all subsequent messages.
-// This is useful for "branching" conversations by removing everything after a certain point.
+// Edits should rather branch the conversation via the edit_message_id of a chat request,
+// which keeps the original messages.
 // @Summary Delete a message and all subsequent messages
-// @Description Delete a message and all messages that follow it in the conversation, allowing conversation branching
+// @Description Delete a message and all messages that follow it on any branch of the conversation
 // @Tags Memory
 // @Accept json
 // @Produce json
@@ -246,12 +247,14 @@ func (rs *RAGService) DeleteConversationHandler(w http.ResponseWriter, req *http
 
 // GetConversationHandler handles the retrieval of the conversation history for a plan.
 // @Summary Get conversation history
-// @Description Get the full conversation history for a specific plan
+// @Description Get the active branch of the conversation for a specific plan. Set branch to a message id to get the branch through that message without selecting it, or view=tree to get all branches as tree.
 // @Tags Memory
 // @Accept json
 // @Produce json
 // @Param plan_id query string true "Plan ID"
-// @Success 200 {array} models.MessagePayload "Conversation history"
+// @Param branch query string false "Message ID of the branch to get"
+// @Param view query string false "'tree' to get all branches"
+// @Success 200 {array} models.MessagePayload "Conversation history, or models.ConversationNode for view=tree"
 // @Failure 400 {string} string "Bad request"
 // @Failure 500 {string} string "Internal server error"
 // @Security BearerAuth
@@ -268,14 +271,34 @@ func (rs *RAGService) GetConversationHandler(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	planID := req.URL.Query().Get("plan_id")
+	query := req.URL.Query()
+	planID := query.Get("plan_id")
 	if planID == "" {
 		http.Error(w, "plan_id is required", http.StatusBadRequest)
 		return
 	}
 	httplog.LogEntrySetField(req.Context(), "plan_id", slog.StringValue(planID))
+	branch, view := query.Get("branch"), query.Get("view")
+	if view != "" && view != "tree" {
+		http.Error(w, "view must be 'tree'", http.StatusBadRequest)
+		return
+	}
+	if branch != "" && view != "" {
+		http.Error(w, "branch and view can not be combined", http.StatusBadRequest)
+		return
+	}
 
-	messages, err := rs.db.Memory.GetConversation(req.Context(), planID, userID)
+	var messages []models.Message
+	var err error
+	if branch != "" {
+		messages, err = rs.db.Memory.GetBranch(req.Context(), planID, userID, branch)
+	} else {
+		messages, err = rs.db.Memory.GetConversation(req.Context(), planID, userID)
+	}
+	var tree []models.Message
+	if err == nil && view == "tree" {
+		tree, err = rs.db.Memory.GetConversationTree(req.Context(), planID, userID)
+	}
 	if err != nil {
 		logger.Error("Failed to get conversation", httplog.ErrAttr(err))
 		if errors.Is(err, rag.ErrMemoryNotFound) {
@@ -286,33 +309,139 @@ func (rs *RAGService) GetConversationHandler(w http.ResponseWriter, req *http.Re
 		return
 	}
 
-	// Convert messages to MessagePayloads
-	var messagePayloads []models.MessagePayload
-	for _, msg := range messages {
-		msgPayload := models.MessagePayload{
-			ID:                msg.ID,
-			PlanID:            msg.PlanID,
-			Role:              msg.Role,
-			Content:           msg.Content,
-			PreviousMessageID: msg.PreviousMessageID,
-			NextMessageID:     msg.NextMessageID,
-			PlanSnapshot:      nil,
-			CreatedAt:         msg.CreatedAt,
+	var response any = messagePayloads(messages)
+	if view == "tree" {
+		response = conversationTree(tree, messages)
+	}
+	logger.Info("Conversation retrieved successfully", "plan_id", planID, "count", len(messages))
+	if err := models.WriteResponseJSON(w, http.StatusOK, response); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// SelectBranchHandler handles the selection of a branch of the conversation.
+// @Summary Select a conversation branch
+// @Description Make the branch through a message the active branch of its conversation. The latest plan snapshot of the branch becomes the current plan.
+// @Tags Memory
+// @Accept json
+// @Produce json
+// @Param request body models.SelectBranchRequest true "Request to select a branch"
+// @Success 200 {array} models.MessagePayload "Messages of the selected branch"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Message not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /memory/branch [post]
+func (rs *RAGService) SelectBranchHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Selecting conversation branch...")
+
+	// Get authenticated user ID
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		logger.Error("User ID not found in context")
+		http.Error(w, "Unauthorized: User ID missing", http.StatusUnauthorized)
+		return
+	}
+
+	sbr := &models.SelectBranchRequest{}
+	if err := models.GetRequestJSON(req, sbr); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if sbr.MessageID == "" {
+		http.Error(w, "message_id is required", http.StatusBadRequest)
+		return
+	}
+	httplog.LogEntrySetField(req.Context(), "message_id", slog.StringValue(sbr.MessageID))
+
+	messages, err := rs.db.Memory.SelectBranch(req.Context(), sbr.MessageID, userID)
+	if err != nil {
+		logger.Error("Failed to select branch", httplog.ErrAttr(err))
+		if errors.Is(err, rag.ErrMemoryNotFound) {
+			http.Error(w, "Message not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
 		}
-		if msg.PlanSnapshot != nil {
-			msgPayload.PlanSnapshot = &models.RAGResponse{
-				Title:       msg.PlanSnapshot.Title,
-				Description: msg.PlanSnapshot.Description,
-				PlanID:      msg.PlanSnapshot.PlanID,
-				Table:       msg.PlanSnapshot.Table,
-			}
+		return
+	}
+
+	logger.Info("Branch selected successfully", "count", len(messages))
+	if err := models.WriteResponseJSON(w, http.StatusOK, messagePayloads(messages)); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// messagePayloads converts messages to their MessagePayloads.
+func messagePayloads(messages []models.Message) []models.MessagePayload {
+	payloads := make([]models.MessagePayload, 0, len(messages))
+	for _, msg := range messages {
+		payloads = append(payloads, messagePayload(msg))
+	}
+	return payloads
+}
+
+func messagePayload(msg models.Message) models.MessagePayload {
+	payload := models.MessagePayload{
+		ID:                msg.ID,
+		PlanID:            msg.PlanID,
+		Role:              msg.Role,
+		Content:           msg.Content,
+		PreviousMessageID: msg.PreviousMessageID,
+		NextMessageID:     msg.NextMessageID,
+		PlanSnapshot:      nil,
+		CreatedAt:         msg.CreatedAt,
+		Siblings:          msg.Siblings,
+	}
+	if msg.PlanSnapshot != nil {
+		payload.PlanSnapshot = &models.RAGResponse{
+			Title:       msg.PlanSnapshot.Title,
+			Description: msg.PlanSnapshot.Description,
+			PlanID:      msg.PlanSnapshot.PlanID,
+			Table:       msg.PlanSnapshot.Table,
 		}
-		messagePayloads = append(messagePayloads, msgPayload)
+	}
+	return payload
+}
 
+// conversationTree nests the messages of all branches below their previous
+// messages and marks the messages of the active branch. Messages whose previous
+// message is missing are returned as roots.
+func conversationTree(messages, active []models.Message) []models.ConversationNode {
+	onActiveBranch := make(map[string]bool, len(active))
+	for _, msg := range active {
+		onActiveBranch[msg.ID] = true
+	}
+	exists := make(map[string]bool, len(messages))
+	for _, msg := range messages {
+		exists[msg.ID] = true
+	}
+	children := make(map[string][]models.Message)
+	var roots []models.Message
+	for _, msg := range messages {
+		if msg.PreviousMessageID == nil || !exists[*msg.PreviousMessageID] {
+			roots = append(roots, msg)
+			continue
+		}
+		children[*msg.PreviousMessageID] = append(children[*msg.PreviousMessageID], msg)
 	}
 
-	logger.Info("Conversation retrieved successfully", "plan_id", planID, "count", len(messages))
-	if err := models.WriteResponseJSON(w, http.StatusOK, messagePayloads); err != nil {
-		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	var build func(msgs []models.Message, visited map[string]bool) []models.ConversationNode
+	build = func(msgs []models.Message, visited map[string]bool) []models.ConversationNode {
+		nodes := make([]models.ConversationNode, 0, len(msgs))
+		for _, msg := range msgs {
+			// Malformed cycles are cut off.
+			if visited[msg.ID] {
+				continue
+			}
+			visited[msg.ID] = true
+			nodes = append(nodes, models.ConversationNode{
+				MessagePayload: messagePayload(msg),
+				Active:         onActiveBranch[msg.ID],
+				Children:       build(children[msg.ID], visited),
+			})
+		}
+		return nodes
 	}
+	return build(roots, make(map[string]bool, len(messages)))
 }
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -22,6 +23,10 @@ type memoryHandlerFake struct {
 	deleteConversation func(context.Context, string, string) error
 	delete             func(context.Context, string, string) error
 	deleteAfter        func(context.Context, string, string) error
+	getBranch          func(context.Context, string, string, string) ([]models.Message, error)
+	getTree            func(context.Context, string, string) ([]models.Message, error)
+	branch             func(context.Context, string, string, string) (*models.Message, error)
+	selectBranch       func(context.Context, string, string) ([]models.Message, error)
 }
 
 func (f *memoryHandlerFake) AddMessage(ctx context.Context, planID, userID string, role models.Role, content string, previousMessageID *string, planSnapshot *models.Plan) (*models.Message, error) {
@@ -48,6 +53,30 @@ func (f *memoryHandlerFake) DeleteMessagesAfter(ctx context.Context, messageID,
 	return f.deleteAfter(ctx, messageID, userID)
 }
 
+func (f *memoryHandlerFake) GetBranch(ctx context.Context, planID, userID, messageID string) ([]models.Message, error) {
+	return f.getBranch(ctx, planID, userID, messageID)
+}
+
+func (f *memoryHandlerFake) GetConversationTree(ctx context.Context, planID, userID string) ([]models.Message, error) {
+	return f.getTree(ctx, planID, userID)
+}
+
+func (f *memoryHandlerFake) BranchMessage(ctx context.Context, messageID, userID, content string) (*models.Message, error) {
+	return f.branch(ctx, messageID, userID, content)
+}
+
+func (f *memoryHandlerFake) SelectBranch(ctx context.Context, messageID, userID string) ([]models.Message, error) {
+	return f.selectBranch(ctx, messageID, userID)
+}
+
+func (*memoryHandlerFake) GetSummary(context.Context, string, string) (*models.ConversationSummary, error) {
+	return nil, nil
+}
+
+func (*memoryHandlerFake) SaveSummary(context.Context, *models.ConversationSummary) error {
+	return nil
+}
+
 func memoryHandlerRequest(method, target, body, userID string) *http.Request {
 	request := httptest.NewRequest(method, target, strings.NewReader(body))
 	if body != "" {
@@ -201,3 +230,98 @@ func TestMemoryHandlersHideUnauthorizedResources(t *testing.T) {
 		})
 	}
 }
+
+func TestGetConversationHandlerReturnsBranchesAndTree(t *testing.T) {
+	root, original, edited, answer := "root", "original", "edited", "answer"
+	tree := []models.Message{
+		{ID: root, Role: models.RoleUser, Content: "Ein Plan", NextMessageID: &answer, Siblings: []string{root}},
+		{ID: answer, Role: models.RoleAI, Content: "Hier", PreviousMessageID: &root, NextMessageID: &edited, Siblings: []string{answer}},
+		{ID: original, Role: models.RoleUser, Content: "Schwerer", PreviousMessageID: &answer, Siblings: []string{original, edited}},
+		{ID: edited, Role: models.RoleUser, Content: "Leichter", PreviousMessageID: &answer, Siblings: []string{original, edited}},
+	}
+	var gotBranch string
+	fake := &memoryHandlerFake{
+		get: func(context.Context, string, string) ([]models.Message, error) {
+			return []models.Message{tree[0], tree[1], tree[3]}, nil
+		},
+		getBranch: func(_ context.Context, _, _, messageID string) ([]models.Message, error) {
+			gotBranch = messageID
+			return []models.Message{tree[0], tree[1], tree[2]}, nil
+		},
+		getTree: func(context.Context, string, string) ([]models.Message, error) {
+			return tree, nil
+		},
+	}
+	service := &RAGService{db: &rag.RAGDB{Memory: fake}}
+
+	response := httptest.NewRecorder()
+	service.GetConversationHandler(response, memoryHandlerRequest(http.MethodGet, "/memory/conversation?plan_id=plan", "", "owner"))
+	require.Equal(t, http.StatusOK, response.Code)
+	var active []models.MessagePayload
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &active))
+	require.Len(t, active, 3)
+	assert.Equal(t, edited, active[2].ID)
+	assert.Equal(t, []string{original, edited}, active[2].Siblings)
+
+	response = httptest.NewRecorder()
+	service.GetConversationHandler(response, memoryHandlerRequest(http.MethodGet, "/memory/conversation?plan_id=plan&branch="+original, "", "owner"))
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, original, gotBranch)
+	var branch []models.MessagePayload
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &branch))
+	assert.Equal(t, original, branch[2].ID)
+
+	response = httptest.NewRecorder()
+	service.GetConversationHandler(response, memoryHandlerRequest(http.MethodGet, "/memory/conversation?plan_id=plan&view=tree", "", "owner"))
+	require.Equal(t, http.StatusOK, response.Code)
+	var nodes []models.ConversationNode
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &nodes))
+	require.Len(t, nodes, 1)
+	assert.True(t, nodes[0].Active)
+	require.Len(t, nodes[0].Children, 1)
+	branches := nodes[0].Children[0].Children
+	require.Len(t, branches, 2)
+	assert.Equal(t, original, branches[0].ID)
+	assert.False(t, branches[0].Active)
+	assert.Equal(t, edited, branches[1].ID)
+	assert.True(t, branches[1].Active)
+	assert.Empty(t, branches[1].Children)
+
+	response = httptest.NewRecorder()
+	service.GetConversationHandler(response, memoryHandlerRequest(http.MethodGet, "/memory/conversation?plan_id=plan&view=list", "", "owner"))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestSelectBranchHandler(t *testing.T) {
+	var gotMessageID, gotUserID string
+	fake := &memoryHandlerFake{
+		selectBranch: func(_ context.Context, messageID, userID string) ([]models.Message, error) {
+			gotMessageID, gotUserID = messageID, userID
+			if messageID == "missing" {
+				return nil, rag.ErrMemoryNotFound
+			}
+			return []models.Message{{ID: messageID, Role: models.RoleUser, Content: "Leichter"}}, nil
+		},
+	}
+	service := &RAGService{db: &rag.RAGDB{Memory: fake}}
+
+	response := httptest.NewRecorder()
+	service.SelectBranchHandler(response, memoryHandlerRequest(http.MethodPost, "/memory/branch", `{"message_id":"edited"}`, "owner"))
+
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "edited", gotMessageID)
+	assert.Equal(t, "owner", gotUserID)
+	assert.Contains(t, response.Body.String(), `"id":"edited"`)
+
+	response = httptest.NewRecorder()
+	service.SelectBranchHandler(response, memoryHandlerRequest(http.MethodPost, "/memory/branch", `{"message_id":"missing"}`, "owner"))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = httptest.NewRecorder()
+	service.SelectBranchHandler(response, memoryHandlerRequest(http.MethodPost, "/memory/branch", `{}`, "owner"))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = httptest.NewRecorder()
+	service.SelectBranchHandler(response, memoryHandlerRequest(http.MethodPost, "/memory/branch", `{"message_id":"edited"}`, ""))
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+}
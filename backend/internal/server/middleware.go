@@ -2,11 +2,15 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/5pirit5eal/swim-gen/internal/config"
 	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/ratelimit"
 	"github.com/go-chi/httplog/v2"
 )
 
@@ -50,3 +54,36 @@ func (rs *RAGService) SupabaseAuthMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// HTTP middleware for rate limits and monthly generation quotas
+//
+// Must be registered after SupabaseAuthMiddleware, as limits are tracked per
+// authenticated user and per client IP. Rejected requests receive a 429 with
+// Retry-After, X-RateLimit-* and X-Quota-* headers.
+func (rs *RAGService) RateLimitMiddleware(next http.Handler) http.Handler {
+	if rs.limiter == nil {
+		return next
+	}
+	return rs.limiter.Middleware(next)
+}
+
+// newLimiter creates the limiter with the store selected in cfg.RateLimit.Store.
+func newLimiter(cfg config.Config, conn ratelimit.Conn) (*ratelimit.Limiter, error) {
+	var store ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "", "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store = ratelimit.NewPostgresStore(conn)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.RateLimit.Store)
+	}
+	slog.Info("Initialized rate limiter", "store", cfg.RateLimit.Store)
+	return ratelimit.New(store, ratelimit.Config{
+		User:           ratelimit.Limit{Burst: cfg.RateLimit.UserBurst, Rate: cfg.RateLimit.UserPerMinute, Period: time.Minute},
+		IP:             ratelimit.Limit{Burst: cfg.RateLimit.IPBurst, Rate: cfg.RateLimit.IPPerMinute, Period: time.Minute},
+		UserQuota:      cfg.RateLimit.MonthlyGenerations,
+		AnonymousQuota: cfg.RateLimit.AnonymousMonthlyGenerations,
+		TrustedProxies: cfg.RateLimit.TrustedProxies,
+	}), nil
+}
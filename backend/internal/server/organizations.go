@@ -0,0 +1,618 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/go-chi/httplog/v2"
+	"github.com/google/uuid"
+)
+
+// CreateOrganizationHandler creates a club with the user as its first coach.
+// @Summary Create an organization
+// @Description Create a club or team. The authenticated user becomes its first coach.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param request body models.CreateOrganizationRequest true "Organization to create"
+// @Success 201 {object} models.Organization "Created organization"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations [post]
+func (rs *RAGService) CreateOrganizationHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Creating organization...")
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cr := &models.CreateOrganizationRequest{}
+	if err := models.GetRequestJSON(req, cr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := cr.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	org, err := rs.db.CreateOrganization(req.Context(), cr.Name, userID)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusCreated, org); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetOrganizationsHandler lists the organizations of the user.
+// @Summary List organizations
+// @Description Get the clubs the authenticated user is a member of, with the role of the user
+// @Tags Organizations
+// @Produce json
+// @Success 200 {array} models.Organization "Organizations"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations [get]
+func (rs *RAGService) GetOrganizationsHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Getting organizations...")
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orgs, err := rs.db.GetOrganizations(req.Context(), userID)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, orgs); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetOrganizationMembersHandler lists the members of an organization.
+// @Summary List organization members
+// @Description Get the coaches and swimmers of a club the user is a member of. Coaches also see the invited users.
+// @Tags Organizations
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Success 200 {array} models.OrganizationMember "Members"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Organization not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/members [get]
+func (rs *RAGService) GetOrganizationMembersHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	members, err := rs.db.GetOrganizationMembers(req.Context(), orgID, userID)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, members); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// AddOrganizationMemberHandler invites a user to an organization.
+// @Summary Invite an organization member
+// @Description Invite a user by username to the club or change the role of a member. Invited users become members once they accept the invitation via /organization-invitations. Only coaches may add members. The last coach can not become a swimmer.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param request body models.AddMemberRequest true "Member to add"
+// @Success 200 {object} models.OrganizationMember "Invited or updated member"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Only coaches may manage the organization"
+// @Failure 404 {string} string "Organization or user not found"
+// @Failure 409 {string} string "The last coach can not become a swimmer"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/members [post]
+func (rs *RAGService) AddOrganizationMemberHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Adding organization member...")
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	ar := &models.AddMemberRequest{}
+	if err := models.GetRequestJSON(req, ar); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ar.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	member, err := rs.db.AddOrganizationMember(req.Context(), orgID, userID, ar.Username, ar.Role)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, member); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// RemoveOrganizationMemberHandler removes a member from an organization.
+// @Summary Remove an organization member
+// @Description Remove a member from the club and its squads. Coaches may remove every member and withdraw invitations, swimmers only remove themselves. The last coach can not leave.
+// @Tags Organizations
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param user_id path string true "User ID of the member"
+// @Success 200 {string} string "Member removed successfully"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Only coaches may manage the organization"
+// @Failure 404 {string} string "Organization or member not found"
+// @Failure 409 {string} string "The last coach can not leave the organization"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/members/{user_id} [delete]
+func (rs *RAGService) RemoveOrganizationMemberHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Removing organization member...")
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+	_, memberID, ok := resourceRequest(w, req, "user_id", "Member not found")
+	if !ok {
+		return
+	}
+
+	if err := rs.db.RemoveOrganizationMember(req.Context(), orgID, userID, memberID); err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("Member removed successfully")); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// CreateSquadHandler creates a squad in an organization.
+// @Summary Create a squad
+// @Description Create a training group with swimmers of the club. Only coaches may create squads.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param request body models.CreateSquadRequest true "Squad to create"
+// @Success 201 {object} models.Squad "Created squad"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Only coaches may manage the organization"
+// @Failure 404 {string} string "Organization or member not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/squads [post]
+func (rs *RAGService) CreateSquadHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Creating squad...")
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	sr := &models.CreateSquadRequest{}
+	if err := models.GetRequestJSON(req, sr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := sr.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	squad, err := rs.db.CreateSquad(req.Context(), orgID, userID, sr.Name, sr.Members)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusCreated, squad); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetSquadsHandler lists the squads of an organization.
+// @Summary List squads
+// @Description Get the training groups of a club the user is a member of
+// @Tags Organizations
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Success 200 {array} models.Squad "Squads"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Organization not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/squads [get]
+func (rs *RAGService) GetSquadsHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	squads, err := rs.db.GetSquads(req.Context(), orgID, userID)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, squads); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// AssignPlanHandler assigns a plan of the coach to a squad.
+// @Summary Assign a plan to a squad
+// @Description Assign a training plan from the coach's history to a squad. The swimmers can read the plan and mark it as swum via /feedback. Only coaches may assign plans.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param request body models.AssignPlanRequest true "Plan and squad"
+// @Success 200 {string} string "Plan assigned successfully"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Only coaches may manage the organization"
+// @Failure 404 {string} string "Organization, squad or plan not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/assignments [post]
+func (rs *RAGService) AssignPlanHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Assigning plan to squad...")
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	ar := &models.AssignPlanRequest{}
+	if err := models.GetRequestJSON(req, ar); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ar.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validUUIDs(ar.PlanID, ar.SquadID) {
+		http.Error(w, "Plan or squad not found", http.StatusNotFound)
+		return
+	}
+
+	if err := rs.db.AssignPlan(req.Context(), orgID, ar.SquadID, ar.PlanID, userID); err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("Plan assigned successfully")); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// ShareWithOrganizationHandler shares a plan with all members of an organization.
+// @Summary Share a plan within the organization
+// @Description Share a training plan from the user's history with all members of the club, without a public link
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param request body models.ShareWithOrganizationRequest true "Plan to share"
+// @Success 200 {string} string "Plan shared successfully"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Organization or plan not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/plans [post]
+func (rs *RAGService) ShareWithOrganizationHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Sharing plan with organization...")
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	sr := &models.ShareWithOrganizationRequest{}
+	if err := models.GetRequestJSON(req, sr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := sr.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validUUIDs(sr.PlanID) {
+		http.Error(w, "Plan not found", http.StatusNotFound)
+		return
+	}
+
+	if err := rs.db.SharePlanWithOrganization(req.Context(), orgID, sr.PlanID, userID); err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("Plan shared successfully")); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetOrganizationPlansHandler lists the plans of an organization.
+// @Summary List organization plans
+// @Description Get the plans shared within the club and the plans assigned to squads. Swimmers only see the assignments of their own squads.
+// @Tags Organizations
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Success 200 {array} models.OrganizationPlan "Plans"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Organization not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/plans [get]
+func (rs *RAGService) GetOrganizationPlansHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	plans, err := rs.db.GetOrganizationPlans(req.Context(), orgID, userID)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, plans); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetPlanAssignmentStatusHandler shows which swimmers swam an assigned plan.
+// @Summary Get the status of an assigned plan
+// @Description Get the swimmers of the squads a plan is assigned to and whether they marked it as swum in their feedback. Ratings are only included for swimmers who share their feedback with the club. Only coaches may see the status.
+// @Tags Organizations
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param plan_id path string true "Plan ID"
+// @Success 200 {array} models.SwimmerPlanStatus "Status per swimmer"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {string} string "Only coaches may manage the organization"
+// @Failure 404 {string} string "Organization not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/plans/{plan_id}/status [get]
+func (rs *RAGService) GetPlanAssignmentStatusHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+	_, planID, ok := resourceRequest(w, req, "plan_id", "Plan not found")
+	if !ok {
+		return
+	}
+
+	status, err := rs.db.GetPlanAssignmentStatus(req.Context(), orgID, planID, userID)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, status); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// UpdateFeedbackSharingHandler sets whether the user shares ratings with the coaches.
+// @Summary Share feedback with the coaches
+// @Description Set whether the coaches of the club see the ratings of the authenticated user on assigned plans. Without it they only see whether a plan was swum.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param request body models.FeedbackSharingRequest true "Feedback sharing"
+// @Success 200 {string} string "Feedback sharing updated successfully"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Organization not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/{org_id}/feedback-sharing [put]
+func (rs *RAGService) UpdateFeedbackSharingHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Updating feedback sharing...")
+
+	userID, orgID, ok := organizationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	fr := &models.FeedbackSharingRequest{}
+	if err := models.GetRequestJSON(req, fr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := rs.db.SetFeedbackSharing(req.Context(), orgID, userID, fr.ShareFeedback); err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("Feedback sharing updated successfully")); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetOrganizationInvitationsHandler lists the organization invitations of the user.
+// @Summary List organization invitations
+// @Description Get the invitations of the authenticated user to clubs, which have to be accepted to become a member
+// @Tags Organizations
+// @Produce json
+// @Success 200 {array} models.OrganizationInvitation "Invitations"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organization-invitations [get]
+func (rs *RAGService) GetOrganizationInvitationsHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	invitations, err := rs.db.GetOrganizationInvitations(req.Context(), userID)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, invitations); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// AcceptOrganizationInvitationHandler makes the user a member of the inviting organization.
+// @Summary Accept an organization invitation
+// @Description Join the club of an invitation with the role chosen by the coach. Only the invited user can accept the invitation.
+// @Tags Organizations
+// @Produce json
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} models.Organization "Joined organization"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Invitation not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organization-invitations/{invitation_id}/accept [post]
+func (rs *RAGService) AcceptOrganizationInvitationHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Accepting organization invitation...")
+
+	userID, invitationID, ok := resourceRequest(w, req, "invitation_id", "Invitation not found")
+	if !ok {
+		return
+	}
+
+	org, err := rs.db.AcceptOrganizationInvitation(req.Context(), invitationID, userID)
+	if err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, org); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// DeclineOrganizationInvitationHandler removes an organization invitation of the user.
+// @Summary Decline an organization invitation
+// @Description Decline an invitation of the authenticated user to a club
+// @Tags Organizations
+// @Produce json
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {string} string "Invitation declined successfully"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Invitation not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organization-invitations/{invitation_id} [delete]
+func (rs *RAGService) DeclineOrganizationInvitationHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Declining organization invitation...")
+
+	userID, invitationID, ok := resourceRequest(w, req, "invitation_id", "Invitation not found")
+	if !ok {
+		return
+	}
+
+	if err := rs.db.DeclineOrganizationInvitation(req.Context(), invitationID, userID); err != nil {
+		writeOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("Invitation declined successfully")); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+func organizationRequest(w http.ResponseWriter, req *http.Request) (userID, orgID string, ok bool) {
+	return resourceRequest(w, req, "org_id", "Organization not found")
+}
+
+func validUUIDs(ids ...string) bool {
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// writeOrganizationError maps the authorization errors of the organization
+// queries to status codes. Non-members get 404 so that organizations can not be probed.
+func writeOrganizationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, rag.ErrOrganizationNotFound):
+		http.Error(w, "Organization not found", http.StatusNotFound)
+	case errors.Is(err, rag.ErrOrganizationForbidden):
+		http.Error(w, "Only coaches may manage the organization", http.StatusForbidden)
+	case errors.Is(err, rag.ErrMemberNotFound):
+		http.Error(w, "Member not found", http.StatusNotFound)
+	case errors.Is(err, rag.ErrSquadNotFound):
+		http.Error(w, "Squad not found", http.StatusNotFound)
+	case errors.Is(err, rag.ErrPlanNotFound):
+		http.Error(w, "Plan not found", http.StatusNotFound)
+	case errors.Is(err, rag.ErrOrganizationInvitationNotFound):
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+	case errors.Is(err, rag.ErrLastCoach):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
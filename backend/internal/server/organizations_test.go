@@ -0,0 +1,96 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationHandlersRequireAuthentication(t *testing.T) {
+	service := &RAGService{}
+	params := map[string]string{"org_id": uuid.NewString(), "user_id": uuid.NewString(), "plan_id": uuid.NewString(), "invitation_id": uuid.NewString()}
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"create":        service.CreateOrganizationHandler,
+		"list":          service.GetOrganizationsHandler,
+		"members":       service.GetOrganizationMembersHandler,
+		"add member":    service.AddOrganizationMemberHandler,
+		"remove member": service.RemoveOrganizationMemberHandler,
+		"create squad":  service.CreateSquadHandler,
+		"squads":        service.GetSquadsHandler,
+		"assign":        service.AssignPlanHandler,
+		"share":         service.ShareWithOrganizationHandler,
+		"plans":         service.GetOrganizationPlansHandler,
+		"status":        service.GetPlanAssignmentStatusHandler,
+		"sharing":       service.UpdateFeedbackSharingHandler,
+		"invitations":   service.GetOrganizationInvitationsHandler,
+		"accept":        service.AcceptOrganizationInvitationHandler,
+		"decline":       service.DeclineOrganizationInvitationHandler,
+	} {
+		response := httptest.NewRecorder()
+		handler(response, blockHandlerRequest(http.MethodPost, "{}", "", params))
+		assert.Equal(t, http.StatusUnauthorized, response.Code, name)
+	}
+}
+
+func TestOrganizationHandlersRejectMalformedIDs(t *testing.T) {
+	service := &RAGService{}
+	userID := uuid.NewString()
+
+	response := httptest.NewRecorder()
+	service.GetSquadsHandler(response, blockHandlerRequest(http.MethodGet, "", userID, map[string]string{"org_id": "invalid-uuid"}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = httptest.NewRecorder()
+	service.RemoveOrganizationMemberHandler(response, blockHandlerRequest(http.MethodDelete, "", userID,
+		map[string]string{"org_id": uuid.NewString(), "user_id": "invalid-uuid"}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = httptest.NewRecorder()
+	service.AcceptOrganizationInvitationHandler(response, blockHandlerRequest(http.MethodPost, "", userID, map[string]string{"invitation_id": "invalid-uuid"}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = httptest.NewRecorder()
+	body := `{"plan_id":"plan_123","squad_id":"` + uuid.NewString() + `"}`
+	service.AssignPlanHandler(response, blockHandlerRequest(http.MethodPost, body, userID, map[string]string{"org_id": uuid.NewString()}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestOrganizationHandlersValidateRequest(t *testing.T) {
+	service := &RAGService{}
+	params := map[string]string{"org_id": uuid.NewString()}
+
+	response := httptest.NewRecorder()
+	service.CreateOrganizationHandler(response, blockHandlerRequest(http.MethodPost, `{"name":"  "}`, uuid.NewString(), nil))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = httptest.NewRecorder()
+	service.AddOrganizationMemberHandler(response, blockHandlerRequest(http.MethodPost, `{"username":"anna_k","role":"admin"}`, uuid.NewString(), params))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "role must be")
+
+	response = httptest.NewRecorder()
+	service.UpdateFeedbackSharingHandler(response, blockHandlerRequest(http.MethodPut, `{"share_feedback":"yes"}`, uuid.NewString(), params))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestWriteOrganizationError(t *testing.T) {
+	for err, code := range map[error]int{
+		rag.ErrOrganizationNotFound:                http.StatusNotFound,
+		rag.ErrOrganizationForbidden:               http.StatusForbidden,
+		rag.ErrMemberNotFound:                      http.StatusNotFound,
+		rag.ErrOrganizationInvitationNotFound:      http.StatusNotFound,
+		fmt.Errorf("demote: %w", rag.ErrLastCoach): http.StatusConflict,
+		errors.New("connection refused"):           http.StatusInternalServerError,
+	} {
+		response := httptest.NewRecorder()
+		writeOrganizationError(response, err)
+		assert.Equal(t, code, response.Code, err.Error())
+	}
+}
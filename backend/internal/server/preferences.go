@@ -0,0 +1,191 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/go-chi/httplog/v2"
+)
+
+// GetPreferencesHandler returns the training preferences of the user.
+// @Summary Get training preferences
+// @Description Get the long-term training preferences (injuries, disliked drills, equipment, pool, session length) used to personalize generated and refined plans
+// @Tags Preferences
+// @Produce json
+// @Success 200 {object} models.TrainingPreferences "Training preferences"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /preferences [get]
+func (rs *RAGService) GetPreferencesHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	preferences, err := rs.db.GetPreferences(req.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to get training preferences", httplog.ErrAttr(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, preferences); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// UpdatePreferencesHandler replaces the training preferences of the user.
+// @Summary Update training preferences
+// @Description Replace the long-term training preferences of the user. Omitted lists are cleared.
+// @Tags Preferences
+// @Accept json
+// @Produce json
+// @Param request body models.TrainingPreferences true "Training preferences"
+// @Success 200 {object} models.TrainingPreferences "Saved training preferences"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /preferences [put]
+func (rs *RAGService) UpdatePreferencesHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Updating training preferences...")
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	preferences := &models.TrainingPreferences{}
+	if err := models.GetRequestJSON(req, preferences); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := preferences.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	saved, err := rs.db.SavePreferences(req.Context(), userID, preferences)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, saved); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetPreferenceProposalsHandler lists the preferences learned from chat messages.
+// @Summary List proposed training preferences
+// @Description Get the long-term preferences found in the chat messages of the user. They only become part of the training preferences once the user accepts them.
+// @Tags Preferences
+// @Produce json
+// @Success 200 {array} models.PreferenceProposal "Proposed preferences, oldest first"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /preferences/proposals [get]
+func (rs *RAGService) GetPreferenceProposalsHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+
+	userID, ok := req.Context().Value(models.UserIdCtxKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	proposals, err := rs.db.GetPreferenceProposals(req.Context(), userID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, proposals); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// AcceptPreferenceProposalHandler adds a proposed preference to the training preferences.
+// @Summary Accept a proposed training preference
+// @Description Confirm a preference learned from a chat message. It is added to the training preferences and used for all following plans.
+// @Tags Preferences
+// @Produce json
+// @Param proposal_id path string true "Proposal ID"
+// @Success 200 {object} models.TrainingPreferences "Updated training preferences"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Proposal not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /preferences/proposals/{proposal_id}/accept [post]
+func (rs *RAGService) AcceptPreferenceProposalHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Accepting preference proposal...")
+
+	userID, proposalID, ok := resourceRequest(w, req, "proposal_id", "Proposal not found")
+	if !ok {
+		return
+	}
+
+	preferences, err := rs.db.AcceptPreferenceProposal(req.Context(), proposalID, userID)
+	if err != nil {
+		writePreferenceError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, preferences); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// RejectPreferenceProposalHandler dismisses a proposed preference.
+// @Summary Reject a proposed training preference
+// @Description Dismiss a preference learned from a chat message. Rejected preferences are not proposed again.
+// @Tags Preferences
+// @Produce plain
+// @Param proposal_id path string true "Proposal ID"
+// @Success 200 {string} string "Proposal rejected successfully"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Proposal not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /preferences/proposals/{proposal_id}/reject [post]
+func (rs *RAGService) RejectPreferenceProposalHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Rejecting preference proposal...")
+
+	userID, proposalID, ok := resourceRequest(w, req, "proposal_id", "Proposal not found")
+	if !ok {
+		return
+	}
+
+	if err := rs.db.RejectPreferenceProposal(req.Context(), proposalID, userID); err != nil {
+		writePreferenceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("Proposal rejected successfully")); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+func writePreferenceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, rag.ErrProposalNotFound):
+		http.Error(w, "Proposal not found", http.StatusNotFound)
+	case errors.Is(err, rag.ErrPreferencesInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
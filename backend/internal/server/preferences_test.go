@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferenceHandlersRequireAuthentication(t *testing.T) {
+	service := &RAGService{}
+	params := map[string]string{"proposal_id": uuid.NewString()}
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"get":       service.GetPreferencesHandler,
+		"update":    service.UpdatePreferencesHandler,
+		"proposals": service.GetPreferenceProposalsHandler,
+		"accept":    service.AcceptPreferenceProposalHandler,
+		"reject":    service.RejectPreferenceProposalHandler,
+	} {
+		response := httptest.NewRecorder()
+		handler(response, blockHandlerRequest(http.MethodPost, "{}", "", params))
+		assert.Equal(t, http.StatusUnauthorized, response.Code, name)
+	}
+}
+
+func TestPreferenceHandlersValidateRequest(t *testing.T) {
+	service := &RAGService{}
+	userID := uuid.NewString()
+
+	response := httptest.NewRecorder()
+	service.UpdatePreferencesHandler(response, blockHandlerRequest(http.MethodPut, `{"equipment":["Schwimmbrille"]}`, userID, nil))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "unknown equipment")
+
+	response = httptest.NewRecorder()
+	service.UpdatePreferencesHandler(response, blockHandlerRequest(http.MethodPut, `{"pool_length":33}`, userID, nil))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"accept": service.AcceptPreferenceProposalHandler,
+		"reject": service.RejectPreferenceProposalHandler,
+	} {
+		response := httptest.NewRecorder()
+		handler(response, blockHandlerRequest(http.MethodPost, "", userID, map[string]string{"proposal_id": "invalid-uuid"}))
+		assert.Equal(t, http.StatusNotFound, response.Code, name)
+	}
+}
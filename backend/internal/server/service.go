@@ -7,12 +7,20 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"path"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/5pirit5eal/swim-gen/internal/blob"
 	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/5pirit5eal/swim-gen/internal/export"
+	"github.com/5pirit5eal/swim-gen/internal/fit"
+	"github.com/5pirit5eal/swim-gen/internal/mail"
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/5pirit5eal/swim-gen/internal/pdf"
 	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/5pirit5eal/swim-gen/internal/ratelimit"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/httplog/v2"
 	"github.com/google/uuid"
@@ -30,6 +38,12 @@ type RAGService struct {
 	auth *supabase.Client
 	// Configuration for the RAG server
 	cfg config.Config
+	// Rate limits and quotas for the generation endpoints
+	limiter *ratelimit.Limiter
+	// Mailer for plans shared by email, nil if email sharing is not configured
+	mailer mail.Mailer
+	// Storage of exported PDFs
+	blobs blob.BlobStore
 }
 
 // Initializes a new RAG service with the given configuration.
@@ -52,11 +66,29 @@ func NewRAGService(ctx context.Context, cfg config.Config) (*RAGService, error)
 
 	slog.Info("Initialized Supabase client successfully")
 
+	limiter, err := newLimiter(cfg, db.Conn)
+	if err != nil {
+		return nil, err
+	}
+
+	mailer, err := newMailer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := newBlobStore(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &RAGService{
-		ctx:  ctx,
-		cfg:  cfg,
-		db:   db,
-		auth: auth,
+		ctx:     ctx,
+		cfg:     cfg,
+		db:      db,
+		auth:    auth,
+		limiter: limiter,
+		mailer:  mailer,
+		blobs:   blobs,
 	}, nil
 }
 
@@ -71,6 +103,11 @@ func (rs *RAGService) Close() {
 	if err := rs.db.DrillStore.Close(); err != nil {
 		slog.Error("Error closing drill store connection", "err", err.Error())
 	}
+	if closer, ok := rs.blobs.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			slog.Error("Error closing blob store", "err", err.Error())
+		}
+	}
 	slog.Info("RAG server closed successfully")
 }
 
@@ -95,6 +132,9 @@ func validateFileContent(fileBytes []byte, expectedMime string) (string, error)
 	if expectedMime == "image/webp" && (detected == "image/webp" || (len(fileBytes) >= 12 && string(fileBytes[0:4]) == "RIFF" && string(fileBytes[8:12]) == "WEBP")) {
 		return "image/webp", nil
 	}
+	if expectedMime == "text/plain" && strings.HasPrefix(detected, "text/plain") && utf8.Valid(fileBytes) {
+		return "text/plain", nil
+	}
 	return "", fmt.Errorf("file content (%s) does not match expected format (%s)", detected, expectedMime)
 }
 
@@ -111,20 +151,25 @@ func getMimeTypeFromFilename(filename string) (string, error) {
 		return "image/jpeg", nil
 	case strings.HasSuffix(filename, ".pdf"):
 		return "application/pdf", nil
+	case strings.HasSuffix(filename, ".txt"):
+		return "text/plain", nil
 	default:
-		return "", fmt.Errorf("unsupported file type: %s. Supported formats: PNG, WEBP, JPEG, PDF", filename)
+		return "", fmt.Errorf("unsupported file type: %s. Supported formats: PNG, WEBP, JPEG, PDF, TXT", filename)
 	}
 }
 
 // UploadPlanHandler handles the HTTP request to upload a private training plan to the database.
 // It parses the request, stores the documents and their embeddings in the
-// database, and responds with a success message.
+// database, and responds with a success message. Besides the JSON request, plans
+// exported as CSV, Markdown or portable JSON document are accepted as body.
 // @Summary Upload a new private training plan
 // @Description Upload and store a new user created swim training plan in the database
 // @Tags Upload
-// @Accept json
+// @Accept json,text/csv,text/markdown,application/vnd.swim-gen.plan+json
 // @Produce json
-// @Param plan body models.UploadPlanRequest true "Training plan data"
+// @Param plan body models.UploadPlanRequest true "Training plan data or exported plan file"
+// @Param allow_sharing query bool false "Allow sharing, only used for exported plan files"
+// @Param language query string false "Language, only used for exported plan files"
 // @Success 200 {string} string "Plan added successfully"
 // @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
@@ -140,10 +185,14 @@ func (rs *RAGService) UploadPlanHandler(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	// Parse HTTP request from JSON.
+	// Parse HTTP request from JSON or from an exported plan file.
 	dpr := &models.UploadPlanRequest{}
-
-	err := models.GetRequestJSON(req, dpr)
+	var err error
+	if format, ok := export.FormatFromMediaType(req.Header.Get("Content-Type")); ok {
+		dpr, err = uploadPlanRequestFromExport(req, format)
+	} else {
+		err = models.GetRequestJSON(req, dpr)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -208,15 +257,16 @@ func (rs *RAGService) UploadPlanHandler(w http.ResponseWriter, req *http.Request
 	}
 }
 
-// FileToPlanHandler handles the request to convert a file (image or PDF) of a plan to a plan
-// The file is sent as form data. Supported formats: PNG, JPEG, PDF
-// @Summary Convert a file (image or PDF) of a plan to a plan
-// @Description Convert a file containing a training plan to a structured plan. Supports PNG, JPEG, and PDF formats.
+// FileToPlanHandler handles the request to convert a file (image, PDF or text) of a plan to a plan
+// The file is sent as form data. Supported formats: PNG, JPEG, PDF, TXT
+// Text files written in swim shorthand are parsed without the LLM, see models.ParseShorthand.
+// @Summary Convert a file (image, PDF or text) of a plan to a plan
+// @Description Convert a file containing a training plan to a structured plan. Supports PNG, JPEG, PDF and plain text formats. Text in swim shorthand is parsed directly and not understood fragments are returned in unparsed.
 // @Tags Upload
 // @Accept multipart/form-data
 // @Produce json
-// @Param image formData file true "File containing a plan (PNG, JPEG, or PDF)"
-// @Success 200 {object} models.RAGResponse "Plan ID of the converted plan"
+// @Param image formData file true "File containing a plan (PNG, JPEG, PDF or TXT)"
+// @Success 200 {object} models.FileToPlanResponse "Converted plan"
 // @Failure 400 {string} string "Bad request or unsupported file type"
 // @Failure 500 {string} string "Internal server error"
 // @Security BearerAuth
@@ -278,6 +328,21 @@ func (rs *RAGService) FileToPlanHandler(w http.ResponseWriter, req *http.Request
 		language = "en"
 	}
 
+	userID, _ := req.Context().Value(models.UserIdCtxKey).(string)
+	if mimeType == "text/plain" {
+		if answer, ok := shorthandToPlan(string(fileBytes), header.Filename); ok {
+			answer.Duration = rs.estimateDuration(req.Context(), userID, answer.Table)
+			logger.Info("Text converted to plan without LLM", "unparsed", len(answer.Unparsed))
+			// Parsing the shorthand is no generation
+			ratelimit.Refund(req.Context())
+			if err := models.WriteResponseJSON(w, http.StatusOK, answer); err != nil {
+				logger.Error("Failed to write response", httplog.ErrAttr(err))
+			}
+			return
+		}
+		logger.Debug("Shorthand parser did not understand the text, falling back to LLM")
+	}
+
 	resp, err := rs.db.Client.FileToPlan(req.Context(), fileBytes, header.Filename, mimeType, models.Language(language))
 	if err != nil {
 		logger.Error("Failed to convert file to plan in the database", httplog.ErrAttr(err))
@@ -285,11 +350,12 @@ func (rs *RAGService) FileToPlanHandler(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	answer := &models.RAGResponse{
+	answer := &models.FileToPlanResponse{RAGResponse: models.RAGResponse{
 		Title:       resp.Title,
 		Description: resp.Description,
 		Table:       resp.Table,
-	}
+		Duration:    rs.estimateDuration(req.Context(), userID, resp.Table),
+	}}
 
 	logger.Info("Image converted to plan successfully", "plan_id", resp)
 	if err := models.WriteResponseJSON(w, http.StatusOK, answer); err != nil {
@@ -297,6 +363,23 @@ func (rs *RAGService) FileToPlanHandler(w http.ResponseWriter, req *http.Request
 	}
 }
 
+// shorthandToPlan parses text in swim shorthand. The result is only used if more
+// rows than fragments were understood, otherwise the LLM is more likely to succeed.
+func shorthandToPlan(text, filename string) (*models.FileToPlanResponse, bool) {
+	res := models.ParseShorthand(text)
+	rows := len(res.Table) - 1 // without total row
+	if rows <= 0 || len(res.Unparsed) >= rows || res.Table.Validate() != nil {
+		return nil, false
+	}
+	return &models.FileToPlanResponse{
+		RAGResponse: models.RAGResponse{
+			Title: strings.TrimSuffix(filename, path.Ext(filename)),
+			Table: res.Table,
+		},
+		Unparsed: res.Unparsed,
+	}, true
+}
+
 // GetUploadedPlansHandler handles the request to get all uploaded plans for a user.
 // @Summary Get uploaded plans
 // @Description Get all plans uploaded by the authenticated user
@@ -389,12 +472,13 @@ func (rs *RAGService) getUploadedPlan(
 
 // PlanToPDFHandler handles the Plan to PDF export request.
 // @Summary Export training plan to PDF
-// @Description Generate and download a PDF version of a training plan
+// @Description Generate and download a PDF version of a training plan. PDFs are cached by their content, so exporting the same plan with the same options again returns a new signed URL of the already uploaded file. With an Accept header of application/pdf the PDF is returned directly instead of a signed URL. With drill_appendix the linked drills are described on an extra page with QR codes to their pages. The layout selects a print layout: standard, compact (A5 card), poolside (high contrast), coach (empty split time columns) or two_lane (two copies side by side).
 // @Tags Training Plans
 // @Accept json
-// @Produce json
+// @Produce json,application/pdf
 // @Param plan body models.PlanToPDFRequest true "Training plan data to export"
-// @Success 200 {object} models.PlanToPDFResponse "PDF export response with URI"
+// @Param Accept header string false "application/pdf to receive the PDF itself"
+// @Success 200 {object} models.PlanToPDFResponse "PDF export response with URI, or the PDF for Accept: application/pdf"
 // @Failure 400 {string} string "Bad request"
 // @Failure 500 {string} string "Internal server error"
 // @Security BearerAuth
@@ -432,31 +516,28 @@ func (rs *RAGService) PlanToPDFHandler(w http.ResponseWriter, req *http.Request)
 		}
 	}
 
-	// Convert the table to PDF
-	planPDF, err := pdf.PlanToPDF(
-		&models.Plan{
-			Title:       qr.Title,
-			Description: qr.Description,
-			Table:       qr.Table,
-		},
-		qr.Horizontal,
-		qr.LargeFont,
-		qr.Language,
-		qr.FrontendBaseURL,
-	)
-	if err != nil {
-		logger.Error("Table generation failed", httplog.ErrAttr(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	plan := &models.Plan{Title: qr.Title, Description: qr.Description, Table: qr.Table}
+	duration := rs.estimateDuration(req.Context(), userID, qr.Table)
+	var appendix *pdf.DrillAppendix
+	if qr.DrillAppendix {
+		appendix = rs.drillAppendix(req.Context(), qr.Language, qr.FrontendBaseURL, qr.Table)
 	}
 
-	// Determine storage path based on reproducible hash without exposing PII
-	storagePath := pdf.GenerateStoragePath(userID, qr.PlanID, qr.Title)
+	// Stream the PDF directly to clients which do not want a signed URL
+	if acceptsPDF(req) {
+		planPDF, err := pdf.ExportPlanPDF(plan, duration, qr.Layout, appendix, qr.Horizontal, qr.LargeFont, qr.Language, qr.FrontendBaseURL)
+		if err != nil {
+			logger.Error("Table generation failed", httplog.ErrAttr(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeAttachment(w, req, "application/pdf", pdf.Filename(qr.Title), planPDF)
+		return
+	}
 
-	// Upload the PDF to cloud storage
-	uri, err := pdf.UploadPDF(req.Context(), rs.cfg.Bucket.ServiceAccount, rs.cfg.Bucket.Name, storagePath, planPDF)
+	uri, err := rs.planPDFURL(req.Context(), plan, duration, qr.Layout, appendix, qr.Horizontal, qr.LargeFont, qr.Language, qr.FrontendBaseURL)
 	if err != nil {
-		logger.Error("PDF upload failed", httplog.ErrAttr(err))
+		logger.Error("PDF export failed", httplog.ErrAttr(err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -469,6 +550,73 @@ func (rs *RAGService) PlanToPDFHandler(w http.ResponseWriter, req *http.Request)
 	}
 }
 
+// planPDFURL returns a signed URL of the PDF of the plan. PDFs are stored under
+// the hash of their content, so repeated exports of the same plan and layout
+// reuse the uploaded object instead of generating it again. The appendix may be nil.
+func (rs *RAGService) planPDFURL(ctx context.Context, plan *models.Plan, duration *models.DurationEstimate, layout models.PDFLayout, appendix *pdf.DrillAppendix, ho, lf bool, lang models.Language, baseURL string) (string, error) {
+	key, err := pdf.CacheKey(plan, duration, layout, appendix, ho, lf, lang, baseURL)
+	if err != nil {
+		return "", err
+	}
+	uri, cached, err := pdf.UploadCachedPDF(ctx, rs.blobs, pdf.CachedStoragePath(key, plan.Title), func() ([]byte, error) {
+		return pdf.ExportPlanPDF(plan, duration, layout, appendix, ho, lf, lang, baseURL)
+	})
+	if err != nil {
+		return "", err
+	}
+	httplog.LogEntry(ctx).Debug("PDF exported", "cached", cached)
+	return uri, nil
+}
+
+// PlanToFITHandler handles the Plan to FIT workout export request.
+// @Summary Export training plan as FIT workout
+// @Description Convert a training plan into a structured pool swim workout file for Garmin and other sport watches
+// @Tags Training Plans
+// @Accept json
+// @Produce application/vnd.ant.fit
+// @Param plan body models.PlanToFITRequest true "Training plan data to export"
+// @Success 200 {file} binary "FIT workout file"
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /export-fit [post]
+func (rs *RAGService) PlanToFITHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Exporting table to FIT...")
+
+	// Parse HTTP request from JSON.
+	qr := &models.PlanToFITRequest{}
+	err := models.GetRequestJSON(req, qr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := qr.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	poolLength, err := fit.ParsePoolLength(qr.PoolLength)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workout := fit.FromTable(qr.Title, qr.Table, poolLength)
+	data, err := workout.Encode(time.Now())
+	if err != nil {
+		// Only tables without any swimmable row end up here
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rs.incrementExportCount(req, qr.PlanID)
+
+	logger.Info("FIT workout generated successfully", "steps", len(workout.Steps))
+	writeAttachment(w, req, "application/vnd.ant.fit", exportFilename(qr.Title, ".fit"), data)
+}
+
 // UpsertPlan upserts a plan into the users history.
 // If the plan exists and belongs to the user, it updates the plan.
 // Otherwise it inserts a new plan for the user.
@@ -624,9 +772,10 @@ func (rs *RAGService) cleanupAddedPlan(
 }
 
 // SharePlanHandler handles the request to share a training plan.
-// It generates a shareable url_hash for an owned plan.
+// It generates a shareable url_hash for an owned plan and, for the email
+// method, sends the plan with the link to the recipient.
 // @Summary Share a training plan
-// @Description Share an owned training plan via link. Email sharing is not implemented yet.
+// @Description Share an owned training plan via link or email. Emails contain the plan and a link to accept the share, senders can share a limited number of plans by email per day.
 // @Tags Training Plans
 // @Accept json
 // @Produce json
@@ -635,17 +784,20 @@ func (rs *RAGService) cleanupAddedPlan(
 // @Failure 400 {string} string "Bad request"
 // @Failure 401 {string} string "Unauthorized"
 // @Failure 404 {string} string "Plan not found"
+// @Failure 429 {string} string "Too many plans shared by email"
 // @Failure 500 {string} string "Internal server error"
+// @Failure 501 {string} string "Email sharing is not configured"
 // @Security BearerAuth
 // @Router /share-plan [post]
 func (rs *RAGService) SharePlanHandler(w http.ResponseWriter, req *http.Request) {
-	rs.sharePlan(w, req, rs.db.SharePlan)
+	rs.sharePlan(w, req, rs.db.SharePlan, rs.emailPlan)
 }
 
 func (rs *RAGService) sharePlan(
 	w http.ResponseWriter,
 	req *http.Request,
-	share func(context.Context, string, string, models.SharingMethod) (string, error),
+	share func(context.Context, string, string, models.SharingMethod, *time.Time) (string, error),
+	email func(context.Context, string, string, string, models.Language) (string, error),
 ) {
 	logger := httplog.LogEntry(req.Context())
 	logger.Info("Sharing plan...")
@@ -665,18 +817,52 @@ func (rs *RAGService) sharePlan(
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
-	if spr.Method != models.SharingMethodLink {
+	if spr.ExpiresInDays < 0 || spr.ExpiresInDays > models.MaxShareExpiryDays {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	switch spr.Method {
+	case models.SharingMethodLink:
+		if spr.Email != "" {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+	case models.SharingMethodEmail:
+		if !validEmail(spr.Email) || spr.ExpiresInDays != 0 {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+	default:
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
 	httplog.LogEntrySetField(req.Context(), "plan_id", slog.StringValue(spr.PlanID))
 
-	urlHash, err := share(req.Context(), spr.PlanID, userId, spr.Method)
+	var urlHash string
+	var err error
+	if spr.Method == models.SharingMethodEmail {
+		urlHash, err = email(req.Context(), spr.PlanID, userId, spr.Email, spr.Language)
+	} else {
+		var expiresAt *time.Time
+		if spr.ExpiresInDays > 0 {
+			expiry := time.Now().AddDate(0, 0, spr.ExpiresInDays)
+			expiresAt = &expiry
+		}
+		urlHash, err = share(req.Context(), spr.PlanID, userId, spr.Method, expiresAt)
+	}
 	if err != nil {
 		if errors.Is(err, rag.ErrShareNotFound) || errors.Is(err, pgx.ErrNoRows) {
 			http.Error(w, "Plan not found", http.StatusNotFound)
 			return
 		}
+		if throttled := (*rag.ThrottledError)(nil); errors.As(err, &throttled) {
+			writeShareThrottled(w, throttled.RetryAfter)
+			return
+		}
+		if errors.Is(err, errMailNotConfigured) {
+			http.Error(w, "Email sharing is not configured", http.StatusNotImplemented)
+			return
+		}
 		logger.Error("Failed to share plan", httplog.ErrAttr(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -125,3 +125,93 @@ func assertAddPlanToHistoryCleanup(t *testing.T, addMessage func(int) (*models.M
 	assert.Equal(t, userID, deletedUserID)
 	assert.GreaterOrEqual(t, messageCalls, 1)
 }
+
+func TestPlanToFITHandlerReturnsWorkoutFile(t *testing.T) {
+	service := &RAGService{}
+	body := `{"title":"Sprint Set","pool_length":50,"table":[{"Amount":4,"Multiplier":"x","Distance":50,"Break":"30","Content":"Kraul","Intensity":"GA2","Sum":200}]}`
+	response := httptest.NewRecorder()
+
+	service.PlanToFITHandler(response, memoryHandlerRequest(http.MethodPost, "/export-fit", body, ""))
+
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Equal(t, "application/vnd.ant.fit", response.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=Sprint_Set.fit`, response.Header().Get("Content-Disposition"))
+	assert.Equal(t, ".FIT", string(response.Body.Bytes()[8:12]))
+}
+
+func TestPlanToFITHandlerRejectsInvalidPoolLength(t *testing.T) {
+	service := &RAGService{}
+	for _, poolLength := range []string{`"Becken"`, `700`} {
+		body := `{"title":"Sprint Set","pool_length":` + poolLength + `,"table":[{"Amount":1,"Multiplier":"x","Distance":50,"Break":"","Content":"Kraul","Intensity":"","Sum":50}]}`
+		response := httptest.NewRecorder()
+
+		service.PlanToFITHandler(response, memoryHandlerRequest(http.MethodPost, "/export-fit", body, ""))
+
+		assert.Equal(t, http.StatusBadRequest, response.Code, "pool length %s", poolLength)
+	}
+}
+
+func TestPlanToPDFHandlerStreamsPDF(t *testing.T) {
+	service := &RAGService{}
+	body := `{"title":"Sprint Set","table":[{"Amount":4,"Multiplier":"x","Distance":50,"Break":"30","Content":"Kraul","Intensity":"GA2","Sum":200}]}`
+	request := memoryHandlerRequest(http.MethodPost, "/export-pdf", body, "")
+	request.Header.Set("Accept", "application/pdf")
+	response := httptest.NewRecorder()
+
+	service.PlanToPDFHandler(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Equal(t, "application/pdf", response.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=sprint_set.pdf`, response.Header().Get("Content-Disposition"))
+	assert.True(t, strings.HasPrefix(response.Body.String(), "%PDF-"))
+}
+
+func TestPlanToPDFHandlerLayout(t *testing.T) {
+	service := &RAGService{}
+	table := `"table":[{"Amount":4,"Multiplier":"x","Distance":50,"Break":"30","Content":"Kraul","Intensity":"GA2","Sum":200}]`
+
+	request := memoryHandlerRequest(http.MethodPost, "/export-pdf", `{"title":"Sprint Set","layout":"coach",`+table+`}`, "")
+	request.Header.Set("Accept", "application/pdf")
+	response := httptest.NewRecorder()
+	service.PlanToPDFHandler(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.True(t, strings.HasPrefix(response.Body.String(), "%PDF-"))
+
+	request = memoryHandlerRequest(http.MethodPost, "/export-pdf", `{"title":"Sprint Set","layout":"poster",`+table+`}`, "")
+	request.Header.Set("Accept", "application/pdf")
+	response = httptest.NewRecorder()
+	service.PlanToPDFHandler(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "layout must be one of")
+}
+
+func TestAcceptsPDF(t *testing.T) {
+	tests := map[string]bool{
+		"":                                  false,
+		"application/json":                  false,
+		"application/pdf":                   true,
+		"application/json, application/pdf": true,
+		"application/pdf;q=0":               false,
+		"application/pdf;q=0.5, */*":        true,
+	}
+	for accept, want := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/export-pdf", nil)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		assert.Equal(t, want, acceptsPDF(request), accept)
+	}
+}
+
+func TestPlanToPDFHandlerStreamsPDFWithoutLinkedDrills(t *testing.T) {
+	service := &RAGService{}
+	body := `{"title":"Sprint Set","drill_appendix":true,"table":[{"Amount":4,"Multiplier":"x","Distance":50,"Break":"30","Content":"Kraul","Intensity":"GA2","Sum":200}]}`
+	request := memoryHandlerRequest(http.MethodPost, "/export-pdf", body, "")
+	request.Header.Set("Accept", "application/pdf")
+	response := httptest.NewRecorder()
+
+	service.PlanToPDFHandler(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.True(t, strings.HasPrefix(response.Body.String(), "%PDF-"))
+}
@@ -1,14 +1,18 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/ratelimit"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -139,6 +143,17 @@ func TestValidateFileContent(t *testing.T) {
 		assert.Equal(t, "image/webp", mime)
 	})
 
+	t.Run("Valid text", func(t *testing.T) {
+		mime, err := validateFileContent([]byte("4x100 Fr @1:45 GA1\n"), "text/plain")
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain", mime)
+	})
+
+	t.Run("Binary content for text", func(t *testing.T) {
+		_, err := validateFileContent(pngBytes, "text/plain")
+		assert.Error(t, err)
+	})
+
 	t.Run("Spoofed PNG with script content", func(t *testing.T) {
 		_, err := validateFileContent(spoofedScriptBytes, "image/png")
 		assert.Error(t, err)
@@ -155,3 +170,48 @@ func TestValidateFileContent(t *testing.T) {
 		assert.Contains(t, err.Error(), "too small")
 	})
 }
+
+func shorthandUploadRequest(t *testing.T) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "Dienstag.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("400 Fr GA1\n4x100 B @2:00\nHauptteil:\n200 R\n"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	request := httptest.NewRequest(http.MethodPost, "/file-to-plan", &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	return request
+}
+
+func TestFileToPlanHandlerParsesShorthandWithoutLLM(t *testing.T) {
+	request := shorthandUploadRequest(t)
+	response := httptest.NewRecorder()
+
+	// No LLM client is configured, so the request fails if the parser is not used.
+	(&RAGService{}).FileToPlanHandler(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	var answer models.FileToPlanResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &answer))
+	assert.Equal(t, "Dienstag", answer.Title)
+	require.Len(t, answer.Table, 4)
+	assert.Equal(t, 1000, answer.Table[3].Sum)
+	assert.Equal(t, []models.ShorthandFragment{{Line: 3, Text: "Hauptteil:", Reason: "missing distance"}}, answer.Unparsed)
+	require.NotNil(t, answer.Duration)
+	assert.Len(t, answer.Duration.Rows, 3)
+	assert.False(t, answer.Duration.Personal)
+}
+
+func TestFileToPlanHandlerDoesNotChargeShorthandUploads(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Config{AnonymousQuota: 1})
+	handler := limiter.Middleware(http.HandlerFunc((&RAGService{}).FileToPlanHandler))
+
+	// The second upload exceeds the quota of one if the first one is charged
+	for range 2 {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, shorthandUploadRequest(t))
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	}
+}
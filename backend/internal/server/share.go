@@ -0,0 +1,296 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/5pirit5eal/swim-gen/internal/mail"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+	"github.com/google/uuid"
+)
+
+var errMailNotConfigured = errors.New("email sharing is not configured")
+
+// newMailer returns the SMTP mailer for plans shared by email, or nil if no
+// SMTP host is configured.
+func newMailer(cfg config.Config) (mail.Mailer, error) {
+	if cfg.Mail.Host == "" {
+		slog.Info("No SMTP host configured, email sharing is disabled")
+		return nil, nil
+	}
+	mailer, err := mail.NewSMTPMailer(mail.SMTPConfig{
+		Host:     cfg.Mail.Host,
+		Port:     cfg.Mail.Port,
+		Username: cfg.Mail.Username,
+		Password: cfg.Mail.Password,
+		From:     cfg.Mail.From,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Initialized mailer", "host", cfg.Mail.Host)
+	return mailer, nil
+}
+
+// emailPlan shares an owned plan by email and returns the url hash of its link
+// share. The invitation is removed again if the email can not be sent.
+func (rs *RAGService) emailPlan(ctx context.Context, planID, userID, recipient string, lang models.Language) (string, error) {
+	if rs.mailer == nil {
+		return "", errMailNotConfigured
+	}
+	logger := httplog.LogEntry(ctx)
+
+	invitation, err := rs.db.ShareByEmail(ctx, planID, userID, recipient, lang, rs.cfg.Mail.SharesPerDay)
+	if err != nil {
+		return "", err
+	}
+
+	msg, err := mail.ShareMessage(recipient, mail.ShareData{
+		Sender:      invitation.SenderName,
+		Title:       invitation.Title,
+		Description: invitation.Description,
+		Table:       invitation.Table,
+		Link:        shareLink(rs.cfg.Mail.FrontendURL, invitation),
+		ExpiresAt:   invitation.ExpiresAt,
+		Language:    invitation.Language,
+	})
+	if err == nil {
+		err = rs.mailer.Send(ctx, msg)
+	}
+	if err != nil {
+		logger.Error("Failed to send share email", httplog.ErrAttr(err))
+		if err := rs.db.DeleteShareInvitation(context.WithoutCancel(ctx), invitation.InvitationID, userID); err != nil {
+			logger.Error("Failed to remove share invitation after email failure", httplog.ErrAttr(err))
+		}
+		return "", err
+	}
+
+	logger.Info("Share email sent", "invitation_id", invitation.InvitationID)
+	return invitation.URLHash, nil
+}
+
+// shareLink returns the frontend link of the shared plan with the invitation to accept.
+func shareLink(frontendURL string, invitation *models.ShareInvitation) string {
+	u, err := url.Parse(frontendURL)
+	if err != nil {
+		u = &url.URL{}
+	}
+	u = u.JoinPath("shared", invitation.URLHash)
+	u.RawQuery = url.Values{"invitation": {invitation.InvitationID}}.Encode()
+	return u.String()
+}
+
+// validEmail reports whether address is a single plain email address, without display name.
+func validEmail(address string) bool {
+	parsed, err := netmail.ParseAddress(address)
+	return err == nil && parsed.Name == "" && parsed.Address == address && len(address) <= 254
+}
+
+func writeShareThrottled(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	http.Error(w, "Too many plans shared by email", http.StatusTooManyRequests)
+}
+
+// AcceptShareInvitationHandler handles the request to accept a plan shared by email.
+// @Summary Accept a plan shared by email
+// @Description Add the plan of an email invitation to the shared history of the authenticated user. Only the account with the invited email address can accept the invitation, until it expires after 30 days.
+// @Tags Training Plans
+// @Produce json
+// @Param invitation_id path string true "Invitation ID from the share email"
+// @Success 200 {object} models.AcceptInvitationResponse "Accepted plan"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Invitation not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /share-invitations/{invitation_id}/accept [post]
+func (rs *RAGService) AcceptShareInvitationHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Accepting share invitation...")
+
+	userID, invitationID, ok := resourceRequest(w, req, "invitation_id", "Invitation not found")
+	if !ok {
+		return
+	}
+
+	accepted, err := rs.db.AcceptShareInvitation(req.Context(), invitationID, userID)
+	if err != nil {
+		if errors.Is(err, rag.ErrInvitationNotFound) {
+			http.Error(w, "Invitation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Share invitation accepted successfully", "plan_id", accepted.PlanID)
+	if err := models.WriteResponseJSON(w, http.StatusOK, accepted); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetSharedPlanHandler resolves a shared plan by the url hash of its share.
+// @Summary Get a shared training plan
+// @Description Get a plan shared via link with the display name of its owner and the view count of the share. Views of visitors other than the owner are counted. Signed in visitors can set pdf=true to receive a short-lived link to the plan as PDF.
+// @Tags Training Plans
+// @Produce json
+// @Param url_hash path string true "URL hash of the share"
+// @Param pdf query bool false "Export the plan to PDF and return its link, requires authentication"
+// @Param lang query string false "Language of the PDF, 'de' or 'en'"
+// @Param horizontal query bool false "Horizontal PDF layout"
+// @Param large_font query bool false "Large font in the PDF"
+// @Success 200 {object} models.SharedPlan "Shared plan"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized, only for pdf=true"
+// @Failure 404 {string} string "Shared plan not found"
+// @Failure 410 {string} string "Shared plan expired or revoked"
+// @Failure 500 {string} string "Internal server error"
+// @Router /shared/{url_hash} [get]
+func (rs *RAGService) GetSharedPlanHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Resolving shared plan...")
+
+	urlHash, ok := sharedPlanHash(w, req)
+	if !ok {
+		return
+	}
+	query := req.URL.Query()
+	var exportPDF, horizontal, largeFont bool
+	for name, value := range map[string]*bool{"pdf": &exportPDF, "horizontal": &horizontal, "large_font": &largeFont} {
+		if raw := query.Get(name); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				http.Error(w, name+" must be a boolean", http.StatusBadRequest)
+				return
+			}
+			*value = parsed
+		}
+	}
+	// Anonymous visitors are allowed, the user id is only used to skip the views of the owner.
+	// Rendering and uploading the PDF is left to signed in visitors.
+	viewerID, _ := req.Context().Value(models.UserIdCtxKey).(string)
+	if exportPDF && viewerID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	shared, err := rs.db.GetSharedPlan(req.Context(), urlHash, viewerID)
+	if err != nil {
+		writeSharedPlanError(w, err)
+		return
+	}
+
+	if exportPDF {
+		plan := &models.Plan{Title: shared.Title, Description: shared.Description, Table: shared.Table}
+		shared.PDFURL, err = rs.planPDFURL(req.Context(), plan, rs.estimateDuration(req.Context(), viewerID, plan.Table), models.PDFLayoutStandard, nil,
+			horizontal, largeFont, models.Language(query.Get("lang")), "")
+		if err != nil {
+			logger.Error("PDF upload failed", httplog.ErrAttr(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, shared); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// ImportSharedPlanHandler copies a shared plan into the history of the user.
+// @Summary Import a shared training plan
+// @Description Copy the plan of an active share into the history of the authenticated user. The copy can be edited and is kept when the share is revoked.
+// @Tags Training Plans
+// @Produce json
+// @Param url_hash path string true "URL hash of the share"
+// @Success 201 {object} models.ImportSharedPlanResponse "Imported plan"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Shared plan not found"
+// @Failure 410 {string} string "Shared plan expired or revoked"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /shared/{url_hash}/import [post]
+func (rs *RAGService) ImportSharedPlanHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Importing shared plan...")
+
+	userID, urlHash, ok := resourceRequest(w, req, "url_hash", "Shared plan not found")
+	if !ok {
+		return
+	}
+
+	planID, err := rs.db.ImportSharedPlan(req.Context(), urlHash, userID)
+	if err != nil {
+		writeSharedPlanError(w, err)
+		return
+	}
+
+	logger.Info("Shared plan imported successfully", "plan_id", planID)
+	if err := models.WriteResponseJSON(w, http.StatusCreated, &models.ImportSharedPlanResponse{PlanID: planID}); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// RevokeSharedPlanHandler revokes a share of the user.
+// @Summary Revoke a shared training plan
+// @Description Revoke the link of a share owned by the authenticated user. The link and open email invitations stop working, sharing the plan again creates a new link.
+// @Tags Training Plans
+// @Produce json
+// @Param url_hash path string true "URL hash of the share"
+// @Success 200 {string} string "Share revoked successfully"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Shared plan not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /shared/{url_hash} [delete]
+func (rs *RAGService) RevokeSharedPlanHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Revoking shared plan...")
+
+	userID, urlHash, ok := resourceRequest(w, req, "url_hash", "Shared plan not found")
+	if !ok {
+		return
+	}
+
+	if err := rs.db.RevokeSharedPlan(req.Context(), urlHash, userID); err != nil {
+		writeSharedPlanError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("Share revoked successfully")); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// sharedPlanHash reads the url hash of a public request to a shared plan.
+func sharedPlanHash(w http.ResponseWriter, req *http.Request) (string, bool) {
+	urlHash := chi.URLParam(req, "url_hash")
+	if _, err := uuid.Parse(urlHash); err != nil {
+		http.Error(w, "Shared plan not found", http.StatusNotFound)
+		return "", false
+	}
+	httplog.LogEntrySetField(req.Context(), "url_hash", slog.StringValue(urlHash))
+	return urlHash, true
+}
+
+func writeSharedPlanError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, rag.ErrShareNotFound):
+		http.Error(w, "Shared plan not found", http.StatusNotFound)
+	case errors.Is(err, rag.ErrShareExpired):
+		http.Error(w, "Shared plan expired or revoked", http.StatusGone)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/5pirit5eal/swim-gen/internal/models"
 	"github.com/5pirit5eal/swim-gen/internal/rag"
@@ -27,13 +28,13 @@ func sharePlanRequest(body, userID string) *http.Request {
 func TestSharePlanHandlerRequiresAuthentication(t *testing.T) {
 	called := false
 	service := &RAGService{}
-	share := func(context.Context, string, string, models.SharingMethod) (string, error) {
+	share := func(context.Context, string, string, models.SharingMethod, *time.Time) (string, error) {
 		called = true
 		return "hash", nil
 	}
 
 	response := httptest.NewRecorder()
-	service.sharePlan(response, sharePlanRequest(`{"plan_id":"`+uuid.NewString()+`","method":"link"}`, ""), share)
+	service.sharePlan(response, sharePlanRequest(`{"plan_id":"`+uuid.NewString()+`","method":"link"}`, ""), share, nil)
 
 	assert.Equal(t, http.StatusUnauthorized, response.Code)
 	assert.False(t, called)
@@ -41,10 +42,14 @@ func TestSharePlanHandlerRequiresAuthentication(t *testing.T) {
 
 func TestSharePlanHandlerValidatesRequest(t *testing.T) {
 	service := &RAGService{}
-	share := func(context.Context, string, string, models.SharingMethod) (string, error) {
+	share := func(context.Context, string, string, models.SharingMethod, *time.Time) (string, error) {
 		t.Fatal("share operation should not be called")
 		return "", nil
 	}
+	email := func(context.Context, string, string, string, models.Language) (string, error) {
+		t.Fatal("email operation should not be called")
+		return "", nil
+	}
 
 	tests := []struct {
 		name string
@@ -53,14 +58,20 @@ func TestSharePlanHandlerValidatesRequest(t *testing.T) {
 		{name: "empty plan ID", body: `{"plan_id":"","method":"link"}`},
 		{name: "malformed plan ID", body: `{"plan_id":"not-a-uuid","method":"link"}`},
 		{name: "empty method", body: `{"plan_id":"` + uuid.NewString() + `","method":""}`},
-		{name: "unsupported method", body: `{"plan_id":"` + uuid.NewString() + `","method":"email"}`},
+		{name: "unsupported method", body: `{"plan_id":"` + uuid.NewString() + `","method":"fax"}`},
+		{name: "email without recipient", body: `{"plan_id":"` + uuid.NewString() + `","method":"email"}`},
+		{name: "malformed recipient", body: `{"plan_id":"` + uuid.NewString() + `","method":"email","email":"anna"}`},
+		{name: "recipient with display name", body: `{"plan_id":"` + uuid.NewString() + `","method":"email","email":"Anna <anna@example.com>"}`},
+		{name: "expiry too long", body: `{"plan_id":"` + uuid.NewString() + `","method":"link","expires_in_days":400}`},
+		{name: "email with expiry", body: `{"plan_id":"` + uuid.NewString() + `","method":"email","email":"anna@example.com","expires_in_days":7}`},
+		{name: "link with recipient", body: `{"plan_id":"` + uuid.NewString() + `","method":"link","email":"anna@example.com"}`},
 		{name: "unknown field", body: `{"plan_id":"` + uuid.NewString() + `","method":"link","owner":"other"}`},
 	}
 
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
 			response := httptest.NewRecorder()
-			service.sharePlan(response, sharePlanRequest(testCase.body, uuid.NewString()), share)
+			service.sharePlan(response, sharePlanRequest(testCase.body, uuid.NewString()), share, email)
 
 			assert.Equal(t, http.StatusBadRequest, response.Code)
 			assert.Equal(t, "Bad request\n", response.Body.String())
@@ -74,13 +85,13 @@ func TestSharePlanHandlerReturnsShareHash(t *testing.T) {
 	var gotPlanID, gotUserID string
 	var gotMethod models.SharingMethod
 	service := &RAGService{}
-	share := func(_ context.Context, receivedPlanID, receivedUserID string, method models.SharingMethod) (string, error) {
+	share := func(_ context.Context, receivedPlanID, receivedUserID string, method models.SharingMethod, _ *time.Time) (string, error) {
 		gotPlanID, gotUserID, gotMethod = receivedPlanID, receivedUserID, method
 		return "share-hash", nil
 	}
 
 	response := httptest.NewRecorder()
-	service.sharePlan(response, sharePlanRequest(`{"plan_id":"`+planID+`","method":"link"}`, userID), share)
+	service.sharePlan(response, sharePlanRequest(`{"plan_id":"`+planID+`","method":"link"}`, userID), share, nil)
 
 	require.Equal(t, http.StatusOK, response.Code)
 	assert.JSONEq(t, `{"url_hash":"share-hash"}`, response.Body.String())
@@ -91,12 +102,12 @@ func TestSharePlanHandlerReturnsShareHash(t *testing.T) {
 
 func TestSharePlanHandlerHidesUnauthorizedPlans(t *testing.T) {
 	service := &RAGService{}
-	share := func(context.Context, string, string, models.SharingMethod) (string, error) {
+	share := func(context.Context, string, string, models.SharingMethod, *time.Time) (string, error) {
 		return "", rag.ErrShareNotFound
 	}
 
 	response := httptest.NewRecorder()
-	service.sharePlan(response, sharePlanRequest(`{"plan_id":"`+uuid.NewString()+`","method":"link"}`, uuid.NewString()), share)
+	service.sharePlan(response, sharePlanRequest(`{"plan_id":"`+uuid.NewString()+`","method":"link"}`, uuid.NewString()), share, nil)
 
 	assert.Equal(t, http.StatusNotFound, response.Code)
 	assert.Equal(t, "Plan not found\n", response.Body.String())
@@ -105,14 +116,127 @@ func TestSharePlanHandlerHidesUnauthorizedPlans(t *testing.T) {
 
 func TestSharePlanHandlerHidesStoreErrors(t *testing.T) {
 	service := &RAGService{}
-	share := func(context.Context, string, string, models.SharingMethod) (string, error) {
+	share := func(context.Context, string, string, models.SharingMethod, *time.Time) (string, error) {
 		return "", errors.New("database password")
 	}
 
 	response := httptest.NewRecorder()
-	service.sharePlan(response, sharePlanRequest(`{"plan_id":"`+uuid.NewString()+`","method":"link"}`, uuid.NewString()), share)
+	service.sharePlan(response, sharePlanRequest(`{"plan_id":"`+uuid.NewString()+`","method":"link"}`, uuid.NewString()), share, nil)
 
 	assert.Equal(t, http.StatusInternalServerError, response.Code)
 	assert.Equal(t, "Internal server error\n", response.Body.String())
 	assert.NotContains(t, response.Body.String(), "database password")
 }
+
+func TestSharePlanHandlerSharesByEmail(t *testing.T) {
+	planID := uuid.NewString()
+	var gotRecipient string
+	var gotLanguage models.Language
+	service := &RAGService{}
+	email := func(_ context.Context, receivedPlanID, _ string, recipient string, lang models.Language) (string, error) {
+		assert.Equal(t, planID, receivedPlanID)
+		gotRecipient, gotLanguage = recipient, lang
+		return "share-hash", nil
+	}
+
+	response := httptest.NewRecorder()
+	body := `{"plan_id":"` + planID + `","method":"email","email":"anna@example.com","language":"de"}`
+	service.sharePlan(response, sharePlanRequest(body, uuid.NewString()), nil, email)
+
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"url_hash":"share-hash"}`, response.Body.String())
+	assert.Equal(t, "anna@example.com", gotRecipient)
+	assert.Equal(t, models.LanguageDE, gotLanguage)
+}
+
+func TestSharePlanHandlerThrottlesEmailShares(t *testing.T) {
+	service := &RAGService{}
+	email := func(context.Context, string, string, string, models.Language) (string, error) {
+		return "", &rag.ThrottledError{RetryAfter: 90*time.Minute + 500*time.Millisecond}
+	}
+
+	response := httptest.NewRecorder()
+	body := `{"plan_id":"` + uuid.NewString() + `","method":"email","email":"anna@example.com"}`
+	service.sharePlan(response, sharePlanRequest(body, uuid.NewString()), nil, email)
+
+	assert.Equal(t, http.StatusTooManyRequests, response.Code)
+	assert.Equal(t, "5401", response.Header().Get("Retry-After"))
+}
+
+func TestSharePlanHandlerRequiresMailer(t *testing.T) {
+	service := &RAGService{}
+
+	response := httptest.NewRecorder()
+	body := `{"plan_id":"` + uuid.NewString() + `","method":"email","email":"anna@example.com"}`
+	service.sharePlan(response, sharePlanRequest(body, uuid.NewString()), nil, service.emailPlan)
+
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+}
+
+func TestShareLinkAddsInvitation(t *testing.T) {
+	invitation := &models.ShareInvitation{InvitationID: "invitation-id", URLHash: "url-hash"}
+
+	assert.Equal(t, "https://swim-gen.com/shared/url-hash?invitation=invitation-id", shareLink("https://swim-gen.com", invitation))
+	assert.Equal(t, "https://swim-gen.com/app/shared/url-hash?invitation=invitation-id", shareLink("https://swim-gen.com/app/", invitation))
+}
+
+func TestAcceptShareInvitationHandlerValidatesRequest(t *testing.T) {
+	service := &RAGService{}
+	params := map[string]string{"invitation_id": uuid.NewString()}
+
+	response := httptest.NewRecorder()
+	service.AcceptShareInvitationHandler(response, blockHandlerRequest(http.MethodPost, "", "", params))
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+
+	response = httptest.NewRecorder()
+	service.AcceptShareInvitationHandler(response, blockHandlerRequest(http.MethodPost, "", uuid.NewString(), map[string]string{"invitation_id": "invalid"}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestSharedPlanHandlersValidateRequest(t *testing.T) {
+	service := &RAGService{}
+	valid := map[string]string{"url_hash": uuid.NewString()}
+
+	response := httptest.NewRecorder()
+	service.GetSharedPlanHandler(response, blockHandlerRequest(http.MethodGet, "", "", map[string]string{"url_hash": "abc123"}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	req := blockHandlerRequest(http.MethodGet, "", "", valid)
+	req.URL.RawQuery = "pdf=maybe"
+	response = httptest.NewRecorder()
+	service.GetSharedPlanHandler(response, req)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, "pdf must be a boolean\n", response.Body.String())
+
+	// Anonymous visitors may view but not export the plan.
+	req = blockHandlerRequest(http.MethodGet, "", "", valid)
+	req.URL.RawQuery = "pdf=true"
+	response = httptest.NewRecorder()
+	service.GetSharedPlanHandler(response, req)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"import": service.ImportSharedPlanHandler,
+		"revoke": service.RevokeSharedPlanHandler,
+	} {
+		response := httptest.NewRecorder()
+		handler(response, blockHandlerRequest(http.MethodPost, "", "", valid))
+		assert.Equal(t, http.StatusUnauthorized, response.Code, name)
+	}
+}
+
+func TestWriteSharedPlanError(t *testing.T) {
+	for _, testCase := range []struct {
+		err  error
+		code int
+	}{
+		{err: rag.ErrShareNotFound, code: http.StatusNotFound},
+		{err: rag.ErrShareExpired, code: http.StatusGone},
+		{err: errors.New("database password"), code: http.StatusInternalServerError},
+	} {
+		response := httptest.NewRecorder()
+		writeSharedPlanError(response, testCase.err)
+		assert.Equal(t, testCase.code, response.Code)
+		assert.NotContains(t, response.Body.String(), "database password")
+	}
+}
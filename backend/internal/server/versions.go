@@ -0,0 +1,278 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog/v2"
+)
+
+var errVersionNotFound = errors.New("plan version not found")
+
+// GetPlanVersionsHandler lists the versions of a plan stored in its conversation.
+// @Summary List the versions of a plan
+// @Description List the plan snapshots of the AI messages in the conversation of a plan, oldest first
+// @Tags Memory
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Success 200 {array} models.PlanVersion "Versions of the plan"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Plan not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /plan/{plan_id}/versions [get]
+func (rs *RAGService) GetPlanVersionsHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Getting plan versions...")
+
+	userID, planID, ok := resourceRequest(w, req, "plan_id", "Plan not found")
+	if !ok {
+		return
+	}
+
+	versions, err := rs.planVersions(req.Context(), planID, userID)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	summaries := make([]models.PlanVersion, len(versions))
+	for i, version := range versions {
+		summaries[i] = version
+		summaries[i].Plan = nil
+	}
+	logger.Info("Plan versions retrieved successfully", "count", len(summaries))
+	if err := models.WriteResponseJSON(w, http.StatusOK, summaries); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetPlanVersionHandler returns a single version of a plan.
+// @Summary Get a version of a plan
+// @Description Get the plan snapshot stored with an AI message in the conversation of a plan
+// @Tags Memory
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Param message_id path string true "ID of the AI message holding the snapshot"
+// @Success 200 {object} models.PlanVersion "Version of the plan"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Plan or version not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /plan/{plan_id}/versions/{message_id} [get]
+func (rs *RAGService) GetPlanVersionHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Getting plan version...")
+
+	userID, planID, messageID, ok := versionRequest(w, req)
+	if !ok {
+		return
+	}
+
+	versions, err := rs.planVersions(req.Context(), planID, userID)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+	version, err := findVersion(versions, messageID)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	if err := models.WriteResponseJSON(w, http.StatusOK, version); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// GetPlanDiffHandler compares two versions of a plan.
+// @Summary Compare two versions of a plan
+// @Description Get the added, removed and changed rows and the volume delta between two plan versions. Without "to" the version is compared with the latest version.
+// @Tags Memory
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Param from query string true "Message ID of the older version"
+// @Param to query string false "Message ID of the newer version, defaults to the latest version"
+// @Success 200 {object} models.PlanDiffResponse "Difference between the versions"
+// @Failure 400 {string} string "Bad request"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Plan or version not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /plan/{plan_id}/diff [get]
+func (rs *RAGService) GetPlanDiffHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Comparing plan versions...")
+
+	userID, planID, ok := resourceRequest(w, req, "plan_id", "Plan not found")
+	if !ok {
+		return
+	}
+	fromID, toID := req.URL.Query().Get("from"), req.URL.Query().Get("to")
+	if fromID == "" {
+		http.Error(w, "from is required", http.StatusBadRequest)
+		return
+	}
+	if !validUUIDs(fromID) || (toID != "" && !validUUIDs(toID)) {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+
+	versions, err := rs.planVersions(req.Context(), planID, userID)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+	from, err := findVersion(versions, fromID)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+	to := versions[len(versions)-1]
+	if toID != "" {
+		if to, err = findVersion(versions, toID); err != nil {
+			writeVersionError(w, err)
+			return
+		}
+	}
+
+	response := models.PlanDiffResponse{From: from, To: to, Diff: models.DiffTables(from.Plan.Table, to.Plan.Table)}
+	response.From.Plan, response.To.Plan = nil, nil
+	logger.Info("Plan versions compared successfully", "from", from.Version, "to", to.Version)
+	if err := models.WriteResponseJSON(w, http.StatusOK, response); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// RestorePlanVersionHandler makes an older version the current plan.
+// @Summary Restore a version of a plan
+// @Description Save the snapshot of an older version as the current plan. The restore is added to the conversation as AI message with the snapshot, so it becomes the latest version.
+// @Tags Memory
+// @Produce json
+// @Param plan_id path string true "Plan ID"
+// @Param message_id path string true "ID of the AI message holding the snapshot"
+// @Param lang query string false "Language of the conversation message, 'de' or 'en'"
+// @Success 200 {object} models.RestorePlanVersionResponse "Restored plan"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Plan or version not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /plan/{plan_id}/versions/{message_id}/restore [post]
+func (rs *RAGService) RestorePlanVersionHandler(w http.ResponseWriter, req *http.Request) {
+	logger := httplog.LogEntry(req.Context())
+	logger.Info("Restoring plan version...")
+
+	userID, planID, messageID, ok := versionRequest(w, req)
+	if !ok {
+		return
+	}
+
+	versions, err := rs.planVersions(req.Context(), planID, userID)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+	version, err := findVersion(versions, messageID)
+	if err != nil {
+		writeVersionError(w, err)
+		return
+	}
+
+	plan := version.Plan.Plan()
+	plan.PlanID = planID
+	content := fmt.Sprintf("Restored version %d of the plan.", version.Version)
+	if models.Language(req.URL.Query().Get("lang")) != models.LanguageEN {
+		content = fmt.Sprintf("Version %d des Plans wiederhergestellt.", version.Version)
+	}
+	// AddMessage checks the ownership of the plan and saves the snapshot as the
+	// plan in the same transaction as the message.
+	msg, err := rs.db.Memory.AddMessage(req.Context(), planID, userID, models.RoleAI, content, nil, plan)
+	if err != nil {
+		logger.Error("Failed to restore plan version", httplog.ErrAttr(err))
+		writeVersionError(w, err)
+		return
+	}
+
+	restored := models.PlanVersion{
+		MessageID: msg.ID,
+		Version:   versions[len(versions)-1].Version + 1,
+		Title:     plan.Title,
+		Volume:    plan.Table.GetTotalVolume(),
+		CreatedAt: time.Now(),
+	}
+	logger.Info("Plan version restored successfully", "restored", version.Version, "version", restored.Version)
+	if err := models.WriteResponseJSON(w, http.StatusOK, &models.RestorePlanVersionResponse{PlanID: planID, Version: restored}); err != nil {
+		logger.Error("Failed to write response", httplog.ErrAttr(err))
+	}
+}
+
+// planVersions returns the plan snapshots of the conversation in order,
+// including the plans.
+func (rs *RAGService) planVersions(ctx context.Context, planID, userID string) ([]models.PlanVersion, error) {
+	messages, err := rs.db.Memory.GetConversation(ctx, planID, userID)
+	if err != nil {
+		return nil, err
+	}
+	versions := []models.PlanVersion{}
+	for _, msg := range messages {
+		if msg.Role != models.RoleAI || msg.PlanSnapshot == nil {
+			continue
+		}
+		versions = append(versions, models.PlanVersion{
+			MessageID: msg.ID,
+			Version:   len(versions) + 1,
+			Title:     msg.PlanSnapshot.Title,
+			Volume:    msg.PlanSnapshot.Table.GetTotalVolume(),
+			CreatedAt: msg.CreatedAt,
+			Plan: &models.RAGResponse{
+				PlanID:      planID,
+				Title:       msg.PlanSnapshot.Title,
+				Description: msg.PlanSnapshot.Description,
+				Table:       msg.PlanSnapshot.Table,
+			},
+		})
+	}
+	return versions, nil
+}
+
+// versionRequest reads the plan and message id of a request to a single plan version.
+func versionRequest(w http.ResponseWriter, req *http.Request) (userID, planID, messageID string, ok bool) {
+	userID, planID, ok = resourceRequest(w, req, "plan_id", "Plan not found")
+	if !ok {
+		return "", "", "", false
+	}
+	messageID = chi.URLParam(req, "message_id")
+	if !validUUIDs(messageID) {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return "", "", "", false
+	}
+	httplog.LogEntrySetField(req.Context(), "message_id", slog.StringValue(messageID))
+	return userID, planID, messageID, true
+}
+
+func findVersion(versions []models.PlanVersion, messageID string) (models.PlanVersion, error) {
+	for _, version := range versions {
+		if version.MessageID == messageID {
+			return version, nil
+		}
+	}
+	return models.PlanVersion{}, errVersionNotFound
+}
+
+func writeVersionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, rag.ErrMemoryNotFound):
+		http.Error(w, "Plan not found", http.StatusNotFound)
+	case errors.Is(err, errVersionNotFound):
+		http.Error(w, "Version not found", http.StatusNotFound)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/rag"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func versionHandlerRequest(method, target, userID string, params map[string]string) *http.Request {
+	req := memoryHandlerRequest(method, target, "", userID)
+	routeCtx := chi.NewRouteContext()
+	for key, value := range params {
+		routeCtx.URLParams.Add(key, value)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+// versionConversation returns a conversation with two plan versions, the
+// second one adds a kick set to the first.
+func versionConversation(planID string) []models.Message {
+	first := models.Table{
+		{Amount: 1, Multiplier: "x", Distance: 400, Content: "Einschwimmen", Sum: 400},
+		{Amount: 4, Multiplier: "x", Distance: 100, Content: "Kraul", Sum: 400},
+		{Content: "Gesamt", Sum: 800},
+	}
+	second := models.Table{
+		first[0],
+		first[1],
+		{Amount: 4, Multiplier: "x", Distance: 50, Content: "Beine", Sum: 200},
+		{Content: "Gesamt", Sum: 1000},
+	}
+	created := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	return []models.Message{
+		{ID: uuid.NewString(), PlanID: planID, Role: models.RoleUser, Content: "Ein kurzer Plan", CreatedAt: created},
+		{ID: uuid.NewString(), PlanID: planID, Role: models.RoleAI, Content: "Hier ist dein Plan", CreatedAt: created.Add(time.Minute),
+			PlanSnapshot: &models.Plan{PlanID: planID, Title: "Kurz", Table: first}},
+		{ID: uuid.NewString(), PlanID: planID, Role: models.RoleUser, Content: "Mehr Beine", CreatedAt: created.Add(2 * time.Minute)},
+		{ID: uuid.NewString(), PlanID: planID, Role: models.RoleAI, Content: "Mit Beinen", CreatedAt: created.Add(3 * time.Minute),
+			PlanSnapshot: &models.Plan{PlanID: planID, Title: "Kurz mit Beinen", Table: second}},
+	}
+}
+
+func TestPlanVersionHandlersRequireAuthenticationAndValidIDs(t *testing.T) {
+	service := &RAGService{}
+	handlers := map[string]http.HandlerFunc{
+		"list":    service.GetPlanVersionsHandler,
+		"get":     service.GetPlanVersionHandler,
+		"diff":    service.GetPlanDiffHandler,
+		"restore": service.RestorePlanVersionHandler,
+	}
+
+	for name, handler := range handlers {
+		response := httptest.NewRecorder()
+		handler(response, versionHandlerRequest(http.MethodGet, "/plan", "", map[string]string{"plan_id": uuid.NewString()}))
+		assert.Equal(t, http.StatusUnauthorized, response.Code, name)
+
+		response = httptest.NewRecorder()
+		handler(response, versionHandlerRequest(http.MethodGet, "/plan", "owner", map[string]string{"plan_id": "plan"}))
+		assert.Equal(t, http.StatusNotFound, response.Code, name)
+	}
+}
+
+func TestGetPlanVersionsHandler(t *testing.T) {
+	planID := uuid.NewString()
+	conversation := versionConversation(planID)
+	var gotUserID string
+	service := &RAGService{db: &rag.RAGDB{Memory: &memoryHandlerFake{
+		get: func(_ context.Context, _, userID string) ([]models.Message, error) {
+			gotUserID = userID
+			return conversation, nil
+		},
+	}}}
+
+	response := httptest.NewRecorder()
+	service.GetPlanVersionsHandler(response, versionHandlerRequest(http.MethodGet, "/plan/"+planID+"/versions", "owner", map[string]string{"plan_id": planID}))
+
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "owner", gotUserID)
+	var versions []models.PlanVersion
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &versions))
+	require.Len(t, versions, 2)
+	assert.Equal(t, conversation[1].ID, versions[0].MessageID)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, 800, versions[0].Volume)
+	assert.Equal(t, 2, versions[1].Version)
+	assert.Equal(t, "Kurz mit Beinen", versions[1].Title)
+	assert.Nil(t, versions[1].Plan)
+
+	response = httptest.NewRecorder()
+	service.GetPlanVersionHandler(response, versionHandlerRequest(http.MethodGet, "/plan", "owner",
+		map[string]string{"plan_id": planID, "message_id": conversation[1].ID}))
+
+	require.Equal(t, http.StatusOK, response.Code)
+	var version models.PlanVersion
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &version))
+	assert.Equal(t, 1, version.Version)
+	require.NotNil(t, version.Plan)
+	assert.Equal(t, planID, version.Plan.PlanID)
+	assert.Len(t, version.Plan.Table, 3)
+
+	// User messages hold no version.
+	response = httptest.NewRecorder()
+	service.GetPlanVersionHandler(response, versionHandlerRequest(http.MethodGet, "/plan", "owner",
+		map[string]string{"plan_id": planID, "message_id": conversation[0].ID}))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, "Version not found\n", response.Body.String())
+}
+
+func TestGetPlanDiffHandler(t *testing.T) {
+	planID := uuid.NewString()
+	conversation := versionConversation(planID)
+	service := &RAGService{db: &rag.RAGDB{Memory: &memoryHandlerFake{
+		get: func(context.Context, string, string) ([]models.Message, error) {
+			return conversation, nil
+		},
+	}}}
+	params := map[string]string{"plan_id": planID}
+
+	response := httptest.NewRecorder()
+	service.GetPlanDiffHandler(response, versionHandlerRequest(http.MethodGet, "/plan/"+planID+"/diff?from="+conversation[1].ID, "owner", params))
+
+	require.Equal(t, http.StatusOK, response.Code)
+	var diff models.PlanDiffResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &diff))
+	assert.Equal(t, 1, diff.From.Version)
+	assert.Equal(t, 2, diff.To.Version)
+	assert.Equal(t, 1, diff.Diff.Added)
+	assert.Equal(t, 200, diff.Diff.VolumeDelta)
+	require.Len(t, diff.Diff.Rows, 1)
+	assert.Equal(t, "Beine", diff.Diff.Rows[0].To.Content)
+
+	response = httptest.NewRecorder()
+	service.GetPlanDiffHandler(response, versionHandlerRequest(http.MethodGet,
+		"/plan/"+planID+"/diff?from="+conversation[3].ID+"&to="+conversation[1].ID, "owner", params))
+
+	require.Equal(t, http.StatusOK, response.Code)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &diff))
+	assert.Equal(t, 1, diff.Diff.Removed)
+	assert.Equal(t, -200, diff.Diff.VolumeDelta)
+
+	response = httptest.NewRecorder()
+	service.GetPlanDiffHandler(response, versionHandlerRequest(http.MethodGet, "/plan/"+planID+"/diff", "owner", params))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = httptest.NewRecorder()
+	service.GetPlanDiffHandler(response, versionHandlerRequest(http.MethodGet, "/plan/"+planID+"/diff?from="+uuid.NewString(), "owner", params))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestRestorePlanVersionHandler(t *testing.T) {
+	planID := uuid.NewString()
+	conversation := versionConversation(planID)
+	var added *models.Plan
+	var addedContent string
+	service := &RAGService{db: &rag.RAGDB{Memory: &memoryHandlerFake{
+		get: func(context.Context, string, string) ([]models.Message, error) {
+			return conversation, nil
+		},
+		add: func(_ context.Context, receivedPlanID, _ string, role models.Role, content string, previousMessageID *string, snapshot *models.Plan) (*models.Message, error) {
+			assert.Equal(t, planID, receivedPlanID)
+			assert.Equal(t, models.RoleAI, role)
+			assert.Nil(t, previousMessageID)
+			added, addedContent = snapshot, content
+			return &models.Message{ID: "restored"}, nil
+		},
+	}}}
+
+	response := httptest.NewRecorder()
+	service.RestorePlanVersionHandler(response, versionHandlerRequest(http.MethodPost, "/plan?lang=en", "owner",
+		map[string]string{"plan_id": planID, "message_id": conversation[1].ID}))
+
+	require.Equal(t, http.StatusOK, response.Code)
+	require.NotNil(t, added)
+	assert.Equal(t, planID, added.PlanID)
+	assert.Equal(t, "Kurz", added.Title)
+	assert.Len(t, added.Table, 3)
+	assert.Equal(t, "Restored version 1 of the plan.", addedContent)
+
+	var restored models.RestorePlanVersionResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &restored))
+	assert.Equal(t, planID, restored.PlanID)
+	assert.Equal(t, "restored", restored.Version.MessageID)
+	assert.Equal(t, 3, restored.Version.Version)
+	assert.Equal(t, 800, restored.Version.Volume)
+}
+
+func TestRestorePlanVersionHandlerStoreErrors(t *testing.T) {
+	planID := uuid.NewString()
+	conversation := versionConversation(planID)
+
+	for err, code := range map[error]int{
+		errors.New("database password"): http.StatusInternalServerError,
+		// The plan was deleted or given away after the conversation was read.
+		rag.ErrMemoryNotFound: http.StatusNotFound,
+	} {
+		service := &RAGService{db: &rag.RAGDB{Memory: &memoryHandlerFake{
+			get: func(context.Context, string, string) ([]models.Message, error) {
+				return conversation, nil
+			},
+			add: func(context.Context, string, string, models.Role, string, *string, *models.Plan) (*models.Message, error) {
+				return nil, err
+			},
+		}}}
+
+		response := httptest.NewRecorder()
+		service.RestorePlanVersionHandler(response, versionHandlerRequest(http.MethodPost, "/plan", "owner",
+			map[string]string{"plan_id": planID, "message_id": conversation[1].ID}))
+
+		assert.Equal(t, code, response.Code, err.Error())
+		assert.NotContains(t, response.Body.String(), "database password")
+	}
+}
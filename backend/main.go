@@ -127,7 +127,7 @@ func setupLogger(cfg config.Config) (*httplog.Logger, error) {
 		RequestHeaders:       false,
 		ResponseHeaders:      false,
 		HideRequestHeaders:   []string{"Authorization", "Cookie", "Set-Cookie"},
-		ReplaceAttrsOverride: logging.NewRedactor(cfg.DB.Pass, cfg.SB.AnonKey, cfg.SB.ServiceRoleKey).ReplaceAttr,
+		ReplaceAttrsOverride: logging.NewRedactor(cfg.DB.Pass, cfg.SB.AnonKey, cfg.SB.ServiceRoleKey, cfg.Mail.Password, cfg.Bucket.SecretKey, cfg.Bucket.SigningKey).ReplaceAttr,
 		MessageFieldName:     "message",
 		LevelFieldName:       "severity",
 		TimeFieldFormat:      time.RFC3339,
@@ -195,22 +195,65 @@ func setupRouter(basePath string, ragServer *server.RAGService, cfg config.Confi
 		r.Get("/uploads", ragServer.GetUploadedPlansHandler)
 		r.Get("/uploads/{plan_id}", ragServer.GetUploadedPlanHandler)
 		r.Post("/prompt", ragServer.GeneratePromptHandler)
-		r.Post("/query", ragServer.QueryHandler)
-		r.Post("/chat", ragServer.ChatHandler)
+		r.With(ragServer.RateLimitMiddleware).Post("/query", ragServer.QueryHandler)
+		r.With(ragServer.RateLimitMiddleware).Post("/chat", ragServer.ChatHandler)
+		r.With(ragServer.RateLimitMiddleware).Post("/chat/stream", ragServer.ChatStreamHandler)
 		r.Post("/export-pdf", ragServer.PlanToPDFHandler)
+		r.Post("/export-booklet", ragServer.BookletToPDFHandler)
+		r.Get("/files/{token}", ragServer.FileHandler)
+		r.Post("/export-fit", ragServer.PlanToFITHandler)
+		r.Get("/export/schema", ragServer.ExportSchemaHandler)
+		r.Post("/export/{format}", ragServer.ExportPlanHandler)
 		r.Post("/upsert-plan", ragServer.UpsertPlanHandler)
 		r.Post("/add-plan-to-history", ragServer.AddPlanToHistoryHandler)
 		r.Post("/share-plan", ragServer.SharePlanHandler)
+		r.Post("/share-invitations/{invitation_id}/accept", ragServer.AcceptShareInvitationHandler)
+		r.Get("/shared/{url_hash}", ragServer.GetSharedPlanHandler)
+		r.Delete("/shared/{url_hash}", ragServer.RevokeSharedPlanHandler)
+		r.Post("/shared/{url_hash}/import", ragServer.ImportSharedPlanHandler)
 		r.Post("/feedback", ragServer.FeedbackHandler)
-		r.Post("/file-to-plan", ragServer.FileToPlanHandler)
+		r.Get("/calibration", ragServer.GetDifficultyCalibrationHandler)
+		r.With(ragServer.RateLimitMiddleware).Post("/file-to-plan", ragServer.FileToPlanHandler)
 		r.Delete("/plan/{plan_id}", ragServer.DeletePlanHandler)
+		r.Get("/plan/{plan_id}/versions", ragServer.GetPlanVersionsHandler)
+		r.Get("/plan/{plan_id}/versions/{message_id}", ragServer.GetPlanVersionHandler)
+		r.Post("/plan/{plan_id}/versions/{message_id}/restore", ragServer.RestorePlanVersionHandler)
+		r.Get("/plan/{plan_id}/diff", ragServer.GetPlanDiffHandler)
 		r.Delete("/user", ragServer.DeleteUserHandler)
+		r.Get("/preferences", ragServer.GetPreferencesHandler)
+		r.Put("/preferences", ragServer.UpdatePreferencesHandler)
+		r.Get("/preferences/proposals", ragServer.GetPreferenceProposalsHandler)
+		r.Post("/preferences/proposals/{proposal_id}/accept", ragServer.AcceptPreferenceProposalHandler)
+		r.Post("/preferences/proposals/{proposal_id}/reject", ragServer.RejectPreferenceProposalHandler)
+		// Training block endpoints
+		r.With(ragServer.RateLimitMiddleware).Post("/blocks", ragServer.CreateTrainingBlockHandler)
+		r.Get("/blocks", ragServer.GetTrainingBlocksHandler)
+		r.Get("/blocks/{block_id}", ragServer.GetTrainingBlockHandler)
+		r.With(ragServer.RateLimitMiddleware).Post("/blocks/{block_id}/sessions/{week}/{session}/regenerate", ragServer.RegenerateBlockSessionHandler)
+		r.Post("/blocks/{block_id}/export-pdf", ragServer.BlockToPDFHandler)
+		// Organization endpoints
+		r.Post("/organizations", ragServer.CreateOrganizationHandler)
+		r.Get("/organizations", ragServer.GetOrganizationsHandler)
+		r.Get("/organizations/{org_id}/members", ragServer.GetOrganizationMembersHandler)
+		r.Post("/organizations/{org_id}/members", ragServer.AddOrganizationMemberHandler)
+		r.Delete("/organizations/{org_id}/members/{user_id}", ragServer.RemoveOrganizationMemberHandler)
+		r.Post("/organizations/{org_id}/squads", ragServer.CreateSquadHandler)
+		r.Get("/organizations/{org_id}/squads", ragServer.GetSquadsHandler)
+		r.Post("/organizations/{org_id}/assignments", ragServer.AssignPlanHandler)
+		r.Post("/organizations/{org_id}/plans", ragServer.ShareWithOrganizationHandler)
+		r.Get("/organizations/{org_id}/plans", ragServer.GetOrganizationPlansHandler)
+		r.Get("/organizations/{org_id}/plans/{plan_id}/status", ragServer.GetPlanAssignmentStatusHandler)
+		r.Put("/organizations/{org_id}/feedback-sharing", ragServer.UpdateFeedbackSharingHandler)
+		r.Get("/organization-invitations", ragServer.GetOrganizationInvitationsHandler)
+		r.Post("/organization-invitations/{invitation_id}/accept", ragServer.AcceptOrganizationInvitationHandler)
+		r.Delete("/organization-invitations/{invitation_id}", ragServer.DeclineOrganizationInvitationHandler)
 		// Memory management endpoints
 		r.Post("/memory/message", ragServer.AddMessageHandler)
 		r.Delete("/memory/message", ragServer.DeleteMessageHandler)
 		r.Delete("/memory/messages-after", ragServer.DeleteMessagesAfterHandler)
 		r.Delete("/memory/conversation", ragServer.DeleteConversationHandler)
 		r.Get("/memory/conversation", ragServer.GetConversationHandler)
+		r.Post("/memory/branch", ragServer.SelectBranchHandler)
 		// Drill endpoints
 		r.Get("/drill", ragServer.GetDrillHandler)
 		r.Get("/drills/search", ragServer.SearchDrillsHandler)
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/5pirit5eal/swim-gen/internal/config"
+	"github.com/5pirit5eal/swim-gen/internal/genai"
+	"github.com/5pirit5eal/swim-gen/internal/models"
+	"github.com/5pirit5eal/swim-gen/internal/server"
+)
+
+// TestRouterEndToEnd exercises the full router against a local Postgres with the
+// fake LLM provider. It only runs if E2E_DB_HOST is set, e.g. for a local
+// Supabase stack:
+//
+//	E2E_DB_HOST=localhost E2E_DB_PORT=54322 E2E_DB_PASS=postgres go test -run TestRouterEndToEnd .
+func TestRouterEndToEnd(t *testing.T) {
+	host := os.Getenv("E2E_DB_HOST")
+	if host == "" {
+		t.Skip("E2E_DB_HOST not set, skipping end-to-end test")
+	}
+
+	var cfg config.Config
+	cfg.LogLevel = "ERROR"
+	cfg.Model = "fake-model"
+	cfg.SmallModel = "fake-small-model"
+	cfg.LLM.Provider = genai.ProviderFake
+	cfg.LLM.FixtureDir = t.TempDir()
+	cfg.Embedding.Name = "embeddings"
+	cfg.Embedding.DrillName = "drill_embeddings"
+	cfg.Embedding.Model = "fake-embedding"
+	cfg.Embedding.Size = 768
+	cfg.DB.Name = envOr("E2E_DB_NAME", "postgres")
+	cfg.DB.Host = host
+	cfg.DB.Port = envOr("E2E_DB_PORT", "5432")
+	cfg.DB.User = envOr("E2E_DB_USER", "postgres")
+	cfg.DB.Pass = envOr("E2E_DB_PASS", "postgres")
+	cfg.DB.SslMode = "disable"
+
+	generated := models.GeneratedPlan{
+		Title:       "Fake Plan",
+		Description: "Canned plan from the fake LLM provider",
+		Table: models.Table{
+			{Amount: 1, Multiplier: "x", Distance: 400, Break: models.Rest(20), Content: "Einschwimmen", Intensity: "GA1"},
+			{Amount: 4, Multiplier: "x", Distance: 100, Break: models.Rest(15), Content: "Kraul", Intensity: "GA2"},
+		},
+	}
+	writeFixture(t, cfg.LLM.FixtureDir, genai.Fixture{Model: cfg.SmallModel, Response: "Erstelle einen Trainingsplan mit 2000m."})
+	writeFixture(t, cfg.LLM.FixtureDir, genai.Fixture{Model: cfg.Model, Response: mustJSON(t, generated)})
+
+	logger, err := setupLogger(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ragServer, err := server.NewRAGService(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewRAGService() error = %v", err)
+	}
+	defer ragServer.Close()
+	srv := httptest.NewServer(setupRouter("/", ragServer, cfg, logger))
+	defer srv.Close()
+
+	t.Run("prompt", func(t *testing.T) {
+		var resp models.GeneratedPromptResponse
+		postJSON(t, srv.URL+"/prompt", models.GeneratePromptRequest{Language: models.LanguageDE}, &resp)
+		if resp.Prompt != "Erstelle einen Trainingsplan mit 2000m." {
+			t.Fatalf("unexpected prompt %q", resp.Prompt)
+		}
+	})
+
+	t.Run("query generate", func(t *testing.T) {
+		var resp models.RAGResponse
+		postJSON(t, srv.URL+"/query", models.QueryRequest{Content: "Ausdauer", Method: "generate", Language: models.LanguageDE, PoolLength: 25}, &resp)
+		if resp.Title != generated.Title {
+			t.Fatalf("unexpected title %q", resp.Title)
+		}
+		if total := resp.Table[len(resp.Table)-1].Sum; total != 800 {
+			t.Fatalf("unexpected total volume %d", total)
+		}
+	})
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func writeFixture(t *testing.T, dir string, f genai.Fixture) {
+	t.Helper()
+	name := genai.FixtureKey(f.Model, f.Prompt, nil) + ".json"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(mustJSON(t, f)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func postJSON(t *testing.T, url string, body, out any) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(mustJSON(t, body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST %s returned status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatal(err)
+	}
+}
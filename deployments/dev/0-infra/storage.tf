@@ -9,6 +9,15 @@ resource "google_storage_bucket" "exported_pdfs" {
     prevent_destroy = false
   }
 
+  lifecycle_rule {
+    condition {
+      age = 1
+    }
+    action {
+      type = "Delete"
+    }
+  }
+
   depends_on = [google_project_service.apis]
 }
 
@@ -0,0 +1,31 @@
+-- Storage for the rate limits and monthly generation quotas enforced by the Go API.
+-- Keys are "user:<uuid>" or "ip:<address>" prefixed with the limited scope.
+create schema if not exists private;
+
+create table private.api_rate_limit_buckets (
+  key text primary key,
+  tokens double precision not null,
+  updated_at timestamptz not null default now()
+);
+
+create table private.api_generation_quotas (
+  key text not null,
+  period date not null,
+  used int not null default 0,
+  primary key (key, period)
+);
+
+-- Only the backend accesses these tables directly, never through PostgREST.
+revoke all on private.api_rate_limit_buckets from anon, authenticated;
+revoke all on private.api_generation_quotas from anon, authenticated;
+
+-- pg_cron job to clean up idle buckets and quotas of past months
+select
+  cron.schedule(
+    'api-rate-limit-cleanup',
+    '30 0 * * *', -- every day at 00:30
+    $$
+      delete from private.api_rate_limit_buckets where updated_at < now() - interval '1 day';
+      delete from private.api_generation_quotas where period < date_trunc('month', now() - interval '1 month');
+    $$
+  );
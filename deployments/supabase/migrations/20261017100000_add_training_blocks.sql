@@ -0,0 +1,46 @@
+-- Multi-week training blocks (periodization). A block links one plan per
+-- session, the plans themselves are stored in plans and the user's history.
+create table training_blocks (
+  block_id uuid primary key default gen_random_uuid(),
+  user_id uuid not null references auth.users on delete cascade,
+  title text not null,
+  goal text not null,
+  weeks int not null check (weeks between 4 and 16),
+  sessions_per_week int not null check (sessions_per_week between 1 and 7),
+  peak_volume int not null check (peak_volume > 0),
+  language text not null default 'de',
+  pool_length text not null default '',
+  constraints jsonb,
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+create index idx_training_blocks_user_id on training_blocks (user_id);
+
+create table training_block_sessions (
+  block_id uuid not null references training_blocks on delete cascade,
+  week int not null,
+  session int not null,
+  phase text not null check (phase in ('base', 'build', 'taper', 'race')),
+  target_volume int not null,
+  plan_id uuid not null references plans on delete cascade,
+  primary key (block_id, week, session)
+);
+create index idx_training_block_sessions_plan_id on training_block_sessions (plan_id);
+
+-- RLS as defense in depth, the blocks are managed by the backend only.
+alter table training_blocks enable row level security;
+create policy "Users can view their own training blocks." on training_blocks
+  for select using ((select auth.uid()) = user_id);
+
+alter table training_block_sessions enable row level security;
+create policy "Users can view the sessions of their own training blocks." on training_block_sessions
+  for select using (
+    exists (
+      select 1 from training_blocks
+      where training_blocks.block_id = training_block_sessions.block_id
+        and training_blocks.user_id = (select auth.uid())
+    )
+  );
+
+revoke all on public.training_blocks from anon, authenticated;
+revoke all on public.training_block_sessions from anon, authenticated;
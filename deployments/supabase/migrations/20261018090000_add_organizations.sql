@@ -0,0 +1,190 @@
+-- Clubs with coach and swimmer roles. Coaches organize swimmers in squads and
+-- assign plans to them, plans can be shared with all members of the club.
+create table organizations (
+  org_id uuid primary key default gen_random_uuid(),
+  name text not null check (char_length(name) between 1 and 200),
+  created_by uuid references auth.users on delete set null,
+  created_at timestamptz not null default now()
+);
+
+create table organization_members (
+  org_id uuid not null references organizations on delete cascade,
+  user_id uuid not null references auth.users on delete cascade,
+  role text not null check (role in ('coach', 'swimmer')),
+  created_at timestamptz not null default now(),
+  primary key (org_id, user_id)
+);
+create index idx_organization_members_user_id on organization_members (user_id);
+
+create table squads (
+  squad_id uuid primary key default gen_random_uuid(),
+  org_id uuid not null references organizations on delete cascade,
+  name text not null check (char_length(name) between 1 and 200),
+  created_at timestamptz not null default now(),
+  unique (squad_id, org_id)
+);
+create index idx_squads_org_id on squads (org_id);
+
+-- Squad members must be members of the club, leaving the club removes them from its squads.
+create table squad_members (
+  squad_id uuid not null,
+  org_id uuid not null,
+  user_id uuid not null,
+  primary key (squad_id, user_id),
+  foreign key (squad_id, org_id) references squads (squad_id, org_id) on delete cascade,
+  foreign key (org_id, user_id) references organization_members (org_id, user_id) on delete cascade
+);
+create index idx_squad_members_user_id on squad_members (user_id);
+
+create table plan_assignments (
+  plan_id uuid not null references plans on delete cascade,
+  squad_id uuid not null references squads on delete cascade,
+  assigned_by uuid references auth.users on delete set null,
+  created_at timestamptz not null default now(),
+  primary key (plan_id, squad_id)
+);
+create index idx_plan_assignments_squad_id on plan_assignments (squad_id);
+
+create table organization_plans (
+  org_id uuid not null references organizations on delete cascade,
+  plan_id uuid not null references plans on delete cascade,
+  shared_by uuid references auth.users on delete set null,
+  created_at timestamptz not null default now(),
+  primary key (org_id, plan_id)
+);
+create index idx_organization_plans_plan_id on organization_plans (plan_id);
+
+-- RLS as defense in depth, members can only see the data of their own clubs.
+-- All writes go through the backend, which checks the roles. The membership
+-- check is a security definer function, as a policy on organization_members
+-- can not query organization_members itself without recursing.
+create or replace function public.is_organization_member(p_org_id uuid)
+returns boolean
+language sql
+stable
+security definer
+set search_path = ''
+as $$
+  select exists (
+    select 1 from public.organization_members m
+    where m.org_id = p_org_id and m.user_id = (select auth.uid())
+  );
+$$;
+
+alter table organizations enable row level security;
+create policy "Members can view their organizations." on organizations
+  for select using (public.is_organization_member(org_id));
+
+alter table organization_members enable row level security;
+create policy "Members can view the members of their organizations." on organization_members
+  for select using (public.is_organization_member(org_id));
+
+alter table squads enable row level security;
+create policy "Members can view the squads of their organizations." on squads
+  for select using (public.is_organization_member(org_id));
+
+alter table squad_members enable row level security;
+create policy "Members can view the squad members of their organizations." on squad_members
+  for select using (public.is_organization_member(org_id));
+
+alter table plan_assignments enable row level security;
+create policy "Members can view the assignments of their organizations." on plan_assignments
+  for select using (
+    exists (
+      select 1 from squads s
+      where s.squad_id = plan_assignments.squad_id and public.is_organization_member(s.org_id)
+    )
+  );
+
+alter table organization_plans enable row level security;
+create policy "Members can view the plans shared with their organizations." on organization_plans
+  for select using (public.is_organization_member(org_id));
+
+revoke all on public.organizations, public.organization_members, public.squads,
+  public.squad_members, public.plan_assignments, public.organization_plans from anon;
+revoke insert, update, delete on public.organizations, public.organization_members, public.squads,
+  public.squad_members, public.plan_assignments, public.organization_plans from authenticated;
+
+-- Swimmers give feedback on the plans assigned to their squads and on plans
+-- shared with their clubs, which lets coaches see which plans were swum.
+create or replace function public.submit_feedback(
+  p_user_id uuid,
+  p_plan_id uuid,
+  p_rating integer,
+  p_was_swam boolean,
+  p_difficulty_rating integer,
+  p_comment text
+)
+returns boolean
+language sql
+security definer
+set search_path = ''
+as $$
+  with upserted as (
+    insert into public.feedback (
+      user_id,
+      plan_id,
+      rating,
+      was_swam,
+      difficulty_rating,
+      comment,
+      removed_from_history
+    )
+    select
+      p_user_id,
+      p_plan_id,
+      p_rating,
+      p_was_swam,
+      p_difficulty_rating,
+      p_comment,
+      false
+    where exists (
+      select 1
+      from public.history h
+      where h.user_id = p_user_id
+        and h.plan_id = p_plan_id
+    )
+    or exists (
+      select 1
+      from public.donations d
+      where d.user_id = p_user_id
+        and d.plan_id = p_plan_id
+    )
+    or exists (
+      select 1
+      from public.shared_history sh
+      join public.shared_plans sp
+        on sp.plan_id = sh.plan_id
+       and sp.user_id = sh.shared_by
+      where sh.user_id = p_user_id
+        and sh.plan_id = p_plan_id
+    )
+    or exists (
+      select 1
+      from public.plan_assignments pa
+      join public.squad_members sm on sm.squad_id = pa.squad_id
+      where sm.user_id = p_user_id
+        and pa.plan_id = p_plan_id
+    )
+    or exists (
+      select 1
+      from public.organization_plans op
+      join public.organization_members om on om.org_id = op.org_id
+      where om.user_id = p_user_id
+        and op.plan_id = p_plan_id
+    )
+    on conflict (user_id, plan_id) do update
+      set rating = excluded.rating,
+          was_swam = excluded.was_swam,
+          difficulty_rating = excluded.difficulty_rating,
+          comment = excluded.comment,
+          removed_from_history = false
+    returning 1
+  )
+  select exists (select 1 from upserted);
+$$;
+
+revoke execute on function public.submit_feedback(uuid, uuid, integer, boolean, integer, text)
+  from public, anon, authenticated, service_role;
+grant execute on function public.submit_feedback(uuid, uuid, integer, boolean, integer, text)
+  to postgres;
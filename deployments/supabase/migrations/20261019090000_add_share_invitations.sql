@@ -0,0 +1,26 @@
+-- Plans shared by email. Each email carries the id of its invitation, which the
+-- recipient accepts with the account of the invited address. Accepting inserts
+-- the plan into the shared_history of the recipient with share_method 'email'.
+create table share_invitations (
+  invitation_id uuid primary key default gen_random_uuid(),
+  plan_id uuid not null,
+  shared_by uuid not null,
+  recipient_email text not null check (char_length(recipient_email) between 3 and 254),
+  language text not null default 'en' check (language in ('de', 'en')),
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null default now() + interval '30 days',
+  accepted_by uuid references auth.users on delete set null,
+  accepted_at timestamptz,
+  -- Invitations are revoked together with the link share of the plan.
+  foreign key (plan_id, shared_by) references shared_plans (plan_id, user_id) on delete cascade
+);
+-- Serves the per-sender throttling, which counts the invitations of the last day.
+create index idx_share_invitations_shared_by_created_at on share_invitations (shared_by, created_at);
+
+alter table share_invitations enable row level security;
+create policy "Senders can view their share invitations." on share_invitations
+  for select using ((select auth.uid()) = shared_by);
+
+-- Invitations are created and accepted by the backend only.
+revoke all on public.share_invitations from anon;
+revoke insert, update, delete on public.share_invitations from authenticated;
@@ -0,0 +1,88 @@
+-- Shares can expire and be revoked by their owner. Expired and revoked shares
+-- no longer resolve, their views are counted while they are active.
+alter table public.shared_plans
+  add column expires_at timestamptz,
+  add column revoked_at timestamptz,
+  add column view_count integer not null default 0,
+  add column last_viewed_at timestamptz;
+
+-- Resolve only active shares from the frontend as well.
+create or replace function public.get_shared_plan_by_hash(p_url_hash uuid)
+returns table (
+  plan_id uuid,
+  sharer_id uuid,
+  sharer_username text,
+  title text,
+  description text,
+  plan_table jsonb
+)
+language sql
+security definer
+set search_path = ''
+as $$
+  select
+    sp.plan_id,
+    sp.user_id,
+    profile.username,
+    plan.title,
+    plan.description,
+    plan.plan_table
+  from public.shared_plans sp
+  join public.plans plan on plan.plan_id = sp.plan_id
+  left join public.profiles profile on profile.user_id = sp.user_id
+  where sp.url_hash = p_url_hash
+    and sp.revoked_at is null
+    and (sp.expires_at is null or sp.expires_at > now());
+$$;
+
+create or replace function public.record_shared_plan(
+  p_url_hash uuid,
+  p_share_method text default 'link'
+)
+returns table (
+  plan_id uuid,
+  shared_by uuid,
+  share_method text,
+  created_at timestamptz
+)
+language plpgsql
+security definer
+set search_path = ''
+as $$
+declare
+  recipient_id uuid := (select auth.uid());
+  shared_plan_id uuid;
+  owner_id uuid;
+begin
+  if recipient_id is null or p_share_method is distinct from 'link' then
+    return;
+  end if;
+
+  select sp.plan_id, sp.user_id
+  into shared_plan_id, owner_id
+  from public.shared_plans sp
+  where sp.url_hash = p_url_hash
+    and sp.revoked_at is null
+    and (sp.expires_at is null or sp.expires_at > now());
+
+  if not found or recipient_id = owner_id then
+    return;
+  end if;
+
+  insert into public.shared_history (user_id, plan_id, share_method, shared_by)
+  values (recipient_id, shared_plan_id, p_share_method, owner_id)
+  on conflict on constraint shared_history_pkey do nothing;
+
+  return query
+  select sh.plan_id, sh.shared_by, sh.share_method, sh.created_at
+  from public.shared_history sh
+  where sh.user_id = recipient_id
+    and sh.plan_id = shared_plan_id;
+end;
+$$;
+
+revoke execute on function public.get_shared_plan_by_hash(uuid) from public, anon, authenticated;
+grant execute on function public.get_shared_plan_by_hash(uuid) to anon, authenticated;
+
+revoke execute on function public.record_shared_plan(uuid, text) from public, anon, authenticated;
+grant execute on function public.record_shared_plan(uuid, text) to authenticated;
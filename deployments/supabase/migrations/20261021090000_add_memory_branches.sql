@@ -0,0 +1,22 @@
+-- Conversations are trees: messages with the same previous message are branches
+-- of the conversation, e.g. an edited user message and the original one. The
+-- next_message_id of a message points to its child on the active branch and
+-- the active branch starts at the root message stored here. Conversations
+-- without a row start at their latest root message.
+create table memory_branches (
+  plan_id uuid not null references plans(plan_id) on delete cascade,
+  user_id uuid not null references auth.users(id) on delete cascade,
+  root_message_id uuid not null references memory(id) on delete cascade,
+  updated_at timestamptz not null default now(),
+  primary key (plan_id, user_id)
+);
+
+-- Serves the lookup of the children and siblings of a message.
+create index idx_memory_previous_message_id on memory(previous_message_id);
+
+alter table memory_branches enable row level security;
+create policy "Users can view their own memory branches." on memory_branches
+  for select using ((select auth.uid()) = user_id);
+
+-- Like the memory itself, branches are managed by the backend only.
+revoke all on public.memory_branches from anon, authenticated;
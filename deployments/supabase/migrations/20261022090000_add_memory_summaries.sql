@@ -0,0 +1,19 @@
+-- Chat prompts only include the latest messages of a conversation. The older
+-- messages are condensed into a rolling summary, which covers the conversation
+-- up to last_message_id. Deleting that message drops the summary, so it is
+-- rebuilt from the remaining messages.
+create table memory_summaries (
+  plan_id uuid not null references plans(plan_id) on delete cascade,
+  user_id uuid not null references auth.users(id) on delete cascade,
+  summary text not null,
+  last_message_id uuid not null references memory(id) on delete cascade,
+  updated_at timestamptz not null default now(),
+  primary key (plan_id, user_id)
+);
+
+alter table memory_summaries enable row level security;
+create policy "Users can view their own memory summaries." on memory_summaries
+  for select using ((select auth.uid()) = user_id);
+
+-- Like the memory itself, summaries are managed by the backend only.
+revoke all on public.memory_summaries from anon, authenticated;
@@ -0,0 +1,39 @@
+-- Long-term training preferences of a user, added to the profile used in the
+-- generation and chat prompts.
+create table training_preferences (
+  user_id uuid primary key references auth.users(id) on delete cascade,
+  injuries text[] not null default '{}',
+  disliked_drills text[] not null default '{}',
+  equipment text[] not null default '{}',
+  pool_length integer check (pool_length in (25, 50)),
+  session_minutes integer check (session_minutes between 1 and 600),
+  updated_at timestamptz not null default now()
+);
+
+-- Preferences found in chat messages only become part of the training
+-- preferences once the user accepts them. Accepted proposals are deleted,
+-- rejected ones are kept so they are not proposed again.
+create table preference_proposals (
+  proposal_id uuid primary key default gen_random_uuid(),
+  user_id uuid not null references auth.users(id) on delete cascade,
+  plan_id uuid references plans(plan_id) on delete set null,
+  message_id uuid references memory(id) on delete set null,
+  field text not null check (field in ('injury', 'disliked_drill', 'equipment', 'pool_length', 'session_minutes')),
+  value text not null,
+  status text not null default 'pending' check (status in ('pending', 'rejected')),
+  created_at timestamptz not null default now()
+);
+
+create unique index preference_proposals_fact_idx on preference_proposals (user_id, field, lower(value));
+
+alter table training_preferences enable row level security;
+create policy "Users can view their own training preferences." on training_preferences
+  for select using ((select auth.uid()) = user_id);
+
+alter table preference_proposals enable row level security;
+create policy "Users can view their own preference proposals." on preference_proposals
+  for select using ((select auth.uid()) = user_id);
+
+-- Preferences are validated and managed by the backend only.
+revoke all on public.training_preferences from anon, authenticated;
+revoke all on public.preference_proposals from anon, authenticated;
@@ -0,0 +1,24 @@
+-- Coaches invite users by username. Invited users only become members of the
+-- club once they accept, accepting moves the invitation to organization_members.
+create table organization_invitations (
+  invitation_id uuid primary key default gen_random_uuid(),
+  org_id uuid not null references organizations on delete cascade,
+  user_id uuid not null references auth.users on delete cascade,
+  role text not null check (role in ('coach', 'swimmer')),
+  invited_by uuid references auth.users on delete set null,
+  created_at timestamptz not null default now(),
+  unique (org_id, user_id)
+);
+create index idx_organization_invitations_user_id on organization_invitations (user_id);
+
+-- Coaches only see whether swimmers swam an assigned plan. Ratings and the time
+-- of the feedback are shared once the swimmer opts in.
+alter table organization_members add column share_feedback boolean not null default false;
+
+alter table organization_invitations enable row level security;
+create policy "Users can view their organization invitations." on organization_invitations
+  for select using ((select auth.uid()) = user_id or public.is_organization_member(org_id));
+
+-- Invitations are created and accepted by the backend only.
+revoke all on public.organization_invitations from anon;
+revoke insert, update, delete on public.organization_invitations from authenticated;